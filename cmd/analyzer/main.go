@@ -2,14 +2,21 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/naoyafurudono/sqlc-use-analysis/internal/config"
 	"github.com/naoyafurudono/sqlc-use-analysis/internal/errors"
 	"github.com/naoyafurudono/sqlc-use-analysis/internal/io"
 	"github.com/naoyafurudono/sqlc-use-analysis/internal/orchestrator"
+	"github.com/naoyafurudono/sqlc-use-analysis/internal/watch"
 	"github.com/naoyafurudono/sqlc-use-analysis/pkg/types"
 )
 
@@ -19,19 +26,39 @@ const (
 )
 
 func main() {
-	if err := run(); err != nil {
+	watchMode := flag.Bool("watch", false, "re-run the analysis whenever .go or .sql files change")
+	configPath := flag.String("config", "", "JSON file with plugin options (watch mode)")
+	queryPaths := flag.String("queries", "", "comma-separated sqlc query files (watch mode)")
+	interval := flag.Duration("interval", time.Second, "polling interval for file changes (watch mode)")
+	debounce := flag.Duration("debounce", 300*time.Millisecond, "quiet period before re-running (watch mode)")
+	inputPath := flag.String("input", "", "read the sqlc request from a file instead of stdin (protobuf or JSON)")
+	flag.Parse()
+	
+	var err error
+	if *watchMode {
+		err = runWatch(*configPath, splitList(*queryPaths), *interval, *debounce)
+	} else {
+		err = run(*inputPath)
+	}
+	if err != nil {
 		log.Fatalf("Error: %v", err)
 	}
 }
 
-func run() error {
+func run(inputPath string) error {
 	ctx := context.Background()
 	
 	// エラーコレクターの初期化
 	errorCollector := errors.NewErrorCollector(100, true)
 	
-	// 入力の読み込み
+	// 入力の読み込み（-input指定時はキャプチャしたリクエストを再生する）
 	inputReader := io.NewInputReader()
+	if inputPath != "" {
+		var err error
+		if inputReader, err = io.NewInputReaderFromFile(inputPath); err != nil {
+			return err
+		}
+	}
 	request, err := inputReader.ReadRequest()
 	if err != nil {
 		return fmt.Errorf("failed to read request: %w", err)
@@ -56,21 +83,15 @@ func run() error {
 		return fmt.Errorf("failed to execute analysis: %w", err)
 	}
 	
-	// 結果の出力
+	// 解析結果を生成ファイルとしてsqlcに返す（書き込みはsqlcが行う）
 	outputWriter := io.NewOutputWriter(cfg)
-	if err := outputWriter.WriteResult(result); err != nil {
-		return fmt.Errorf("failed to write result: %w", err)
+	outputWriter.SetErrorCollector(errorCollector)
+	files, err := outputWriter.GeneratedFiles(result)
+	if err != nil {
+		return fmt.Errorf("failed to render result: %w", err)
 	}
 	
-	// sqlcプラグインレスポンスの生成
 	responseWriter := io.NewResponseWriter()
-	files := []*types.GeneratedFile{
-		{
-			Name:     ".sqlc_dependency_analysis",
-			Contents: []byte("// Analysis completed successfully"),
-		},
-	}
-	
 	if err := responseWriter.WriteResponse(files); err != nil {
 		return fmt.Errorf("failed to write response: %w", err)
 	}
@@ -78,6 +99,124 @@ func run() error {
 	return nil
 }
 
+// runWatch analyzes the project outside of sqlc and re-runs on every file change
+func runWatch(configPath string, queryPaths []string, interval, debounce time.Duration) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	
+	if len(queryPaths) == 0 {
+		return fmt.Errorf("-queries is required in watch mode")
+	}
+	
+	options, err := readOptions(configPath)
+	if err != nil {
+		return err
+	}
+	
+	request := &config.CodeGeneratorRequest{Settings: options}
+	cfg, err := config.NewConfigLoader().LoadFromRequest(request)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	
+	// オーケストレーターはサイクル間で再利用する
+	errorCollector := errors.NewErrorCollector(100, true)
+	orch, err := orchestrator.New(cfg, errorCollector)
+	if err != nil {
+		return fmt.Errorf("failed to create orchestrator: %w", err)
+	}
+	outputWriter := io.NewOutputWriter(cfg)
+	outputWriter.SetErrorCollector(errorCollector)
+	// 解析の前に出力先を確認し、書き込めない場合は監視を始めない
+	if err := outputWriter.CheckWritable(); err != nil {
+		return err
+	}
+	
+	var previous *types.DependencyResult
+	analyze := func(ctx context.Context, changed []string) error {
+		errorCollector.Clear()
+		
+		// クエリファイル自体の変更も反映するため毎回読み直す
+		queries, err := io.ReadQueryFiles(queryPaths)
+		if err != nil {
+			log.Printf("analysis skipped: %v", err)
+			return nil
+		}
+		request.Queries = queries
+		
+		result, err := orch.Execute(ctx, request)
+		if err != nil {
+			log.Printf("analysis failed: %v", err)
+			return nil
+		}
+		if err := outputWriter.WriteResult(result); err != nil {
+			log.Printf("failed to write result: %v", err)
+			return nil
+		}
+		
+		log.Printf("analyzed %d functions, %d tables (%d files changed)\n%s",
+			result.Metadata.TotalFuncs, result.Metadata.TotalTables, len(changed), watch.DiffResults(previous, result))
+		previous = result
+		return nil
+	}
+	
+	if err := analyze(ctx, nil); err != nil {
+		return err
+	}
+	
+	// Goパッケージのルートとクエリファイルのディレクトリを監視
+	roots := []string{cfg.RootPath}
+	for _, path := range queryPaths {
+		roots = appendRoot(roots, filepath.Dir(path))
+	}
+	
+	source := watch.NewPollingSource(roots, interval)
+	if err := source.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start watching: %w", err)
+	}
+	
+	log.Printf("watching %s for changes", strings.Join(roots, ", "))
+	return watch.New(source, debounce, analyze).Run(ctx)
+}
+
+// readOptions reads plugin options from a JSON file, as they would appear in sqlc.yaml
+func readOptions(path string) (map[string]interface{}, error) {
+	options := make(map[string]interface{})
+	if path == "" {
+		return options, nil
+	}
+	
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := json.Unmarshal(data, &options); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	return options, nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// appendRoot adds dir unless it is already covered by one of the roots
+func appendRoot(roots []string, dir string) []string {
+	for _, root := range roots {
+		if rel, err := filepath.Rel(root, dir); err == nil && !strings.HasPrefix(rel, "..") {
+			return roots
+		}
+	}
+	return append(roots, dir)
+}
+
 func init() {
 	// デバッグ情報の設定
 	if os.Getenv("SQLC_ANALYZER_DEBUG") == "true" {
@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/naoyafurudono/sqlc-use-analysis/pkg/analyzer"
@@ -51,8 +52,14 @@ func main() {
 }
 
 func runDemo(projectPath string) error {
-	// アナライザーを作成
-	a := analyzer.New()
+	// アナライザーを作成（パッケージパスからレイヤーを判定）
+	a := analyzer.NewWithOptions(analyzer.Options{
+		LayerRules: []analyzer.LayerRule{
+			{Pattern: "internal/db", Layer: "db"},
+			{Pattern: "internal/service", Layer: "service"},
+			{Pattern: "internal/handler", Layer: "handler"},
+		},
+	})
 	
 	fmt.Printf("%s1. Setting up analysis...%s\n", colorBlue, colorReset)
 	
@@ -165,7 +172,8 @@ func displayResults(result *analyzer.Result) {
 	
 	// テーブル一覧
 	fmt.Printf("\n  %sTables:%s\n", colorPurple, colorReset)
-	for tableName, tableInfo := range result.Tables {
+	for _, tableName := range sortedKeys(result.Tables) {
+		tableInfo := result.Tables[tableName]
 		fmt.Printf("    • %s%s%s (accessed by %d functions)\n", 
 			colorWhite, tableName, colorReset, len(tableInfo.AccessedBy))
 	}
@@ -173,8 +181,8 @@ func displayResults(result *analyzer.Result) {
 	// 操作統計
 	if len(result.Summary.OperationCounts) > 0 {
 		fmt.Printf("\n  %sOperations:%s\n", colorPurple, colorReset)
-		for operation, count := range result.Summary.OperationCounts {
-			fmt.Printf("    • %s: %s%d%s times\n", operation, colorWhite, count, colorReset)
+		for _, operation := range sortedKeys(result.Summary.OperationCounts) {
+			fmt.Printf("    • %s: %s%d%s times\n", operation, colorWhite, result.Summary.OperationCounts[operation], colorReset)
 		}
 	}
 }
@@ -184,15 +192,17 @@ func displayDependencyAnalysis(result *analyzer.Result) {
 	fmt.Printf("  %sService Layer Analysis:%s\n", colorPurple, colorReset)
 	
 	serviceCount := 0
-	for funcName, funcInfo := range result.Functions {
-		if funcInfo.Package == "service" {
+	for _, funcName := range sortedKeys(result.Functions) {
+		funcInfo := result.Functions[funcName]
+		if funcInfo.Layer == "service" {
 			serviceCount++
 			fmt.Printf("    • %s%s%s:\n", colorWhite, funcName, colorReset)
 			
 			if len(funcInfo.TableAccess) == 0 {
 				fmt.Printf("      - No direct table access\n")
 			} else {
-				for tableName, access := range funcInfo.TableAccess {
+				for _, tableName := range sortedKeys(funcInfo.TableAccess) {
+					access := funcInfo.TableAccess[tableName]
 					fmt.Printf("      - %s%s%s: %v (%d calls)\n", 
 						colorCyan, tableName, colorReset, access.Operations, access.Count)
 				}
@@ -208,15 +218,11 @@ func displayDependencyAnalysis(result *analyzer.Result) {
 	fmt.Printf("\n  %sComplex Dependencies:%s\n", colorPurple, colorReset)
 	complexFound := false
 	
-	for funcName, funcInfo := range result.Functions {
-		if len(funcInfo.TableAccess) > 1 {
+	for _, hotspot := range result.Hotspots(0).Functions {
+		if hotspot.Count > 1 {
 			complexFound = true
-			tableNames := make([]string, 0, len(funcInfo.TableAccess))
-			for tableName := range funcInfo.TableAccess {
-				tableNames = append(tableNames, tableName)
-			}
 			fmt.Printf("    • %s%s%s accesses: %v\n", 
-				colorWhite, funcName, colorReset, tableNames)
+				colorWhite, hotspot.Name, colorReset, sortedKeys(result.Functions[hotspot.Name].TableAccess))
 		}
 	}
 	
@@ -241,4 +247,13 @@ func saveResults(result *analyzer.Result) error {
 		colorGreen, filename, colorReset, len(jsonData))
 	
 	return nil
-}
\ No newline at end of file
+}
+// sortedKeys returns map keys in sorted order so the demo output is stable
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
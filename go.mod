@@ -1,16 +1,16 @@
 module github.com/naoyafurudono/sqlc-use-analysis
 
-go 1.24.1
+go 1.25.0
 
 require (
 	github.com/stretchr/testify v1.9.0
-	golang.org/x/tools v0.34.0
+	golang.org/x/tools v0.47.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	golang.org/x/mod v0.25.0 // indirect
-	golang.org/x/sync v0.15.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	golang.org/x/mod v0.37.0 // indirect
+	golang.org/x/sync v0.21.0 // indirect
 )
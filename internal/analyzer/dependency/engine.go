@@ -1,9 +1,12 @@
 package dependency
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	gostatic "github.com/naoyafurudono/sqlc-use-analysis/internal/analyzer/go"
 	"github.com/naoyafurudono/sqlc-use-analysis/internal/analyzer/sql"
@@ -14,17 +17,61 @@ import (
 // Engine orchestrates the complete dependency analysis
 type Engine struct {
 	sqlAnalyzer    *sql.Analyzer
+	analyzeQuery   func(*sql.Analyzer, sql.Query) (types.SQLMethodInfo, error) // テストで差し替え可能
 	goAnalyzer     *gostatic.Analyzer
 	mapper         *gostatic.DependencyMapper
 	errorCollector *errors.ErrorCollector
+	dialect        string
+	caseSensitive  bool
+	explain        bool
+	knownTables    map[string]string // 小文字化したテーブル名 -> カタログ上の名前
+	methodPrefixes []string
+	acronyms       []string
+	includeDDL     bool
+	readContext    bool
+	includePkgs    []string
+	excludePkgs    []string
+	followAll      bool
+	followPkgs     []string
+	loadRetries    int
+	rawSQL         bool
+	skipGenerated  bool
+	driverCalls    bool
+	strictTypes    bool
+	memoryLimit    int
+	externalFuncs  []string
+	ignoredMethods []string // 無視指定されたクエリのメソッド名
+	rootPath       string // Goパッケージを読み込むモジュールのルート（空の場合はカレントディレクトリ）
+	progress       func(phase string, current, total int)
+	lastRun        RunMetrics
+	loadedPkgs     []string
+	failedPkgs     []gostatic.PackageLoadFailure
 }
 
 // NewEngine creates a new dependency analysis engine
 func NewEngine(errorCollector *errors.ErrorCollector) *Engine {
-	return &Engine{
-		sqlAnalyzer:    sql.NewAnalyzer("mysql", false, errorCollector),
+	return NewEngineWithDialect(sql.DialectMySQL, false, errorCollector)
+}
+
+// NewEngineWithDialect creates a new dependency analysis engine for the given SQL dialect
+func NewEngineWithDialect(dialect string, caseSensitive bool, errorCollector *errors.ErrorCollector) *Engine {
+	e := &Engine{
+		analyzeQuery:   (*sql.Analyzer).AnalyzeQuery,
 		errorCollector: errorCollector,
+		dialect:        dialect,
+		caseSensitive:  caseSensitive,
 	}
+	e.sqlAnalyzer = e.newSQLAnalyzer()
+	return e
+}
+
+// newSQLAnalyzer creates a SQL analyzer with the engine's dialect and SQL settings
+func (e *Engine) newSQLAnalyzer() *sql.Analyzer {
+	analyzer := sql.NewAnalyzer(e.dialect, e.caseSensitive, e.errorCollector)
+	analyzer.SetIncludeDDL(e.includeDDL)
+	analyzer.SetReadContextEdges(e.readContext)
+	analyzer.SetAcronyms(e.acronyms)
+	return analyzer
 }
 
 // AnalyzeDependencies performs complete dependency analysis
@@ -32,88 +79,415 @@ func (e *Engine) AnalyzeDependencies(
 	sqlQueries []types.QueryInfo,
 	goPackagePaths []string,
 ) (types.AnalysisResult, error) {
+	return e.AnalyzeDependenciesContext(context.Background(), sqlQueries, goPackagePaths)
+}
+
+// AnalyzeDependenciesContext is AnalyzeDependencies that stops loading Go packages when ctx is cancelled
+func (e *Engine) AnalyzeDependenciesContext(
+	ctx context.Context,
+	sqlQueries []types.QueryInfo,
+	goPackagePaths []string,
+) (types.AnalysisResult, error) {
+	e.lastRun = RunMetrics{QueryCount: len(sqlQueries)}
+	e.loadedPkgs, e.failedPkgs = nil, nil
 	
 	// Step 1: Analyze SQL queries to extract method and table information
-	sqlMethods, err := e.analyzeSQLQueries(sqlQueries)
+	sqlMethods, err := e.timedSQLAnalysis(sqlQueries)
 	if err != nil {
 		return types.AnalysisResult{}, fmt.Errorf("SQL analysis failed: %w", err)
 	}
 
+	// Go packages are optional: without them only the tables of the queries are reported
+	if len(goPackagePaths) == 0 {
+		return e.tablesOnlyResult(sqlMethods), nil
+	}
+
 	// Step 2: Analyze Go code to extract function and method call information
-	goFunctions, err := e.analyzeGoCode(goPackagePaths)
+	goFunctions, err := e.analyzeGoCode(ctx, goPackagePaths, sqlMethods)
+	if err != nil {
+		return types.AnalysisResult{}, fmt.Errorf("Go analysis failed: %w", err)
+	}
+
+	// Step 3 & 4: Map and validate dependencies
+	return e.mapDependencies(goFunctions, sqlMethods)
+}
+
+// AnalyzeSources performs dependency analysis on in-memory Go source files
+// Keys of sources are file paths, values are file contents
+func (e *Engine) AnalyzeSources(
+	sqlQueries []types.QueryInfo,
+	sources map[string][]byte,
+) (types.AnalysisResult, error) {
+	e.lastRun = RunMetrics{QueryCount: len(sqlQueries)}
+	e.loadedPkgs, e.failedPkgs = nil, nil
+	
+	sqlMethods, err := e.timedSQLAnalysis(sqlQueries)
+	if err != nil {
+		return types.AnalysisResult{}, fmt.Errorf("SQL analysis failed: %w", err)
+	}
+
+	goFunctions, err := e.analyzeGoSources(sources, sqlMethods)
 	if err != nil {
 		return types.AnalysisResult{}, fmt.Errorf("Go analysis failed: %w", err)
 	}
 
-	// Step 3: Map dependencies between Go functions and SQL methods
+	return e.mapDependencies(goFunctions, sqlMethods)
+}
+
+// mapDependencies maps Go functions to SQL methods and validates the result
+func (e *Engine) mapDependencies(
+	goFunctions map[string]types.GoFunctionInfo,
+	sqlMethods map[string]types.SQLMethodInfo,
+) (types.AnalysisResult, error) {
+	start := time.Now()
+	defer func() { e.lastRun.MappingDuration = time.Since(start) }()
+	
 	e.mapper = gostatic.NewDependencyMapper(e.errorCollector)
+	e.mapper.SetExternalFunctions(e.externalFuncs)
+	e.mapper.SetIgnoredMethods(e.ignoredMethods)
 	result, err := e.mapper.MapDependencies(goFunctions, sqlMethods)
 	if err != nil {
 		return types.AnalysisResult{}, fmt.Errorf("dependency mapping failed: %w", err)
 	}
 
-	// Step 4: Validate the mapping results
 	if err := e.mapper.ValidateDependencies(result); err != nil {
 		return types.AnalysisResult{}, fmt.Errorf("dependency validation failed: %w", err)
 	}
 
+	result.EntryPoints = e.mapper.FindEntryPoints(result)
+
 	return result, nil
 }
 
+// tablesOnlyResult builds a result without functions from the tables the queries access
+// OperationSummaryはテーブルごとに各操作を行うクエリの数を表す
+func (e *Engine) tablesOnlyResult(sqlMethods map[string]types.SQLMethodInfo) types.AnalysisResult {
+	e.mapper = gostatic.NewDependencyMapper(e.errorCollector)
+	result := types.AnalysisResult{
+		FunctionView: make(map[string]types.FunctionViewEntry),
+		TableView:    make(map[string]types.TableViewEntry),
+	}
+	for _, method := range sqlMethods {
+		for _, table := range method.Tables {
+			entry, exists := result.TableView[table.TableName]
+			if !exists {
+				entry = types.TableViewEntry{
+					TableName:        table.TableName,
+					AccessedBy:       make(map[string]types.FunctionAccess),
+					OperationSummary: make(map[string]int),
+				}
+			}
+			for _, operation := range table.Operations {
+				entry.OperationSummary[operation]++
+			}
+			result.TableView[table.TableName] = entry
+		}
+	}
+	return result
+}
+
+// timedSQLAnalysis runs analyzeSQLQueries and records its duration
+func (e *Engine) timedSQLAnalysis(queries []types.QueryInfo) (map[string]types.SQLMethodInfo, error) {
+	start := time.Now()
+	defer func() { e.lastRun.SQLAnalysisDuration = time.Since(start) }()
+	return e.analyzeSQLQueries(queries)
+}
+
 // analyzeSQLQueries analyzes SQL queries and extracts method information
+// A query whose analysis panics is reported as an error and the remaining queries are still analyzed
 func (e *Engine) analyzeSQLQueries(queries []types.QueryInfo) (map[string]types.SQLMethodInfo, error) {
 	sqlMethods := make(map[string]types.SQLMethodInfo)
 	reporter := errors.NewErrorReporter(e.errorCollector)
+	e.ignoredMethods = nil
+
+	var collectErr error
+	analyzed := 0
+	partialResult := errors.ProcessWithPartialFailure(
+		queries,
+		func(query types.QueryInfo) error {
+			analyzed++
+			if e.progress != nil {
+				e.progress("queries", analyzed, len(queries))
+			}
+			// エラーの上限に達した後は解析しない
+			if collectErr == nil {
+				collectErr = e.analyzeSQLQuery(query, reporter, sqlMethods)
+			}
+			return nil
+		},
+		e.errorCollector,
+		"SQL query analysis",
+	)
+	if collectErr != nil {
+		return nil, collectErr
+	}
+		
+	// Add query context to recovered panics
+	for _, err := range partialResult.Errors {
+		if index, ok := err.Details["item_index"].(int); ok && index < len(queries) {
+			err.Details["query_name"] = queries[index].Name
+			err.Details["sql"] = queries[index].SQL
+		}
+	}
+
+	if err := e.reportTableNameCaseConflicts(sqlMethods); err != nil {
+		return nil, err
+	}
+	return sqlMethods, nil
+}
+
+// analyzeSQLQuery analyzes one query into sqlMethods
+// Analysis errors are reported and skipped; only a failure to collect them is returned
+func (e *Engine) analyzeSQLQuery(query types.QueryInfo, reporter *errors.ErrorReporter, sqlMethods map[string]types.SQLMethodInfo) error {
+	// Create SQL Query object
+	sqlQuery := sql.Query{
+		Text:     query.SQL,
+		Name:     query.Name,
+		Cmd:      query.Cmd,
+		Filename: query.Filename,
+		Comments: query.Comments,
+	}
+	if sqlQuery.Cmd == "" {
+		sqlQuery.Cmd = ":exec" // Default command
+	}
+
+	// 無視指定のクエリは結果に含めず、Goからの呼び出しも警告しない
+	if sql.IsIgnored(sqlQuery) {
+		e.ignoredMethods = append(e.ignoredMethods, e.sqlAnalyzer.MethodName(sqlQuery))
+		return nil
+	}
+
+	// Analyze the SQL query
+	analysisResult, err := e.analyzeQuery(e.sqlAnalyzer, sqlQuery)
+	if err != nil {
+		// Log error but continue processing using the new error helper
+		queryReporter := reporter.WithQueryContext(query.Name, query.SQL)
+		return queryReporter.Error(errors.CategoryAnalysis,
+			fmt.Sprintf("failed to analyze SQL query: %v", err))
+	}
 
-	for _, query := range queries {
-		// Create SQL Query object
-		sqlQuery := sql.Query{
-			Text:     query.SQL,
-			Name:     query.Name,
-			Cmd:      ":exec", // Default command
-			Filename: "",
+	if e.knownTables != nil {
+		queryReporter := reporter.WithQueryContext(query.Name, query.SQL)
+		if err := e.resolveKnownTables(&analysisResult, queryReporter); err != nil {
+			return err
 		}
+	}
+
+	// The analysisResult is already a SQLMethodInfo, so use it directly
+	sqlMethods[analysisResult.MethodName] = analysisResult
+	return nil
+}
 
-		// Analyze the SQL query
-		analysisResult, err := e.sqlAnalyzer.AnalyzeQuery(sqlQuery)
-		if err != nil {
-			// Log error but continue processing using the new error helper
-			queryReporter := reporter.WithQueryContext(query.Name, query.SQL)
-			if collectErr := queryReporter.Error(errors.CategoryAnalysis, 
-				fmt.Sprintf("failed to analyze SQL query: %v", err)); collectErr != nil {
-				return nil, collectErr
+// reportTableNameCaseConflicts warns about table names that differ only by case
+// 大文字小文字を区別するモードではテーブルが別々に集計されるため、多くの場合クエリの誤り
+func (e *Engine) reportTableNameCaseConflicts(sqlMethods map[string]types.SQLMethodInfo) error {
+	// 小文字化したテーブル名 -> 表記 -> その表記を使うクエリ
+	spellings := make(map[string]map[string][]string)
+	for methodName, method := range sqlMethods {
+		for _, table := range method.Tables {
+			key := strings.ToLower(table.TableName)
+			if spellings[key] == nil {
+				spellings[key] = make(map[string][]string)
 			}
+			spellings[key][table.TableName] = append(spellings[key][table.TableName], methodName)
+		}
+	}
+	
+	reporter := errors.NewErrorReporter(e.errorCollector)
+	keys := make([]string, 0, len(spellings))
+	for key := range spellings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if len(spellings[key]) < 2 {
 			continue
 		}
-
-		// The analysisResult is already a SQLMethodInfo, so use it directly
-		sqlMethods[analysisResult.MethodName] = analysisResult
+		
+		names := make([]string, 0, len(spellings[key]))
+		for name := range spellings[key] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		usages := make([]string, 0, len(names))
+		for _, name := range names {
+			queries := spellings[key][name]
+			sort.Strings(queries)
+			usages = append(usages, fmt.Sprintf("%s (%s)", name, strings.Join(queries, ", ")))
+		}
+		details := errors.TableDetails(key)
+		details["spellings"] = strings.Join(usages, "; ")
+		
+		message := fmt.Sprintf("table names %s differ only by case and are analyzed as separate tables; "+
+			"use one spelling in the queries or disable case-sensitive table names", strings.Join(names, ", "))
+		if err := reporter.ReportWarning(errors.CategoryValidation, message, details); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	return sqlMethods, nil
+// resolveKnownTables normalizes table names to the catalog's spelling
+// Tables missing from the catalog are kept but reported as warnings
+func (e *Engine) resolveKnownTables(method *types.SQLMethodInfo, reporter *errors.QueryErrorReporter) error {
+	for i, table := range method.Tables {
+		if name, ok := e.knownTables[strings.ToLower(table.TableName)]; ok {
+			method.Tables[i].TableName = name
+			continue
+		}
+		
+		message := fmt.Sprintf("table %q is not defined in the schema catalog", table.TableName)
+		if err := reporter.Warning(errors.CategoryAnalysis, message); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // analyzeGoCode analyzes Go source code and extracts function information
-func (e *Engine) analyzeGoCode(packagePaths []string) (map[string]types.GoFunctionInfo, error) {
+func (e *Engine) analyzeGoCode(ctx context.Context, packagePaths []string, sqlMethods map[string]types.SQLMethodInfo) (map[string]types.GoFunctionInfo, error) {
 	if len(packagePaths) == 0 {
 		return make(map[string]types.GoFunctionInfo), nil
 	}
 
 	// Initialize Go analyzer
-	e.goAnalyzer = gostatic.NewAnalyzer(".", e.errorCollector)
+	e.goAnalyzer = e.newGoAnalyzer(sqlMethods)
 
 	// Load packages
-	if err := e.goAnalyzer.LoadPackages(packagePaths...); err != nil {
+	loadStart := time.Now()
+	err := e.goAnalyzer.LoadPackages(packagePaths...)
+	e.recordPackageLoad(loadStart)
+	if err != nil {
 		return nil, fmt.Errorf("failed to load Go packages: %w", err)
 	}
+	if err := e.checkLoadedPackages(len(packagePaths)); err != nil {
+		return nil, err
+	}
 
 	// Analyze packages
-	functions, err := e.goAnalyzer.AnalyzePackages()
+	analyzeStart := time.Now()
+	functions, err := e.goAnalyzer.AnalyzePackagesContext(ctx)
+	e.lastRun.GoAnalysisDuration = time.Since(analyzeStart)
 	if err != nil {
 		return nil, fmt.Errorf("failed to analyze Go packages: %w", err)
 	}
 
-	return functions, nil
+	return functions, e.analyzeInlineSQL(functions)
+}
+
+// checkLoadedPackages records which packages loaded and reports when none did
+// 「依存関係が見つからない」と「何も解析できなかった」を区別するためのエラー
+func (e *Engine) checkLoadedPackages(requested int) error {
+	e.loadedPkgs, e.failedPkgs = e.goAnalyzer.LoadSummary()
+	if len(e.loadedPkgs) > 0 || len(e.failedPkgs) == 0 {
+		return nil
+	}
+	
+	loadErr := errors.NewError(errors.CategoryParse, errors.SeverityError,
+		fmt.Sprintf("no Go packages loaded; %d of %d paths failed", len(e.failedPkgs), requested))
+	for _, failure := range e.failedPkgs {
+		loadErr.Details[failure.Path] = failure.Reason
+	}
+	return e.errorCollector.Add(loadErr)
+}
+
+// PackageLoadSummary returns the packages loaded by the last run and the paths that failed
+func (e *Engine) PackageLoadSummary() (loaded []string, failed []gostatic.PackageLoadFailure) {
+	return e.loadedPkgs, e.failedPkgs
+}
+
+// analyzeGoSources analyzes in-memory Go source files and extracts function information
+func (e *Engine) analyzeGoSources(sources map[string][]byte, sqlMethods map[string]types.SQLMethodInfo) (map[string]types.GoFunctionInfo, error) {
+	e.goAnalyzer = e.newGoAnalyzer(sqlMethods)
+
+	loadStart := time.Now()
+	err := e.goAnalyzer.LoadOverlay(sources)
+	e.recordPackageLoad(loadStart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Go sources: %w", err)
+	}
+	e.loadedPkgs, e.failedPkgs = e.goAnalyzer.LoadSummary()
+
+	analyzeStart := time.Now()
+	functions, err := e.goAnalyzer.AnalyzePackages()
+	e.lastRun.GoAnalysisDuration = time.Since(analyzeStart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze Go sources: %w", err)
+	}
+
+	return functions, e.analyzeInlineSQL(functions)
+}
+
+// analyzeInlineSQL resolves the tables of SQL strings passed directly to database/sql
+// 解析できないSQLは警告として報告し、その呼び出しはテーブルに帰属させない
+func (e *Engine) analyzeInlineSQL(functions map[string]types.GoFunctionInfo) error {
+	reporter := errors.NewErrorReporter(e.errorCollector)
+	for _, function := range functions {
+		for i, call := range function.SQLCalls {
+			if call.SQL == "" || sql.IsIgnored(sql.Query{Text: call.SQL}) {
+				continue
+			}
+			
+			queryReporter := reporter.WithQueryContext(call.MethodName, call.SQL)
+			methodInfo, err := e.sqlAnalyzer.AnalyzeQuery(sql.Query{Name: call.MethodName, Text: call.SQL})
+			if err != nil {
+				if collectErr := queryReporter.Warning(errors.CategoryAnalysis,
+					fmt.Sprintf("failed to analyze inline SQL in %s: %v", function.FunctionName, err)); collectErr != nil {
+					return collectErr
+				}
+				continue
+			}
+			
+			if e.knownTables != nil {
+				if err := e.resolveKnownTables(&methodInfo, queryReporter); err != nil {
+					return err
+				}
+			}
+			function.SQLCalls[i].Tables = methodInfo.Tables
+		}
+	}
+	return nil
+}
+
+// recordPackageLoad records the package loading duration and what was loaded
+func (e *Engine) recordPackageLoad(start time.Time) {
+	e.lastRun.PackageLoadDuration = time.Since(start)
+	e.lastRun.PackageCount, e.lastRun.FileCount = e.goAnalyzer.LoadedCounts()
+}
+
+// newGoAnalyzer creates a Go analyzer, enabling explanations when requested
+func (e *Engine) newGoAnalyzer(sqlMethods map[string]types.SQLMethodInfo) *gostatic.Analyzer {
+	rootPath := e.rootPath
+	if rootPath == "" {
+		rootPath = "."
+	}
+	goAnalyzer := gostatic.NewAnalyzer(rootPath, e.errorCollector)
+	goAnalyzer.AddMethodPrefixes(e.methodPrefixes...)
+	goAnalyzer.SetPackageFilters(e.includePkgs, e.excludePkgs)
+	goAnalyzer.SetFollowDependencies(e.followAll, e.followPkgs)
+	goAnalyzer.SetLoadRetries(e.loadRetries)
+	goAnalyzer.SetMemoryLimit(e.memoryLimit)
+	goAnalyzer.SetExternalFunctions(e.externalFuncs)
+	if e.rawSQL {
+		goAnalyzer.EnableRawSQL()
+	}
+	goAnalyzer.SetProgress(e.progress)
+	goAnalyzer.SetSkipGeneratedFiles(e.skipGenerated)
+	if e.driverCalls {
+		goAnalyzer.EnableDriverCalls()
+	}
+	goAnalyzer.SetStrictTypes(e.strictTypes)
+	
+	knownQueries := make([]string, 0, len(sqlMethods))
+	for methodName := range sqlMethods {
+		knownQueries = append(knownQueries, methodName)
+	}
+	goAnalyzer.SetKnownQueries(knownQueries)
+	if e.explain {
+		goAnalyzer.EnableExplain(knownQueries)
+	}
+	
+	return goAnalyzer
 }
 
 // GenerateReport generates a comprehensive analysis report
@@ -187,6 +561,7 @@ func (e *Engine) GetStats() EngineStats {
 		HasErrors:        e.errorCollector.HasErrors(),
 		HasWarnings:      e.errorCollector.HasWarnings(),
 		ErrorsByCategory: e.getErrorsByCategory(),
+		RunMetrics:       e.lastRun,
 	}
 }
 
@@ -207,22 +582,152 @@ type EngineStats struct {
 	HasErrors        bool           `json:"has_errors"`
 	HasWarnings      bool           `json:"has_warnings"`
 	ErrorsByCategory map[string]int `json:"errors_by_category"`
+	RunMetrics
+}
+
+// RunMetrics represents per-phase timings and input counts of the last analysis run
+type RunMetrics struct {
+	SQLAnalysisDuration time.Duration `json:"sql_analysis_duration"`
+	PackageLoadDuration time.Duration `json:"package_load_duration"`
+	GoAnalysisDuration  time.Duration `json:"go_analysis_duration"`
+	MappingDuration     time.Duration `json:"mapping_duration"`
+	QueryCount          int           `json:"query_count"`
+	PackageCount        int           `json:"package_count"`
+	FileCount           int           `json:"file_count"`
 }
 
 // Reset clears the engine state for reuse
+// The dialect and other configured settings are kept
 func (e *Engine) Reset() {
 	e.errorCollector.Clear()
-	e.sqlAnalyzer = sql.NewAnalyzer("mysql", false, e.errorCollector)
+	e.sqlAnalyzer = e.newSQLAnalyzer()
 	e.goAnalyzer = nil
 	e.mapper = nil
+	e.lastRun = RunMetrics{}
+	e.loadedPkgs, e.failedPkgs = nil, nil
 }
 
 // SetMaxErrors sets the maximum number of errors to collect
 func (e *Engine) SetMaxErrors(maxErrors int) {
-	e.errorCollector = errors.NewErrorCollector(maxErrors, e.errorCollector.IsDebugMode())
+	e.errorCollector = e.errorCollector.WithLimits(maxErrors, e.errorCollector.IsDebugMode())
+}
+
+// SetKnownTables sets the tables defined in the schema catalog
+// Extracted tables not in the set are flagged, and matching names take the catalog's case
+func (e *Engine) SetKnownTables(tables map[string]struct{}) {
+	if tables == nil {
+		e.knownTables = nil
+		return
+	}
+	
+	e.knownTables = make(map[string]string, len(tables))
+	for name := range tables {
+		e.knownTables[strings.ToLower(name)] = name
+	}
+}
+
+// SchemaTables returns the tables defined by schema DDL, named with the engine's dialect rules
+func (e *Engine) SchemaTables(schema string) []string {
+	return e.sqlAnalyzer.SchemaTables(schema)
+}
+
+// SchemaRelations returns the tables and views defined by schema DDL with their kind
+func (e *Engine) SchemaRelations(schema string) []sql.Relation {
+	return e.sqlAnalyzer.SchemaRelations(schema)
+}
+
+// SetMethodPrefixes sets additional method name prefixes recognized as sqlc query methods
+func (e *Engine) SetMethodPrefixes(prefixes []string) {
+	e.methodPrefixes = prefixes
+}
+
+// SetAcronyms sets words kept upper case in method names derived from query names (e.g. "ID", "API")
+func (e *Engine) SetAcronyms(acronyms []string) {
+	e.acronyms = acronyms
+	e.sqlAnalyzer.SetAcronyms(acronyms)
+}
+
+// SetIncludeDDL enables recording of CREATE/ALTER/DROP/TRUNCATE queries as DDL operations
+func (e *Engine) SetIncludeDDL(include bool) {
+	e.includeDDL = include
+	e.sqlAnalyzer.SetIncludeDDL(include)
+}
+
+// SetPackageFilters limits Go analysis to packages matching include and not matching exclude
+func (e *Engine) SetPackageFilters(include, exclude []string) {
+	e.includePkgs = include
+	e.excludePkgs = exclude
+}
+
+// SetFollowDependencies makes Go analysis descend into imported packages
+// all follows every dependency outside the standard library, prefixes only matching import paths
+func (e *Engine) SetFollowDependencies(all bool, prefixes []string) {
+	e.followAll = all
+	e.followPkgs = prefixes
+}
+
+// SetLoadRetries sets how many times Go package loading is retried after a transient failure
+func (e *Engine) SetLoadRetries(retries int) {
+	e.loadRetries = retries
+}
+
+// SetMemoryLimit loads Go packages in batches that fit within mb megabytes (0 disables batching)
+func (e *Engine) SetMemoryLimit(mb int) {
+	e.memoryLimit = mb
+}
+
+// SetRootPath sets the directory Go package patterns and source paths are resolved against
+// Relative patterns such as "./internal/..." then work regardless of the working directory
+func (e *Engine) SetRootPath(dir string) {
+	e.rootPath = dir
+}
+
+// SetExternalFunctions treats functions from an earlier run as call targets without analyzing them
+// Used to re-analyze a single file against the rest of a previous result; nil clears the set
+func (e *Engine) SetExternalFunctions(names []string) {
+	e.externalFuncs = names
+}
+
+// SetRawSQL also attributes tables from constant SQL strings passed to database/sql calls
+func (e *Engine) SetRawSQL(enabled bool) {
+	e.rawSQL = enabled
+}
+
+// SetSkipGeneratedFiles leaves sqlc-generated files out of the Go function analysis
+func (e *Engine) SetSkipGeneratedFiles(skip bool) {
+	e.skipGenerated = skip
+}
+
+// SetIncludeDriverCalls records direct driver method calls as DRIVER edges to the "<raw>" table
+func (e *Engine) SetIncludeDriverCalls(enabled bool) {
+	e.driverCalls = enabled
+}
+
+// SetStrictTypes fails the analysis when a Go package cannot be fully type-checked
+// Without it such packages are analyzed anyway and calls that need type information are missed
+func (e *Engine) SetStrictTypes(strict bool) {
+	e.strictTypes = strict
+}
+
+// SetProgress registers a callback reporting query-by-query ("queries") and
+// package-by-package ("packages") progress; nil disables it
+func (e *Engine) SetProgress(progress func(phase string, current, total int)) {
+	e.progress = progress
+}
+
+// SetReadContextEdges records tables write statements only read as "reference" SELECTs
+func (e *Engine) SetReadContextEdges(enabled bool) {
+	e.readContext = enabled
+	e.sqlAnalyzer.SetReadContextEdges(enabled)
+}
+
+// EnableExplainMode records why each Go method call was or wasn't linked to a query
+// Explanations are collected as informational findings
+func (e *Engine) EnableExplainMode() {
+	e.explain = true
 }
 
 // EnableDebugMode enables debug mode for detailed error information
 func (e *Engine) EnableDebugMode() {
-	e.errorCollector = errors.NewErrorCollector(e.errorCollector.GetMaxErrors(), true)
+	e.errorCollector = e.errorCollector.WithLimits(e.errorCollector.GetMaxErrors(), true)
 }
\ No newline at end of file
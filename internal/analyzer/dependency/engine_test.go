@@ -1,8 +1,10 @@
 package dependency
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/naoyafurudono/sqlc-use-analysis/internal/analyzer/sql"
 	"github.com/naoyafurudono/sqlc-use-analysis/internal/errors"
 	"github.com/naoyafurudono/sqlc-use-analysis/pkg/types"
 )
@@ -136,6 +138,160 @@ func TestEngine_analyzeSQLQueries(t *testing.T) {
 	}
 }
 
+func TestEngine_analyzeSQLQueries_Cmd(t *testing.T) {
+	engine := NewEngine(errors.NewErrorCollector(10, false))
+	
+	queries := []types.QueryInfo{
+		{Name: "GetActiveUser", SQL: "SELECT id FROM users WHERE active = 1", Cmd: ":many"},
+		{Name: "GetUser", SQL: "SELECT id FROM users WHERE id = ?"},
+	}
+	
+	result, err := engine.analyzeSQLQueries(queries)
+	if err != nil {
+		t.Fatalf("analyzeSQLQueries() error = %v", err)
+	}
+	
+	// メソッド名はクエリのcmdに従う（cmdがなければ:execとして扱う）
+	for _, name := range []string{"GetActiveUsers", "GetUser"} {
+		if _, ok := result[name]; !ok {
+			t.Errorf("Expected method %q, got %v", name, result)
+		}
+	}
+}
+
+func TestEngine_SetKnownTables(t *testing.T) {
+	errorCollector := errors.NewErrorCollector(10, false)
+	engine := NewEngine(errorCollector)
+	engine.SetKnownTables(map[string]struct{}{
+		"Users": {},
+		"posts": {},
+	})
+	
+	queries := []types.QueryInfo{
+		{Name: "GetUser", SQL: "SELECT id, name FROM users WHERE id = $1"},
+		{Name: "ListPosts", SQL: "SELECT id FROM psots"},
+	}
+	
+	result, err := engine.analyzeSQLQueries(queries)
+	if err != nil {
+		t.Fatalf("analyzeSQLQueries() error = %v", err)
+	}
+	
+	tables := make(map[string]bool)
+	for _, method := range result {
+		for _, table := range method.Tables {
+			tables[table.TableName] = true
+		}
+	}
+	
+	if !tables["Users"] {
+		t.Errorf("Expected table name normalized to catalog case 'Users', got %v", tables)
+	}
+	if !tables["psots"] {
+		t.Errorf("Expected unknown table 'psots' to be kept, got %v", tables)
+	}
+	
+	warnings := errorCollector.GetWarnings()
+	if len(warnings) != 1 {
+		t.Fatalf("Expected 1 warning, got %d", len(warnings))
+	}
+	if warnings[0].Category != errors.CategoryAnalysis {
+		t.Errorf("Expected category %s, got %s", errors.CategoryAnalysis, warnings[0].Category)
+	}
+	if !strings.Contains(warnings[0].Message, "psots") {
+		t.Errorf("Expected warning to mention 'psots', got %q", warnings[0].Message)
+	}
+}
+
+func TestEngine_analyzeSQLQueries_RecoversFromPanic(t *testing.T) {
+	errorCollector := errors.NewErrorCollector(10, false)
+	engine := NewEngineWithDialect("postgresql", false, errorCollector)
+	// Simulate an extractor bug triggered by one query
+	engine.analyzeQuery = func(analyzer *sql.Analyzer, query sql.Query) (types.SQLMethodInfo, error) {
+		if query.Name == "Broken" {
+			var tables []string
+			_ = tables[1]
+		}
+		return analyzer.AnalyzeQuery(query)
+	}
+	
+	queries := []types.QueryInfo{
+		{Name: "GetUser", SQL: "SELECT id FROM users WHERE id = $1"},
+		{Name: "Broken", SQL: "SELECT ((( FROM"},
+		{Name: "ListPosts", SQL: "SELECT id FROM posts"},
+	}
+	result, err := engine.analyzeSQLQueries(queries)
+	if err != nil {
+		t.Fatalf("analyzeSQLQueries() error = %v", err)
+	}
+	
+	for _, name := range []string{"GetUser", "ListPosts"} {
+		if len(result[name].Tables) != 1 {
+			t.Errorf("Expected %s to be analyzed, got %+v", name, result[name])
+		}
+	}
+	if _, exists := result["Broken"]; exists {
+		t.Errorf("Expected the panicking query to be skipped")
+	}
+	
+	errs := errorCollector.GetErrors()
+	if len(errs) != 1 {
+		t.Fatalf("Expected 1 error for the panicking query, got %d: %v", len(errs), errs)
+	}
+	if errs[0].Category != errors.CategoryInternal || errs[0].Details["query_name"] != "Broken" {
+		t.Errorf("Expected an internal error for Broken, got %s %v", errs[0].Category, errs[0].Details)
+	}
+}
+
+func TestEngine_TableNameCaseConflicts(t *testing.T) {
+	queries := []types.QueryInfo{
+		{Name: "GetUser", SQL: "SELECT id, name FROM Users WHERE id = $1"},
+		{Name: "ListUsers", SQL: "SELECT id, name FROM users"},
+		{Name: "ListPosts", SQL: "SELECT id FROM posts"},
+	}
+	
+	tests := []struct {
+		name          string
+		caseSensitive bool
+		wantWarning   bool
+	}{
+		{name: "case-sensitive tables", caseSensitive: true, wantWarning: true},
+		{name: "case-insensitive tables", caseSensitive: false, wantWarning: false},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errorCollector := errors.NewErrorCollector(10, false)
+			engine := NewEngineWithDialect("postgresql", tt.caseSensitive, errorCollector)
+			
+			if _, err := engine.analyzeSQLQueries(queries); err != nil {
+				t.Fatalf("analyzeSQLQueries() error = %v", err)
+			}
+			
+			warnings := errorCollector.GetWarnings()
+			if !tt.wantWarning {
+				if len(warnings) != 0 {
+					t.Errorf("Expected no warnings, got %v", warnings)
+				}
+				return
+			}
+			
+			if len(warnings) != 1 {
+				t.Fatalf("Expected 1 warning, got %d: %v", len(warnings), warnings)
+			}
+			if warnings[0].Category != errors.CategoryValidation {
+				t.Errorf("Expected category %s, got %s", errors.CategoryValidation, warnings[0].Category)
+			}
+			if !strings.Contains(warnings[0].Message, "Users, users") {
+				t.Errorf("Expected warning to name both spellings, got %q", warnings[0].Message)
+			}
+			if want := "Users (GetUser); users (ListUsers)"; warnings[0].Details["spellings"] != want {
+				t.Errorf("spellings = %v, want %q", warnings[0].Details["spellings"], want)
+			}
+		})
+	}
+}
+
 func TestEngine_GetStats(t *testing.T) {
 	engine := NewEngine(errors.NewErrorCollector(10, false))
 	
@@ -176,6 +332,22 @@ func TestEngine_SetMaxErrors(t *testing.T) {
 	}
 }
 
+func TestEngine_SetMaxErrors_KeepsCountOnly(t *testing.T) {
+	collector := errors.NewErrorCollector(10, false)
+	collector.SetCountOnly(true)
+	engine := NewEngine(collector)
+	
+	engine.SetMaxErrors(1)
+	engine.EnableDebugMode()
+	
+	for i := 0; i < 3; i++ {
+		err := errors.NewError(errors.CategoryAnalysis, errors.SeverityError, "test error")
+		if addErr := engine.errorCollector.Add(err); addErr != nil {
+			t.Fatalf("Add() #%d error = %v, want nil in count-only mode", i+1, addErr)
+		}
+	}
+}
+
 func TestEngine_Reset(t *testing.T) {
 	engine := NewEngine(errors.NewErrorCollector(10, false))
 	
@@ -197,6 +369,30 @@ func TestEngine_Reset(t *testing.T) {
 	}
 }
 
+func TestEngine_ResetKeepsDialect(t *testing.T) {
+	engine := NewEngineWithDialect("postgresql", true, errors.NewErrorCollector(10, false))
+	engine.SetIncludeDDL(true)
+	engine.Reset()
+	
+	queries := []types.QueryInfo{
+		{Name: "GetUser", SQL: `SELECT id FROM "Users" WHERE id = $1`},
+		{Name: "CreateAuditTable", SQL: "CREATE TABLE audit (id bigint)"},
+	}
+	result, err := engine.analyzeSQLQueries(queries)
+	if err != nil {
+		t.Fatalf("analyzeSQLQueries() error = %v", err)
+	}
+	
+	// Quoted PostgreSQL identifiers keep their case
+	tables := result["GetUser"].Tables
+	if len(tables) != 1 || tables[0].TableName != "Users" {
+		t.Errorf("Expected quoted table \"Users\" after Reset, got %+v", tables)
+	}
+	if tables := result["CreateAuditTable"].Tables; len(tables) != 1 || tables[0].TableName != "audit" {
+		t.Errorf("Expected DDL to stay enabled after Reset, got %+v", tables)
+	}
+}
+
 func TestEngine_isValidPackagePath(t *testing.T) {
 	tests := []struct {
 		name string
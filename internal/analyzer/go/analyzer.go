@@ -1,11 +1,25 @@
 package gostatic
 
 import (
+	"context"
+	stderrors "errors"
 	"fmt"
 	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/scanner"
 	"go/token"
 	"go/types"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
 	"strings"
+	"syscall"
 	"golang.org/x/tools/go/packages"
 
 	"github.com/naoyafurudono/sqlc-use-analysis/internal/errors"
@@ -18,70 +32,640 @@ type Analyzer struct {
 	errorCollector  *errors.ErrorCollector
 	fset            *token.FileSet
 	packages        []*packages.Package
+	explain         bool
+	knownQueries    map[string]bool
+	methodPrefixes  []string
+	includePackages []string
+	excludePackages []string
+	followAll       bool     // 標準ライブラリ以外の依存パッケージもすべて解析する
+	followPackages  []string // 解析対象に加える依存パッケージのインポートパスの接頭辞
+	followed        map[string]bool // 解析対象に加えた依存パッケージ
+	loader          packageLoader
+	loadRetries     int
+	rawSQL          bool
+	skipGenerated   bool
+	driverCalls     bool
+	strictTypes     bool
+	generated       map[string]bool // 除外した生成ファイルで定義されたメソッド名
+	progress        func(phase string, current, total int)
+	memoryLimit     int                    // MB単位、0は無制限
+	batches         [][]*packages.Package  // 上限を超える場合に分割して読み込むパッケージ
+	memoryUsage     func() uint64          // 現在のヒープ使用量（テストで差し替え可能）
+	memoryWarned    bool
+	external        map[string]bool // 以前の解析で得た、呼び出し先として扱う関数
 }
 
+// estimatedFileMemory is the rough cost of one Go file with its syntax tree and type information
+const estimatedFileMemory = 2 << 20
+
+// packageLoader loads packages matching patterns (packages.Load, replaceable in tests)
+type packageLoader func(cfg *packages.Config, patterns ...string) ([]*packages.Package, error)
+
 // NewAnalyzer creates a new Go static analyzer
 func NewAnalyzer(packagePath string, errorCollector *errors.ErrorCollector) *Analyzer {
 	return &Analyzer{
 		packagePath:    packagePath,
 		errorCollector: errorCollector,
 		fset:          token.NewFileSet(),
+		loader:         packages.Load,
+		memoryUsage:    heapInUse,
+	}
+}
+
+// EnableExplain records why each method call was or wasn't linked to a query
+// Explanations are collected as SeverityInfo findings; knownQueries are the
+// query method names calls are matched against
+func (a *Analyzer) EnableExplain(knownQueries []string) {
+	a.explain = true
+	a.SetKnownQueries(knownQueries)
+}
+
+// SetExternalFunctions makes calls to functions analyzed in an earlier run count as direct calls
+// even though their packages are not loaded (incremental analysis)
+func (a *Analyzer) SetExternalFunctions(names []string) {
+	a.external = make(map[string]bool, len(names))
+	for _, name := range names {
+		a.external[name] = true
 	}
 }
 
+// SetKnownQueries sets the query method names used to recognize interfaces standing in for Queries
+func (a *Analyzer) SetKnownQueries(knownQueries []string) {
+	a.knownQueries = make(map[string]bool, len(knownQueries))
+	for _, name := range knownQueries {
+		a.knownQueries[name] = true
+	}
+}
+
+// AddMethodPrefixes adds method name prefixes that identify sqlc query methods
+// in addition to the defaults (Get, List, Create, ...)
+func (a *Analyzer) AddMethodPrefixes(prefixes ...string) {
+	for _, prefix := range prefixes {
+		if prefix != "" {
+			a.methodPrefixes = append(a.methodPrefixes, prefix)
+		}
+	}
+}
+
+// EnableRawSQL records database/sql Query/Exec/Prepare calls whose SQL argument is a
+// constant string; the SQL is kept on the call for the caller to analyze
+func (a *Analyzer) EnableRawSQL() {
+	a.rawSQL = true
+}
+
+// EnableDriverCalls records direct calls to driver methods such as QueryRowContext,
+// on database/sql types or on interfaces like sqlc's DBTX, as driver SQL calls
+func (a *Analyzer) EnableDriverCalls() {
+	a.driverCalls = true
+}
+
+// SetStrictTypes reports packages with type errors or without type information as errors
+// and makes loading fail, since calls in them cannot be resolved reliably
+func (a *Analyzer) SetStrictTypes(strict bool) {
+	a.strictTypes = strict
+}
+
+// SetSkipGeneratedFiles leaves functions in sqlc-generated files out of the analysis
+// The methods those files declare are still recognized as query methods at call sites
+func (a *Analyzer) SetSkipGeneratedFiles(skip bool) {
+	a.skipGenerated = skip
+}
+
+// SetProgress registers a callback invoked as each package is analyzed
+func (a *Analyzer) SetProgress(progress func(phase string, current, total int)) {
+	a.progress = progress
+}
+
+// SetPackageFilters restricts which loaded packages are analyzed
+// Patterns are matched against import paths on segment boundaries, may use glob
+// wildcards per segment, and a trailing "/..." also matches sub-packages.
+// When include is non-empty only matching packages are analyzed; exclude always wins.
+func (a *Analyzer) SetPackageFilters(include, exclude []string) {
+	a.includePackages = include
+	a.excludePackages = exclude
+}
+
+// SetFollowDependencies makes the analysis descend into packages the loaded packages import
+// When all is set every dependency outside the standard library is analyzed; otherwise
+// only dependencies whose import path starts with one of prefixes (on a "/" boundary).
+// By default only the requested packages are analyzed.
+func (a *Analyzer) SetFollowDependencies(all bool, prefixes []string) {
+	a.followAll = all
+	a.followPackages = prefixes
+}
+
+// SetLoadRetries sets how many times package loading is retried after a transient failure
+// Only known transient failures (network and I/O errors, a killed go list) are retried; 0 disables retries
+func (a *Analyzer) SetLoadRetries(retries int) {
+	a.loadRetries = retries
+}
+
+// SetMemoryLimit bounds the memory used for syntax trees and type information, in MB
+// When the packages do not fit, they are loaded and analyzed in batches; 0 loads everything at once
+// Batches are split further while the heap exceeds the limit, and analysis fails when that is not enough
+func (a *Analyzer) SetMemoryLimit(mb int) {
+	a.memoryLimit = mb
+}
+
 // LoadPackages loads Go packages for analysis
 func (a *Analyzer) LoadPackages(patterns ...string) error {
-	cfg := &packages.Config{
-		Mode: packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
-			packages.NeedImports | packages.NeedTypes | packages.NeedSyntax |
-			packages.NeedTypesInfo | packages.NeedTypesSizes,
+	a.batches = nil
+	if a.memoryLimit > 0 {
+		return a.loadBatched(patterns)
+	}
+	return a.loadAll(a.newLoadConfig(), patterns)
+}
+
+// loadBatched lists the packages without syntax and splits them into batches that fit the memory limit
+// 全体が上限に収まる場合は通常どおり一括で読み込む
+func (a *Analyzer) loadBatched(patterns []string) error {
+	listCfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles,
+		Dir:  a.packagePath,
 		Fset: a.fset,
 	}
+	pkgs, err := a.load(listCfg, patterns)
+	if err != nil {
+		return err
+	}
+	a.packages = pkgs
+	
+	// パッケージのエラーは構文を読み込むバッチ側で記録する
+	if batches := a.planBatches(a.filteredPackages()); len(batches) > 1 {
+		a.batches = batches
+		return nil
+	}
+	return a.loadAll(a.newLoadConfig(), patterns)
+}
 
-	// Use error recovery for package loading
-	err := errors.SafeExecute(a.errorCollector, func() error {
-		pkgs, err := packages.Load(cfg, patterns...)
-		if err != nil {
-			return fmt.Errorf("failed to load packages: %w", err)
+// planBatches groups packages so that the estimated memory of each batch fits the limit
+// 単独で上限を超えるパッケージはそれだけで1つのバッチとする
+func (a *Analyzer) planBatches(pkgs []*packages.Package) [][]*packages.Package {
+	budget := int64(a.memoryLimit) << 20
+	var batches [][]*packages.Package
+	var current []*packages.Package
+	var size int64
+	for _, pkg := range pkgs {
+		cost := int64(max(len(pkg.CompiledGoFiles), 1)) * estimatedFileMemory
+		if len(current) > 0 && size+cost > budget {
+			batches = append(batches, current)
+			current, size = nil, 0
 		}
+		current = append(current, pkg)
+		size += cost
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+	return batches
+}
 
-		// Check for package loading errors
-		for _, pkg := range pkgs {
-			if len(pkg.Errors) > 0 {
-				for _, pkgErr := range pkg.Errors {
-					goErr := errors.NewError(errors.CategoryParse, errors.SeverityError,
-						fmt.Sprintf("package loading error: %s", pkgErr.Msg))
-					goErr.Details["package"] = pkg.PkgPath
-					goErr.Details["package_name"] = pkg.Name
-					goErr.Details["error_position"] = pkgErr.Pos
+// loadAll loads packages with syntax and type information and records their errors
+func (a *Analyzer) loadAll(cfg *packages.Config, patterns []string) error {
+	pkgs, err := a.load(cfg, patterns)
+	if err != nil {
+		return err
+	}
+	a.packages = pkgs
+	return a.recordPackageErrors(pkgs)
+}
 
-					if collectErr := a.errorCollector.Add(goErr); collectErr != nil {
-						return collectErr
-					}
+// LoadOverlay loads Go packages from in-memory source files
+// Relative file paths are resolved against the analyzer's package path
+func (a *Analyzer) LoadOverlay(files map[string][]byte) error {
+	if len(files) == 0 {
+		return fmt.Errorf("no source files provided")
+	}
+	
+	root, err := filepath.Abs(a.packagePath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve package path '%s': %w", a.packagePath, err)
+	}
+	
+	cfg := a.newLoadConfig()
+	cfg.Dir = root
+	cfg.Overlay = make(map[string][]byte, len(files))
+	// メモリ上のファイル同士のインポートを解決するため、依存パッケージもソースから型検査する
+	cfg.Mode |= packages.NeedDeps
+	
+	// ディレクトリごとに1ファイルを指定してパッケージを特定
+	dirs := make(map[string]string)
+	for name, content := range files {
+		path := name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(root, path)
+		}
+		cfg.Overlay[path] = content
+		
+		dir := filepath.Dir(path)
+		if current, exists := dirs[dir]; !exists || path < current {
+			dirs[dir] = path
+		}
+	}
+	
+	patterns := make([]string, 0, len(dirs))
+	for _, path := range dirs {
+		patterns = append(patterns, "file="+path)
+	}
+	sort.Strings(patterns)
+	
+	a.batches = nil
+	return a.loadAll(cfg, patterns)
+}
+
+// newLoadConfig creates the package loading configuration shared by all loaders
+// パターンはパッケージパス（モジュールのルート）を基準に解決する
+func (a *Analyzer) newLoadConfig() *packages.Config {
+	mode := packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
+		packages.NeedImports | packages.NeedTypes | packages.NeedSyntax |
+		packages.NeedTypesInfo | packages.NeedTypesSizes
+	// 依存パッケージの型はエクスポートデータから読む。構文木まで読み込むのは依存パッケージも解析する場合のみ
+	if a.followAll || len(a.followPackages) > 0 {
+		mode |= packages.NeedDeps | packages.NeedModule
+	}
+	return &packages.Config{
+		Mode: mode,
+		Dir:  a.packagePath,
+		Fset: a.fset,
+	}
+}
+
+// load loads packages matching patterns
+// Transient failures are retried with the recovery options when retries are enabled.
+// Every attempt runs under SafeExecute, so a panic in the loader is recorded and returned as an error.
+func (a *Analyzer) load(cfg *packages.Config, patterns []string) ([]*packages.Package, error) {
+	var pkgs []*packages.Package
+	completed := false
+	attempt := func() error {
+		completed = false
+		var err error
+		pkgs, err = a.loadOnce(cfg, patterns)
+		completed = true
+		if err != nil && isTransientLoadError(err) && a.loadRetries > 0 {
+			// 再試行で回復する可能性があるため、失敗した試行は警告として記録する
+			attemptErr := errors.NewError(errors.CategoryIO, errors.SeverityWarning, err.Error())
+			attemptErr.Wrapped = err
+			return attemptErr
+		}
+		return err
+	}
+	
+	var err error
+	if a.loadRetries <= 0 {
+		err = errors.SafeExecute(a.errorCollector, attempt, "Go package loading")
+	} else {
+		options := errors.DefaultRecoveryOptions()
+		options.MaxRetries = a.loadRetries
+		options.Retryable = isTransientLoadError
+		// RetryWithRecovery runs each attempt under SafeExecute
+		err = errors.RetryWithRecovery(attempt, options, a.errorCollector, "Go package loading")
+	}
+	
+	// SafeExecuteはパニックを記録した後にnilを返すため、読み込みが完了していなければ失敗として扱う
+	if err == nil && !completed {
+		return nil, fmt.Errorf("failed to load packages: the package loader panicked")
+	}
+	return pkgs, err
+}
+
+// loadOnce runs the package loader once
+func (a *Analyzer) loadOnce(cfg *packages.Config, patterns []string) ([]*packages.Package, error) {
+	pkgs, err := a.loader(cfg, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load packages: %w", err)
+	}
+	return pkgs, nil
+}
+
+// recordPackageErrors records errors reported for individual packages
+func (a *Analyzer) recordPackageErrors(pkgs []*packages.Package) error {
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			for _, pkgErr := range pkg.Errors {
+				goErr := errors.NewError(errors.CategoryParse, errors.SeverityError,
+					fmt.Sprintf("package loading error: %s", pkgErr.Msg))
+				goErr.Details["package"] = pkg.PkgPath
+				goErr.Details["package_name"] = pkg.Name
+				goErr.Details["error_position"] = pkgErr.Pos
+
+				if collectErr := a.errorCollector.Add(goErr); collectErr != nil {
+					return collectErr
 				}
 			}
 		}
+	}
+	
+	if a.strictTypes {
+		return a.checkTypeInfo(pkgs)
+	}
+	return nil
+}
 
-		a.packages = pkgs
-		return nil
-	}, "Go package loading")
+// checkTypeInfo records packages whose type information is incomplete and fails if there are any
+// 型情報が欠けているとTypesInfo.TypeOfがnilを返し、クエリ呼び出しが検出されないまま結果が空になる
+func (a *Analyzer) checkTypeInfo(pkgs []*packages.Package) error {
+	var incomplete []string
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo != nil && pkg.Types != nil && !pkg.IllTyped {
+			continue
+		}
+		incomplete = append(incomplete, pkg.PkgPath)
+		
+		typeErr := errors.NewError(errors.CategoryAnalysis, errors.SeverityError,
+			fmt.Sprintf("incomplete type information for package '%s': query calls may be missed", pkg.PkgPath))
+		typeErr.Details["package"] = pkg.PkgPath
+		typeErr.Details["package_name"] = pkg.Name
+		if collectErr := a.errorCollector.Add(typeErr); collectErr != nil {
+			return collectErr
+		}
+	}
+	
+	if len(incomplete) > 0 {
+		return fmt.Errorf("incomplete type information for %d package(s) (strict types): %s",
+			len(incomplete), strings.Join(incomplete, ", "))
+	}
+	return nil
+}
+
+// transientLoadMessages are parts of go list failure messages that may not recur on a retry
+// go listの失敗はエラー型を持たないため、メッセージで判定する
+var transientLoadMessages = []string{
+	"unexpected EOF",
+	"i/o timeout",
+	"signal: killed",
+	"connection reset",
+	"connection refused",
+	"TLS handshake timeout",
+	"temporary failure",
+	"resource temporarily unavailable",
+	"text file busy",
+}
+
+// isTransientLoadError reports whether a package loading failure may succeed when retried
+// 既知の一時的な失敗（ネットワークやI/O、go listの強制終了）のみを対象とし、
+// 構文エラーや型エラー、エラー数の上限到達などは再試行しない
+func isTransientLoadError(err error) bool {
+	var scanErrs scanner.ErrorList
+	var scanErr scanner.Error
+	var typeErr types.Error
+	var pkgErr packages.Error
+	var netErr net.Error
+	switch {
+	case stderrors.As(err, &scanErrs), stderrors.As(err, &scanErr), stderrors.As(err, &typeErr):
+		return false
+	case stderrors.As(err, &pkgErr):
+		if pkgErr.Kind == packages.ParseError || pkgErr.Kind == packages.TypeError {
+			return false
+		}
+	case stderrors.Is(err, context.Canceled), stderrors.Is(err, context.DeadlineExceeded):
+		return false
+	case stderrors.As(err, &netErr) && netErr.Timeout(),
+		stderrors.Is(err, io.ErrUnexpectedEOF), stderrors.Is(err, os.ErrDeadlineExceeded),
+		stderrors.Is(err, syscall.EAGAIN), stderrors.Is(err, syscall.EINTR),
+		stderrors.Is(err, syscall.ECONNRESET), stderrors.Is(err, syscall.ETXTBSY):
+		return true
+	}
+	
+	message := err.Error()
+	for _, transient := range transientLoadMessages {
+		if strings.Contains(message, transient) {
+			return true
+		}
+	}
+	return false
+}
 
-	return err
+// LoadedCounts returns the number of loaded packages and their parsed Go files
+// In batched mode files are counted from the package listing, since they are parsed per batch
+func (a *Analyzer) LoadedCounts() (packageCount, fileCount int) {
+	for _, pkg := range a.packages {
+		if a.batches != nil {
+			fileCount += len(pkg.CompiledGoFiles)
+		} else {
+			fileCount += len(pkg.Syntax)
+		}
+	}
+	return len(a.packages), fileCount
+}
+
+// PackageLoadFailure describes a requested package that could not be loaded
+type PackageLoadFailure struct {
+	Path   string
+	Reason string
+}
+
+// LoadSummary splits the loaded packages into those with Go files and those that failed to load
+// Packages that failed have no files and are identified by the path they were requested with
+func (a *Analyzer) LoadSummary() (loaded []string, failed []PackageLoadFailure) {
+	for _, pkg := range a.packages {
+		if len(pkg.Errors) == 0 || len(pkg.GoFiles)+len(pkg.CompiledGoFiles) > 0 {
+			loaded = append(loaded, pkg.PkgPath)
+			continue
+		}
+		
+		reasons := make([]string, 0, len(pkg.Errors))
+		for _, pkgErr := range pkg.Errors {
+			reasons = append(reasons, pkgErr.Msg)
+		}
+		failed = append(failed, PackageLoadFailure{Path: pkg.ID, Reason: strings.Join(reasons, "; ")})
+	}
+	return loaded, failed
 }
 
 // AnalyzePackages analyzes loaded packages and extracts function information
 func (a *Analyzer) AnalyzePackages() (map[string]pkgtypes.GoFunctionInfo, error) {
+	return a.AnalyzePackagesContext(context.Background())
+}
+
+// AnalyzePackagesContext is AnalyzePackages that stops when ctx is cancelled
+// Cancellation is checked between batches, packages and functions
+func (a *Analyzer) AnalyzePackagesContext(ctx context.Context) (map[string]pkgtypes.GoFunctionInfo, error) {
 	if len(a.packages) == 0 {
 		return nil, fmt.Errorf("no packages loaded")
 	}
+	if a.batches != nil {
+		return a.analyzeBatches(ctx)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	functions := make(map[string]pkgtypes.GoFunctionInfo)
+	a.followed = make(map[string]bool)
+	targets := append(append([]*packages.Package{}, a.filteredPackages()...), a.followedDependencies(a.packages)...)
+	analyzed := 0
+	
+	// 呼び出し側の解析より先に、生成ファイルからメソッド名を収集する
+	if a.skipGenerated {
+		a.generated = make(map[string]bool)
+		a.collectGeneratedMethods(a.packages)
+	}
+
+	if err := a.analyzeTargets(ctx, targets, functions, &analyzed, len(targets)); err != nil {
+		return nil, err
+	}
+	return functions, nil
+}
+
+// analyzeBatches loads, analyzes and releases each batch of packages in turn
+// 各バッチの構文木と型情報は関数情報を取り出した後に破棄する
+func (a *Analyzer) analyzeBatches(ctx context.Context) (map[string]pkgtypes.GoFunctionInfo, error) {
+	functions := make(map[string]pkgtypes.GoFunctionInfo)
+	a.followed = make(map[string]bool)
+	total, analyzed := 0, 0
+	for _, batch := range a.batches {
+		total += len(batch)
+	}
+	
+	if a.skipGenerated {
+		a.generated = make(map[string]bool)
+		for _, batch := range a.batches {
+			var withGenerated []*packages.Package
+			for _, pkg := range batch {
+				if hasGeneratedFiles(pkg) {
+					withGenerated = append(withGenerated, pkg)
+				}
+			}
+			if len(withGenerated) == 0 {
+				continue
+			}
+			// エラーは本体の解析で記録するため、ここでは記録しない
+			loaded, err := a.loadBatch(ctx, withGenerated)
+			if err != nil {
+				return nil, err
+			}
+			a.collectGeneratedMethods(loaded)
+			releasePackages(loaded)
+		}
+	}
+	
+	// 上限を超えた場合は残りのバッチを分割するため、a.batchesは走査中に伸びることがある
+	for i := 0; i < len(a.batches); i++ {
+		loaded, err := a.loadBatch(ctx, a.batches[i])
+		if err != nil {
+			return nil, err
+		}
+		if err := a.recordPackageErrors(loaded); err != nil {
+			return nil, err
+		}
+		
+		dependencies := a.followedDependencies(loaded)
+		total += len(dependencies)
+		if err := a.analyzeTargets(ctx, append(loaded, dependencies...), functions, &analyzed, total); err != nil {
+			return nil, err
+		}
+		releasePackages(loaded)
+		if err := a.enforceMemoryLimit(i + 1); err != nil {
+			return nil, err
+		}
+	}
+	return functions, nil
+}
+
+// loadBatch loads one batch of listed packages with syntax and type information
+func (a *Analyzer) loadBatch(ctx context.Context, batch []*packages.Package) ([]*packages.Package, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	
+	ids := make([]string, 0, len(batch))
+	for _, pkg := range batch {
+		ids = append(ids, pkg.ID)
+	}
+	// 位置情報はバッチごとのFileSetに記録し、解析済みのバッチのファイルを保持し続けないようにする
+	a.fset = token.NewFileSet()
+	cfg := a.newLoadConfig()
+	cfg.Context = ctx
+	return a.load(cfg, ids)
+}
+
+// releasePackages drops the syntax trees and type information of analyzed packages
+func releasePackages(pkgs []*packages.Package) {
+	for _, pkg := range pkgs {
+		pkg.Syntax = nil
+		pkg.TypesInfo = nil
+		pkg.Types = nil
+		pkg.Imports = nil
+	}
+}
+
+// enforceMemoryLimit frees released batches and, when the heap stays above the limit,
+// splits the batches from next on; it fails when they cannot be made any smaller
+func (a *Analyzer) enforceMemoryLimit(next int) error {
+	limit := uint64(a.memoryLimit) << 20
+	if a.memoryUsage() <= limit {
+		return nil
+	}
+	
+	// 破棄したバッチを回収してから再計測する
+	debug.FreeOSMemory()
+	usage := a.memoryUsage()
+	if usage <= limit {
+		return nil
+	}
+	
+	if !a.splitBatches(next) {
+		return fmt.Errorf("memory usage of %d MB exceeds the limit of %d MB with one package per batch", usage>>20, a.memoryLimit)
+	}
+	if a.memoryWarned {
+		return nil
+	}
+	a.memoryWarned = true
+	memErr := errors.NewError(errors.CategoryInternal, errors.SeverityWarning,
+		fmt.Sprintf("memory usage of %d MB exceeds the limit of %d MB; loading the remaining packages in smaller batches", usage>>20, a.memoryLimit))
+	memErr.Details["batches"] = len(a.batches)
+	return a.errorCollector.Add(memErr)
+}
+
+// splitBatches halves every batch from next on and reports whether any batch was split
+func (a *Analyzer) splitBatches(next int) bool {
+	if next >= len(a.batches) {
+		// 残りのバッチがなければ分割する必要はない
+		return true
+	}
+	
+	split := false
+	batches := append([][]*packages.Package{}, a.batches[:next]...)
+	for _, batch := range a.batches[next:] {
+		if len(batch) < 2 {
+			batches = append(batches, batch)
+			continue
+		}
+		half := len(batch) / 2
+		batches = append(batches, batch[:half], batch[half:])
+		split = true
+	}
+	a.batches = batches
+	return split
+}
 
+// heapInUse returns the bytes of allocated heap objects
+func heapInUse() uint64 {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+// analyzeTargets analyzes packages into functions, reporting progress against total
+// It returns ctx's error when ctx is cancelled, leaving the remaining packages unanalyzed
+func (a *Analyzer) analyzeTargets(ctx context.Context, targets []*packages.Package, functions map[string]pkgtypes.GoFunctionInfo, analyzed *int, total int) error {
 	// Use error recovery for robust package processing
 	partialResult := errors.ProcessWithPartialFailure(
-		a.packages,
+		targets,
 		func(pkg *packages.Package) error {
-			pkgFunctions, err := a.analyzePackage(pkg)
+			// キャンセルは解析エラーとして記録せず、呼び出し元に返す
+			if ctx.Err() != nil {
+				return nil
+			}
+			*analyzed++
+			if a.progress != nil {
+				a.progress("packages", *analyzed, total)
+			}
+			
+			pkgFunctions, err := a.analyzePackage(ctx, pkg)
+			if ctx.Err() != nil {
+				return nil
+			}
 			if err != nil {
 				return errors.Wrap(err, fmt.Sprintf("failed to analyze package '%s'", pkg.PkgPath))
 			}
@@ -98,7 +682,7 @@ func (a *Analyzer) AnalyzePackages() (map[string]pkgtypes.GoFunctionInfo, error)
 
 	// Add package context to errors
 	for _, err := range partialResult.Errors {
-		for _, pkg := range a.packages {
+		for _, pkg := range targets {
 			if strings.Contains(err.Message, pkg.PkgPath) {
 				err.Details["package"] = pkg.PkgPath
 				err.Details["package_name"] = pkg.Name
@@ -106,18 +690,142 @@ func (a *Analyzer) AnalyzePackages() (map[string]pkgtypes.GoFunctionInfo, error)
 			}
 		}
 	}
+	return ctx.Err()
+}
 
-	return functions, nil
+// filteredPackages returns the loaded packages selected by the include/exclude filters
+func (a *Analyzer) filteredPackages() []*packages.Package {
+	if len(a.includePackages) == 0 && len(a.excludePackages) == 0 {
+		return a.packages
+	}
+	
+	var selected []*packages.Package
+	for _, pkg := range a.packages {
+		if len(a.includePackages) > 0 && !matchesAnyPackagePattern(a.includePackages, pkg.PkgPath) {
+			continue
+		}
+		if matchesAnyPackagePattern(a.excludePackages, pkg.PkgPath) {
+			continue
+		}
+		selected = append(selected, pkg)
+	}
+	return selected
+}
+
+// followedDependencies returns the imported packages of roots selected by SetFollowDependencies
+// Packages already analyzed or returned by an earlier call are skipped
+func (a *Analyzer) followedDependencies(roots []*packages.Package) []*packages.Package {
+	if !a.followAll && len(a.followPackages) == 0 {
+		return nil
+	}
+	
+	isRoot := make(map[string]bool, len(a.packages))
+	for _, pkg := range a.packages {
+		isRoot[pkg.PkgPath] = true
+	}
+	
+	var dependencies []*packages.Package
+	packages.Visit(roots, nil, func(pkg *packages.Package) {
+		if isRoot[pkg.PkgPath] || a.followed[pkg.PkgPath] || len(pkg.Syntax) == 0 || !a.followsPackage(pkg) {
+			return
+		}
+		a.followed[pkg.PkgPath] = true
+		dependencies = append(dependencies, pkg)
+	})
+	sort.Slice(dependencies, func(i, j int) bool {
+		return dependencies[i].PkgPath < dependencies[j].PkgPath
+	})
+	return dependencies
+}
+
+// followsPackage reports whether a dependency is analyzed
+func (a *Analyzer) followsPackage(pkg *packages.Package) bool {
+	if a.followAll {
+		return !isStandardPackage(pkg)
+	}
+	for _, prefix := range a.followPackages {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if prefix != "" && (pkg.PkgPath == prefix || strings.HasPrefix(pkg.PkgPath, prefix+"/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// isStandardPackage reports whether pkg belongs to the standard library
+// 標準ライブラリのパッケージはモジュールに属さない（myapp/... のようなドットのないモジュールも区別できる）
+func isStandardPackage(pkg *packages.Package) bool {
+	return pkg.Module == nil
+}
+
+// matchesAnyPackagePattern reports whether pkgPath matches one of the patterns
+func matchesAnyPackagePattern(patterns []string, pkgPath string) bool {
+	for _, pattern := range patterns {
+		if matchPackagePattern(pattern, pkgPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPackagePattern matches an import path against a package filter pattern
+// e.g. "internal/..." matches ".../internal" and ".../internal/db", "internal/telemetry" only that package
+func matchPackagePattern(pattern, pkgPath string) bool {
+	pattern = strings.Trim(pattern, "/")
+	if pattern == "" {
+		return false
+	}
+	if pattern == "..." {
+		return true
+	}
+	
+	recursive := strings.HasSuffix(pattern, "/...")
+	patternSegments := strings.Split(strings.TrimSuffix(pattern, "/..."), "/")
+	pathSegments := strings.Split(pkgPath, "/")
+	
+	// パターンはインポートパスの任意のセグメント境界から一致させる
+	for start := 0; start+len(patternSegments) <= len(pathSegments); start++ {
+		end := start + len(patternSegments)
+		if !segmentsMatch(patternSegments, pathSegments[start:end]) {
+			continue
+		}
+		if recursive || end == len(pathSegments) {
+			return true
+		}
+	}
+	return false
+}
+
+// segmentsMatch matches path segments pairwise using glob patterns
+func segmentsMatch(patterns, segments []string) bool {
+	for i, pattern := range patterns {
+		if matched, err := path.Match(pattern, segments[i]); err != nil || !matched {
+			return false
+		}
+	}
+	return true
 }
 
 // analyzePackage analyzes a single package
-func (a *Analyzer) analyzePackage(pkg *packages.Package) (map[string]pkgtypes.GoFunctionInfo, error) {
+// ctx is checked before each function so that huge files do not delay cancellation
+func (a *Analyzer) analyzePackage(ctx context.Context, pkg *packages.Package) (map[string]pkgtypes.GoFunctionInfo, error) {
 	functions := make(map[string]pkgtypes.GoFunctionInfo)
 
 	for _, file := range pkg.Syntax {
+		if a.skipGenerated && a.isGeneratedFile(file) {
+			continue
+		}
+		
 		ast.Inspect(file, func(n ast.Node) bool {
 			switch node := n.(type) {
 			case *ast.FuncDecl:
+				if ctx.Err() != nil {
+					return false
+				}
+				// 無視指定の関数は本体内のクロージャも含めて解析しない
+				if hasIgnoreDirective(node.Doc) {
+					return false
+				}
 				funcInfo, err := a.analyzeFuncDecl(node, pkg)
 				if err != nil {
 					// エラーを収集して処理を継続
@@ -132,15 +840,93 @@ func (a *Analyzer) analyzePackage(pkg *packages.Package) (map[string]pkgtypes.Go
 					return true
 				}
 
-				functions[funcInfo.FunctionName] = funcInfo
+				// 別パッケージの同名関数と衝突しないようインポートパスで修飾したキーを使う
+				functions[funcInfo.FullName] = funcInfo
 			}
 			return true
 		})
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 	}
 
 	return functions, nil
 }
 
+// collectGeneratedMethods records the methods declared in sqlc-generated files of pkgs
+func (a *Analyzer) collectGeneratedMethods(pkgs []*packages.Package) {
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			if !a.isGeneratedFile(file) {
+				continue
+			}
+			for _, decl := range file.Decls {
+				funcDecl, ok := decl.(*ast.FuncDecl)
+				if !ok || funcDecl.Recv == nil || pkg.TypesInfo == nil {
+					continue
+				}
+				// クエリメソッドはcontext.Contextを第1引数に取る（WithTxなどを除く）
+				if fn, ok := pkg.TypesInfo.Defs[funcDecl.Name].(*types.Func); ok {
+					params := fn.Type().(*types.Signature).Params()
+					if params.Len() > 0 && params.At(0).Type().String() == "context.Context" {
+						a.generated[funcDecl.Name.Name] = true
+					}
+				}
+			}
+		}
+	}
+}
+
+// isGeneratedFile reports whether file was generated by sqlc, judged by its
+// "Code generated by sqlc. DO NOT EDIT." header or a *.sql.go file name
+func (a *Analyzer) isGeneratedFile(file *ast.File) bool {
+	return isGeneratedSource(a.fset.Position(file.Package).Filename, file)
+}
+
+// hasGeneratedFiles reports whether a listed package contains sqlc-generated files
+// 構文木を読み込む前なので、ファイル先頭のコメントだけを解析して判定する
+func hasGeneratedFiles(pkg *packages.Package) bool {
+	for _, filename := range pkg.CompiledGoFiles {
+		file, err := parser.ParseFile(token.NewFileSet(), filename, nil, parser.PackageClauseOnly|parser.ParseComments)
+		if err == nil && isGeneratedSource(filename, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// isGeneratedSource judges a file by its name and the comments above its package clause
+func isGeneratedSource(filename string, file *ast.File) bool {
+	if strings.HasSuffix(filename, ".sql.go") {
+		return true
+	}
+	for _, group := range file.Comments {
+		if group.Pos() > file.Package {
+			break
+		}
+		if strings.Contains(group.Text(), "Code generated by sqlc") {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoreDirective excludes the function it documents from analysis
+const ignoreDirective = "//sqlc-analysis:ignore"
+
+// hasIgnoreDirective reports whether a function's doc comment contains the ignore directive
+func hasIgnoreDirective(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, comment := range doc.List {
+		if fields := strings.Fields(comment.Text); len(fields) > 0 && fields[0] == ignoreDirective {
+			return true
+		}
+	}
+	return false
+}
+
 // analyzeFuncDecl analyzes a function declaration
 func (a *Analyzer) analyzeFuncDecl(funcDecl *ast.FuncDecl, pkg *packages.Package) (pkgtypes.GoFunctionInfo, error) {
 	funcName := funcDecl.Name.Name
@@ -155,8 +941,10 @@ func (a *Analyzer) analyzeFuncDecl(funcDecl *ast.FuncDecl, pkg *packages.Package
 	pos := a.fset.Position(funcDecl.Pos())
 
 	funcInfo := pkgtypes.GoFunctionInfo{
+		FullName:     QualifiedName(pkg.PkgPath, funcName),
 		FunctionName: funcName,
 		PackageName:  pkg.Name,
+		PackagePath:  pkg.PkgPath,
 		FileName:     pos.Filename,
 		FilePath:     pos.Filename,
 		StartLine:    pos.Line,
@@ -165,8 +953,14 @@ func (a *Analyzer) analyzeFuncDecl(funcDecl *ast.FuncDecl, pkg *packages.Package
 	}
 
 	// 関数内のSQLメソッド呼び出しを抽出
-	sqlCalls := a.extractSQLCalls(funcDecl.Body, pkg)
+	sqlCalls, err := a.extractSQLCalls(funcDecl.Body, pkg)
+	if err != nil {
+		return funcInfo, err
+	}
 	funcInfo.SQLCalls = sqlCalls
+	
+	// 呼び出しグラフ用に解析対象パッケージ内の関数参照を抽出
+	funcInfo.DirectCalls = a.extractDirectCalls(funcDecl.Body, pkg)
 
 	return funcInfo, nil
 }
@@ -178,29 +972,278 @@ func (a *Analyzer) extractReceiverType(expr ast.Expr) string {
 		return t.Name
 	case *ast.StarExpr:
 		return a.extractReceiverType(t.X)
+	case *ast.ParenExpr:
+		return a.extractReceiverType(t.X)
+	case *ast.IndexExpr:
+		// ジェネリック型のレシーバー (e.g., *Repo[T])
+		return a.extractReceiverType(t.X)
+	case *ast.IndexListExpr:
+		// 複数の型パラメータを持つレシーバー (e.g., *Cache[K, V])
+		return a.extractReceiverType(t.X)
 	default:
 		return "Unknown"
 	}
 }
 
 // extractSQLCalls extracts SQL method calls from a function body
-func (a *Analyzer) extractSQLCalls(body *ast.BlockStmt, pkg *packages.Package) []pkgtypes.SQLCall {
+func (a *Analyzer) extractSQLCalls(body *ast.BlockStmt, pkg *packages.Package) ([]pkgtypes.SQLCall, error) {
 	var sqlCalls []pkgtypes.SQLCall
 
 	if body == nil {
-		return sqlCalls
+		return sqlCalls, nil
 	}
 
+	// クロージャ（go/defer内の関数リテラルを含む）内の呼び出しは
+	// 外側の名前付き関数に帰属させる
+	callees := make(map[*ast.SelectorExpr]bool)
+	// 変数に代入されたメソッド値は代入箇所ではなく呼び出し箇所で数える
+	variables := a.methodVariables(body, pkg)
+	for _, variable := range variables {
+		for _, selExpr := range variable.selectors {
+			callees[selExpr] = true
+		}
+	}
+	var stack []ast.Node
+	var collectErr error
 	ast.Inspect(body, func(n ast.Node) bool {
-		if callExpr, ok := n.(*ast.CallExpr); ok {
-			if sqlCall := a.analyzeSQLCall(callExpr, pkg); sqlCall != nil {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		if collectErr != nil {
+			return false
+		}
+		stack = append(stack, n)
+		
+		switch node := n.(type) {
+		case *ast.CallExpr:
+			if collectErr = a.checkDynamicSQL(node, pkg); collectErr != nil {
+				return false
+			}
+			if sqlCall := a.analyzeSQLCall(node, pkg); sqlCall != nil {
+				sqlCall.LoopLine = a.enclosingLoopLine(stack, node.Pos())
+				sqlCalls = append(sqlCalls, *sqlCall)
+			}
+			if ident, ok := ast.Unparen(node.Fun).(*ast.Ident); ok && variables != nil {
+				if variable, exists := variables[pkg.TypesInfo.Uses[ident]]; exists {
+					sqlCall := a.newSQLCall(node.Pos(), variable.method, pkgtypes.ConfidenceHigh)
+					sqlCall.LoopLine = a.enclosingLoopLine(stack, node.Pos())
+					sqlCalls = append(sqlCalls, *sqlCall)
+				}
+			}
+			// 呼び出し先のセレクターをメソッド値として二重に数えない
+			if selExpr, ok := node.Fun.(*ast.SelectorExpr); ok {
+				callees[selExpr] = true
+			}
+		case *ast.SelectorExpr:
+			if callees[node] {
+				return true
+			}
+			if sqlCall := a.analyzeMethodValue(node, pkg); sqlCall != nil {
+				sqlCall.LoopLine = a.enclosingLoopLine(stack, node.Pos())
 				sqlCalls = append(sqlCalls, *sqlCall)
 			}
 		}
 		return true
 	})
 
-	return sqlCalls
+	return sqlCalls, collectErr
+}
+
+// enclosingLoopLine returns the line of the innermost for/range loop whose body contains pos, or 0
+// "for _, u := range q.ListUsers(ctx)" のようにループの初期化・条件部での呼び出しは1回のみなので対象外
+func (a *Analyzer) enclosingLoopLine(stack []ast.Node, pos token.Pos) int {
+	for i := len(stack) - 1; i >= 0; i-- {
+		var body *ast.BlockStmt
+		switch loop := stack[i].(type) {
+		case *ast.ForStmt:
+			body = loop.Body
+		case *ast.RangeStmt:
+			body = loop.Body
+		default:
+			continue
+		}
+		if body != nil && body.Pos() <= pos && pos < body.End() {
+			return a.fset.Position(stack[i].Pos()).Line
+		}
+	}
+	return 0
+}
+
+// extractDirectCalls returns the analyzed functions referenced from a function body
+// Calls, method values and function values all count; names use the same
+// "Receiver.Method" scheme as analyzeFuncDecl
+func (a *Analyzer) extractDirectCalls(body *ast.BlockStmt, pkg *packages.Package) []string {
+	if body == nil || pkg.TypesInfo == nil {
+		return nil
+	}
+	
+	loaded := make(map[string]bool, len(a.packages)+len(a.followed))
+	for _, p := range a.packages {
+		loaded[p.PkgPath] = true
+	}
+	for pkgPath := range a.followed {
+		loaded[pkgPath] = true
+	}
+	
+	seen := make(map[string]bool)
+	var calls []string
+	ast.Inspect(body, func(n ast.Node) bool {
+		ident, ok := n.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		
+		fn, ok := pkg.TypesInfo.Uses[ident].(*types.Func)
+		if !ok || fn.Pkg() == nil {
+			return true
+		}
+		
+		name := functionKey(fn)
+		if name == "" || seen[name] || (!loaded[fn.Pkg().Path()] && !a.external[name]) {
+			return true
+		}
+		seen[name] = true
+		calls = append(calls, name)
+		return true
+	})
+	
+	sort.Strings(calls)
+	return calls
+}
+
+// QualifiedName returns the key of a function in the analysis result
+// e.g. "example.com/app/service.UserService.GetUser" for a method
+func QualifiedName(pkgPath, funcName string) string {
+	if pkgPath == "" {
+		return funcName
+	}
+	return pkgPath + "." + funcName
+}
+
+// functionKey returns the function name used as a key in the analysis result
+func functionKey(fn *types.Func) string {
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil {
+		return QualifiedName(fn.Pkg().Path(), fn.Name())
+	}
+	
+	recvType := sig.Recv().Type()
+	if ptr, ok := recvType.(*types.Pointer); ok {
+		recvType = ptr.Elem()
+	}
+	
+	named, ok := recvType.(*types.Named)
+	if !ok {
+		return ""
+	}
+	return QualifiedName(fn.Pkg().Path(), named.Obj().Name()+"."+fn.Name())
+}
+
+// analyzeMethodValue detects sqlc methods referenced as method values (e.g., fn := q.GetUser)
+// or method expressions (e.g., fn := (*db.Queries).GetUser)
+func (a *Analyzer) analyzeMethodValue(selExpr *ast.SelectorExpr, pkg *packages.Package) *pkgtypes.SQLCall {
+	if !a.isSQLCMethodValue(selExpr, pkg) {
+		return nil
+	}
+	return a.newSQLCall(selExpr.Pos(), selExpr.Sel.Name, pkgtypes.ConfidenceHigh)
+}
+
+// isSQLCMethodValue reports whether selExpr refers to a sqlc method without calling it
+func (a *Analyzer) isSQLCMethodValue(selExpr *ast.SelectorExpr, pkg *packages.Package) bool {
+	if pkg.TypesInfo == nil {
+		return false
+	}
+	
+	selection := pkg.TypesInfo.Selections[selExpr]
+	if selection == nil || (selection.Kind() != types.MethodVal && selection.Kind() != types.MethodExpr) {
+		return false
+	}
+	return a.isSQLCMethod(selection.Recv(), selExpr.Sel.Name)
+}
+
+// methodVariable is a local variable that holds a sqlc method value
+type methodVariable struct {
+	method    string
+	selectors []*ast.SelectorExpr // 代入されたメソッド値
+}
+
+// methodVariables finds the local variables in body that only ever hold one sqlc method
+// and are called in body, e.g. "get := q.GetUser" followed by "get(ctx, id)"
+// 他の値も代入される変数や呼び出されない変数（コールバックとして渡すだけなど）は対象外
+func (a *Analyzer) methodVariables(body *ast.BlockStmt, pkg *packages.Package) map[types.Object]*methodVariable {
+	if pkg.TypesInfo == nil || pkg.Types == nil {
+		return nil
+	}
+	
+	variables := make(map[types.Object]*methodVariable)
+	excluded := make(map[types.Object]bool)
+	assign := func(lhs ast.Expr, rhs ast.Expr) {
+		ident, ok := lhs.(*ast.Ident)
+		if !ok || ident.Name == "_" {
+			return
+		}
+		obj, ok := pkg.TypesInfo.ObjectOf(ident).(*types.Var)
+		if !ok || obj.Parent() == pkg.Types.Scope() {
+			return
+		}
+		selExpr, ok := ast.Unparen(rhs).(*ast.SelectorExpr)
+		if rhs == nil || !ok || !a.isSQLCMethodValue(selExpr, pkg) {
+			excluded[obj] = true
+			return
+		}
+		variable, exists := variables[obj]
+		if !exists {
+			variable = &methodVariable{method: selExpr.Sel.Name}
+			variables[obj] = variable
+		} else if variable.method != selExpr.Sel.Name {
+			excluded[obj] = true
+		}
+		variable.selectors = append(variable.selectors, selExpr)
+	}
+	
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range node.Lhs {
+				var rhs ast.Expr
+				if len(node.Lhs) == len(node.Rhs) {
+					rhs = node.Rhs[i]
+				}
+				assign(lhs, rhs)
+			}
+		case *ast.ValueSpec:
+			// 初期値のない宣言はゼロ値なので代入として扱わない
+			if len(node.Values) == 0 {
+				return true
+			}
+			for i, name := range node.Names {
+				var rhs ast.Expr
+				if len(node.Names) == len(node.Values) {
+					rhs = node.Values[i]
+				}
+				assign(name, rhs)
+			}
+		}
+		return true
+	})
+	
+	called := make(map[types.Object]bool)
+	ast.Inspect(body, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			if ident, ok := ast.Unparen(call.Fun).(*ast.Ident); ok {
+				called[pkg.TypesInfo.Uses[ident]] = true
+			}
+		}
+		return true
+	})
+	
+	for obj := range variables {
+		if excluded[obj] || !called[obj] {
+			delete(variables, obj)
+		}
+	}
+	return variables
 }
 
 // analyzeSQLCall analyzes a function call to determine if it's an SQL method call
@@ -212,26 +1255,204 @@ func (a *Analyzer) analyzeSQLCall(callExpr *ast.CallExpr, pkg *packages.Package)
 		// 型情報を使用して呼び出し元の型を判定
 		if pkg.TypesInfo != nil {
 			if objType := pkg.TypesInfo.TypeOf(selExpr.X); objType != nil {
-				// SQLCで生成されたクエリメソッドかどうかを判定
-				if a.isSQLCMethod(objType, methodName) {
-					pos := a.fset.Position(callExpr.Pos())
-					return &pkgtypes.SQLCall{
-						MethodName: methodName,
-						Line:       pos.Line,
-						Column:     pos.Column,
+				// database/sqlに直接渡されたSQLは文字列から解析する
+				if a.rawSQL {
+					if sqlCall := a.analyzeRawSQLCall(callExpr, objType, methodName, pkg); sqlCall != nil {
+						return sqlCall
+					}
+				}
+				
+				if a.driverCalls {
+					if sqlCall := a.analyzeDriverCall(callExpr, objType, methodName); sqlCall != nil {
+						return sqlCall
 					}
 				}
+				
+				// SQLCで生成されたクエリメソッドかどうかを判定
+				linked := a.isSQLCMethod(objType, methodName)
+				if a.explain {
+					a.explainCall(callExpr, objType, methodName, linked)
+				}
+				
+				if !linked {
+					return nil
+				}
+				
+				// メソッドまで型情報で解決できた場合のみ確実とみなす
+				confidence := pkgtypes.ConfidenceMedium
+				if selection := pkg.TypesInfo.Selections[selExpr]; selection != nil && selection.Kind() == types.MethodVal {
+					confidence = pkgtypes.ConfidenceHigh
+				}
+				return a.newSQLCall(callExpr.Pos(), methodName, confidence)
 			}
 		}
+		
+		// 型情報が得られない場合はメソッド名のみで判定する
+		if a.isSQLCMethodName(methodName) && !a.isStandardSQLMethod(methodName) {
+			return a.newSQLCall(callExpr.Pos(), methodName, pkgtypes.ConfidenceLow)
+		}
 	}
 
 	return nil
 }
 
+// rawSQLMethods maps database/sql methods taking a query to the index of the query argument
+var rawSQLMethods = map[string]int{
+	"Query": 0, "QueryRow": 0, "Exec": 0, "Prepare": 0,
+	"QueryContext": 1, "QueryRowContext": 1, "ExecContext": 1, "PrepareContext": 1,
+}
+
+// analyzeRawSQLCall records a database/sql call whose query is a constant string
+// 動的に組み立てられたSQLは解析できないため対象外とする
+func (a *Analyzer) analyzeRawSQLCall(callExpr *ast.CallExpr, objType types.Type, methodName string, pkg *packages.Package) *pkgtypes.SQLCall {
+	index, ok := rawSQLMethods[methodName]
+	if !ok || index >= len(callExpr.Args) || !isDatabaseSQLType(objType) {
+		return nil
+	}
+	
+	value := pkg.TypesInfo.Types[callExpr.Args[index]].Value
+	if value == nil || value.Kind() != constant.String {
+		return nil
+	}
+	
+	sqlCall := a.newSQLCall(callExpr.Pos(), methodName, pkgtypes.ConfidenceHigh)
+	sqlCall.SQL = constant.StringVal(value)
+	return sqlCall
+}
+
+// analyzeDriverCall records a Query/Exec/Prepare call on a database/sql type or an interface
+// インターフェース経由の呼び出しは実装を特定できないため確度を下げる
+func (a *Analyzer) analyzeDriverCall(callExpr *ast.CallExpr, objType types.Type, methodName string) *pkgtypes.SQLCall {
+	if _, ok := rawSQLMethods[methodName]; !ok {
+		return nil
+	}
+	
+	confidence := pkgtypes.ConfidenceHigh
+	if !isDatabaseSQLType(objType) {
+		if _, ok := objType.Underlying().(*types.Interface); !ok {
+			return nil
+		}
+		confidence = pkgtypes.ConfidenceMedium
+	}
+	
+	sqlCall := a.newSQLCall(callExpr.Pos(), methodName, confidence)
+	sqlCall.Driver = true
+	return sqlCall
+}
+
+// checkDynamicSQL warns about database/sql calls whose query is built by string
+// concatenation or fmt.Sprintf, which risks SQL injection
+// 変数を経由して組み立てられたSQLは追跡せず、引数に直接書かれた式のみを対象とする
+func (a *Analyzer) checkDynamicSQL(callExpr *ast.CallExpr, pkg *packages.Package) error {
+	selExpr, ok := callExpr.Fun.(*ast.SelectorExpr)
+	if !ok || pkg.TypesInfo == nil {
+		return nil
+	}
+	methodName := selExpr.Sel.Name
+	index, ok := rawSQLMethods[methodName]
+	if !ok || index >= len(callExpr.Args) || !isDatabaseSQLType(pkg.TypesInfo.TypeOf(selExpr.X)) {
+		return nil
+	}
+	
+	arg := ast.Unparen(callExpr.Args[index])
+	if pkg.TypesInfo.Types[arg].Value != nil {
+		return nil // 定数同士の連結は安全
+	}
+	
+	var construction string
+	switch expr := arg.(type) {
+	case *ast.BinaryExpr:
+		if expr.Op != token.ADD {
+			return nil
+		}
+		construction = "string concatenation"
+	case *ast.CallExpr:
+		if !isFmtSprintf(expr, pkg) {
+			return nil
+		}
+		construction = "fmt.Sprintf"
+	default:
+		return nil
+	}
+	
+	pos := a.fset.Position(callExpr.Pos())
+	warning := errors.NewError(errors.CategorySecurity, errors.SeverityWarning,
+		fmt.Sprintf("query passed to %s is built with %s; use placeholders to avoid SQL injection", methodName, construction)).
+		WithLocation(pos.Filename, pos.Line, pos.Column)
+	warning.Details["method"] = methodName
+	warning.Details["construction"] = construction
+	
+	return a.errorCollector.Add(warning)
+}
+
+// isFmtSprintf reports whether call is a call to fmt.Sprintf
+func isFmtSprintf(call *ast.CallExpr, pkg *packages.Package) bool {
+	selExpr, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	fn, ok := pkg.TypesInfo.Uses[selExpr.Sel].(*types.Func)
+	return ok && fn.Pkg() != nil && fn.Pkg().Path() == "fmt" && fn.Name() == "Sprintf"
+}
+
+// isDatabaseSQLType reports whether t is (a pointer to) a type from database/sql, e.g. *sql.DB or *sql.Tx
+func isDatabaseSQLType(t types.Type) bool {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "database/sql"
+}
+
+// newSQLCall creates an SQL call record at the given position
+func (a *Analyzer) newSQLCall(pos token.Pos, methodName string, confidence pkgtypes.Confidence) *pkgtypes.SQLCall {
+	position := a.fset.Position(pos)
+	return &pkgtypes.SQLCall{
+		MethodName: methodName,
+		Line:       position.Line,
+		Column:     position.Column,
+		Confidence: confidence,
+	}
+}
+
+// explainCall records an explanation of the linking decision for a method call
+func (a *Analyzer) explainCall(callExpr *ast.CallExpr, objType types.Type, methodName string, linked bool) {
+	typeName := objType.String()
+	queriesType := a.isQueriesType(receiverTypeName(objType))
+	methodPattern := a.isSQLCMethodName(methodName) && !a.isStandardSQLMethod(methodName)
+	knownQuery := a.knownQueries[methodName]
+	
+	var reason string
+	switch {
+	case linked && knownQuery:
+		reason = "linked to known query"
+	case linked:
+		reason = "linked, but no query with this name is known"
+	case !queriesType:
+		reason = fmt.Sprintf("receiver type '%s' is not a Queries type", typeName)
+	default:
+		reason = "method name does not look like a sqlc query method"
+	}
+	
+	pos := a.fset.Position(callExpr.Pos())
+	info := errors.NewError(errors.CategoryAnalysis, errors.SeverityInfo,
+		fmt.Sprintf("call to %s: %s", methodName, reason))
+	info.Details["method"] = methodName
+	info.Details["receiver_type"] = typeName
+	info.Details["queries_type"] = queriesType
+	info.Details["method_pattern"] = methodPattern
+	info.Details["known_query"] = knownQuery
+	info.Details["linked"] = linked
+	info.Details["file"] = pos.Filename
+	info.Details["line"] = pos.Line
+	
+	a.errorCollector.Add(info)
+}
+
 // isSQLCMethod determines if a method call is an SQLC-generated query method
 func (a *Analyzer) isSQLCMethod(objType types.Type, methodName string) bool {
 	// 型名を取得
-	typeName := objType.String()
+	typeName := receiverTypeName(objType)
 	
 	// まず、明らかにSQL driverメソッドを除外
 	if a.isStandardSQLMethod(methodName) {
@@ -240,7 +1461,13 @@ func (a *Analyzer) isSQLCMethod(objType types.Type, methodName string) bool {
 	
 	// SQLC生成のQueries型かチェック（より厳密に）
 	if !a.isQueriesType(typeName) {
-		return false
+		if !a.isQuerierInterface(objType) {
+			return false
+		}
+		// インターフェース経由の呼び出しは既知のクエリ名でも判定する
+		if a.knownQueries[methodName] {
+			return true
+		}
 	}
 	
 	// メソッド名がsqlcパターンかチェック
@@ -248,9 +1475,61 @@ func (a *Analyzer) isSQLCMethod(objType types.Type, methodName string) bool {
 		return true
 	}
 	
+	// 生成ファイルで定義されたメソッドは接頭辞によらずクエリとみなす
+	if a.generated[methodName] {
+		return true
+	}
+	
 	return false
 }
 
+// isQuerierInterface reports whether objType is an interface standing in for the Queries type
+// sqlcのemit_interfaceで生成されるQuerier、またはメソッドがすべて既知のクエリであるインターフェースを対象とする
+func (a *Analyzer) isQuerierInterface(objType types.Type) bool {
+	iface, ok := objType.Underlying().(*types.Interface)
+	if !ok || iface.NumMethods() == 0 {
+		return false
+	}
+	
+	if named, ok := objType.(*types.Named); ok {
+		name := named.Obj().Name()
+		if name == "Querier" || strings.HasSuffix(name, "Queries") {
+			return true
+		}
+	}
+	
+	if len(a.knownQueries) == 0 {
+		return false
+	}
+	for i := 0; i < iface.NumMethods(); i++ {
+		if name := iface.Method(i).Name(); !a.knownQueries[name] && name != "WithTx" {
+			return false
+		}
+	}
+	return true
+}
+
+// receiverTypeName returns the qualified name of a receiver type without type arguments
+// e.g., *example.com/db.Repo[*example.com/db.Queries] becomes *example.com/db.Repo
+func receiverTypeName(objType types.Type) string {
+	prefix := ""
+	if ptr, ok := objType.(*types.Pointer); ok {
+		prefix = "*"
+		objType = ptr.Elem()
+	}
+	
+	named, ok := objType.(*types.Named)
+	if !ok || named.TypeArgs().Len() == 0 {
+		return prefix + objType.String()
+	}
+	
+	obj := named.Obj()
+	if obj.Pkg() == nil {
+		return prefix + obj.Name()
+	}
+	return prefix + obj.Pkg().Path() + "." + obj.Name()
+}
+
 // isStandardSQLMethod checks if method name is a standard SQL driver method
 func (a *Analyzer) isStandardSQLMethod(methodName string) bool {
 	standardMethods := []string{
@@ -300,8 +1579,8 @@ func (a *Analyzer) isSQLCMethodName(methodName string) bool {
 		"Get", "List", "Create", "Update", "Delete", "Count", "Find", "Select", "Insert",
 	}
 	
-	for _, prefix := range commonPrefixes {
-		if len(methodName) >= len(prefix) && methodName[:len(prefix)] == prefix {
+	for _, prefix := range append(commonPrefixes, a.methodPrefixes...) {
+		if strings.HasPrefix(methodName, prefix) {
 			return true
 		}
 	}
@@ -1,10 +1,17 @@
 package gostatic
 
 import (
+	"context"
+	stderrors "errors"
+	"fmt"
 	"go/ast"
 	"go/parser"
+	"go/scanner"
 	"go/token"
 	"go/types"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	"golang.org/x/tools/go/packages"
@@ -31,6 +38,16 @@ func TestAnalyzer_extractReceiverType(t *testing.T) {
 			code:     "package main\nfunc (u User) GetName() string { return u.Name }",
 			expected: "User",
 		},
+		{
+			name:     "Generic receiver",
+			code:     "package main\nfunc (r *Repo[T]) GetUser() T { var t T; return t }",
+			expected: "Repo",
+		},
+		{
+			name:     "Generic receiver with multiple type parameters",
+			code:     "package main\nfunc (c Cache[K, V]) Get(k K) V { return c.m[k] }",
+			expected: "Cache",
+		},
 	}
 	
 	for _, tt := range tests {
@@ -256,7 +273,10 @@ func ProcessData() {
 				pkg.TypesInfo.Types = make(map[ast.Expr]types.TypeAndValue)
 			}
 			
-			result := analyzer.extractSQLCalls(funcDecl.Body, pkg)
+			result, err := analyzer.extractSQLCalls(funcDecl.Body, pkg)
+			if err != nil {
+				t.Fatalf("extractSQLCalls() error = %v", err)
+			}
 			
 			if len(result) != len(tt.expected) {
 				t.Errorf("Expected %d SQL calls, got %d", len(tt.expected), len(result))
@@ -441,4 +461,1131 @@ func (m *mockType) String() string {
 
 func (m *mockType) Underlying() types.Type {
 	return m
-}
\ No newline at end of file
+}
+func TestAnalyzer_LoadOverlay(t *testing.T) {
+	analyzer := NewAnalyzer(".", errors.NewErrorCollector(10, false))
+	
+	source := `package overlay
+
+import "context"
+
+type Queries struct{}
+
+func (q *Queries) GetUser(ctx context.Context, id int64) error {
+	return nil
+}
+
+func HandleGetUser(ctx context.Context, q *Queries) error {
+	return q.GetUser(ctx, 1)
+}
+`
+	
+	err := analyzer.LoadOverlay(map[string][]byte{
+		"overlay/handler.go": []byte(source),
+	})
+	if err != nil {
+		t.Fatalf("LoadOverlay() error = %v", err)
+	}
+	
+	functions, err := analyzer.AnalyzePackages()
+	if err != nil {
+		t.Fatalf("AnalyzePackages() error = %v", err)
+	}
+	
+	handler, exists := findFunction(functions, "HandleGetUser")
+	if !exists {
+		t.Fatalf("Expected HandleGetUser in %v", functions)
+	}
+	
+	if len(handler.SQLCalls) != 1 || handler.SQLCalls[0].MethodName != "GetUser" {
+		t.Errorf("Expected a single GetUser call, got %+v", handler.SQLCalls)
+	}
+}
+
+func TestAnalyzer_LoadOverlay_Empty(t *testing.T) {
+	analyzer := NewAnalyzer(".", errors.NewErrorCollector(10, false))
+	
+	if err := analyzer.LoadOverlay(nil); err == nil {
+		t.Error("Expected error for empty overlay")
+	}
+}
+
+func TestAnalyzer_newLoadConfig_Deps(t *testing.T) {
+	analyzer := NewAnalyzer(".", errors.NewErrorCollector(10, false))
+	// Dependencies are type-checked from export data unless they are analyzed too
+	if analyzer.newLoadConfig().Mode&packages.NeedDeps != 0 {
+		t.Error("Expected dependencies not to be loaded from source by default")
+	}
+	
+	analyzer.SetFollowDependencies(false, []string{"example.com/service"})
+	if analyzer.newLoadConfig().Mode&packages.NeedDeps == 0 {
+		t.Error("Expected dependencies to be loaded when they are followed")
+	}
+}
+
+func TestAnalyzer_ClosureCalls(t *testing.T) {
+	analyzer := NewAnalyzer(".", errors.NewErrorCollector(10, false))
+	
+	source := `package closures
+
+import "context"
+
+type Queries struct{}
+
+func (q *Queries) GetUser(ctx context.Context, id int64) error { return nil }
+func (q *Queries) ListUsers(ctx context.Context) error         { return nil }
+func (q *Queries) CountUsers(ctx context.Context) error        { return nil }
+func (q *Queries) Close() error                                { return nil }
+
+func Handle(ctx context.Context, q *Queries) {
+	defer q.Close()
+	go func() {
+		_ = q.GetUser(ctx, 1)
+	}()
+	defer func() {
+		_ = q.ListUsers(ctx)
+	}()
+	count := q.CountUsers
+	_ = count(ctx)
+}
+`
+	
+	if err := analyzer.LoadOverlay(map[string][]byte{"closures/handler.go": []byte(source)}); err != nil {
+		t.Fatalf("LoadOverlay() error = %v", err)
+	}
+	
+	functions, err := analyzer.AnalyzePackages()
+	if err != nil {
+		t.Fatalf("AnalyzePackages() error = %v", err)
+	}
+	
+	handler, exists := findFunction(functions, "Handle")
+	if !exists {
+		t.Fatalf("Expected Handle in %v", functions)
+	}
+	
+	expected := []pkgtypes.SQLCall{
+		{MethodName: "GetUser", Line: 15, Column: 7, Confidence: pkgtypes.ConfidenceHigh},
+		{MethodName: "ListUsers", Line: 18, Column: 7, Confidence: pkgtypes.ConfidenceHigh},
+		// The method value is counted where the variable holding it is called
+		{MethodName: "CountUsers", Line: 21, Column: 6, Confidence: pkgtypes.ConfidenceHigh},
+	}
+	if !reflect.DeepEqual(handler.SQLCalls, expected) {
+		t.Errorf("SQLCalls = %+v, want %+v", handler.SQLCalls, expected)
+	}
+}
+
+func TestAnalyzer_GenericReceivers(t *testing.T) {
+	analyzer := NewAnalyzer(".", errors.NewErrorCollector(10, false))
+	
+	source := `package generic
+
+import "context"
+
+type Queries struct{}
+
+func (q *Queries) GetUser(ctx context.Context, id int64) error { return nil }
+
+type Repo[T any] struct {
+	queries *Queries
+}
+
+func (r *Repo[T]) GetUser(ctx context.Context, id int64) error {
+	return r.queries.GetUser(ctx, id)
+}
+
+type Cache[K comparable, V any] struct {
+	queries *Queries
+}
+
+func (c *Cache[K, V]) Load(ctx context.Context) error {
+	return c.queries.GetUser(ctx, 1)
+}
+
+func FindUser(ctx context.Context, r *Repo[*Queries]) error {
+	return r.GetUser(ctx, 1)
+}
+`
+	
+	if err := analyzer.LoadOverlay(map[string][]byte{"generic/repo.go": []byte(source)}); err != nil {
+		t.Fatalf("LoadOverlay() error = %v", err)
+	}
+	
+	functions, err := analyzer.AnalyzePackages()
+	if err != nil {
+		t.Fatalf("AnalyzePackages() error = %v", err)
+	}
+	
+	for _, name := range []string{"Repo.GetUser", "Cache.Load"} {
+		function, exists := findFunction(functions, name)
+		if !exists {
+			t.Errorf("Expected %s in %v", name, functions)
+			continue
+		}
+		if len(function.SQLCalls) != 1 || function.SQLCalls[0].MethodName != "GetUser" {
+			t.Errorf("Expected a single GetUser call in %s, got %+v", name, function.SQLCalls)
+		}
+	}
+	
+	// 型引数にQueriesを含むだけのリポジトリはQueries型として扱わない
+	if repo, _ := findFunction(functions, "FindUser"); len(repo.SQLCalls) != 0 {
+		t.Errorf("Expected no SQL calls through Repo[*Queries], got %+v", repo.SQLCalls)
+	}
+}
+
+func TestAnalyzer_AddMethodPrefixes(t *testing.T) {
+	source := `package prefixes
+
+import "context"
+
+type Queries struct{}
+
+func (q *Queries) SaveUser(ctx context.Context, name string) error { return nil }
+
+func Register(ctx context.Context, q *Queries) error {
+	return q.SaveUser(ctx, "alice")
+}
+`
+	
+	tests := []struct {
+		name      string
+		prefixes  []string
+		wantCalls int
+	}{
+		{name: "default prefixes", prefixes: nil, wantCalls: 0},
+		{name: "with Save prefix", prefixes: []string{"Save"}, wantCalls: 1},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := NewAnalyzer(".", errors.NewErrorCollector(10, false))
+			analyzer.AddMethodPrefixes(tt.prefixes...)
+			
+			if err := analyzer.LoadOverlay(map[string][]byte{"prefixes/register.go": []byte(source)}); err != nil {
+				t.Fatalf("LoadOverlay() error = %v", err)
+			}
+			
+			functions, err := analyzer.AnalyzePackages()
+			if err != nil {
+				t.Fatalf("AnalyzePackages() error = %v", err)
+			}
+			
+			register, _ := findFunction(functions, "Register")
+			calls := register.SQLCalls
+			if len(calls) != tt.wantCalls {
+				t.Errorf("Expected %d SQL calls, got %+v", tt.wantCalls, calls)
+			}
+			if tt.wantCalls > 0 && calls[0].MethodName != "SaveUser" {
+				t.Errorf("Expected SaveUser call, got %s", calls[0].MethodName)
+			}
+		})
+	}
+}
+
+func TestAnalyzer_Explain(t *testing.T) {
+	collector := errors.NewErrorCollector(10, false)
+	analyzer := NewAnalyzer(".", collector)
+	analyzer.EnableExplain([]string{"GetUser"})
+	
+	source := `package explain
+
+import "context"
+
+type Store struct{}
+
+func (s *Store) GetUser(ctx context.Context, id int64) error {
+	return nil
+}
+
+func HandleGetUser(ctx context.Context, s *Store) error {
+	return s.GetUser(ctx, 1)
+}
+`
+	
+	if err := analyzer.LoadOverlay(map[string][]byte{"explain/handler.go": []byte(source)}); err != nil {
+		t.Fatalf("LoadOverlay() error = %v", err)
+	}
+	
+	if _, err := analyzer.AnalyzePackages(); err != nil {
+		t.Fatalf("AnalyzePackages() error = %v", err)
+	}
+	
+	var explanation *errors.AnalysisError
+	for _, info := range collector.GetInfos() {
+		if info.Details["method"] == "GetUser" {
+			explanation = info
+			break
+		}
+	}
+	
+	if explanation == nil {
+		t.Fatalf("Expected an explanation for the GetUser call, got %v", collector.GetInfos())
+	}
+	
+	if explanation.Severity != errors.SeverityInfo {
+		t.Errorf("Expected SeverityInfo, got %v", explanation.Severity)
+	}
+	
+	if receiverType, _ := explanation.Details["receiver_type"].(string); !strings.HasSuffix(receiverType, "explain.Store") {
+		t.Errorf("Unexpected receiver_type: %v", explanation.Details["receiver_type"])
+	}
+	
+	if explanation.Details["queries_type"] != false {
+		t.Error("Expected queries_type to be false for Store receiver")
+	}
+	
+	if explanation.Details["known_query"] != true {
+		t.Error("Expected known_query to be true for GetUser")
+	}
+	
+	if explanation.Details["linked"] != false {
+		t.Error("Expected call not to be linked")
+	}
+	
+	// 説明はエラー・警告として数えない
+	if collector.Count() != 0 {
+		t.Errorf("Expected explanations not to count as errors, got %d", collector.Count())
+	}
+}
+
+func TestAnalyzer_SetPackageFilters(t *testing.T) {
+	dbSource := `package db
+
+import "context"
+
+type Queries struct{}
+
+func (q *Queries) GetUser(ctx context.Context, id int64) error { return nil }
+
+func LoadUser(ctx context.Context, q *Queries) error {
+	return q.GetUser(ctx, 1)
+}
+`
+	telemetrySource := `package telemetry
+
+import "context"
+
+type Queries struct{}
+
+func (q *Queries) InsertEvent(ctx context.Context, name string) error { return nil }
+
+func RecordEvent(ctx context.Context, q *Queries) error {
+	return q.InsertEvent(ctx, "login")
+}
+`
+	
+	tests := []struct {
+		name          string
+		include       []string
+		exclude       []string
+		wantFunctions []string
+		skipFunctions []string
+	}{
+		{
+			name:          "no filters",
+			wantFunctions: []string{"LoadUser", "RecordEvent"},
+		},
+		{
+			name:          "include only",
+			include:       []string{"filters/internal/db"},
+			wantFunctions: []string{"LoadUser"},
+			skipFunctions: []string{"RecordEvent"},
+		},
+		{
+			name:          "exclude only",
+			exclude:       []string{"internal/telemetry"},
+			wantFunctions: []string{"LoadUser"},
+			skipFunctions: []string{"RecordEvent"},
+		},
+		{
+			name:          "recursive include with exclude",
+			include:       []string{"filters/internal/..."},
+			exclude:       []string{"internal/telemetry"},
+			wantFunctions: []string{"LoadUser"},
+			skipFunctions: []string{"RecordEvent"},
+		},
+		{
+			name:          "nothing matches",
+			include:       []string{"cmd/..."},
+			skipFunctions: []string{"LoadUser", "RecordEvent"},
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := NewAnalyzer(".", errors.NewErrorCollector(10, false))
+			analyzer.SetPackageFilters(tt.include, tt.exclude)
+			
+			err := analyzer.LoadOverlay(map[string][]byte{
+				"filters/internal/db/user.go":           []byte(dbSource),
+				"filters/internal/telemetry/metrics.go": []byte(telemetrySource),
+			})
+			if err != nil {
+				t.Fatalf("LoadOverlay() error = %v", err)
+			}
+			
+			functions, err := analyzer.AnalyzePackages()
+			if err != nil {
+				t.Fatalf("AnalyzePackages() error = %v", err)
+			}
+			
+			for _, name := range tt.wantFunctions {
+				if _, exists := findFunction(functions, name); !exists {
+					t.Errorf("Expected %s to be analyzed, got %v", name, functions)
+				}
+			}
+			for _, name := range tt.skipFunctions {
+				if _, exists := findFunction(functions, name); exists {
+					t.Errorf("Expected %s to be filtered out", name)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchPackagePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		pkgPath string
+		want    bool
+	}{
+		{pattern: "internal/telemetry", pkgPath: "example.com/app/internal/telemetry", want: true},
+		{pattern: "internal/telemetry", pkgPath: "example.com/app/internal/telemetry/otel", want: false},
+		{pattern: "internal/...", pkgPath: "example.com/app/internal", want: true},
+		{pattern: "internal/...", pkgPath: "example.com/app/internal/db/queries", want: true},
+		{pattern: "internal/...", pkgPath: "example.com/app/cmd/server", want: false},
+		{pattern: "example.com/app/...", pkgPath: "example.com/app/cmd/server", want: true},
+		{pattern: "internal/*_test", pkgPath: "example.com/app/internal/db_test", want: true},
+		{pattern: "tele", pkgPath: "example.com/app/internal/telemetry", want: false},
+		{pattern: "...", pkgPath: "example.com/app", want: true},
+		{pattern: "", pkgPath: "example.com/app", want: false},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.pkgPath, func(t *testing.T) {
+			if got := matchPackagePattern(tt.pattern, tt.pkgPath); got != tt.want {
+				t.Errorf("matchPackagePattern(%q, %q) = %v, want %v", tt.pattern, tt.pkgPath, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzer_CallConfidence(t *testing.T) {
+	source := `package confidence
+
+import "context"
+
+type Queries struct{}
+
+func (q *Queries) GetUser(ctx context.Context, id int64) error { return nil }
+
+func Resolved(ctx context.Context, q *Queries) error {
+	return q.GetUser(ctx, 1)
+}
+`
+	
+	analyzer := NewAnalyzer(".", errors.NewErrorCollector(10, false))
+	if err := analyzer.LoadOverlay(map[string][]byte{"confidence/handler.go": []byte(source)}); err != nil {
+		t.Fatalf("LoadOverlay() error = %v", err)
+	}
+	
+	functions, err := analyzer.AnalyzePackages()
+	if err != nil {
+		t.Fatalf("AnalyzePackages() error = %v", err)
+	}
+	
+	resolved, _ := findFunction(functions, "Resolved")
+	calls := resolved.SQLCalls
+	if len(calls) != 1 || calls[0].Confidence != pkgtypes.ConfidenceHigh {
+		t.Errorf("Expected a single high-confidence call, got %+v", calls)
+	}
+}
+
+func TestAnalyzer_CallConfidence_NameOnly(t *testing.T) {
+	code := `package main
+
+func Handle(db *Queries) {
+	db.GetUser(1)
+}
+`
+	
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", code, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse code: %v", err)
+	}
+	
+	analyzer := NewAnalyzer("test", errors.NewErrorCollector(10, false))
+	analyzer.fset = fset
+	
+	// Without type information only the method name can be used
+	funcDecl := file.Decls[0].(*ast.FuncDecl)
+	calls, err := analyzer.extractSQLCalls(funcDecl.Body, &packages.Package{Name: "main"})
+	if err != nil {
+		t.Fatalf("extractSQLCalls() error = %v", err)
+	}
+	
+	if len(calls) != 1 || calls[0].MethodName != "GetUser" {
+		t.Fatalf("Expected a single GetUser call, got %+v", calls)
+	}
+	if calls[0].Confidence != pkgtypes.ConfidenceLow {
+		t.Errorf("Confidence = %s, want %s", calls[0].Confidence, pkgtypes.ConfidenceLow)
+	}
+}
+
+func TestAnalyzer_LoadRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     []error
+		retries      int
+		wantErr      bool
+		wantAttempts int
+	}{
+		{
+			name:         "transient failures are retried",
+			failures:     []error{fmt.Errorf("go: downloading example.com/dep: unexpected EOF"), fmt.Errorf("go list: signal: killed")},
+			retries:      3,
+			wantAttempts: 3,
+		},
+		{
+			name:         "syntax errors are not retried",
+			failures:     []error{scanner.ErrorList{&scanner.Error{Msg: "expected ';', found 'EOF'"}}},
+			retries:      3,
+			wantErr:      true,
+			wantAttempts: 1,
+		},
+		{
+			name:         "unknown failures are not retried",
+			failures:     []error{fmt.Errorf("go: malformed module path \"example.com/app\"")},
+			retries:      3,
+			wantErr:      true,
+			wantAttempts: 1,
+		},
+		{
+			name:         "retries disabled",
+			failures:     []error{fmt.Errorf("go list: signal: killed")},
+			retries:      0,
+			wantErr:      true,
+			wantAttempts: 1,
+		},
+		{
+			name:         "retries exhausted",
+			failures:     []error{fmt.Errorf("i/o timeout"), fmt.Errorf("i/o timeout"), fmt.Errorf("i/o timeout")},
+			retries:      2,
+			wantErr:      true,
+			wantAttempts: 3,
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := errors.NewErrorCollector(10, false)
+			analyzer := NewAnalyzer(".", collector)
+			analyzer.SetLoadRetries(tt.retries)
+			
+			attempts := 0
+			analyzer.loader = func(cfg *packages.Config, patterns ...string) ([]*packages.Package, error) {
+				attempts++
+				if attempts <= len(tt.failures) {
+					return nil, tt.failures[attempts-1]
+				}
+				return []*packages.Package{{ID: "example.com/app", PkgPath: "example.com/app", GoFiles: []string{"app.go"}}}, nil
+			}
+			
+			err := analyzer.LoadPackages("./...")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadPackages() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("loader called %d times, want %d", attempts, tt.wantAttempts)
+			}
+			
+			if tt.wantErr {
+				return
+			}
+			// Attempts that were recovered from must not surface as errors
+			if collector.HasErrors() {
+				t.Errorf("Unexpected errors after successful retry: %v", collector.GetErrors())
+			}
+			if loaded, _ := analyzer.LoadSummary(); len(loaded) != 1 || loaded[0] != "example.com/app" {
+				t.Errorf("LoadSummary() loaded = %v, want [example.com/app]", loaded)
+			}
+		})
+	}
+}
+
+func TestAnalyzer_LoadPanic(t *testing.T) {
+	for _, retries := range []int{0, 2} {
+		t.Run(fmt.Sprintf("retries=%d", retries), func(t *testing.T) {
+			collector := errors.NewErrorCollector(10, false)
+			analyzer := NewAnalyzer(".", collector)
+			analyzer.SetLoadRetries(retries)
+			attempts := 0
+			analyzer.loader = func(cfg *packages.Config, patterns ...string) ([]*packages.Package, error) {
+				attempts++
+				panic("loader bug")
+			}
+			
+			if err := analyzer.LoadPackages("./..."); err == nil {
+				t.Fatal("Expected an error when the loader panics")
+			}
+			if attempts != 1 {
+				t.Errorf("loader called %d times, want 1", attempts)
+			}
+			if !collector.HasErrors() {
+				t.Error("Expected the panic to be recorded")
+			}
+		})
+	}
+}
+
+// findFunction looks up an analyzed function by its unqualified name
+func findFunction(functions map[string]pkgtypes.GoFunctionInfo, name string) (pkgtypes.GoFunctionInfo, bool) {
+	for _, function := range functions {
+		if function.FunctionName == name {
+			return function, true
+		}
+	}
+	return pkgtypes.GoFunctionInfo{}, false
+}
+
+func TestAnalyzer_DynamicSQLWarning(t *testing.T) {
+	collector := errors.NewErrorCollector(10, false)
+	analyzer := NewAnalyzer(".", collector)
+	
+	if err := analyzer.LoadPackages("github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/report"); err != nil {
+		t.Fatalf("LoadPackages() error = %v", err)
+	}
+	if _, err := analyzer.AnalyzePackages(); err != nil {
+		t.Fatalf("AnalyzePackages() error = %v", err)
+	}
+	
+	var findings []*errors.AnalysisError
+	for _, warning := range collector.GetWarnings() {
+		if warning.Category == errors.CategorySecurity {
+			findings = append(findings, warning)
+		}
+	}
+	
+	// Only the fmt.Sprintf query is flagged; the constant query in TotalByUser is safe
+	if len(findings) != 1 {
+		t.Fatalf("Expected 1 security warning, got %d: %v", len(findings), findings)
+	}
+	finding := findings[0]
+	if finding.Details["construction"] != "fmt.Sprintf" {
+		t.Errorf("construction = %v, want fmt.Sprintf", finding.Details["construction"])
+	}
+	if filepath.Base(finding.Location.File) != "order_report.go" || finding.Location.Line != 36 {
+		t.Errorf("Location = %s:%d, want order_report.go:36", finding.Location.File, finding.Location.Line)
+	}
+}
+
+func TestAnalyzer_FollowDependencies(t *testing.T) {
+	const fixture = "github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/"
+	
+	analyze := func(all bool, prefixes []string) map[string]pkgtypes.GoFunctionInfo {
+		analyzer := NewAnalyzer(".", errors.NewErrorCollector(10, false))
+		analyzer.SetFollowDependencies(all, prefixes)
+		if err := analyzer.LoadPackages(fixture + "handler"); err != nil {
+			t.Fatalf("LoadPackages() error = %v", err)
+		}
+		functions, err := analyzer.AnalyzePackages()
+		if err != nil {
+			t.Fatalf("AnalyzePackages() error = %v", err)
+		}
+		return functions
+	}
+	
+	createUser := fixture + "handler.UserHandler.CreateUser"
+	serviceCreateUser := fixture + "service.UserService.CreateUser"
+	
+	t.Run("default stays within the requested packages", func(t *testing.T) {
+		functions := analyze(false, nil)
+		if _, exists := functions[serviceCreateUser]; exists {
+			t.Errorf("Expected %s not to be analyzed", serviceCreateUser)
+		}
+		if calls := functions[createUser].DirectCalls; len(calls) != 0 {
+			t.Errorf("Expected no calls into unanalyzed packages, got %v", calls)
+		}
+	})
+	
+	t.Run("allowlisted package is followed", func(t *testing.T) {
+		functions := analyze(false, []string{fixture + "service"})
+		service, exists := functions[serviceCreateUser]
+		if !exists {
+			t.Fatalf("Expected %s to be analyzed", serviceCreateUser)
+		}
+		if len(service.SQLCalls) != 1 || service.SQLCalls[0].MethodName != "CreateUser" {
+			t.Errorf("Expected the CreateUser query call, got %+v", service.SQLCalls)
+		}
+		if calls := functions[createUser].DirectCalls; !reflect.DeepEqual(calls, []string{serviceCreateUser}) {
+			t.Errorf("DirectCalls = %v, want [%s]", calls, serviceCreateUser)
+		}
+		// Packages outside the allowlist stay unanalyzed
+		for name := range functions {
+			if strings.HasPrefix(name, fixture+"db.") {
+				t.Errorf("Expected the db package not to be followed, got %s", name)
+			}
+		}
+	})
+	
+	t.Run("include vendor follows every non-standard package", func(t *testing.T) {
+		functions := analyze(true, nil)
+		if _, exists := functions[serviceCreateUser]; !exists {
+			t.Errorf("Expected %s to be analyzed", serviceCreateUser)
+		}
+		if _, exists := functions[fixture+"db.Queries.CreateUser"]; !exists {
+			t.Errorf("Expected the db package to be followed")
+		}
+		for name, function := range functions {
+			if !strings.HasPrefix(function.PackagePath, "github.com/naoyafurudono/sqlc-use-analysis/") {
+				t.Errorf("Expected the standard library not to be followed, got %s", name)
+			}
+		}
+	})
+}
+
+func TestIsStandardPackage(t *testing.T) {
+	// Modules without a dot in their first path element are not the standard library
+	module := &packages.Package{PkgPath: "myapp/internal/service", Module: &packages.Module{Path: "myapp"}}
+	if isStandardPackage(module) {
+		t.Errorf("Expected %s not to be a standard package", module.PkgPath)
+	}
+	if std := (&packages.Package{PkgPath: "net/http"}); !isStandardPackage(std) {
+		t.Errorf("Expected %s to be a standard package", std.PkgPath)
+	}
+}
+
+func TestAnalyzer_SkipGeneratedFiles(t *testing.T) {
+	generated := `// Code generated by sqlc. DO NOT EDIT.
+
+package generated
+
+import "context"
+
+type Queries struct{}
+
+func (q *Queries) WithTx() *Queries { return q }
+
+func (q *Queries) GetUser(ctx context.Context, id int64) error { return nil }
+
+func (q *Queries) ArchivePost(ctx context.Context, id int64) error { return nil }
+`
+	service := `package generated
+
+import "context"
+
+func Archive(ctx context.Context, q *Queries) error {
+	if err := q.GetUser(ctx, 1); err != nil {
+		return err
+	}
+	return q.WithTx().ArchivePost(ctx, 1)
+}
+`
+	
+	tests := []struct {
+		name          string
+		skip          bool
+		wantGenerated bool
+		wantCalls     []string
+	}{
+		{name: "analyze generated files", skip: false, wantGenerated: true, wantCalls: []string{"GetUser"}},
+		{name: "skip generated files", skip: true, wantGenerated: false, wantCalls: []string{"GetUser", "ArchivePost"}},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := NewAnalyzer(".", errors.NewErrorCollector(10, false))
+			analyzer.SetSkipGeneratedFiles(tt.skip)
+			
+			err := analyzer.LoadOverlay(map[string][]byte{
+				"generated/queries.go": []byte(generated),
+				"generated/service.go": []byte(service),
+			})
+			if err != nil {
+				t.Fatalf("LoadOverlay() error = %v", err)
+			}
+			
+			functions, err := analyzer.AnalyzePackages()
+			if err != nil {
+				t.Fatalf("AnalyzePackages() error = %v", err)
+			}
+			
+			const pkgPath = "github.com/naoyafurudono/sqlc-use-analysis/internal/analyzer/go/generated."
+			if _, exists := functions[pkgPath+"Queries.GetUser"]; exists != tt.wantGenerated {
+				t.Errorf("Queries.GetUser analyzed = %v, want %v", exists, tt.wantGenerated)
+			}
+			
+			var calls []string
+			for _, call := range functions[pkgPath+"Archive"].SQLCalls {
+				calls = append(calls, call.MethodName)
+			}
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("SQL calls = %v, want %v", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestAnalyzer_MemoryLimitBatches(t *testing.T) {
+	const fixture = "github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/..."
+	
+	for _, skipGenerated := range []bool{false, true} {
+		t.Run(fmt.Sprintf("skipGenerated=%v", skipGenerated), func(t *testing.T) {
+			single := NewAnalyzer(".", errors.NewErrorCollector(10, false))
+			single.SetSkipGeneratedFiles(skipGenerated)
+			if err := single.LoadPackages(fixture); err != nil {
+				t.Fatalf("LoadPackages() error = %v", err)
+			}
+			want, err := single.AnalyzePackages()
+			if err != nil {
+				t.Fatalf("AnalyzePackages() error = %v", err)
+			}
+			
+			// A 1 MB limit is below the estimate of any package, so each package is its own batch
+			batched := NewAnalyzer(".", errors.NewErrorCollector(10, false))
+			batched.SetSkipGeneratedFiles(skipGenerated)
+			batched.SetMemoryLimit(1)
+			batched.memoryUsage = func() uint64 { return 0 }
+			loads := 0
+			batched.loader = func(cfg *packages.Config, patterns ...string) ([]*packages.Package, error) {
+				loads++
+				return packages.Load(cfg, patterns...)
+			}
+			if err := batched.LoadPackages(fixture); err != nil {
+				t.Fatalf("LoadPackages() error = %v", err)
+			}
+			if len(batched.batches) < 2 {
+				t.Fatalf("Expected several batches, got %d", len(batched.batches))
+			}
+			got, err := batched.AnalyzePackages()
+			if err != nil {
+				t.Fatalf("AnalyzePackages() error = %v", err)
+			}
+			
+			if loads <= len(batched.batches) {
+				t.Errorf("Expected the listing plus one load per batch, got %d loads for %d batches", loads, len(batched.batches))
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Batched functions differ from single-shot loading:\ngot  %v\nwant %v", got, want)
+			}
+			for _, pkg := range batched.packages {
+				if pkg.Syntax != nil || pkg.TypesInfo != nil {
+					t.Errorf("Package %s still holds its syntax after analysis", pkg.PkgPath)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzer_MemoryLimitExceeded(t *testing.T) {
+	pkg := func(path string) *packages.Package {
+		return &packages.Package{ID: path, PkgPath: path}
+	}
+	collector := errors.NewErrorCollector(10, false)
+	analyzer := NewAnalyzer(".", collector)
+	analyzer.SetMemoryLimit(1)
+	analyzer.memoryUsage = func() uint64 { return 2 << 20 }
+	analyzer.batches = [][]*packages.Package{{pkg("a")}, {pkg("b"), pkg("c"), pkg("d")}, {pkg("e")}}
+	
+	// The batches after the analyzed one are halved
+	if err := analyzer.enforceMemoryLimit(1); err != nil {
+		t.Fatalf("enforceMemoryLimit() error = %v", err)
+	}
+	var sizes []int
+	for _, batch := range analyzer.batches {
+		sizes = append(sizes, len(batch))
+	}
+	if want := []int{1, 1, 2, 1}; !reflect.DeepEqual(sizes, want) {
+		t.Errorf("Batch sizes = %v, want %v", sizes, want)
+	}
+	if len(collector.GetWarnings()) != 1 {
+		t.Errorf("Expected one warning about the split, got %v", collector.GetWarnings())
+	}
+	
+	// Once every remaining batch holds a single package the limit cannot be met
+	if err := analyzer.enforceMemoryLimit(3); err == nil {
+		t.Error("Expected an error when single-package batches exceed the limit")
+	}
+	
+	// Loading fails instead of running past the limit
+	exceeded := NewAnalyzer(".", errors.NewErrorCollector(10, false))
+	exceeded.SetMemoryLimit(1)
+	exceeded.memoryUsage = func() uint64 { return 2 << 20 }
+	if err := exceeded.LoadPackages("github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/..."); err != nil {
+		t.Fatalf("LoadPackages() error = %v", err)
+	}
+	if _, err := exceeded.AnalyzePackages(); err == nil {
+		t.Error("Expected AnalyzePackages() to fail above the memory limit")
+	}
+}
+
+func TestAnalyzer_MemoryLimitCancelled(t *testing.T) {
+	analyzer := NewAnalyzer(".", errors.NewErrorCollector(10, false))
+	analyzer.SetMemoryLimit(1)
+	if err := analyzer.LoadPackages("github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/..."); err != nil {
+		t.Fatalf("LoadPackages() error = %v", err)
+	}
+	
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := analyzer.AnalyzePackagesContext(ctx); !stderrors.Is(err, context.Canceled) {
+		t.Errorf("AnalyzePackagesContext() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestAnalyzer_QuerierInterface(t *testing.T) {
+	knownQueries := []string{"GetUser", "ListUsers", "CreateUser", "GetPost", "ListPostsByUser", "CreatePost", "GetCommentsByPost", "CreateComment"}
+	
+	tests := []struct {
+		name         string
+		knownQueries []string
+		want         map[string][]string
+	}{
+		{
+			name:         "Querier by name and narrow interfaces by method set",
+			knownQueries: knownQueries,
+			want: map[string][]string{
+				"UserLookup.FindUser": {"GetUser"},
+				"LatestPost":          {"ListPostsByUser"},
+			},
+		},
+		{
+			name: "Without known queries only Querier is recognized",
+			want: map[string][]string{
+				"UserLookup.FindUser": {"GetUser"},
+				"LatestPost":          nil,
+			},
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := NewAnalyzer(".", errors.NewErrorCollector(10, false))
+			analyzer.SetKnownQueries(tt.knownQueries)
+			if err := analyzer.LoadPackages("github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/lookup"); err != nil {
+				t.Fatalf("LoadPackages() error = %v", err)
+			}
+			functions, err := analyzer.AnalyzePackages()
+			if err != nil {
+				t.Fatalf("AnalyzePackages() error = %v", err)
+			}
+			
+			for name, want := range tt.want {
+				function, ok := findFunction(functions, name)
+				if !ok {
+					t.Fatalf("Function %s not found", name)
+				}
+				var got []string
+				for _, call := range function.SQLCalls {
+					got = append(got, call.MethodName)
+					if call.Confidence != pkgtypes.ConfidenceHigh {
+						t.Errorf("%s: Confidence = %s, want high for a call resolved on the interface", name, call.Confidence)
+					}
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("%s: SQL calls = %v, want %v", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDependencyMapper_FindCircularDependencies(t *testing.T) {
+	usersAccess := map[string]pkgtypes.TableAccessInfo{"users": {}}
+	result := pkgtypes.AnalysisResult{
+		FunctionView: map[string]pkgtypes.FunctionViewEntry{
+			// handler.A -> service.B -> repo.C -> handler.A is a real cycle
+			"handler.A": {FunctionName: "A", Calls: []string{"service.B"}, TableAccess: usersAccess},
+			"service.B": {FunctionName: "B", Calls: []string{"repo.C", "repo.D"}},
+			"repo.C":    {FunctionName: "C", Calls: []string{"handler.A"}, TableAccess: usersAccess},
+			"repo.D":    {FunctionName: "D", TableAccess: usersAccess},
+			// Readers of the same table without calls between them are not a cycle
+			"report.E": {FunctionName: "E", TableAccess: usersAccess},
+			"report.F": {FunctionName: "F", TableAccess: usersAccess},
+			// Direct recursion is a cycle of one function
+			"tree.Walk": {FunctionName: "Walk", Calls: []string{"tree.Walk"}},
+		},
+		TableView: map[string]pkgtypes.TableViewEntry{
+			"users": {
+				TableName: "users",
+				AccessedBy: map[string]pkgtypes.FunctionAccess{
+					"handler.A": {Function: "handler.A"},
+					"repo.C":    {Function: "repo.C"},
+					"repo.D":    {Function: "repo.D"},
+					"report.E":  {Function: "report.E"},
+					"report.F":  {Function: "report.F"},
+				},
+			},
+		},
+	}
+	
+	got := NewDependencyMapper(nil).FindCircularDependencies(result)
+	want := []pkgtypes.CircularDependency{
+		{Functions: []string{"handler.A", "service.B", "repo.C", "handler.A"}, Type: "call"},
+		{Functions: []string{"tree.Walk", "tree.Walk"}, Type: "call"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindCircularDependencies() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDependencyMapper_MapDependencies_Recursion(t *testing.T) {
+	goFunctions := map[string]pkgtypes.GoFunctionInfo{
+		"tree.Walk": {
+			FunctionName: "Walk",
+			DirectCalls:  []string{"tree.Walk"},
+			SQLCalls:     []pkgtypes.SQLCall{{MethodName: "GetNode", Line: 12}},
+		},
+	}
+	sqlMethods := map[string]pkgtypes.SQLMethodInfo{
+		"GetNode": {MethodName: "GetNode", Tables: []pkgtypes.TableOperation{{TableName: "nodes", Operations: []string{"SELECT"}}}},
+	}
+	
+	mapper := NewDependencyMapper(errors.NewErrorCollector(10, false))
+	result, err := mapper.MapDependencies(goFunctions, sqlMethods)
+	if err != nil {
+		t.Fatalf("MapDependencies() error = %v", err)
+	}
+	
+	// Self-calls are kept, so direct recursion is reported as a cycle
+	if calls := result.FunctionView["tree.Walk"].Calls; !reflect.DeepEqual(calls, []string{"tree.Walk"}) {
+		t.Errorf("Calls = %v, want the recursive call", calls)
+	}
+	want := []pkgtypes.CircularDependency{{Functions: []string{"tree.Walk", "tree.Walk"}, Type: "call"}}
+	if got := mapper.FindCircularDependencies(result); !reflect.DeepEqual(got, want) {
+		t.Errorf("FindCircularDependencies() = %+v, want %+v", got, want)
+	}
+	
+	// A function that only calls itself is still an entry point
+	if _, exists := mapper.FindEntryPoints(result)["tree.Walk"]; !exists {
+		t.Error("Expected the recursive function to remain an entry point")
+	}
+}
+
+func TestAnalyzer_AnalyzePackagesContext_Cancelled(t *testing.T) {
+	collector := errors.NewErrorCollector(10, false)
+	analyzer := NewAnalyzer(".", collector)
+	if err := analyzer.LoadPackages("github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/..."); err != nil {
+		t.Fatalf("LoadPackages() error = %v", err)
+	}
+	
+	// Cancel while the first package is being analyzed
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	analyzed := 0
+	analyzer.SetProgress(func(phase string, current, total int) {
+		if phase == "packages" {
+			analyzed = current
+			cancel()
+		}
+	})
+	
+	functions, err := analyzer.AnalyzePackagesContext(ctx)
+	if !stderrors.Is(err, context.Canceled) {
+		t.Fatalf("AnalyzePackagesContext() error = %v, want context.Canceled", err)
+	}
+	if functions != nil {
+		t.Errorf("Expected no functions after cancellation, got %d", len(functions))
+	}
+	if analyzed != 1 {
+		t.Errorf("Expected analysis to stop after the first package, analyzed %d", analyzed)
+	}
+	if collector.HasErrors() {
+		t.Errorf("Expected cancellation not to be recorded as an error, got %v", collector.GetErrors())
+	}
+	
+	// A package walk started with a cancelled context stops before its first function
+	if _, err := analyzer.analyzePackage(ctx, analyzer.packages[0]); !stderrors.Is(err, context.Canceled) {
+		t.Errorf("analyzePackage() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestAnalyzer_IgnoreDirective(t *testing.T) {
+	analyzer := NewAnalyzer(".", errors.NewErrorCollector(10, false))
+	
+	source := `package overlay
+
+import "context"
+
+type Queries struct{}
+
+func (q *Queries) GetUser(ctx context.Context, id int64) error {
+	return nil
+}
+
+// DebugUser dumps a user for local debugging.
+//
+//sqlc-analysis:ignore
+func DebugUser(ctx context.Context, q *Queries) error {
+	load := func() error {
+		return q.GetUser(ctx, 1)
+	}
+	return load()
+}
+
+func HandleGetUser(ctx context.Context, q *Queries) error {
+	return q.GetUser(ctx, 1)
+}
+`
+	if err := analyzer.LoadOverlay(map[string][]byte{"overlay/handler.go": []byte(source)}); err != nil {
+		t.Fatalf("LoadOverlay() error = %v", err)
+	}
+	functions, err := analyzer.AnalyzePackages()
+	if err != nil {
+		t.Fatalf("AnalyzePackages() error = %v", err)
+	}
+	
+	for name := range functions {
+		if strings.Contains(name, "DebugUser") {
+			t.Errorf("Expected the ignored function and its closures to be skipped, got %s", name)
+		}
+	}
+	if _, exists := findFunction(functions, "HandleGetUser"); !exists {
+		t.Errorf("Expected HandleGetUser in %v", functions)
+	}
+}
+
+func TestAnalyzer_MethodVariables(t *testing.T) {
+	const fixture = "github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/batch."
+	analyzer := NewAnalyzer(".", errors.NewErrorCollector(10, false))
+	if err := analyzer.LoadPackages(strings.TrimSuffix(fixture, ".")); err != nil {
+		t.Fatalf("LoadPackages() error = %v", err)
+	}
+	functions, err := analyzer.AnalyzePackages()
+	if err != nil {
+		t.Fatalf("AnalyzePackages() error = %v", err)
+	}
+	
+	tests := []struct {
+		function string
+		method   string
+		line     int
+		loopLine int
+	}{
+		// The call through the variable is counted where it happens, inside the loop
+		{function: "Loader.UsersByID", method: "GetUser", line: 24, loopLine: 23},
+		{function: "PostsOf", method: "ListPostsByUser", line: 36},
+		// A method value passed as a callback is attributed where it is taken
+		{function: "Loader.CountUsers", method: "ListUsers", line: 41},
+	}
+	for _, tt := range tests {
+		t.Run(tt.function, func(t *testing.T) {
+			calls := functions[fixture+tt.function].SQLCalls
+			if len(calls) != 1 {
+				t.Fatalf("SQLCalls = %+v, want one call to %s", calls, tt.method)
+			}
+			call := calls[0]
+			if call.MethodName != tt.method || call.Line != tt.line || call.LoopLine != tt.loopLine {
+				t.Errorf("SQLCall = %+v, want %s at line %d in loop %d", call, tt.method, tt.line, tt.loopLine)
+			}
+			if call.Confidence != pkgtypes.ConfidenceHigh {
+				t.Errorf("Confidence = %s, want %s", call.Confidence, pkgtypes.ConfidenceHigh)
+			}
+		})
+	}
+	
+	if calls := functions[fixture+"count"].SQLCalls; len(calls) != 0 {
+		t.Errorf("Expected the callback parameter not to be resolved, got %+v", calls)
+	}
+}
@@ -2,6 +2,7 @@ package gostatic
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/naoyafurudono/sqlc-use-analysis/internal/errors"
@@ -11,6 +12,8 @@ import (
 // DependencyMapper maps Go functions to SQL methods and database tables
 type DependencyMapper struct {
 	errorCollector *errors.ErrorCollector
+	external       map[string]bool // 以前の解析で得た、今回は解析しない関数
+	ignored        map[string]bool // 無視指定されたクエリのメソッド
 }
 
 // NewDependencyMapper creates a new dependency mapper
@@ -20,6 +23,23 @@ func NewDependencyMapper(errorCollector *errors.ErrorCollector) *DependencyMappe
 	}
 }
 
+// SetExternalFunctions keeps calls to functions analyzed in an earlier run (incremental analysis)
+func (m *DependencyMapper) SetExternalFunctions(names []string) {
+	m.external = make(map[string]bool, len(names))
+	for _, name := range names {
+		m.external[name] = true
+	}
+}
+
+// SetIgnoredMethods sets SQL methods excluded by an ignore directive
+// Calls to them are dropped without a "not found" warning
+func (m *DependencyMapper) SetIgnoredMethods(names []string) {
+	m.ignored = make(map[string]bool, len(names))
+	for _, name := range names {
+		m.ignored[name] = true
+	}
+}
+
 // MapDependencies maps Go functions to SQL methods and creates dependency relationships
 func (m *DependencyMapper) MapDependencies(
 	goFunctions map[string]types.GoFunctionInfo,
@@ -36,20 +56,45 @@ func (m *DependencyMapper) MapDependencies(
 		entry := types.FunctionViewEntry{
 			FunctionName: funcInfo.FunctionName,
 			PackageName:  funcInfo.PackageName,
+			PackagePath:  funcInfo.PackagePath,
 			FileName:     funcInfo.FileName,
 			StartLine:    funcInfo.StartLine,
 			EndLine:      funcInfo.EndLine,
 			TableAccess:  make(map[string]types.TableAccessInfo),
 		}
 
+		// 解析対象の関数への呼び出しのみを残す（再帰呼び出しも閉路として検出できるよう残す）
+		for _, callee := range funcInfo.DirectCalls {
+			if _, exists := goFunctions[callee]; exists || m.external[callee] {
+				entry.Calls = append(entry.Calls, callee)
+			}
+		}
+
 		// Map SQL calls to table access
 		for _, sqlCall := range funcInfo.SQLCalls {
+			// ドライバーメソッドの直接呼び出しは仮想テーブルへの操作として記録する
+			if sqlCall.Driver {
+				m.addTableAccess(&entry, types.TableOperation{
+					TableName:  types.RawTable,
+					Operations: []string{string(types.OpDriver)},
+				}, sqlCall)
+				continue
+			}
+			
+			// database/sqlに直接渡されたSQLは解析済みのテーブル操作を使う
+			if sqlCall.SQL != "" {
+				for _, tableOp := range sqlCall.Tables {
+					m.addTableAccess(&entry, tableOp, sqlCall)
+				}
+				continue
+			}
+			
 			if sqlMethodInfo, exists := sqlMethods[sqlCall.MethodName]; exists {
 				// Add table access for each table in the SQL method
 				for _, tableOp := range sqlMethodInfo.Tables {
 					m.addTableAccess(&entry, tableOp, sqlCall)
 				}
-			} else {
+			} else if !m.ignored[sqlCall.MethodName] {
 				// Log warning for unmapped SQL method
 				mapErr := errors.NewError(errors.CategoryMapping, errors.SeverityWarning,
 					fmt.Sprintf("SQL method '%s' not found in SQL analysis", sqlCall.MethodName))
@@ -63,6 +108,13 @@ func (m *DependencyMapper) MapDependencies(
 			}
 		}
 
+		if err := m.detectWriteThenRead(funcInfo, sqlMethods); err != nil {
+			return result, err
+		}
+		if err := m.detectQueriesInLoops(funcInfo, sqlMethods); err != nil {
+			return result, err
+		}
+
 		result.FunctionView[funcName] = entry
 	}
 
@@ -72,6 +124,134 @@ func (m *DependencyMapper) MapDependencies(
 	return result, nil
 }
 
+// detectWriteThenRead reports tables that a function writes and later reads
+// Such read-your-write patterns may need a transaction or a consistent replica
+func (m *DependencyMapper) detectWriteThenRead(
+	funcInfo types.GoFunctionInfo,
+	sqlMethods map[string]types.SQLMethodInfo,
+) error {
+	calls := make([]types.SQLCall, len(funcInfo.SQLCalls))
+	copy(calls, funcInfo.SQLCalls)
+	sort.SliceStable(calls, func(i, j int) bool {
+		if calls[i].Line != calls[j].Line {
+			return calls[i].Line < calls[j].Line
+		}
+		return calls[i].Column < calls[j].Column
+	})
+
+	writes := make(map[string]types.SQLCall) // テーブル名 -> 最初の書き込み
+	reported := make(map[string]bool)
+	for _, call := range calls {
+		method, exists := sqlMethods[call.MethodName]
+		if !exists {
+			continue
+		}
+
+		// 同じ呼び出し内の読み込み（INSERT ... SELECT等）は対象外とするため、読み込みを先に判定する
+		for _, tableOp := range method.Tables {
+			write, written := writes[tableOp.TableName]
+			if !written || reported[tableOp.TableName] || !containsOperation(tableOp.Operations, "SELECT") {
+				continue
+			}
+			reported[tableOp.TableName] = true
+
+			finding := errors.NewError(errors.CategoryAnalysis, errors.SeverityInfo,
+				fmt.Sprintf("write-then-read on table %s in function %s", tableOp.TableName, funcInfo.FunctionName))
+			finding.Details["function"] = funcInfo.FunctionName
+			finding.Details["table"] = tableOp.TableName
+			finding.Details["write_method"] = write.MethodName
+			finding.Details["write_line"] = write.Line
+			finding.Details["read_method"] = call.MethodName
+			finding.Details["read_line"] = call.Line
+
+			if collectErr := m.errorCollector.Add(finding); collectErr != nil {
+				return collectErr
+			}
+		}
+
+		for _, tableOp := range method.Tables {
+			if _, written := writes[tableOp.TableName]; written {
+				continue
+			}
+			if containsOperation(tableOp.Operations, "INSERT") || containsOperation(tableOp.Operations, "UPDATE") {
+				writes[tableOp.TableName] = call
+			}
+		}
+	}
+
+	return nil
+}
+
+// detectQueriesInLoops reports read queries called inside a for/range loop
+// Each iteration issues its own query, the classic N+1 pattern that a batched query avoids
+func (m *DependencyMapper) detectQueriesInLoops(
+	funcInfo types.GoFunctionInfo,
+	sqlMethods map[string]types.SQLMethodInfo,
+) error {
+	reported := make(map[string]bool) // "メソッド名:ループ行" ごとに1回だけ報告する
+	for _, call := range funcInfo.SQLCalls {
+		if call.LoopLine == 0 || call.Driver {
+			continue
+		}
+		tables := call.Tables
+		if call.SQL == "" {
+			method, exists := sqlMethods[call.MethodName]
+			if !exists {
+				continue
+			}
+			tables = method.Tables
+		}
+		if !isReadOnly(tables) {
+			continue
+		}
+		
+		key := fmt.Sprintf("%s:%d", call.MethodName, call.LoopLine)
+		if reported[key] {
+			continue
+		}
+		reported[key] = true
+		
+		finding := errors.NewError(errors.CategoryAnalysis, errors.SeverityWarning,
+			fmt.Sprintf("possible N+1: method %s called in a loop in function %s", call.MethodName, funcInfo.FunctionName)).
+			WithLocation(funcInfo.FilePath, call.Line, call.Column)
+		finding.Details["function"] = funcInfo.FunctionName
+		finding.Details["method"] = call.MethodName
+		finding.Details["line"] = call.Line
+		finding.Details["loop_line"] = call.LoopLine
+		
+		if collectErr := m.errorCollector.Add(finding); collectErr != nil {
+			return collectErr
+		}
+	}
+	
+	return nil
+}
+
+// isReadOnly reports whether the table operations only read (SELECT)
+func isReadOnly(tables []types.TableOperation) bool {
+	if len(tables) == 0 {
+		return false
+	}
+	for _, tableOp := range tables {
+		for _, operation := range tableOp.Operations {
+			if operation != "SELECT" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// containsOperation checks if operations contains the given operation
+func containsOperation(operations []string, operation string) bool {
+	for _, op := range operations {
+		if op == operation {
+			return true
+		}
+	}
+	return false
+}
+
 // addTableAccess adds table access information to a function view entry
 func (m *DependencyMapper) addTableAccess(
 	entry *types.FunctionViewEntry,
@@ -79,6 +259,10 @@ func (m *DependencyMapper) addTableAccess(
 	sqlCall types.SQLCall,
 ) {
 	tableName := tableOp.TableName
+	kind := tableOp.Kind
+	if kind == "" {
+		kind = types.AccessPrimary
+	}
 	
 	// Get existing table access or create new one
 	access, exists := entry.TableAccess[tableName]
@@ -95,14 +279,40 @@ func (m *DependencyMapper) addTableAccess(
 			MethodName: sqlCall.MethodName,
 			Line:       sqlCall.Line,
 			Column:     sqlCall.Column,
+			Kind:       kind,
+			Confidence: sqlCall.Confidence,
 		}
 
 		access.Operations[operation] = append(access.Operations[operation], opCall)
+		
+		// 列レベルのアクセスは操作ごとに重複なく記録する
+		for _, column := range tableOp.Columns {
+			if access.Columns == nil {
+				access.Columns = make(map[string][]string)
+			}
+			if !containsColumn(access.Columns[operation], column) {
+				access.Columns[operation] = append(access.Columns[operation], column)
+			}
+		}
+	}
+	
+	if tableOp.Locking != "" && !containsOperation(access.Locking, tableOp.Locking) {
+		access.Locking = append(access.Locking, tableOp.Locking)
 	}
 
 	entry.TableAccess[tableName] = access
 }
 
+// containsColumn reports whether columns contains column
+func containsColumn(columns []string, column string) bool {
+	for _, c := range columns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
 // createTableView creates table view entries from function view
 func (m *DependencyMapper) createTableView(
 	functionView map[string]types.FunctionViewEntry,
@@ -110,7 +320,7 @@ func (m *DependencyMapper) createTableView(
 	
 	tableView := make(map[string]types.TableViewEntry)
 
-	for _, funcEntry := range functionView {
+	for funcName, funcEntry := range functionView {
 		for tableName, tableAccess := range funcEntry.TableAccess {
 			// Get existing table view entry or create new one
 			entry, exists := tableView[tableName]
@@ -129,7 +339,7 @@ func (m *DependencyMapper) createTableView(
 			}
 			
 			funcAccess := types.FunctionAccess{
-				Function:   funcEntry.FunctionName,
+				Function:   funcName,
 				Operations: operations,
 			}
 
@@ -138,7 +348,7 @@ func (m *DependencyMapper) createTableView(
 				entry.OperationSummary[operation] += len(calls)
 			}
 
-			entry.AccessedBy[funcEntry.FunctionName] = funcAccess
+			entry.AccessedBy[funcName] = funcAccess
 			tableView[tableName] = entry
 		}
 	}
@@ -217,6 +427,77 @@ func (m *DependencyMapper) ValidateDependencies(result types.AnalysisResult) err
 	return nil
 }
 
+// FindEntryPoints finds functions that no other analyzed function calls
+// and collects the tables each one transitively accesses
+// Entry points that reach no table are omitted
+func (m *DependencyMapper) FindEntryPoints(result types.AnalysisResult) map[string]types.EntryPoint {
+	called := make(map[string]bool)
+	for funcName, funcEntry := range result.FunctionView {
+		for _, callee := range funcEntry.Calls {
+			// 自身の再帰呼び出しだけではエントリポイントから外さない
+			if callee != funcName {
+				called[callee] = true
+			}
+		}
+	}
+
+	entryPoints := make(map[string]types.EntryPoint)
+	for funcName := range result.FunctionView {
+		if called[funcName] {
+			continue
+		}
+
+		reached := m.reachableFunctions(result, funcName)
+		tables := make(map[string][]string)
+		for _, name := range reached {
+			for tableName, tableAccess := range result.FunctionView[name].TableAccess {
+				for operation := range tableAccess.Operations {
+					if !containsOperation(tables[tableName], operation) {
+						tables[tableName] = append(tables[tableName], operation)
+					}
+				}
+			}
+		}
+		if len(tables) == 0 {
+			continue
+		}
+
+		for tableName := range tables {
+			sort.Strings(tables[tableName])
+		}
+		entryPoints[funcName] = types.EntryPoint{
+			Function: funcName,
+			Reaches:  reached[1:],
+			Tables:   tables,
+		}
+	}
+
+	return entryPoints
+}
+
+// reachableFunctions returns start followed by every function it transitively calls, sorted
+func (m *DependencyMapper) reachableFunctions(result types.AnalysisResult, start string) []string {
+	visited := map[string]bool{start: true}
+	stack := []string{start}
+	var reached []string
+
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		for _, callee := range result.FunctionView[current].Calls {
+			if !visited[callee] {
+				visited[callee] = true
+				reached = append(reached, callee)
+				stack = append(stack, callee)
+			}
+		}
+	}
+
+	sort.Strings(reached)
+	return append([]string{start}, reached...)
+}
+
 // GenerateSummary generates a summary of the dependency analysis
 func (m *DependencyMapper) GenerateSummary(result types.AnalysisResult) types.AnalysisSummary {
 	summary := types.AnalysisSummary{
@@ -242,63 +523,124 @@ func (m *DependencyMapper) GenerateSummary(result types.AnalysisResult) types.An
 	return summary
 }
 
-// FindCircularDependencies finds circular dependencies in the analysis result
+// FindCircularDependencies finds cycles in the call graph between analyzed functions
+// Each cycle starts and ends with its alphabetically first function, e.g. [A B C A]
 func (m *DependencyMapper) FindCircularDependencies(result types.AnalysisResult) []types.CircularDependency {
 	var circular []types.CircularDependency
 
-	// Build dependency graph
-	graph := make(map[string][]string)
+	// テーブルの共有ではなく、実際の呼び出し関係から強連結成分を求める
+	for _, component := range m.callGraphComponents(result) {
+		cycle := m.cycleWithin(result, component)
+		if cycle == nil {
+			continue
+		}
+		circular = append(circular, types.CircularDependency{
+			Functions: cycle,
+			Type:      "call",
+		})
+	}
 	
-	for funcName, funcEntry := range result.FunctionView {
-		for tableName := range funcEntry.TableAccess {
-			// In this context, we consider functions that access the same table
-			// as potentially having circular dependencies
-			if tableEntry, exists := result.TableView[tableName]; exists {
-				for otherFuncName := range tableEntry.AccessedBy {
-					if otherFuncName != funcName {
-						graph[funcName] = append(graph[funcName], otherFuncName)
-					}
-				}
+	sort.Slice(circular, func(i, j int) bool {
+		return circular[i].Functions[0] < circular[j].Functions[0]
+	})
+	return circular
+}
+
+// callGraphComponents returns the strongly connected components of the call graph (Tarjan)
+func (m *DependencyMapper) callGraphComponents(result types.AnalysisResult) [][]string {
+	functions := make([]string, 0, len(result.FunctionView))
+	for funcName := range result.FunctionView {
+		functions = append(functions, funcName)
+	}
+	sort.Strings(functions)
+
+	index := make(map[string]int)
+	lowLink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var components [][]string
+
+	var visit func(string)
+	visit = func(node string) {
+		index[node] = len(index)
+		lowLink[node] = index[node]
+		stack = append(stack, node)
+		onStack[node] = true
+
+		for _, callee := range result.FunctionView[node].Calls {
+			if _, exists := result.FunctionView[callee]; !exists {
+				continue
+			}
+			if _, seen := index[callee]; !seen {
+				visit(callee)
+				lowLink[node] = min(lowLink[node], lowLink[callee])
+			} else if onStack[callee] {
+				lowLink[node] = min(lowLink[node], index[callee])
 			}
 		}
-	}
 
-	// Simple cycle detection (for demonstration)
-	visited := make(map[string]bool)
-	recStack := make(map[string]bool)
-	
-	var dfs func(string, []string) bool
-	dfs = func(node string, path []string) bool {
-		visited[node] = true
-		recStack[node] = true
-		
-		for _, neighbor := range graph[node] {
-			if !visited[neighbor] {
-				if dfs(neighbor, append(path, node)) {
-					return true
-				}
-			} else if recStack[neighbor] {
-				// Found cycle
-				cycle := append(path, node, neighbor)
-				circular = append(circular, types.CircularDependency{
-					Functions: cycle,
-					Type:      "table_access",
-				})
-				return true
+		if lowLink[node] != index[node] {
+			return
+		}
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == node {
+				break
 			}
 		}
-		
-		recStack[node] = false
-		return false
+		sort.Strings(component)
+		components = append(components, component)
 	}
 
-	for funcName := range result.FunctionView {
-		if !visited[funcName] {
-			dfs(funcName, []string{})
+	for _, funcName := range functions {
+		if _, seen := index[funcName]; !seen {
+			visit(funcName)
 		}
 	}
+	return components
+}
+
+// cycleWithin returns a shortest call cycle through the first function of a component,
+// or nil when the component is a single function that does not call itself
+func (m *DependencyMapper) cycleWithin(result types.AnalysisResult, component []string) []string {
+	members := make(map[string]bool, len(component))
+	for _, funcName := range component {
+		members[funcName] = true
+	}
 
-	return circular
+	start := component[0]
+	previous := map[string]string{}
+	queue := []string{start}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, callee := range result.FunctionView[current].Calls {
+			if !members[callee] {
+				continue
+			}
+			if callee == start {
+				// startに戻る経路を逆順にたどって閉路を組み立てる
+				cycle := []string{start}
+				for node := current; node != start; node = previous[node] {
+					cycle = append(cycle, node)
+				}
+				for i, j := 1, len(cycle)-1; i < j; i, j = i+1, j-1 {
+					cycle[i], cycle[j] = cycle[j], cycle[i]
+				}
+				return append(cycle, start)
+			}
+			if _, seen := previous[callee]; !seen {
+				previous[callee] = current
+				queue = append(queue, callee)
+			}
+		}
+	}
+	return nil
 }
 
 // OptimizeDependencies suggests optimizations for the dependency structure
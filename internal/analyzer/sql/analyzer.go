@@ -3,17 +3,39 @@ package sql
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/naoyafurudono/sqlc-use-analysis/internal/errors"
 	"github.com/naoyafurudono/sqlc-use-analysis/pkg/types"
 )
 
+// サポートするSQL方言
+const (
+	DialectMySQL      = "mysql"
+	DialectPostgreSQL = "postgresql"
+	DialectSQLite     = "sqlite"
+	DialectANSI       = "ansi" // 標準SQL（未知の方言もこの扱いになる）
+)
+
+// SupportedDialects returns the SQL dialects understood by the analyzer
+func SupportedDialects() []string {
+	return []string{DialectMySQL, DialectPostgreSQL, DialectSQLite, DialectANSI}
+}
+
+// opMerge はMERGE文を表す文種別（テーブルごとの操作はWHEN句から判定する）
+const opMerge types.Operation = "MERGE"
+
 // Analyzer analyzes SQL queries and extracts table operations
 type Analyzer struct {
 	dialect         string
 	caseSensitive   bool
 	errorCollector  *errors.ErrorCollector
+	includeDDL      bool
+	readContext     bool
+	acronyms        map[string]bool // メソッド名で大文字のまま扱う略語（大文字で保持）
+	reportedNames   map[string]bool // 報告済みの識別子の警告（重複報告を防ぐ）
+	tableRefs       []string        // 解析中のクエリでテーブル位置に現れた表記（識別子の検査用）
 }
 
 // NewAnalyzer creates a new SQL analyzer
@@ -25,12 +47,59 @@ func NewAnalyzer(dialect string, caseSensitive bool, errorCollector *errors.Erro
 	}
 }
 
+// SetIncludeDDL enables recognition of CREATE/ALTER/DROP/TRUNCATE statements
+// When disabled, DDL statements are reported as unknown operations
+func (a *Analyzer) SetIncludeDDL(include bool) {
+	a.includeDDL = include
+}
+
+// SetReadContextEdges records tables that write statements only read (JOIN/FROM/USING/subquery)
+// as AccessReference SELECTs instead of attributing the write operation to them
+func (a *Analyzer) SetReadContextEdges(enabled bool) {
+	a.readContext = enabled
+}
+
+// SetAcronyms sets words kept upper case when query names are converted to method names
+// so that "get_api_url" becomes "GetAPIURL" with the acronyms "API" and "URL"
+func (a *Analyzer) SetAcronyms(acronyms []string) {
+	a.acronyms = make(map[string]bool, len(acronyms))
+	for _, acronym := range acronyms {
+		if acronym = strings.TrimSpace(acronym); acronym != "" {
+			a.acronyms[strings.ToUpper(acronym)] = true
+		}
+	}
+}
+
 // Query represents a SQL query from sqlc
 type Query struct {
 	Text     string `json:"text"`
 	Name     string `json:"name"`
 	Cmd      string `json:"cmd"`
 	Filename string `json:"filename"`
+	Comments []string `json:"comments,omitempty"` // sqlcプラグインではコメントが本文から除かれ、ここに渡される
+}
+
+var (
+	// ignoreDirectivePattern matches the "-- sqlc-analysis:ignore" marker that excludes a query
+	ignoreDirectivePattern = regexp.MustCompile(`--\s*sqlc-analysis:ignore\b`)
+	// ignoreCommentPattern matches the marker in a comment sqlc passed without its "--"
+	ignoreCommentPattern = regexp.MustCompile(`^\s*(?:--\s*)?sqlc-analysis:ignore\b`)
+)
+
+// IsIgnored reports whether a query is marked with "-- sqlc-analysis:ignore",
+// either in its text or in the comments sqlc removed from it
+func IsIgnored(query Query) bool {
+	for _, comment := range query.Comments {
+		if ignoreCommentPattern.MatchString(comment) {
+			return true
+		}
+	}
+	return ignoreDirectivePattern.MatchString(query.Text)
+}
+
+// MethodName returns the Go method name sqlc generates for a query
+func (a *Analyzer) MethodName(query Query) string {
+	return a.generateMethodName(query.Name, query.Cmd)
 }
 
 // AnalyzeQueries analyzes multiple SQL queries
@@ -41,6 +110,9 @@ func (a *Analyzer) AnalyzeQueries(queries []Query) (map[string]types.SQLMethodIn
 	partialResult := errors.ProcessWithPartialFailure(
 		queries,
 		func(query Query) error {
+			if IsIgnored(query) {
+				return nil
+			}
 			methodInfo, err := a.AnalyzeQuery(query)
 			if err != nil {
 				return errors.Wrap(err, fmt.Sprintf("failed to analyze query '%s'", query.Name))
@@ -70,8 +142,45 @@ func (a *Analyzer) AnalyzeQueries(queries []Query) (map[string]types.SQLMethodIn
 	return results, nil
 }
 
+// IndexByTable inverts analyzed queries into table -> operation -> query method names
+// Goコードがなくても「テーブルXを読み書きするクエリ」を一覧できる。メソッド名はソート済み
+func (a *Analyzer) IndexByTable(methods map[string]types.SQLMethodInfo) map[string]map[string][]string {
+	index := make(map[string]map[string][]string)
+	for methodName, method := range methods {
+		for _, tableOp := range method.Tables {
+			operations, exists := index[tableOp.TableName]
+			if !exists {
+				operations = make(map[string][]string)
+				index[tableOp.TableName] = operations
+			}
+			for _, operation := range tableOp.Operations {
+				operations[operation] = appendUnique(operations[operation], methodName)
+			}
+		}
+	}
+	
+	for _, operations := range index {
+		for _, names := range operations {
+			sort.Strings(names)
+		}
+	}
+	return index
+}
+
 // AnalyzeQuery analyzes a single SQL query
 func (a *Analyzer) AnalyzeQuery(query Query) (types.SQLMethodInfo, error) {
+	a.tableRefs = nil
+	methodInfo, err := a.analyzeStatement(query)
+	if err != nil {
+		return types.SQLMethodInfo{}, err
+	}
+	a.checkTableIdentifiers(methodInfo.Tables)
+	methodInfo.Fingerprint = Fingerprint(query.Text)
+	return methodInfo, nil
+}
+
+// analyzeStatement extracts the method name and table operations of a query
+func (a *Analyzer) analyzeStatement(query Query) (types.SQLMethodInfo, error) {
 	// メソッド名の生成
 	methodName := a.generateMethodName(query.Name, query.Cmd)
 	
@@ -81,20 +190,116 @@ func (a *Analyzer) AnalyzeQuery(query Query) (types.SQLMethodInfo, error) {
 		return types.SQLMethodInfo{}, fmt.Errorf("failed to detect operation type: %w", err)
 	}
 	
-	// テーブル名の抽出
-	tables, err := a.extractTables(query.Text, operation)
-	if err != nil {
-		return types.SQLMethodInfo{}, fmt.Errorf("failed to extract tables: %w", err)
+	// MERGE文はテーブルごとに異なる操作を持つ
+	if operation == opMerge {
+		tableOps, err := a.analyzeMerge(query.Text)
+		if err != nil {
+			return types.SQLMethodInfo{}, fmt.Errorf("failed to analyze MERGE statement: %w", err)
+		}
+		return types.SQLMethodInfo{
+			MethodName: methodName,
+			Tables:     tableOps,
+		}, nil
 	}
 	
-	// 結果の構築
-	tableOps := make([]types.TableOperation, 0, len(tables))
-	for _, table := range tables {
-		tableOp := types.TableOperation{
-			TableName:  table,
-			Operations: []string{string(operation)},
+	// COPY文は取り込み先・書き出し元のテーブルを記録する
+	if isCopy(query.Text) {
+		tableOps, err := a.analyzeCopy(query.Text)
+		if err != nil {
+			return types.SQLMethodInfo{}, fmt.Errorf("failed to analyze COPY statement: %w", err)
+		}
+		return types.SQLMethodInfo{
+			MethodName: methodName,
+			Tables:     tableOps,
+		}, nil
+	}
+	
+	// SELECT ... INTO は新しいテーブルへの挿入と元のテーブルの読み取りとして扱う
+	if operation == types.OpInsert && a.isSelectInto(query.Text) {
+		tableOps, err := a.analyzeSelectInto(query.Text)
+		if err != nil {
+			return types.SQLMethodInfo{}, fmt.Errorf("failed to analyze SELECT INTO statement: %w", err)
+		}
+		return types.SQLMethodInfo{
+			MethodName: methodName,
+			Tables:     tableOps,
+		}, nil
+	}
+	
+	// DDL文は対象テーブルのみを記録する
+	if operation.IsDDL() {
+		tables, err := a.extractDDLTables(query.Text, operation)
+		if err != nil {
+			return types.SQLMethodInfo{}, fmt.Errorf("failed to analyze %s statement: %w", operation, err)
+		}
+		tableOps := make([]types.TableOperation, 0, len(tables))
+		for _, table := range tables {
+			tableOps = append(tableOps, types.TableOperation{
+				TableName:  table,
+				Operations: []string{string(operation)},
+			})
+		}
+		return types.SQLMethodInfo{
+			MethodName: methodName,
+			Tables:     tableOps,
+		}, nil
+	}
+	
+	// WITH句はCTEの本体ごとにテーブルと操作を判定し、以降の検査は続く本体の文に対して行う
+	statement := query.Text
+	var tableOps []types.TableOperation
+	if isCTE(query.Text) {
+		tableOps, statement, operation, err = a.analyzeCTE(query.Text)
+		if err != nil {
+			return types.SQLMethodInfo{}, fmt.Errorf("failed to analyze WITH clause: %w", err)
+		}
+	} else {
+		// テーブル名の抽出
+		tableOps, err = a.statementTableOps(query.Text, operation)
+		if err != nil {
+			return types.SQLMethodInfo{}, fmt.Errorf("failed to extract tables: %w", err)
+		}
+	}
+	
+	// INSERT ... ON CONFLICT DO UPDATE / ON DUPLICATE KEY UPDATE は挿入先の更新も行う
+	if operation == types.OpInsert && isUpsert(statement) {
+		target := a.extractWriteTarget(statement, operation)
+		for i := range tableOps {
+			if tableOps[i].TableName == target && tableOps[i].Kind != types.AccessReference {
+				tableOps[i].Operations = appendUnique(tableOps[i].Operations, string(types.OpUpdate))
+			}
+		}
+	}
+	
+	// REPLACE は競合する既存の行を削除してから挿入する
+	if operation == types.OpInsert && isReplace(statement) {
+		target := a.extractWriteTarget(statement, operation)
+		for i := range tableOps {
+			if tableOps[i].TableName == target && tableOps[i].Kind != types.AccessReference {
+				tableOps[i].Operations = appendUnique(tableOps[i].Operations, string(types.OpDelete))
+			}
+		}
+	}
+	
+	// WHEREのないUPDATE/DELETEは全行が対象になるため警告する
+	if operation == types.OpUpdate || operation == types.OpDelete {
+		a.reportUnqualifiedWrite(query, statement, operation, tableOps)
+	}
+	
+	// 列レベルのアクセスを記録する（参照のみのテーブルは対象外）
+	columns := a.extractColumns(statement, operation)
+	for i := range tableOps {
+		if tableOps[i].Kind != types.AccessReference {
+			tableOps[i].Columns = columns[tableOps[i].TableName]
+		}
+	}
+	
+	// SELECT ... FOR UPDATE などの行ロックを記録する（操作はSELECTのまま）
+	if operation == types.OpSelect {
+		locks := a.extractLocking(statement)
+		for i := range tableOps {
+			tableOps[i].Locking = locks[tableOps[i].TableName]
 		}
-		tableOps = append(tableOps, tableOp)
 	}
 	
 	return types.SQLMethodInfo{
@@ -106,7 +311,7 @@ func (a *Analyzer) AnalyzeQuery(query Query) (types.SQLMethodInfo, error) {
 // generateMethodName generates a Go method name from query name and command
 func (a *Analyzer) generateMethodName(queryName, cmd string) string {
 	// クエリ名をPascalCaseに変換
-	methodName := toPascalCase(queryName)
+	methodName := toPascalCase(queryName, a.acronyms)
 	
 	// コマンドタイプに応じた調整
 	switch cmd {
@@ -134,13 +339,28 @@ func (a *Analyzer) detectOperationType(sqlText string) (types.Operation, error)
 	
 	switch {
 	case strings.HasPrefix(upperSQL, "SELECT"):
+		if a.isSelectInto(normalizedSQL) {
+			return types.OpInsert, nil
+		}
 		return types.OpSelect, nil
-	case strings.HasPrefix(upperSQL, "INSERT"):
+	case strings.HasPrefix(upperSQL, "INSERT"), strings.HasPrefix(upperSQL, "REPLACE"):
 		return types.OpInsert, nil
 	case strings.HasPrefix(upperSQL, "UPDATE"):
 		return types.OpUpdate, nil
 	case strings.HasPrefix(upperSQL, "DELETE"):
 		return types.OpDelete, nil
+	case strings.HasPrefix(upperSQL, "MERGE"):
+		return opMerge, nil
+	case strings.HasPrefix(upperSQL, "COPY"):
+		return a.copyOperation(normalizedSQL)
+	case a.includeDDL && strings.HasPrefix(upperSQL, "CREATE"):
+		return types.OpCreate, nil
+	case a.includeDDL && strings.HasPrefix(upperSQL, "ALTER"):
+		return types.OpAlter, nil
+	case a.includeDDL && strings.HasPrefix(upperSQL, "DROP"):
+		return types.OpDrop, nil
+	case a.includeDDL && strings.HasPrefix(upperSQL, "TRUNCATE"):
+		return types.OpTruncate, nil
 	case strings.HasPrefix(upperSQL, "WITH"):
 		// CTE（Common Table Expression）の場合は本体を解析
 		return a.detectCTEOperationType(upperSQL)
@@ -151,21 +371,11 @@ func (a *Analyzer) detectOperationType(sqlText string) (types.Operation, error)
 
 // detectCTEOperationType detects operation type in CTE
 func (a *Analyzer) detectCTEOperationType(sqlText string) (types.Operation, error) {
-	// WITH句の後の最終的なクエリを見つける
-	// 簡単な実装：最後のSELECT/INSERT/UPDATE/DELETEを探す
-	ctePattern := regexp.MustCompile(`(?i)WITH\s+.*?\)\s*(SELECT|INSERT|UPDATE|DELETE)`)
-	matches := ctePattern.FindStringSubmatch(sqlText)
-	
-	if len(matches) >= 2 {
-		switch strings.ToUpper(matches[1]) {
-		case "SELECT":
-			return types.OpSelect, nil
-		case "INSERT":
-			return types.OpInsert, nil
-		case "UPDATE":
-			return types.OpUpdate, nil
-		case "DELETE":
-			return types.OpDelete, nil
+	// WITH句の後に続く本体の文の操作種別を使う
+	_, mainStatement, err := a.splitCTE(sqlText)
+	if err == nil && mainStatement != "" {
+		if operation, err := a.detectOperationType(mainStatement); err == nil {
+			return operation, nil
 		}
 	}
 	
@@ -173,6 +383,76 @@ func (a *Analyzer) detectCTEOperationType(sqlText string) (types.Operation, erro
 	return types.OpSelect, nil
 }
 
+// statementTableOps extracts the tables of a DML statement with their operations
+func (a *Analyzer) statementTableOps(sqlText string, operation types.Operation) ([]types.TableOperation, error) {
+	tables, err := a.extractTables(sqlText, operation)
+	if err != nil {
+		return nil, err
+	}
+	
+	target := ""
+	if a.readContext && operation != types.OpSelect {
+		target = a.extractWriteTarget(sqlText, operation)
+	}
+	
+	// 書き込み対象を区別しない場合は全テーブルに文の操作を割り当てる
+	if target == "" {
+		tableOps := make([]types.TableOperation, 0, len(tables))
+		for _, table := range tables {
+			tableOps = append(tableOps, types.TableOperation{
+				TableName:  table,
+				Operations: []string{string(operation)},
+			})
+		}
+		return tableOps, nil
+	}
+	
+	return a.targetTableOps(sqlText, operation, tables, target)
+}
+
+// targetTableOps attributes the write operation to target and SELECT (as references) to
+// every other table the statement reads
+func (a *Analyzer) targetTableOps(sqlText string, operation types.Operation, tables []string, target string) ([]types.TableOperation, error) {
+	// INSERT ... SELECT のソーステーブルも参照として扱う
+	if operation == types.OpInsert {
+		body, conflict := splitConflictClause(normalizeSQL(sqlText))
+		if loc := regexp.MustCompile(`(?i)\bSELECT\b`).FindStringIndex(body); loc != nil {
+			sources, err := a.extractTablesFromSelect(body[loc[0]:])
+			if err != nil {
+				return nil, err
+			}
+			tables = append(tables, sources...)
+		}
+		
+		// 競合対象や述語は列のみを参照するため、DO UPDATE SET内のサブクエリだけを読み取る
+		_, subqueries := splitSubqueries(conflict)
+		for _, subquery := range subqueries {
+			sources, err := a.extractTablesFromSelect(subquery)
+			if err != nil {
+				return nil, err
+			}
+			tables = append(tables, sources...)
+		}
+	}
+	
+	tableOps := []types.TableOperation{{
+		TableName:  target,
+		Operations: []string{string(operation)},
+		Kind:       types.AccessPrimary,
+	}}
+	for _, table := range removeDuplicates(tables) {
+		if table == target {
+			continue
+		}
+		tableOps = append(tableOps, types.TableOperation{
+			TableName:  table,
+			Operations: []string{string(types.OpSelect)},
+			Kind:       types.AccessReference,
+		})
+	}
+	return tableOps, nil
+}
+
 // extractTables extracts table names from SQL
 func (a *Analyzer) extractTables(sqlText string, operation types.Operation) ([]string, error) {
 	normalizedSQL := normalizeSQL(sqlText)
@@ -201,16 +481,37 @@ func (a *Analyzer) extractTables(sqlText string, operation types.Operation) ([]s
 	return removeDuplicates(tables), nil
 }
 
+var (
+	// sqlc.embed(table) はテーブルの全カラムに展開される
+	sqlcEmbedPattern = regexp.MustCompile(`(?i)\bsqlc\.embed\s*\(\s*([^()\s]+)\s*\)`)
+	// sqlc.arg / sqlc.narg / sqlc.slice などはパラメータとして扱う
+	sqlcFuncPattern = regexp.MustCompile(`(?i)\bsqlc\.[a-z_]+\s*\([^()]*\)`)
+	// 文字列リテラル（''によるエスケープを含む）とコメント
+	// 先頭から順に照合し、コメント中のアポストロフィやリテラル中の"--"を取り違えないようにする
+	literalOrCommentPattern = regexp.MustCompile(`'(?:[^']|'')*'|--[^\n]*|/\*[\s\S]*?\*/`)
+)
+
 // normalizeSQL normalizes SQL text
 func normalizeSQL(sql string) string {
+	// JSONのキー（data->>'from'など）や文字列中のキーワードをFROM/JOINと誤認しないよう中身を除去し、コメントは空白にする
+	sql = literalOrCommentPattern.ReplaceAllStringFunc(sql, func(token string) string {
+		if strings.HasPrefix(token, "'") {
+			return "''"
+		}
+		return " "
+	})
 	// 改行を空白に変換
 	sql = regexp.MustCompile(`\s+`).ReplaceAllString(sql, " ")
+	// sqlcのマクロ関数を除去してFROM/JOINの解析に影響しないようにする
+	sql = sqlcEmbedPattern.ReplaceAllString(sql, "$1.*")
+	sql = sqlcFuncPattern.ReplaceAllString(sql, "?")
 	// 前後の空白を除去
 	return strings.TrimSpace(sql)
 }
 
 // toPascalCase converts string to PascalCase
-func toPascalCase(s string) string {
+// Words found in acronyms are written in upper case
+func toPascalCase(s string, acronyms map[string]bool) string {
 	if s == "" {
 		return s
 	}
@@ -226,6 +527,10 @@ func toPascalCase(s string) string {
 	
 	for _, word := range words {
 		if len(word) > 0 {
+			if acronyms[strings.ToUpper(word)] {
+				result += strings.ToUpper(word)
+				continue
+			}
 			// 最初の文字を大文字に、残りを小文字に
 			result += strings.ToUpper(word[:1]) + strings.ToLower(word[1:])
 		}
@@ -1,6 +1,8 @@
 package sql
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/naoyafurudono/sqlc-use-analysis/internal/errors"
@@ -54,6 +56,36 @@ func TestAnalyzer_detectOperationType(t *testing.T) {
 			expected: types.OpSelect,
 			wantErr:  false,
 		},
+		{
+			name:     "MERGE statement",
+			sql:      "MERGE INTO users u USING staging_users s ON u.id = s.id WHEN MATCHED THEN UPDATE SET name = s.name",
+			expected: opMerge,
+			wantErr:  false,
+		},
+		{
+			name:     "COPY FROM",
+			sql:      "COPY users (id, name) FROM STDIN",
+			expected: types.OpInsert,
+			wantErr:  false,
+		},
+		{
+			name:     "COPY TO",
+			sql:      "copy public.users to stdout",
+			expected: types.OpSelect,
+			wantErr:  false,
+		},
+		{
+			name:     "REPLACE",
+			sql:      "REPLACE INTO users (id, name) VALUES ($1, $2)",
+			expected: types.OpInsert,
+			wantErr:  false,
+		},
+		{
+			name:     "SELECT INTO",
+			sql:      "SELECT id, name INTO archived_users FROM users WHERE deleted",
+			expected: types.OpInsert,
+			wantErr:  false,
+		},
 		{
 			name:    "Unknown operation",
 			sql:     "CREATE TABLE users (id INT)",
@@ -130,6 +162,21 @@ func TestAnalyzer_extractTablesFromSelect(t *testing.T) {
 			          RIGHT JOIN comments c ON p.id = c.post_id`,
 			expected: []string{"users", "posts", "comments"},
 		},
+		{
+			name:     "Alias reused in WHERE subquery",
+			sql:      "SELECT u.name FROM users u WHERE u.id IN (SELECT u.user_id FROM audit u WHERE u.action = 'login')",
+			expected: []string{"users", "audit"},
+		},
+		{
+			name:     "Alias reused in derived table",
+			sql:      "SELECT u.name FROM (SELECT u.user_id FROM audit u) a JOIN users u ON u.id = a.user_id",
+			expected: []string{"audit", "users"},
+		},
+		{
+			name:     "Nested subqueries",
+			sql:      "SELECT * FROM users u WHERE EXISTS (SELECT 1 FROM posts u WHERE u.author_id IN (SELECT u.id FROM comments u))",
+			expected: []string{"users", "posts", "comments"},
+		},
 	}
 	
 	for _, tt := range tests {
@@ -183,11 +230,45 @@ func TestAnalyzer_extractTablesFromInsert(t *testing.T) {
 			expected: []string{"public.users"},
 			wantErr:  false,
 		},
+		{
+			name:     "Multi-row VALUES",
+			sql:      "INSERT INTO users (name, email) VALUES ($1, $2), ($3, $4)",
+			expected: []string{"users"},
+			wantErr:  false,
+		},
+		{
+			name:     "Column-less multi-row VALUES",
+			sql:      "INSERT INTO users VALUES ($1,$2),($3,$4)",
+			expected: []string{"users"},
+			wantErr:  false,
+		},
+		{
+			name:     "INSERT with SELECT",
+			sql:      "INSERT INTO archived_users (id, name) SELECT id, name FROM users",
+			expected: []string{"archived_users"},
+			wantErr:  false,
+		},
+		{
+			name:     "INSERT with DEFAULT VALUES",
+			sql:      "INSERT INTO users DEFAULT VALUES",
+			expected: []string{"users"},
+			wantErr:  false,
+		},
 		{
 			name:    "Invalid INSERT",
 			sql:     "INSERT VALUES ($1, $2)",
 			wantErr: true,
 		},
+		{
+			name:    "INSERT without table name",
+			sql:     "INSERT INTO VALUES ($1, $2)",
+			wantErr: true,
+		},
+		{
+			name:    "INSERT without values",
+			sql:     "INSERT INTO users (name, email)",
+			wantErr: true,
+		},
 	}
 	
 	for _, tt := range tests {
@@ -213,6 +294,122 @@ func TestAnalyzer_extractTablesFromInsert(t *testing.T) {
 	}
 }
 
+func TestAnalyzer_extractTablesFromInsert_ColumnlessWarning(t *testing.T) {
+	tests := []struct {
+		name        string
+		sql         string
+		wantWarning bool
+	}{
+		{
+			name:        "With column list",
+			sql:         "INSERT INTO users (name, email) VALUES ($1, $2), ($3, $4)",
+			wantWarning: false,
+		},
+		{
+			name:        "Without column list",
+			sql:         "INSERT INTO users VALUES ($1, $2), ($3, $4)",
+			wantWarning: true,
+		},
+		{
+			name:        "DEFAULT VALUES",
+			sql:         "INSERT INTO users DEFAULT VALUES",
+			wantWarning: false,
+		},
+		{
+			name:        "MySQL INSERT ... SET",
+			sql:         "INSERT INTO users SET name = ?, email = ?",
+			wantWarning: false,
+		},
+		{
+			name:        "MySQL REPLACE ... SET",
+			sql:         "REPLACE INTO users SET id = ?, name = ?",
+			wantWarning: false,
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := errors.NewErrorCollector(10, false)
+			analyzer := NewAnalyzer("postgresql", false, collector)
+			
+			if _, err := analyzer.extractTablesFromInsert(tt.sql); err != nil {
+				t.Fatalf("extractTablesFromInsert() error = %v", err)
+			}
+			
+			warnings := collector.GetWarnings()
+			if tt.wantWarning {
+				if len(warnings) != 1 {
+					t.Fatalf("Expected 1 warning, got %d", len(warnings))
+				}
+				if warnings[0].Details["table_name"] != "users" {
+					t.Errorf("Expected table_name 'users' in details, got %v", warnings[0].Details["table_name"])
+				}
+			} else if len(warnings) != 0 {
+				t.Errorf("Expected no warnings, got %d", len(warnings))
+			}
+		})
+	}
+}
+
+func TestAnalyzer_AnalyzeQuery_UnqualifiedWrite(t *testing.T) {
+	tests := []struct {
+		name      string
+		sql       string
+		wantTable string
+	}{
+		{name: "DELETE with WHERE", sql: "DELETE FROM users WHERE id = $1"},
+		{name: "UPDATE with WHERE", sql: "UPDATE users SET name = $1 WHERE id = $2"},
+		{name: "DELETE without WHERE", sql: "DELETE FROM users", wantTable: "users"},
+		{name: "UPDATE without WHERE", sql: "UPDATE users SET active = false", wantTable: "users"},
+		{
+			name:      "WHERE only inside a subquery",
+			sql:       "UPDATE users SET score = (SELECT COUNT(*) FROM posts WHERE posts.author_id = users.id)",
+			wantTable: "users",
+		},
+		{
+			name:      "DELETE with a subquery in WHERE",
+			sql:       "DELETE FROM comments WHERE post_id IN (SELECT id FROM posts WHERE archived)",
+		},
+		{
+			name:      "DELETE without WHERE after a WITH clause",
+			sql:       "WITH x AS (SELECT id FROM users) DELETE FROM posts",
+			wantTable: "posts",
+		},
+		{
+			name: "DELETE with WHERE after a WITH clause",
+			sql:  "WITH stale AS (SELECT id FROM posts WHERE archived) DELETE FROM comments WHERE post_id IN (SELECT id FROM stale)",
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := errors.NewErrorCollector(10, false)
+			analyzer := NewAnalyzer("postgresql", false, collector)
+			
+			if _, err := analyzer.AnalyzeQuery(Query{Name: "Q", Text: tt.sql, Cmd: ":exec"}); err != nil {
+				t.Fatalf("AnalyzeQuery() error = %v", err)
+			}
+			
+			warnings := collector.GetWarnings()
+			if tt.wantTable == "" {
+				if len(warnings) != 0 {
+					t.Errorf("Expected no warnings, got %v", warnings[0].Message)
+				}
+				return
+			}
+			if len(warnings) != 1 {
+				t.Fatalf("Expected 1 warning, got %d", len(warnings))
+			}
+			if !strings.HasPrefix(warnings[0].Message, "unqualified ") || warnings[0].Details["table_name"] != tt.wantTable {
+				t.Errorf("warning = %q %v, want unqualified write on %s", warnings[0].Message, warnings[0].Details, tt.wantTable)
+			}
+			if warnings[0].Severity != errors.SeverityWarning {
+				t.Errorf("Severity = %v, want warning", warnings[0].Severity)
+			}
+		})
+	}
+}
+
 func TestAnalyzer_extractTablesFromUpdate(t *testing.T) {
 	analyzer := NewAnalyzer("postgresql", false, errors.NewErrorCollector(10, false))
 	
@@ -300,6 +497,12 @@ func TestAnalyzer_extractTablesFromDelete(t *testing.T) {
 			expected: []string{"users", "posts"},
 			wantErr:  false,
 		},
+		{
+			name:     "DELETE with a subquery",
+			sql:      "DELETE FROM users WHERE id IN (SELECT user_id FROM banned)",
+			expected: []string{"users", "banned"},
+			wantErr:  false,
+		},
 	}
 	
 	for _, tt := range tests {
@@ -390,6 +593,41 @@ func TestAnalyzer_generateMethodName(t *testing.T) {
 	}
 }
 
+func TestAnalyzer_generateMethodName_Acronyms(t *testing.T) {
+	analyzer := NewAnalyzer("postgresql", false, errors.NewErrorCollector(10, false))
+	analyzer.SetAcronyms([]string{"ID", "API", "url", "UUID", "SQL"})
+	
+	tests := []struct {
+		queryName string
+		cmd       string
+		expected  string
+	}{
+		{queryName: "get_api_url", cmd: ":one", expected: "GetAPIURL"},
+		{queryName: "get_user_by_id", cmd: ":one", expected: "GetUserByID"},
+		{queryName: "find_by_uuid", cmd: ":one", expected: "FindByUUID"},
+		{queryName: "list_api_keys", cmd: ":many", expected: "ListAPIKeys"},
+		{queryName: "run_raw_sql", cmd: ":exec", expected: "RunRawSQL"},
+		// 略語を含む単語は対象外
+		{queryName: "get_identity", cmd: ":one", expected: "GetIdentity"},
+		// 既にPascalCaseの名前は変換しない
+		{queryName: "GetApiUrl", cmd: ":one", expected: "GetApiUrl"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.queryName, func(t *testing.T) {
+			if result := analyzer.generateMethodName(tt.queryName, tt.cmd); result != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+	
+	// 略語を設定しない場合は従来通り先頭のみ大文字にする
+	plain := NewAnalyzer("postgresql", false, errors.NewErrorCollector(10, false))
+	if result := plain.generateMethodName("get_api_url", ":one"); result != "GetApiUrl" {
+		t.Errorf("Expected GetApiUrl without acronyms, got %s", result)
+	}
+}
+
 func TestAnalyzer_AnalyzeQuery(t *testing.T) {
 	analyzer := NewAnalyzer("postgresql", false, errors.NewErrorCollector(10, false))
 	
@@ -423,4 +661,1202 @@ func TestAnalyzer_AnalyzeQuery(t *testing.T) {
 	if len(table.Operations) != 1 || table.Operations[0] != "SELECT" {
 		t.Errorf("Expected operations ['SELECT'], got %v", table.Operations)
 	}
-}
\ No newline at end of file
+}
+func TestAnalyzer_SQLCMacros(t *testing.T) {
+	analyzer := NewAnalyzer("postgresql", false, errors.NewErrorCollector(10, false))
+	
+	tests := []struct {
+		name      string
+		sql       string
+		operation string
+		expected  []string
+	}{
+		{
+			name:      "sqlc.slice in ANY",
+			sql:       "SELECT id, name FROM users WHERE id = ANY(sqlc.slice('ids'))",
+			operation: "SELECT",
+			expected:  []string{"users"},
+		},
+		{
+			name:      "sqlc.embed projection",
+			sql:       "SELECT sqlc.embed(users), sqlc.embed(posts) FROM users JOIN posts ON posts.author_id = users.id",
+			operation: "SELECT",
+			expected:  []string{"users", "posts"},
+		},
+		{
+			name:      "sqlc.arg and sqlc.narg parameters",
+			sql:       "UPDATE users SET name = sqlc.arg(name), email = sqlc.narg('email') WHERE id = @id",
+			operation: "UPDATE",
+			expected:  []string{"users"},
+		},
+		{
+			name:      "sqlc.slice in DELETE",
+			sql:       "DELETE FROM posts WHERE id IN (sqlc.slice('ids'))",
+			operation: "DELETE",
+			expected:  []string{"posts"},
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := analyzer.AnalyzeQuery(Query{Name: "Test", Text: tt.sql, Cmd: ":exec"})
+			if err != nil {
+				t.Fatalf("AnalyzeQuery() error = %v", err)
+			}
+			
+			if len(result.Tables) != len(tt.expected) {
+				t.Fatalf("Expected %d tables, got %d: %v", len(tt.expected), len(result.Tables), result.Tables)
+			}
+			
+			for i, table := range result.Tables {
+				if table.TableName != tt.expected[i] {
+					t.Errorf("Expected table '%s', got '%s'", tt.expected[i], table.TableName)
+				}
+				if len(table.Operations) != 1 || table.Operations[0] != tt.operation {
+					t.Errorf("Expected operations [%s], got %v", tt.operation, table.Operations)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeSQL_SQLCMacros(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		expected string
+	}{
+		{
+			name:     "sqlc.embed expands to table columns",
+			sql:      "SELECT sqlc.embed(users) FROM users",
+			expected: "SELECT users.* FROM users",
+		},
+		{
+			name:     "sqlc.slice becomes a parameter",
+			sql:      "SELECT *\nFROM users\nWHERE id = ANY(sqlc.slice('ids'))",
+			expected: "SELECT * FROM users WHERE id = ANY(?)",
+		},
+		{
+			name:     "Named parameters are kept",
+			sql:      "SELECT * FROM users WHERE id = @user_id",
+			expected: "SELECT * FROM users WHERE id = @user_id",
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeSQL(tt.sql); got != tt.expected {
+				t.Errorf("normalizeSQL() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnalyzer_AnalyzeQuery_Merge(t *testing.T) {
+	analyzer := NewAnalyzer("postgresql", false, errors.NewErrorCollector(10, false))
+	
+	tests := []struct {
+		name     string
+		sql      string
+		expected map[string][]string
+		wantErr  bool
+	}{
+		{
+			name: "Matched update and not matched insert",
+			sql: `MERGE INTO users u
+			      USING staging_users s ON u.id = s.id
+			      WHEN MATCHED THEN UPDATE SET name = s.name
+			      WHEN NOT MATCHED THEN INSERT (id, name) VALUES (s.id, s.name)`,
+			expected: map[string][]string{
+				"users":         {"UPDATE", "INSERT"},
+				"staging_users": {"SELECT"},
+			},
+		},
+		{
+			name: "Matched delete with subquery source",
+			sql: `MERGE INTO posts p
+			      USING (SELECT id FROM archived_posts WHERE archived = true) a ON p.id = a.id
+			      WHEN MATCHED THEN DELETE`,
+			expected: map[string][]string{
+				"posts":          {"DELETE"},
+				"archived_posts": {"SELECT"},
+			},
+		},
+		{
+			name:    "MERGE without actions",
+			sql:     "MERGE INTO users u USING staging_users s ON u.id = s.id",
+			wantErr: true,
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := analyzer.AnalyzeQuery(Query{Name: "MergeUsers", Text: tt.sql, Cmd: ":exec"})
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected error, but got none")
+				}
+				return
+			}
+			
+			if err != nil {
+				t.Fatalf("AnalyzeQuery() error = %v", err)
+			}
+			
+			if len(result.Tables) != len(tt.expected) {
+				t.Fatalf("Expected %d tables, got %d: %v", len(tt.expected), len(result.Tables), result.Tables)
+			}
+			
+			for _, table := range result.Tables {
+				expectedOps, exists := tt.expected[table.TableName]
+				if !exists {
+					t.Errorf("Unexpected table '%s'", table.TableName)
+					continue
+				}
+				if strings.Join(table.Operations, ",") != strings.Join(expectedOps, ",") {
+					t.Errorf("Table '%s': expected operations %v, got %v", table.TableName, expectedOps, table.Operations)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzer_AnalyzeQuery_DDL(t *testing.T) {
+	analyzer := NewAnalyzer("postgresql", false, errors.NewErrorCollector(10, false))
+	analyzer.SetIncludeDDL(true)
+	
+	tests := []struct {
+		name     string
+		sql      string
+		expected map[string][]string
+		wantErr  bool
+	}{
+		{
+			name:     "CREATE TABLE",
+			sql:      "CREATE TABLE IF NOT EXISTS audit_logs (id BIGSERIAL PRIMARY KEY, message TEXT NOT NULL)",
+			expected: map[string][]string{"audit_logs": {"CREATE"}},
+		},
+		{
+			name:     "CREATE INDEX",
+			sql:      "CREATE UNIQUE INDEX users_email_idx ON users (email)",
+			expected: map[string][]string{"users": {"CREATE"}},
+		},
+		{
+			name:     "ALTER TABLE ADD COLUMN",
+			sql:      "ALTER TABLE Users ADD COLUMN deleted_at TIMESTAMP",
+			expected: map[string][]string{"users": {"ALTER"}},
+		},
+		{
+			name:     "DROP TABLE",
+			sql:      "DROP TABLE IF EXISTS posts, comments CASCADE",
+			expected: map[string][]string{"posts": {"DROP"}, "comments": {"DROP"}},
+		},
+		{
+			name:     "TRUNCATE",
+			sql:      "TRUNCATE TABLE sessions",
+			expected: map[string][]string{"sessions": {"TRUNCATE"}},
+		},
+		{
+			name:    "CREATE without table",
+			sql:     "CREATE EXTENSION IF NOT EXISTS pgcrypto",
+			wantErr: true,
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := analyzer.AnalyzeQuery(Query{Name: "Migrate", Text: tt.sql, Cmd: ":exec"})
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected error, but got none")
+				}
+				return
+			}
+			
+			if err != nil {
+				t.Fatalf("AnalyzeQuery() error = %v", err)
+			}
+			
+			if len(result.Tables) != len(tt.expected) {
+				t.Fatalf("Expected %d tables, got %d: %v", len(tt.expected), len(result.Tables), result.Tables)
+			}
+			
+			for _, table := range result.Tables {
+				expectedOps, exists := tt.expected[table.TableName]
+				if !exists {
+					t.Errorf("Unexpected table '%s'", table.TableName)
+					continue
+				}
+				if strings.Join(table.Operations, ",") != strings.Join(expectedOps, ",") {
+					t.Errorf("Table '%s': expected operations %v, got %v", table.TableName, expectedOps, table.Operations)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzer_AnalyzeQuery_DDLDisabled(t *testing.T) {
+	analyzer := NewAnalyzer("postgresql", false, errors.NewErrorCollector(10, false))
+	
+	if _, err := analyzer.AnalyzeQuery(Query{Name: "CreateUsers", Text: "CREATE TABLE users (id INT)", Cmd: ":exec"}); err == nil {
+		t.Error("Expected DDL to be rejected when IncludeDDL is disabled")
+	}
+}
+
+func TestAnalyzer_AnalyzeQuery_CTE(t *testing.T) {
+	analyzer := NewAnalyzer("postgresql", false, errors.NewErrorCollector(10, false))
+	
+	tests := []struct {
+		name     string
+		sql      string
+		expected map[string][]string
+		wantErr  bool
+	}{
+		{
+			name:     "Single CTE",
+			sql:      "WITH active_users AS (SELECT * FROM users WHERE active = true) SELECT * FROM active_users",
+			expected: map[string][]string{"users": {"SELECT"}},
+		},
+		{
+			name: "Multiple CTEs referencing different tables",
+			sql: `WITH active_users AS (
+			        SELECT id FROM users WHERE active = true
+			      ), recent_posts (author_id) AS (
+			        SELECT author_id FROM posts WHERE created_at > $1
+			      )
+			      SELECT u.id, c.body
+			      FROM active_users u
+			      JOIN recent_posts p ON p.author_id = u.id
+			      JOIN comments c ON c.author_id = u.id`,
+			expected: map[string][]string{
+				"users":    {"SELECT"},
+				"posts":    {"SELECT"},
+				"comments": {"SELECT"},
+			},
+		},
+		{
+			name: "Recursive CTE",
+			sql: `WITH RECURSIVE category_tree AS (
+			        SELECT id, parent_id FROM categories WHERE parent_id IS NULL
+			        UNION ALL
+			        SELECT c.id, c.parent_id FROM categories c JOIN category_tree t ON c.parent_id = t.id
+			      )
+			      SELECT id FROM category_tree`,
+			expected: map[string][]string{"categories": {"SELECT"}},
+		},
+		{
+			name: "Data-modifying CTE",
+			sql: `WITH archived AS (
+			        DELETE FROM posts WHERE created_at < $1 RETURNING id, title
+			      )
+			      INSERT INTO archived_posts (id, title) SELECT id, title FROM archived`,
+			expected: map[string][]string{
+				"archived_posts": {"INSERT"},
+				"posts":          {"DELETE"},
+			},
+		},
+		{
+			name: "CTE followed by INSERT ... SELECT",
+			sql: `WITH active AS (SELECT id FROM users WHERE active = true)
+			      INSERT INTO audit_log (user_id)
+			      SELECT a.id FROM active a JOIN sessions s ON s.user_id = a.id`,
+			expected: map[string][]string{
+				"audit_log": {"INSERT"},
+				"users":     {"SELECT"},
+			},
+		},
+		{
+			name: "CTE followed by UPDATE ... FROM",
+			sql: `WITH stats AS (SELECT author_id, count(*) AS n FROM posts GROUP BY author_id)
+			      UPDATE users u SET post_count = s.n
+			      FROM stats s JOIN teams t ON t.id = s.author_id
+			      WHERE u.id = s.author_id`,
+			expected: map[string][]string{
+				"users": {"UPDATE"},
+				"posts": {"SELECT"},
+				"teams": {"UPDATE"},
+			},
+		},
+		{
+			name:    "Unbalanced CTE body",
+			sql:     "WITH broken AS (SELECT id FROM users SELECT * FROM broken",
+			wantErr: true,
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := analyzer.AnalyzeQuery(Query{Name: "WithQuery", Text: tt.sql, Cmd: ":many"})
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected error, but got none")
+				}
+				return
+			}
+			
+			if err != nil {
+				t.Fatalf("AnalyzeQuery() error = %v", err)
+			}
+			
+			if len(result.Tables) != len(tt.expected) {
+				t.Fatalf("Expected %d tables, got %d: %v", len(tt.expected), len(result.Tables), result.Tables)
+			}
+			
+			for _, table := range result.Tables {
+				expectedOps, exists := tt.expected[table.TableName]
+				if !exists {
+					t.Errorf("Unexpected table '%s'", table.TableName)
+					continue
+				}
+				if strings.Join(table.Operations, ",") != strings.Join(expectedOps, ",") {
+					t.Errorf("Table '%s': expected operations %v, got %v", table.TableName, expectedOps, table.Operations)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzer_AnalyzeQuery_ReadContextEdges(t *testing.T) {
+	type tableAccess struct {
+		operations string
+		kind       types.AccessKind
+	}
+	
+	tests := []struct {
+		name     string
+		sql      string
+		enabled  bool
+		expected map[string]tableAccess
+	}{
+		{
+			name:    "UPDATE ... FROM",
+			sql:     "UPDATE users SET post_count = p.cnt FROM posts p WHERE p.author_id = users.id",
+			enabled: true,
+			expected: map[string]tableAccess{
+				"users": {operations: "UPDATE", kind: types.AccessPrimary},
+				"posts": {operations: "SELECT", kind: types.AccessReference},
+			},
+		},
+		{
+			name:    "DELETE ... USING",
+			sql:     "DELETE FROM comments USING posts WHERE comments.post_id = posts.id AND posts.archived = true",
+			enabled: true,
+			expected: map[string]tableAccess{
+				"comments": {operations: "DELETE", kind: types.AccessPrimary},
+				"posts":    {operations: "SELECT", kind: types.AccessReference},
+			},
+		},
+		{
+			name:    "DELETE with a subquery",
+			sql:     "DELETE FROM users WHERE id IN (SELECT user_id FROM banned)",
+			enabled: true,
+			expected: map[string]tableAccess{
+				"users":  {operations: "DELETE", kind: types.AccessPrimary},
+				"banned": {operations: "SELECT", kind: types.AccessReference},
+			},
+		},
+		{
+			name:    "INSERT ... SELECT",
+			sql:     "INSERT INTO archived_posts (id, title) SELECT id, title FROM posts WHERE archived = true",
+			enabled: true,
+			expected: map[string]tableAccess{
+				"archived_posts": {operations: "INSERT", kind: types.AccessPrimary},
+				"posts":          {operations: "SELECT", kind: types.AccessReference},
+			},
+		},
+		{
+			name: "CTE followed by INSERT ... SELECT",
+			sql: `WITH active AS (SELECT id FROM users WHERE active = true)
+			      INSERT INTO audit_log (user_id)
+			      SELECT a.id FROM active a JOIN sessions s ON s.user_id = a.id`,
+			enabled: true,
+			expected: map[string]tableAccess{
+				"audit_log": {operations: "INSERT", kind: types.AccessPrimary},
+				"users":     {operations: "SELECT", kind: types.AccessReference},
+				"sessions":  {operations: "SELECT", kind: types.AccessReference},
+			},
+		},
+		{
+			name: "CTE followed by UPDATE ... FROM",
+			sql: `WITH stats AS (SELECT author_id, count(*) AS n FROM posts GROUP BY author_id)
+			      UPDATE users u SET post_count = s.n
+			      FROM stats s JOIN teams t ON t.id = s.author_id
+			      WHERE u.id = s.author_id`,
+			enabled: true,
+			expected: map[string]tableAccess{
+				"users": {operations: "UPDATE", kind: types.AccessPrimary},
+				"posts": {operations: "SELECT", kind: types.AccessReference},
+				"teams": {operations: "SELECT", kind: types.AccessReference},
+			},
+		},
+		{
+			name:    "SELECT is unaffected",
+			sql:     "SELECT u.id FROM users u JOIN posts p ON p.author_id = u.id",
+			enabled: true,
+			expected: map[string]tableAccess{
+				"users": {operations: "SELECT"},
+				"posts": {operations: "SELECT"},
+			},
+		},
+		{
+			name:    "Disabled keeps the write operation on every table",
+			sql:     "UPDATE users SET post_count = p.cnt FROM posts p WHERE p.author_id = users.id",
+			enabled: false,
+			expected: map[string]tableAccess{
+				"users": {operations: "UPDATE"},
+				"posts": {operations: "UPDATE"},
+			},
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := NewAnalyzer("postgresql", false, errors.NewErrorCollector(10, false))
+			analyzer.SetReadContextEdges(tt.enabled)
+			
+			result, err := analyzer.AnalyzeQuery(Query{Name: "Write", Text: tt.sql, Cmd: ":exec"})
+			if err != nil {
+				t.Fatalf("AnalyzeQuery() error = %v", err)
+			}
+			
+			if len(result.Tables) != len(tt.expected) {
+				t.Fatalf("Expected %d tables, got %d: %v", len(tt.expected), len(result.Tables), result.Tables)
+			}
+			
+			for _, table := range result.Tables {
+				expected, exists := tt.expected[table.TableName]
+				if !exists {
+					t.Errorf("Unexpected table '%s'", table.TableName)
+					continue
+				}
+				if got := strings.Join(table.Operations, ","); got != expected.operations {
+					t.Errorf("Table '%s': expected operations %s, got %s", table.TableName, expected.operations, got)
+				}
+				if table.Kind != expected.kind {
+					t.Errorf("Table '%s': expected kind %q, got %q", table.TableName, expected.kind, table.Kind)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzer_AnalyzeQuery_Columns(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		expected map[string][]string
+	}{
+		{
+			name: "INSERT with explicit column list",
+			sql:  "INSERT INTO users (name, email, created_at) VALUES ($1, $2, NOW())",
+			expected: map[string][]string{
+				"users": {"name", "email", "created_at"},
+			},
+		},
+		{
+			name: "UPDATE SET targets",
+			sql:  "UPDATE users SET email = $1, updated_at = NOW() WHERE id = $2",
+			expected: map[string][]string{
+				"users": {"email", "updated_at"},
+			},
+		},
+		{
+			name: "UPDATE with a subquery in SET",
+			sql:  "UPDATE posts SET posts.comment_count = (SELECT COUNT(*) FROM comments c WHERE c.post_id = posts.id), title = $1 WHERE id = $2",
+			expected: map[string][]string{
+				"posts": {"comment_count", "title"},
+			},
+		},
+		{
+			name: "SELECT projection resolves aliases",
+			sql:  "SELECT u.id, u.name AS author, p.title, COUNT(*) AS total FROM users u JOIN posts p ON p.author_id = u.id GROUP BY u.id, u.name, p.title",
+			expected: map[string][]string{
+				"users": {"id", "name"},
+				"posts": {"title"},
+			},
+		},
+		{
+			name: "SELECT * records a wildcard",
+			sql:  "SELECT * FROM users WHERE id = $1",
+			expected: map[string][]string{
+				"users": {types.ColumnWildcard},
+			},
+		},
+		{
+			name: "Qualified wildcard",
+			sql:  "SELECT u.*, p.title FROM users AS u, posts AS p WHERE p.author_id = u.id",
+			expected: map[string][]string{
+				"users": {types.ColumnWildcard},
+				"posts": {"title"},
+			},
+		},
+		{
+			name: "Casts in the projection",
+			sql:  "SELECT u.id::text, p.views::numeric(10, 2) AS views FROM users u JOIN posts p ON p.author_id = u.id",
+			expected: map[string][]string{
+				"users": {"id"},
+				"posts": {"views"},
+			},
+		},
+		{
+			name:     "DELETE has no columns",
+			sql:      "DELETE FROM sessions WHERE expires_at < NOW()",
+			expected: map[string][]string{},
+		},
+		{
+			name: "UPDATE after a WITH clause",
+			sql:  "WITH recent AS (SELECT author_id FROM posts WHERE created_at > NOW() - INTERVAL '1 day') UPDATE users SET active = true, updated_at = NOW() WHERE id IN (SELECT author_id FROM recent)",
+			expected: map[string][]string{
+				"users": {"active", "updated_at"},
+			},
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := NewAnalyzer("postgresql", false, errors.NewErrorCollector(10, false))
+			
+			result, err := analyzer.AnalyzeQuery(Query{Name: "Query", Text: tt.sql, Cmd: ":exec"})
+			if err != nil {
+				t.Fatalf("AnalyzeQuery() error = %v", err)
+			}
+			
+			got := make(map[string][]string)
+			for _, table := range result.Tables {
+				if len(table.Columns) > 0 {
+					got[table.TableName] = table.Columns
+				}
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Columns = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnalyzer_AnalyzeQuery_Upsert(t *testing.T) {
+	tests := []struct {
+		name        string
+		sql         string
+		readContext bool
+		expected    []types.TableOperation
+	}{
+		{
+			name: "ON CONFLICT with a partial index target and DO UPDATE WHERE",
+			sql:  "INSERT INTO users (id, name) VALUES ($1, $2) ON CONFLICT (id) WHERE active DO UPDATE SET name = EXCLUDED.name WHERE users.active",
+			expected: []types.TableOperation{
+				{TableName: "users", Operations: []string{"INSERT", "UPDATE"}, Columns: []string{"id", "name"}},
+			},
+		},
+		{
+			name: "Expression conflict target",
+			sql:  "INSERT INTO users AS u (email, name) VALUES ($1, $2) ON CONFLICT (lower(email)) WHERE deleted_at IS NULL DO UPDATE SET name = u.name || EXCLUDED.name",
+			expected: []types.TableOperation{
+				{TableName: "users", Operations: []string{"INSERT", "UPDATE"}, Columns: []string{"email", "name"}},
+			},
+		},
+		{
+			name: "DO NOTHING does not update",
+			sql:  "INSERT INTO users (id, name) VALUES ($1, $2) ON CONFLICT ON CONSTRAINT users_pkey DO NOTHING",
+			expected: []types.TableOperation{
+				{TableName: "users", Operations: []string{"INSERT"}, Columns: []string{"id", "name"}},
+			},
+		},
+		{
+			name: "MySQL ON DUPLICATE KEY UPDATE",
+			sql:  "INSERT INTO users (id, name) VALUES (?, ?) ON DUPLICATE KEY UPDATE name = VALUES(name)",
+			expected: []types.TableOperation{
+				{TableName: "users", Operations: []string{"INSERT", "UPDATE"}, Columns: []string{"id", "name"}},
+			},
+		},
+		{
+			name:        "Read context ignores the conflict clause",
+			sql:         "INSERT INTO users (id, name) SELECT id, name FROM staging ON CONFLICT (id) WHERE active DO UPDATE SET name = (SELECT n FROM names WHERE names.id = EXCLUDED.id)",
+			readContext: true,
+			expected: []types.TableOperation{
+				{TableName: "users", Operations: []string{"INSERT", "UPDATE"}, Kind: types.AccessPrimary, Columns: []string{"id", "name"}},
+				{TableName: "staging", Operations: []string{"SELECT"}, Kind: types.AccessReference},
+				{TableName: "names", Operations: []string{"SELECT"}, Kind: types.AccessReference},
+			},
+		},
+		{
+			name: "Upsert after a WITH clause",
+			sql:  "WITH src AS (SELECT id, name FROM staging) INSERT INTO users (id, name) SELECT id, name FROM src ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name",
+			expected: []types.TableOperation{
+				{TableName: "users", Operations: []string{"INSERT", "UPDATE"}, Columns: []string{"id", "name"}},
+				{TableName: "staging", Operations: []string{"SELECT"}},
+			},
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := NewAnalyzer("postgresql", false, errors.NewErrorCollector(10, false))
+			analyzer.SetReadContextEdges(tt.readContext)
+			
+			result, err := analyzer.AnalyzeQuery(Query{Name: "UpsertUser", Text: tt.sql, Cmd: ":exec"})
+			if err != nil {
+				t.Fatalf("AnalyzeQuery() error = %v", err)
+			}
+			if !reflect.DeepEqual(result.Tables, tt.expected) {
+				t.Errorf("Tables = %+v, want %+v", result.Tables, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnalyzer_AnalyzeQuery_UpdateAlias(t *testing.T) {
+	tests := []struct {
+		name        string
+		dialect     string
+		sql         string
+		readContext bool
+		expected    []types.TableOperation
+	}{
+		{
+			name: "Alias without AS",
+			sql:  "UPDATE users u SET name = $1 WHERE u.id = $2",
+			expected: []types.TableOperation{
+				{TableName: "users", Operations: []string{"UPDATE"}, Columns: []string{"name"}},
+			},
+		},
+		{
+			name: "Alias with AS and FROM",
+			sql:  "UPDATE users AS u SET name = p.title FROM posts p WHERE p.author_id = u.id",
+			expected: []types.TableOperation{
+				{TableName: "users", Operations: []string{"UPDATE"}, Columns: []string{"name"}},
+				{TableName: "posts", Operations: []string{"UPDATE"}},
+			},
+		},
+		{
+			name:        "JOIN in the FROM part",
+			sql:         "UPDATE users AS u SET name = p.title FROM posts p JOIN comments c ON c.post_id = p.id WHERE p.author_id = u.id",
+			readContext: true,
+			expected: []types.TableOperation{
+				{TableName: "users", Operations: []string{"UPDATE"}, Kind: types.AccessPrimary, Columns: []string{"name"}},
+				{TableName: "posts", Operations: []string{"SELECT"}, Kind: types.AccessReference},
+				{TableName: "comments", Operations: []string{"SELECT"}, Kind: types.AccessReference},
+			},
+		},
+		{
+			name:        "MySQL multi-table UPDATE with JOIN",
+			dialect:     "mysql",
+			sql:         "UPDATE users u INNER JOIN posts p ON p.author_id = u.id SET u.name = p.title",
+			readContext: true,
+			expected: []types.TableOperation{
+				{TableName: "users", Operations: []string{"UPDATE"}, Kind: types.AccessPrimary, Columns: []string{"name"}},
+				{TableName: "posts", Operations: []string{"SELECT"}, Kind: types.AccessReference},
+			},
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialect := tt.dialect
+			if dialect == "" {
+				dialect = "postgresql"
+			}
+			analyzer := NewAnalyzer(dialect, false, errors.NewErrorCollector(10, false))
+			analyzer.SetReadContextEdges(tt.readContext)
+			
+			result, err := analyzer.AnalyzeQuery(Query{Name: "UpdateUser", Text: tt.sql, Cmd: ":exec"})
+			if err != nil {
+				t.Fatalf("AnalyzeQuery() error = %v", err)
+			}
+			if !reflect.DeepEqual(result.Tables, tt.expected) {
+				t.Errorf("Tables = %+v, want %+v", result.Tables, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnalyzer_AnalyzeQuery_PostgresOperators(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		expected []string
+	}{
+		{
+			name:     "JSONB operators over a JOIN",
+			sql:      "SELECT p.id, p.data->>'title' AS title FROM posts p JOIN users u ON u.id = (p.data->>'author_id')::int WHERE u.settings->'flags' @> '[\"beta\"]'::jsonb AND p.data #>> '{meta,from}' = $1",
+			expected: []string{"posts", "users"},
+		},
+		{
+			name:     "Array contains over a JOIN",
+			sql:      "SELECT p.id, u.name FROM posts p JOIN users u ON u.id = p.author_id WHERE p.tags && ARRAY[$1]::text[] AND p.tags @> $2::text[]",
+			expected: []string{"posts", "users"},
+		},
+		{
+			name:     "Keywords inside a JSON key or string",
+			sql:      "SELECT p.data->'from' FROM posts p WHERE p.data->>'join' = 'join users on true'",
+			expected: []string{"posts"},
+		},
+		{
+			name:     "Apostrophe inside a line comment",
+			sql:      "SELECT p.id -- author's posts\nFROM posts p JOIN users u ON u.id = p.author_id",
+			expected: []string{"posts", "users"},
+		},
+		{
+			name:     "Apostrophe inside a block comment",
+			sql:      "SELECT p.id /* don't */ FROM posts p JOIN users u ON u.id = p.author_id",
+			expected: []string{"posts", "users"},
+		},
+		{
+			name:     "Comment markers inside a string",
+			sql:      "SELECT p.id FROM posts p JOIN users u ON u.id = p.author_id WHERE p.title <> '-- /* draft'",
+			expected: []string{"posts", "users"},
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := NewAnalyzer("postgresql", false, errors.NewErrorCollector(10, false))
+			
+			result, err := analyzer.AnalyzeQuery(Query{Name: "Query", Text: tt.sql, Cmd: ":many"})
+			if err != nil {
+				t.Fatalf("AnalyzeQuery() error = %v", err)
+			}
+			
+			var tables []string
+			for _, table := range result.Tables {
+				tables = append(tables, table.TableName)
+			}
+			if !reflect.DeepEqual(tables, tt.expected) {
+				t.Errorf("Tables = %v, want %v", tables, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnalyzer_IdentifierRules(t *testing.T) {
+	longName := strings.Repeat("a", 64)
+	
+	tests := []struct {
+		name        string
+		dialect     string
+		sql         string
+		wantWarning string
+	}{
+		{
+			name:        "Reserved word",
+			sql:         "SELECT id, name FROM user WHERE id = $1",
+			wantWarning: "table name 'user' is a reserved word in postgresql and must be quoted",
+		},
+		{
+			name: "Quoted reserved word",
+			sql:  `SELECT id, name FROM "user" WHERE id = $1`,
+		},
+		{
+			name:        "Name longer than 63 characters",
+			sql:         "SELECT id FROM " + longName + " WHERE id = $1",
+			wantWarning: "table name '" + longName + "' exceeds the 63-character identifier limit of postgresql",
+		},
+		{
+			name: "Valid name",
+			sql:  "SELECT id FROM users WHERE id = $1",
+		},
+		{
+			name: "Quoted reserved word next to the same keyword",
+			sql:  `SELECT * FROM "order" ORDER BY id`,
+		},
+		{
+			name:    "Quoted reserved word next to the same keyword in MySQL",
+			dialect: "mysql",
+			sql:     "SELECT * FROM `group` GROUP BY id",
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialect := tt.dialect
+			if dialect == "" {
+				dialect = "postgresql"
+			}
+			collector := errors.NewErrorCollector(10, false)
+			analyzer := NewAnalyzer(dialect, false, collector)
+			
+			// Analyze twice: the same name is reported only once
+			for i := 0; i < 2; i++ {
+				if _, err := analyzer.AnalyzeQuery(Query{Name: "GetUser", Text: tt.sql, Cmd: ":one"}); err != nil {
+					t.Fatalf("AnalyzeQuery() error = %v", err)
+				}
+			}
+			
+			var messages []string
+			for _, warning := range collector.GetWarnings() {
+				if warning.Category == errors.CategoryValidation {
+					messages = append(messages, warning.Message)
+				}
+			}
+			var want []string
+			if tt.wantWarning != "" {
+				want = []string{tt.wantWarning}
+			}
+			if !reflect.DeepEqual(messages, want) {
+				t.Errorf("warnings = %v, want %v", messages, want)
+			}
+		})
+	}
+}
+
+func TestAnalyzer_normalizeTableName_NoWarnings(t *testing.T) {
+	collector := errors.NewErrorCollector(10, false)
+	analyzer := NewAnalyzer("postgresql", false, collector)
+	
+	// Normalization has no side effects; only AnalyzeQuery reports identifier rules
+	if got := analyzer.normalizeTableName("user"); got != "user" {
+		t.Errorf("normalizeTableName() = %q, want %q", got, "user")
+	}
+	if warnings := collector.GetWarnings(); len(warnings) != 0 {
+		t.Errorf("Expected no warnings, got %v", warnings)
+	}
+}
+
+func TestAnalyzer_AnalyzeQuery_Locking(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		expected map[string]string
+	}{
+		{
+			name: "FOR UPDATE locks every table",
+			sql:  "SELECT u.id, a.balance FROM users u JOIN accounts a ON a.user_id = u.id WHERE u.id = $1 FOR UPDATE",
+			expected: map[string]string{
+				"users":    "FOR UPDATE",
+				"accounts": "FOR UPDATE",
+			},
+		},
+		{
+			name: "FOR SHARE OF limits the lock",
+			sql:  "SELECT * FROM users JOIN posts ON posts.author_id = users.id WHERE users.id = $1 FOR SHARE OF users",
+			expected: map[string]string{
+				"users": "FOR SHARE",
+			},
+		},
+		{
+			name: "FOR NO KEY UPDATE with SKIP LOCKED and an alias",
+			sql: `SELECT j.id FROM jobs j
+				WHERE j.state = 'queued'
+				LIMIT 10
+				FOR NO KEY UPDATE OF j SKIP LOCKED;`,
+			expected: map[string]string{
+				"jobs": "FOR NO KEY UPDATE",
+			},
+		},
+		{
+			name: "Lock in a subquery does not lock the outer tables",
+			sql:  "SELECT * FROM orders WHERE user_id IN (SELECT id FROM users WHERE id = $1 FOR UPDATE)",
+			expected: map[string]string{},
+		},
+		{
+			name:     "Plain SELECT",
+			sql:      "SELECT id FROM users WHERE id = $1",
+			expected: map[string]string{},
+		},
+		{
+			name: "FOR UPDATE after a WITH clause",
+			sql:  "WITH active AS (SELECT id FROM users WHERE active) SELECT a.id FROM accounts a JOIN active ON active.id = a.user_id FOR UPDATE OF a",
+			expected: map[string]string{
+				"accounts": "FOR UPDATE",
+			},
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := NewAnalyzer("postgresql", false, errors.NewErrorCollector(10, false))
+			
+			result, err := analyzer.AnalyzeQuery(Query{Name: "Query", Text: tt.sql, Cmd: ":many"})
+			if err != nil {
+				t.Fatalf("AnalyzeQuery() error = %v", err)
+			}
+			
+			got := make(map[string]string)
+			for _, table := range result.Tables {
+				if !reflect.DeepEqual(table.Operations, []string{"SELECT"}) {
+					t.Errorf("Operations of %s = %v, want [SELECT]", table.TableName, table.Operations)
+				}
+				if table.Locking != "" {
+					got[table.TableName] = table.Locking
+				}
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Locking = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnalyzer_AnalyzeQuery_Copy(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		expected []types.TableOperation
+	}{
+		{
+			name: "COPY FROM STDIN loads rows",
+			sql:  "COPY users FROM STDIN",
+			expected: []types.TableOperation{
+				{TableName: "users", Operations: []string{"INSERT"}},
+			},
+		},
+		{
+			name: "COPY TO STDOUT exports every column",
+			sql:  "COPY users TO STDOUT WITH (FORMAT csv, HEADER true)",
+			expected: []types.TableOperation{
+				{TableName: "users", Operations: []string{"SELECT"}, Columns: []string{"*"}},
+			},
+		},
+		{
+			name: "Schema-qualified table with a column list",
+			sql:  "COPY billing.invoices (id, amount) FROM '/tmp/invoices.csv' WITH (FORMAT csv)",
+			expected: []types.TableOperation{
+				{TableName: "billing.invoices", Operations: []string{"INSERT"}, Columns: []string{"id", "amount"}},
+			},
+		},
+		{
+			name: "Column list on export",
+			sql:  "COPY public.users(id, email) TO STDOUT",
+			expected: []types.TableOperation{
+				{TableName: "public.users", Operations: []string{"SELECT"}, Columns: []string{"id", "email"}},
+			},
+		},
+		{
+			name: "Query export reads the tables of the query",
+			sql:  "COPY (SELECT p.id FROM posts p JOIN users u ON u.id = p.author_id) TO STDOUT",
+			expected: []types.TableOperation{
+				{TableName: "posts", Operations: []string{"SELECT"}, Columns: []string{"id"}},
+				{TableName: "users", Operations: []string{"SELECT"}},
+			},
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := NewAnalyzer("postgresql", false, errors.NewErrorCollector(10, false))
+			
+			result, err := analyzer.AnalyzeQuery(Query{Name: "CopyUsers", Text: tt.sql, Cmd: ":exec"})
+			if err != nil {
+				t.Fatalf("AnalyzeQuery() error = %v", err)
+			}
+			if !reflect.DeepEqual(result.Tables, tt.expected) {
+				t.Errorf("Tables = %+v, want %+v", result.Tables, tt.expected)
+			}
+		})
+	}
+	
+	analyzer := NewAnalyzer("postgresql", false, errors.NewErrorCollector(10, false))
+	if _, err := analyzer.AnalyzeQuery(Query{Name: "CopyUsers", Text: "COPY users", Cmd: ":exec"}); err == nil {
+		t.Error("Expected an error for a COPY statement without a direction")
+	}
+}
+
+func TestAnalyzer_AnalyzeQuery_ReplaceAndSelectInto(t *testing.T) {
+	tests := []struct {
+		name     string
+		dialect  string
+		sql      string
+		expected []types.TableOperation
+	}{
+		{
+			name:    "MySQL REPLACE deletes and inserts the target",
+			dialect: "mysql",
+			sql:     "REPLACE INTO users (id, name) VALUES (?, ?)",
+			expected: []types.TableOperation{
+				{TableName: "users", Operations: []string{"INSERT", "DELETE"}, Columns: []string{"id", "name"}},
+			},
+		},
+		{
+			name:    "MySQL REPLACE with SET",
+			dialect: "mysql",
+			sql:     "REPLACE LOW_PRIORITY INTO `sessions` SET token = ?, user_id = ?",
+			expected: []types.TableOperation{
+				{TableName: "sessions", Operations: []string{"INSERT", "DELETE"}},
+			},
+		},
+		{
+			name:    "SQLite INSERT OR REPLACE",
+			dialect: "sqlite",
+			sql:     "INSERT OR REPLACE INTO settings (key, value) VALUES (?, ?)",
+			expected: []types.TableOperation{
+				{TableName: "settings", Operations: []string{"INSERT", "DELETE"}, Columns: []string{"key", "value"}},
+			},
+		},
+		{
+			name:    "SQLite INSERT OR REPLACE after a WITH clause",
+			dialect: "sqlite",
+			sql:     "WITH defaults AS (SELECT key, value FROM default_settings) INSERT OR REPLACE INTO settings (key, value) SELECT key, value FROM defaults",
+			expected: []types.TableOperation{
+				{TableName: "settings", Operations: []string{"INSERT", "DELETE"}, Columns: []string{"key", "value"}},
+				{TableName: "default_settings", Operations: []string{"SELECT"}},
+			},
+		},
+		{
+			name:    "PostgreSQL SELECT INTO creates a table from the source",
+			dialect: "postgresql",
+			sql:     "SELECT id, name INTO archived_users FROM users WHERE deleted_at IS NOT NULL",
+			expected: []types.TableOperation{
+				{TableName: "archived_users", Operations: []string{"INSERT"}},
+				{TableName: "users", Operations: []string{"SELECT"}, Columns: []string{"id", "name"}},
+			},
+		},
+		{
+			name:    "PostgreSQL SELECT INTO TEMP TABLE with a join",
+			dialect: "postgresql",
+			sql:     "SELECT u.id, p.title INTO TEMP TABLE recent_posts FROM users u JOIN posts p ON p.author_id = u.id",
+			expected: []types.TableOperation{
+				{TableName: "recent_posts", Operations: []string{"INSERT"}},
+				{TableName: "users", Operations: []string{"SELECT"}, Columns: []string{"id"}},
+				{TableName: "posts", Operations: []string{"SELECT"}, Columns: []string{"title"}},
+			},
+		},
+		{
+			name:    "MySQL SELECT INTO a variable stays a read",
+			dialect: "mysql",
+			sql:     "SELECT COUNT(*) INTO @total FROM users",
+			expected: []types.TableOperation{
+				{TableName: "users", Operations: []string{"SELECT"}},
+			},
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := NewAnalyzer(tt.dialect, false, errors.NewErrorCollector(10, false))
+			
+			result, err := analyzer.AnalyzeQuery(Query{Name: "SaveUser", Text: tt.sql, Cmd: ":exec"})
+			if err != nil {
+				t.Fatalf("AnalyzeQuery() error = %v", err)
+			}
+			if !reflect.DeepEqual(result.Tables, tt.expected) {
+				t.Errorf("Tables = %+v, want %+v", result.Tables, tt.expected)
+			}
+		})
+	}
+	
+	// With read-context edges the source tables of SELECT INTO are references
+	analyzer := NewAnalyzer("postgresql", false, errors.NewErrorCollector(10, false))
+	analyzer.SetReadContextEdges(true)
+	result, err := analyzer.AnalyzeQuery(Query{Name: "ArchiveUsers", Text: "SELECT * INTO archived_users FROM users", Cmd: ":exec"})
+	if err != nil {
+		t.Fatalf("AnalyzeQuery() error = %v", err)
+	}
+	expected := []types.TableOperation{
+		{TableName: "archived_users", Operations: []string{"INSERT"}, Kind: types.AccessPrimary},
+		{TableName: "users", Operations: []string{"SELECT"}, Kind: types.AccessReference},
+	}
+	if !reflect.DeepEqual(result.Tables, expected) {
+		t.Errorf("Tables = %+v, want %+v", result.Tables, expected)
+	}
+}
+
+func TestAnalyzer_IndexByTable(t *testing.T) {
+	analyzer := NewAnalyzer("postgresql", false, errors.NewErrorCollector(10, false))
+	
+	methods, err := analyzer.AnalyzeQueries([]Query{
+		{Name: "GetUser", Cmd: ":one", Text: "SELECT id, name FROM users WHERE id = $1"},
+		{Name: "ListUsers", Cmd: ":many", Text: "SELECT id, name FROM users ORDER BY id"},
+		{Name: "CreateUser", Cmd: ":one", Text: "INSERT INTO users (name) VALUES ($1) RETURNING id"},
+		{Name: "RenameUser", Cmd: ":exec", Text: "UPDATE users SET name = $2 WHERE id = $1"},
+		{Name: "GetPostWithAuthor", Cmd: ":one", Text: "SELECT p.title, u.name FROM posts p JOIN users u ON p.author_id = u.id WHERE p.id = $1"},
+		{Name: "DeleteUserPosts", Cmd: ":exec", Text: "DELETE FROM posts WHERE author_id = $1"},
+	})
+	if err != nil {
+		t.Fatalf("AnalyzeQueries() error = %v", err)
+	}
+	
+	want := map[string]map[string][]string{
+		"users": {
+			"SELECT": {"GetPostWithAuthor", "GetUser", "ListUsers"},
+			"INSERT": {"CreateUser"},
+			"UPDATE": {"RenameUser"},
+		},
+		"posts": {
+			"SELECT": {"GetPostWithAuthor"},
+			"DELETE": {"DeleteUserPosts"},
+		},
+	}
+	if got := analyzer.IndexByTable(methods); !reflect.DeepEqual(got, want) {
+		t.Errorf("IndexByTable() = %v, want %v", got, want)
+	}
+	
+	if got := analyzer.IndexByTable(nil); len(got) != 0 {
+		t.Errorf("IndexByTable(nil) = %v, want empty", got)
+	}
+}
+
+func TestAnalyzer_AnalyzeQueries_IgnoreDirective(t *testing.T) {
+	analyzer := NewAnalyzer("postgresql", false, errors.NewErrorCollector(10, false))
+	
+	methods, err := analyzer.AnalyzeQueries([]Query{
+		{Name: "GetUser", Cmd: ":one", Text: "SELECT id, name FROM users WHERE id = $1"},
+		{Name: "PurgeAuditLog", Cmd: ":exec", Text: "-- sqlc-analysis:ignore\nDELETE FROM audit_log WHERE created_at < $1"},
+		{Name: "CountUsers", Cmd: ":one", Text: "SELECT count(*) FROM users -- sqlc-analysis:ignored is not the directive"},
+		// The sqlc plugin passes comments separately from the query text
+		{Name: "PurgeSessions", Cmd: ":exec", Text: "DELETE FROM sessions", Comments: []string{"Drops every session", "sqlc-analysis:ignore"}},
+		{Name: "ListPosts", Cmd: ":many", Text: "SELECT id FROM posts", Comments: []string{"mentions sqlc-analysis:ignore in passing"}},
+	})
+	if err != nil {
+		t.Fatalf("AnalyzeQueries() error = %v", err)
+	}
+	
+	for _, name := range []string{"PurgeAuditLog", "PurgeSessions"} {
+		if _, exists := methods[name]; exists {
+			t.Errorf("Expected the ignored query %s to be skipped, got %+v", name, methods[name])
+		}
+	}
+	for _, name := range []string{"GetUser", "CountUsers", "ListPosts"} {
+		if _, exists := methods[name]; !exists {
+			t.Errorf("Expected %s to be analyzed, got %v", name, methods)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	base := "SELECT id, name FROM users WHERE id = $1 AND status = 'active'"
+	
+	same := []struct {
+		name string
+		sql  string
+	}{
+		{"Whitespace and newlines", "SELECT id,name\n\tFROM   users\nWHERE id=$1 AND status = 'active';"},
+		{"Keyword and identifier case", "select ID, Name from Users where id = $1 and status = 'active'"},
+		{"Comments", "-- fetch a user\nSELECT id, name /* columns */ FROM users WHERE id = $1 AND status = 'active'"},
+		{"Other literal and parameter style", "SELECT id, name FROM users WHERE id = ? AND status = 'it''s deleted'"},
+		{"Named parameters", "SELECT id, name FROM users WHERE id = sqlc.arg(user_id) AND status = @status"},
+		{"Numeric literal", "SELECT id, name FROM users WHERE id = 42 AND status = 'x'"},
+	}
+	for _, tt := range same {
+		t.Run(tt.name, func(t *testing.T) {
+			if Fingerprint(tt.sql) != Fingerprint(base) {
+				t.Errorf("Fingerprint differs:\n%s\n%s", fingerprintSQL(tt.sql), fingerprintSQL(base))
+			}
+		})
+	}
+	
+	different := []struct {
+		name string
+		sql  string
+	}{
+		{"Other table", "SELECT id, name FROM accounts WHERE id = $1 AND status = 'active'"},
+		{"Extra column", "SELECT id, name, email FROM users WHERE id = $1 AND status = 'active'"},
+		{"Other operator", "SELECT id, name FROM users WHERE id > $1 AND status = 'active'"},
+		{"Quoted identifier keeps its case", `SELECT id, name FROM "Users" WHERE id = $1 AND status = 'active'`},
+	}
+	for _, tt := range different {
+		t.Run(tt.name, func(t *testing.T) {
+			if Fingerprint(tt.sql) == Fingerprint(base) {
+				t.Errorf("Expected a different fingerprint for %q, both normalize to %s", tt.sql, fingerprintSQL(base))
+			}
+		})
+	}
+	
+	analyzer := NewAnalyzer("postgresql", false, errors.NewErrorCollector(10, false))
+	result, err := analyzer.AnalyzeQuery(Query{Name: "GetUser", Cmd: ":one", Text: base})
+	if err != nil {
+		t.Fatalf("AnalyzeQuery() error = %v", err)
+	}
+	if result.Fingerprint != Fingerprint(base) {
+		t.Errorf("AnalyzeQuery().Fingerprint = %q, want %q", result.Fingerprint, Fingerprint(base))
+	}
+}
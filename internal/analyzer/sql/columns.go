@@ -0,0 +1,218 @@
+package sql
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/naoyafurudono/sqlc-use-analysis/pkg/types"
+)
+
+// sqlKeywords are words that can follow a table name but are never its alias
+var sqlKeywords = map[string]bool{
+	"ON": true, "USING": true, "WHERE": true, "SET": true, "JOIN": true, "INNER": true,
+	"LEFT": true, "RIGHT": true, "FULL": true, "CROSS": true, "NATURAL": true, "OUTER": true,
+	"GROUP": true, "ORDER": true, "HAVING": true, "LIMIT": true, "OFFSET": true, "UNION": true,
+	"RETURNING": true, "FOR": true, "WINDOW": true, "VALUES": true, "SELECT": true,
+}
+
+// extractColumns returns the columns a DML statement reads or writes, per table
+// SELECTは最外側の射影、INSERTは列リスト、UPDATEはSET句の代入先を対象とする
+func (a *Analyzer) extractColumns(sqlText string, operation types.Operation) map[string][]string {
+	normalizedSQL := normalizeSQL(sqlText)
+	
+	switch operation {
+	case types.OpSelect:
+		return a.extractSelectColumns(normalizedSQL)
+	case types.OpInsert:
+		return a.extractInsertColumns(normalizedSQL)
+	case types.OpUpdate:
+		return a.extractUpdateColumns(normalizedSQL)
+	default:
+		return nil
+	}
+}
+
+// extractSelectColumns resolves the projection of the outermost SELECT to table columns
+// 式や関数呼び出しは列として扱わず、修飾のない列はテーブルが1つの場合のみ割り当てる
+func (a *Analyzer) extractSelectColumns(sqlText string) map[string][]string {
+	outer, _ := splitSubqueries(sqlText)
+	loc := regexp.MustCompile(`(?i)^SELECT\s+(?:DISTINCT\s+|ALL\s+)?(.+?)\s+FROM\s`).FindStringSubmatchIndex(outer)
+	if loc == nil {
+		return nil
+	}
+	
+	// 射影の後ろのFROM句以降からテーブルとエイリアスを解決する
+	aliases, tables := a.tableAliases(outer[loc[3]:])
+	columnPattern := regexp.MustCompile(`^(?:(` + a.getIdentifierPartPattern() + `)\.)?(` + a.getIdentifierPartPattern() + `|\*)$`)
+	
+	columns := make(map[string][]string)
+	for _, item := range splitTopLevel(outer[loc[2]:loc[3]]) {
+		parts := columnPattern.FindStringSubmatch(stripCast(stripColumnAlias(item)))
+		if parts == nil {
+			continue
+		}
+		column := types.ColumnWildcard
+		if parts[2] != "*" {
+			column = a.normalizeColumnName(parts[2])
+		}
+		
+		switch {
+		case parts[1] != "":
+			if table, ok := aliases[a.normalizeTableName(parts[1])]; ok {
+				columns[table] = appendUnique(columns[table], column)
+			}
+		case column == types.ColumnWildcard:
+			for _, table := range tables {
+				columns[table] = appendUnique(columns[table], column)
+			}
+		case len(tables) == 1:
+			columns[tables[0]] = appendUnique(columns[tables[0]], column)
+		}
+	}
+	return columns
+}
+
+// extractInsertColumns returns the explicit column list of an INSERT statement
+func (a *Analyzer) extractInsertColumns(sqlText string) map[string][]string {
+	pattern := regexp.MustCompile(`(?i)^` + insertIntoPattern + a.getTableNamePattern() +
+		`(?:\s+AS\s+[a-zA-Z_][a-zA-Z0-9_]*)?\s*\(([^()]*)\)`)
+	matches := pattern.FindStringSubmatch(sqlText)
+	if len(matches) < 3 {
+		return nil
+	}
+	
+	table := a.normalizeTableName(matches[1])
+	var columns []string
+	for _, column := range strings.Split(matches[2], ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			columns = appendUnique(columns, a.normalizeColumnName(column))
+		}
+	}
+	return map[string][]string{table: columns}
+}
+
+// extractUpdateColumns returns the targets of the SET clause of an UPDATE statement
+// 修飾のない代入先は更新対象のテーブルに割り当てる
+func (a *Analyzer) extractUpdateColumns(sqlText string) map[string][]string {
+	outer, _ := splitSubqueries(sqlText)
+	matches := regexp.MustCompile(`(?i)\bSET\s+(.+?)(?:\s+(?:FROM|WHERE|RETURNING|ORDER\s+BY|LIMIT)\s|$)`).FindStringSubmatch(outer)
+	if len(matches) < 2 {
+		return nil
+	}
+	
+	target := a.extractWriteTarget(outer, types.OpUpdate)
+	aliases, _ := a.tableAliases(outer)
+	targetPattern := regexp.MustCompile(`^(?:(` + a.getIdentifierPartPattern() + `)\.)?(` + a.getIdentifierPartPattern() + `)\s*=`)
+	
+	columns := make(map[string][]string)
+	for _, assignment := range splitTopLevel(matches[1]) {
+		parts := targetPattern.FindStringSubmatch(assignment)
+		if parts == nil {
+			continue
+		}
+		table := target
+		if parts[1] != "" {
+			table = aliases[a.normalizeTableName(parts[1])]
+		}
+		if table != "" {
+			columns[table] = appendUnique(columns[table], a.normalizeColumnName(parts[2]))
+		}
+	}
+	return columns
+}
+
+// tableAliases maps the names and aliases usable as column qualifiers to their tables
+// Tables are returned in the order they appear in the statement
+func (a *Analyzer) tableAliases(sqlText string) (map[string]string, []string) {
+	// ロック句の "FOR UPDATE OF t" をUPDATE文のテーブルと誤認しないよう除外する
+	if loc := lockingClausePattern.FindStringIndex(sqlText); loc != nil {
+		sqlText = sqlText[:loc[0]]
+	}
+	part := a.getIdentifierPartPattern()
+	pattern := regexp.MustCompile(`(?i)\b(?:FROM|JOIN|UPDATE)\s+(?:ONLY\s+)?` + a.getTableNamePattern() +
+		`(?:\s+(?:AS\s+)?(` + part + `))?`)
+	listPattern := regexp.MustCompile(`(?i)^\s*,\s*` + a.getTableNamePattern() + `(?:\s+(?:AS\s+)?(` + part + `))?`)
+	
+	aliases := make(map[string]string)
+	var tables []string
+	add := func(name, alias string) {
+		table := a.normalizeTableName(name)
+		tables = appendUnique(tables, table)
+		aliases[table] = table
+		// スキーマ修飾されたテーブルはテーブル名でも参照できる
+		if dot := strings.LastIndex(table, "."); dot >= 0 {
+			aliases[table[dot+1:]] = table
+		}
+		if alias != "" && !sqlKeywords[strings.ToUpper(alias)] {
+			aliases[a.normalizeTableName(alias)] = table
+		}
+	}
+	
+	for _, loc := range pattern.FindAllStringSubmatchIndex(sqlText, -1) {
+		add(submatch(sqlText, loc, 1), submatch(sqlText, loc, 2))
+		
+		// FROM a x, b y のようなカンマ区切りのテーブルリスト
+		rest := sqlText[loc[1]:]
+		for {
+			next := listPattern.FindStringSubmatchIndex(rest)
+			if next == nil {
+				break
+			}
+			add(submatch(rest, next, 1), submatch(rest, next, 2))
+			rest = rest[next[1]:]
+		}
+	}
+	return aliases, tables
+}
+
+// submatch returns the i-th group of a FindStringSubmatchIndex result, or ""
+func submatch(text string, loc []int, i int) string {
+	if loc[2*i] < 0 {
+		return ""
+	}
+	return text[loc[2*i]:loc[2*i+1]]
+}
+
+// splitTopLevel splits a comma-separated list, ignoring commas inside parentheses
+func splitTopLevel(list string) []string {
+	var items []string
+	depth, start := 0, 0
+	for i, r := range list {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				items = append(items, strings.TrimSpace(list[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(items, strings.TrimSpace(list[start:]))
+}
+
+// stripColumnAlias removes a trailing "AS alias" (or bare alias) from a projection item
+func stripColumnAlias(item string) string {
+	if loc := regexp.MustCompile(`(?i)\s+AS\s+\S+$`).FindStringIndex(item); loc != nil {
+		return item[:loc[0]]
+	}
+	if fields := strings.Fields(item); len(fields) == 2 {
+		return fields[0]
+	}
+	return item
+}
+
+// castPattern matches a trailing PostgreSQL cast such as "::text", "::varchar(20)" or "::int[]"
+var castPattern = regexp.MustCompile(`(?:\s*::\s*[a-zA-Z_][a-zA-Z0-9_.]*(?:\s*\([^()]*\))?(?:\[\])*)+$`)
+
+// stripCast removes trailing casts from a projection item so "u.id::text" is read as u.id
+func stripCast(item string) string {
+	return castPattern.ReplaceAllString(item, "")
+}
+
+// normalizeColumnName normalizes a column name like a table name (quotes, case)
+func (a *Analyzer) normalizeColumnName(column string) string {
+	return a.normalizeTableName(column)
+}
@@ -0,0 +1,75 @@
+package sql
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/naoyafurudono/sqlc-use-analysis/pkg/types"
+)
+
+// copyQueryPattern matches "COPY (query) TO ..."; group 1 is the query
+var copyQueryPattern = regexp.MustCompile(`(?is)^COPY\s*\((.*)\)\s*TO\b`)
+
+// isCopy reports whether a statement is a PostgreSQL COPY
+func isCopy(sqlText string) bool {
+	return strings.HasPrefix(strings.ToUpper(normalizeSQL(sqlText)), "COPY")
+}
+
+// copyTablePattern matches "COPY table [(columns)] FROM|TO"; groups are the table, the column list and the direction
+func (a *Analyzer) copyTablePattern() *regexp.Regexp {
+	return regexp.MustCompile(`(?i)^COPY\s+` + a.getTableNamePattern() + `\s*(?:\(([^()]*)\))?\s+(FROM|TO)\b`)
+}
+
+// copyOperation classifies a COPY statement
+// FROMはテーブルへの取り込み（INSERT）、TOは書き出し（SELECT）として扱う
+func (a *Analyzer) copyOperation(sqlText string) (types.Operation, error) {
+	normalizedSQL := normalizeSQL(sqlText)
+	if copyQueryPattern.MatchString(normalizedSQL) {
+		return types.OpSelect, nil
+	}
+	matches := a.copyTablePattern().FindStringSubmatch(normalizedSQL)
+	if matches == nil {
+		return "", fmt.Errorf("unrecognized COPY statement: %s", sqlText)
+	}
+	if strings.EqualFold(matches[3], "FROM") {
+		return types.OpInsert, nil
+	}
+	return types.OpSelect, nil
+}
+
+// analyzeCopy returns the table a COPY statement loads into or exports from
+// COPY (query) TO はクエリが読み取るテーブルをそのまま使う
+func (a *Analyzer) analyzeCopy(sqlText string) ([]types.TableOperation, error) {
+	normalizedSQL := normalizeSQL(sqlText)
+	if matches := copyQueryPattern.FindStringSubmatch(normalizedSQL); matches != nil {
+		info, err := a.AnalyzeQuery(Query{Text: matches[1]})
+		if err != nil {
+			return nil, fmt.Errorf("failed to analyze COPY query: %w", err)
+		}
+		return info.Tables, nil
+	}
+	
+	operation, err := a.copyOperation(normalizedSQL)
+	if err != nil {
+		return nil, err
+	}
+	matches := a.copyTablePattern().FindStringSubmatch(normalizedSQL)
+	
+	// 列リストがない場合、TOはすべての列を書き出す
+	var columns []string
+	for _, column := range strings.Split(matches[2], ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			columns = appendUnique(columns, a.normalizeColumnName(column))
+		}
+	}
+	if len(columns) == 0 && operation == types.OpSelect {
+		columns = []string{types.ColumnWildcard}
+	}
+	
+	return []types.TableOperation{{
+		TableName:  a.tableReference(matches[1]),
+		Operations: []string{string(operation)},
+		Columns:    columns,
+	}}, nil
+}
@@ -4,54 +4,196 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+
+	"github.com/naoyafurudono/sqlc-use-analysis/internal/errors"
+	"github.com/naoyafurudono/sqlc-use-analysis/pkg/types"
 )
 
 // extractTablesFromSelect extracts table names from SELECT statements
+// Subqueries are resolved in their own scope, so an alias reused inside a
+// subquery never shadows or leaks into the enclosing query
 func (a *Analyzer) extractTablesFromSelect(sqlText string) ([]string, error) {
 	var tables []string
+	outer, subqueries := splitSubqueries(sqlText)
 	
 	// FROM句のテーブルを抽出
-	fromTables, err := a.extractFromClause(sqlText)
+	fromTables, err := a.extractFromClause(outer)
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract FROM clause: %w", err)
 	}
 	tables = append(tables, fromTables...)
 	
 	// JOIN句のテーブルを抽出
-	joinTables, err := a.extractJoinTables(sqlText)
+	joinTables, err := a.extractJoinTables(outer)
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract JOIN tables: %w", err)
 	}
 	tables = append(tables, joinTables...)
 	
+	// サブクエリは再帰的に解析する
+	for _, subquery := range subqueries {
+		subTables, err := a.extractTablesFromSelect(subquery)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, subTables...)
+	}
+	
 	return tables, nil
 }
 
+// subqueryPattern matches the opening parenthesis of a subquery
+var subqueryPattern = regexp.MustCompile(`(?i)\(\s*SELECT\b`)
+
+// splitSubqueries replaces each top-level subquery with "(subquery)" and returns their bodies
+// 括弧の対応が取れないサブクエリはそのまま残す
+func splitSubqueries(sqlText string) (string, []string) {
+	var outer strings.Builder
+	var subqueries []string
+	
+	rest := sqlText
+	for {
+		loc := subqueryPattern.FindStringIndex(rest)
+		if loc == nil {
+			break
+		}
+		end := findClosingParen(rest, loc[0])
+		if end < 0 {
+			break
+		}
+		outer.WriteString(rest[:loc[0]])
+		outer.WriteString("(subquery)")
+		subqueries = append(subqueries, strings.TrimSpace(rest[loc[0]+1:end]))
+		rest = rest[end+1:]
+	}
+	outer.WriteString(rest)
+	
+	return outer.String(), subqueries
+}
+
+// insertIntoPattern matches the head of an INSERT statement up to the target table
+// MySQLのREPLACEとSQLiteのINSERT OR REPLACEも挿入として扱う
+const insertIntoPattern = `(?:INSERT\s+(?:IGNORE\s+|OR\s+REPLACE\s+)?|REPLACE\s+(?:LOW_PRIORITY\s+|DELAYED\s+)?)INTO\s+`
+
+// replacePattern matches MySQL's REPLACE and SQLite's INSERT OR REPLACE
+var replacePattern = regexp.MustCompile(`(?i)^(?:REPLACE|INSERT\s+OR\s+REPLACE)\b`)
+
+// defaultValuesOrSetPattern matches the INSERT bodies that need no column list
+var defaultValuesOrSetPattern = regexp.MustCompile(`(?i)^(?:DEFAULT\s+VALUES|SET)$`)
+
 // extractTablesFromInsert extracts table names from INSERT statements
 func (a *Analyzer) extractTablesFromInsert(sqlText string) ([]string, error) {
-	// MySQL/PostgreSQL共通: INSERT INTO table_name の形式
-	// また、バッククォートでのテーブル名も対応
-	pattern := regexp.MustCompile(`(?i)INSERT\s+INTO\s+` + a.getTableNamePattern())
+	// MySQL/PostgreSQL共通: INSERT INTO table_name [(col, ...)] VALUES/SELECT ... の形式
+	// 列リストの省略や複数行のVALUESにも対応し、本体のないINSERTはエラーとする
+	pattern := regexp.MustCompile(`(?i)` + insertIntoPattern + a.getTableNamePattern() +
+		`(?:\s+AS\s+[a-zA-Z_][a-zA-Z0-9_]*)?\s*(\([^()]*\))?\s*(VALUES?|SELECT|WITH|DEFAULT\s+VALUES|SET)\b`)
 	matches := pattern.FindStringSubmatch(sqlText)
 	
-	if len(matches) >= 2 {
-		tableName := a.normalizeTableName(matches[1])
+	if len(matches) >= 4 {
+		tableName := a.tableReference(matches[1])
+		// DEFAULT VALUESとMySQLのINSERT ... SETは列を指定する必要がない
+		if matches[2] == "" && !defaultValuesOrSetPattern.MatchString(matches[3]) {
+			a.reportColumnlessInsert(tableName, sqlText)
+		}
 		return []string{tableName}, nil
 	}
 	
 	return nil, fmt.Errorf("could not extract table name from INSERT statement: %s", sqlText)
 }
 
+// reportColumnlessInsert reports an INSERT statement without an explicit column list
+func (a *Analyzer) reportColumnlessInsert(tableName, sqlText string) {
+	if a.errorCollector == nil {
+		return
+	}
+	
+	// 列リストの省略はテーブル定義の列順に依存するため警告として報告する
+	details := errors.TableDetails(tableName)
+	details["sql"] = sqlText
+	reporter := errors.NewErrorReporter(a.errorCollector)
+	reporter.ReportWarning(errors.CategoryAnalysis,
+		fmt.Sprintf("INSERT into '%s' has no column list", tableName), details)
+}
+
+// whereClausePattern matches a WHERE keyword
+var whereClausePattern = regexp.MustCompile(`(?i)\bWHERE\b`)
+
+// hasTopLevelWhere reports whether a statement has a WHERE clause outside its subqueries
+func hasTopLevelWhere(sqlText string) bool {
+	// サブクエリ内のWHEREは書き込み対象の行を絞り込まないため数えない
+	outer, _ := splitSubqueries(normalizeSQL(sqlText))
+	return whereClausePattern.MatchString(outer)
+}
+
+// reportUnqualifiedWrite reports an UPDATE or DELETE statement without a top-level WHERE clause
+// statementはWITH句を除いた本体の文で、警告の詳細にはクエリ全体を記録する
+func (a *Analyzer) reportUnqualifiedWrite(query Query, statement string, operation types.Operation, tableOps []types.TableOperation) {
+	if a.errorCollector == nil || hasTopLevelWhere(statement) {
+		return
+	}
+	
+	// 書き込み対象を特定できない場合（複数テーブルのDELETEなど）は参照以外のテーブルすべてを対象とする
+	var targets []string
+	if target := a.extractWriteTarget(statement, operation); target != "" {
+		targets = []string{target}
+	} else {
+		for _, tableOp := range tableOps {
+			if tableOp.Kind != types.AccessReference {
+				targets = append(targets, tableOp.TableName)
+			}
+		}
+	}
+	
+	reporter := errors.NewErrorReporter(a.errorCollector)
+	for _, table := range targets {
+		details := errors.TableDetails(table)
+		details["query_name"] = query.Name
+		details["sql"] = query.Text
+		reporter.ReportWarning(errors.CategoryAnalysis,
+			fmt.Sprintf("unqualified %s on table '%s'", operation, table), details)
+	}
+}
+
+// conflictClausePattern matches the start of an INSERT's ON CONFLICT or ON DUPLICATE KEY UPDATE clause
+var conflictClausePattern = regexp.MustCompile(`(?i)\bON\s+(?:CONFLICT\b|DUPLICATE\s+KEY\s+UPDATE\b)`)
+
+// doUpdatePattern matches the update action of an ON CONFLICT or ON DUPLICATE KEY clause
+var doUpdatePattern = regexp.MustCompile(`(?i)^ON\s+DUPLICATE\b|\bDO\s+UPDATE\b`)
+
+// splitConflictClause splits an INSERT statement into its body and its conflict clause ("" if none)
+func splitConflictClause(sqlText string) (string, string) {
+	loc := conflictClausePattern.FindStringIndex(sqlText)
+	if loc == nil {
+		return sqlText, ""
+	}
+	return sqlText[:loc[0]], sqlText[loc[0]:]
+}
+
+// isUpsert reports whether an INSERT statement updates the existing row on conflict
+// ON CONFLICT ... DO NOTHING は更新を伴わない
+func isUpsert(sqlText string) bool {
+	_, conflict := splitConflictClause(normalizeSQL(sqlText))
+	return doUpdatePattern.MatchString(conflict)
+}
+
+// isReplace reports whether an INSERT statement deletes the conflicting row before inserting
+func isReplace(sqlText string) bool {
+	return replacePattern.MatchString(normalizeSQL(sqlText))
+}
+
 // extractTablesFromUpdate extracts table names from UPDATE statements
 func (a *Analyzer) extractTablesFromUpdate(sqlText string) ([]string, error) {
 	var tables []string
 	
-	// UPDATE table_name SET の形式（MySQL/PostgreSQL対応）
-	pattern := regexp.MustCompile(`(?i)UPDATE\s+` + a.getTableNamePattern() + `\s+SET`)
+	// UPDATE table_name [[AS] alias] SET の形式（PostgreSQL）と
+	// UPDATE table_name [alias] JOIN ... SET の形式（MySQLの複数テーブル更新）に対応
+	pattern := regexp.MustCompile(`(?i)\bUPDATE\s+(?:ONLY\s+)?` + a.getTableNamePattern() +
+		`(?:\s+(?:AS\s+)?` + a.getIdentifierPartPattern() + `)?` +
+		`\s+(?:SET|(?:(?:INNER|LEFT|RIGHT|CROSS)\s+)?(?:OUTER\s+)?JOIN)\b`)
 	matches := pattern.FindStringSubmatch(sqlText)
 	
 	if len(matches) >= 2 {
-		tableName := a.normalizeTableName(matches[1])
+		tableName := a.tableReference(matches[1])
 		tables = append(tables, tableName)
 	}
 	
@@ -80,26 +222,27 @@ func (a *Analyzer) extractTablesFromUpdate(sqlText string) ([]string, error) {
 // extractTablesFromDelete extracts table names from DELETE statements
 func (a *Analyzer) extractTablesFromDelete(sqlText string) ([]string, error) {
 	var tables []string
+	outer, subqueries := splitSubqueries(sqlText)
 	
 	// DELETE FROM table_name の形式（MySQL/PostgreSQL対応）
 	pattern := regexp.MustCompile(`(?i)DELETE\s+FROM\s+` + a.getTableNamePattern())
-	matches := pattern.FindStringSubmatch(sqlText)
+	matches := pattern.FindStringSubmatch(outer)
 	
 	if len(matches) >= 2 {
-		tableName := a.normalizeTableName(matches[1])
+		tableName := a.tableReference(matches[1])
 		tables = append(tables, tableName)
 	}
 	
 	// USING句がある場合のテーブルも抽出
-	if strings.Contains(strings.ToUpper(sqlText), " USING ") {
-		usingTables, err := a.extractUsingClause(sqlText)
+	if strings.Contains(strings.ToUpper(outer), " USING ") {
+		usingTables, err := a.extractUsingClause(outer)
 		if err == nil {
 			tables = append(tables, usingTables...)
 		}
 	}
 	
 	// JOIN句のテーブルも抽出
-	joinTables, err := a.extractJoinTables(sqlText)
+	joinTables, err := a.extractJoinTables(outer)
 	if err == nil {
 		tables = append(tables, joinTables...)
 	}
@@ -108,9 +251,324 @@ func (a *Analyzer) extractTablesFromDelete(sqlText string) ([]string, error) {
 		return nil, fmt.Errorf("could not extract table name from DELETE statement: %s", sqlText)
 	}
 	
+	// WHERE句などのサブクエリが読むテーブルも抽出
+	for _, subquery := range subqueries {
+		subTables, err := a.extractTablesFromSelect(subquery)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract tables from subquery: %w", err)
+		}
+		tables = append(tables, subTables...)
+	}
+	
+	// 重複を除去
+	return removeDuplicates(tables), nil
+}
+
+// analyzeMerge extracts table operations from MERGE statements
+// 対象テーブルにはWHEN句に現れるUPDATE/INSERT/DELETEを、USINGのソースにはSELECTを割り当てる
+func (a *Analyzer) analyzeMerge(sqlText string) ([]types.TableOperation, error) {
+	normalizedSQL := normalizeSQL(sqlText)
+	
+	targetPattern := regexp.MustCompile(`(?i)^MERGE\s+(?:INTO\s+)?` + a.getTableNamePattern())
+	matches := targetPattern.FindStringSubmatch(normalizedSQL)
+	if len(matches) < 2 {
+		return nil, fmt.Errorf("no target table found in MERGE statement")
+	}
+	target := a.tableReference(matches[1])
+	
+	// WHEN [NOT] MATCHED ... THEN の操作を収集
+	var targetOps []string
+	actionPattern := regexp.MustCompile(`(?i)\bTHEN\s+(UPDATE|INSERT|DELETE)\b`)
+	for _, action := range actionPattern.FindAllStringSubmatch(normalizedSQL, -1) {
+		targetOps = appendUnique(targetOps, strings.ToUpper(action[1]))
+	}
+	if len(targetOps) == 0 {
+		return nil, fmt.Errorf("no WHEN ... THEN UPDATE/INSERT/DELETE clause found in MERGE statement")
+	}
+	
+	tableOps := []types.TableOperation{{TableName: target, Operations: targetOps}}
+	sourceKind := types.AccessKind("")
+	if a.readContext {
+		tableOps[0].Kind = types.AccessPrimary
+		sourceKind = types.AccessReference
+	}
+	
+	// USING句のソース（テーブルまたはサブクエリ）
+	sourcePattern := regexp.MustCompile(`(?i)\bUSING\s+(\(?)\s*` + a.getTableNamePattern())
+	var sources []string
+	if source := sourcePattern.FindStringSubmatch(normalizedSQL); len(source) >= 3 {
+		if source[1] == "(" {
+			usingIndex := sourcePattern.FindStringIndex(normalizedSQL)[0]
+			selectTables, err := a.extractTablesFromSelect(normalizedSQL[usingIndex:])
+			if err != nil {
+				return nil, fmt.Errorf("failed to extract MERGE source tables: %w", err)
+			}
+			sources = selectTables
+		} else {
+			sources = []string{a.tableReference(source[2])}
+		}
+	}
+	
+	for _, source := range removeDuplicates(sources) {
+		if source == target {
+			tableOps[0].Operations = appendUnique(tableOps[0].Operations, string(types.OpSelect))
+			continue
+		}
+		tableOps = append(tableOps, types.TableOperation{
+			TableName:  source,
+			Operations: []string{string(types.OpSelect)},
+			Kind:       sourceKind,
+		})
+	}
+	
+	return tableOps, nil
+}
+
+// cteDefinition is a named subquery declared in a WITH clause
+type cteDefinition struct {
+	name string
+	body string
+}
+
+var (
+	withPattern        = regexp.MustCompile(`(?i)^WITH\s+(?:RECURSIVE\s+)?`)
+	setOperatorPattern = regexp.MustCompile(`(?i)\s+(?:UNION(?:\s+ALL)?|INTERSECT|EXCEPT)\s+`)
+)
+
+// isCTE reports whether the statement starts with a WITH clause
+func isCTE(sqlText string) bool {
+	return withPattern.MatchString(normalizeSQL(sqlText))
+}
+
+// splitCTE splits a WITH statement into its CTE definitions and the main statement
+func (a *Analyzer) splitCTE(sqlText string) ([]cteDefinition, string, error) {
+	rest := strings.TrimSpace(sqlText)
+	loc := withPattern.FindStringIndex(rest)
+	if loc == nil {
+		return nil, "", fmt.Errorf("statement does not start with WITH")
+	}
+	rest = rest[loc[1]:]
+	
+	// name [(columns)] AS [[NOT] MATERIALIZED] ( body )
+	headerPattern := regexp.MustCompile(`(?i)^` + a.getTableNamePattern() +
+		`\s*(?:\([^()]*\)\s*)?AS\s+(?:(?:NOT\s+)?MATERIALIZED\s+)?\(`)
+	
+	var ctes []cteDefinition
+	for {
+		header := headerPattern.FindStringSubmatchIndex(rest)
+		if header == nil {
+			return nil, "", fmt.Errorf("invalid CTE definition near: %s", rest)
+		}
+		
+		open := header[1] - 1
+		end := findClosingParen(rest, open)
+		if end < 0 {
+			return nil, "", fmt.Errorf("unbalanced parentheses in CTE '%s'", rest[header[2]:header[3]])
+		}
+		ctes = append(ctes, cteDefinition{
+			name: a.normalizeTableName(rest[header[2]:header[3]]),
+			body: strings.TrimSpace(rest[open+1 : end]),
+		})
+		
+		rest = strings.TrimSpace(rest[end+1:])
+		if !strings.HasPrefix(rest, ",") {
+			break
+		}
+		rest = strings.TrimSpace(rest[1:])
+	}
+	
+	return ctes, rest, nil
+}
+
+// findClosingParen returns the index of the parenthesis closing the one at open, or -1
+// 文字列リテラル内の括弧は無視する
+func findClosingParen(text string, open int) int {
+	depth := 0
+	inString := false
+	for i := open; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == '\'':
+			inString = !inString
+		case inString:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// analyzeCTE extracts table operations from a WITH statement and returns the statement following it
+// CTE名はテーブルとして扱わず、各CTE本体と本体の文が参照する実テーブルを記録する
+func (a *Analyzer) analyzeCTE(sqlText string) ([]types.TableOperation, string, types.Operation, error) {
+	ctes, mainStatement, err := a.splitCTE(normalizeSQL(sqlText))
+	if err != nil {
+		return nil, "", "", err
+	}
+	if mainStatement == "" {
+		return nil, "", "", fmt.Errorf("no statement follows the WITH clause")
+	}
+	
+	// RECURSIVEの自己参照を含め、CTE名への参照は除外する
+	cteNames := make(map[string]bool, len(ctes))
+	for _, cte := range ctes {
+		cteNames[cte.name] = true
+	}
+	
+	var tableOps []types.TableOperation
+	record := func(ops []types.TableOperation) {
+		for _, op := range ops {
+			if cteNames[op.TableName] {
+				continue
+			}
+			found := false
+			for i := range tableOps {
+				if tableOps[i].TableName == op.TableName {
+					for _, operation := range op.Operations {
+						tableOps[i].Operations = appendUnique(tableOps[i].Operations, operation)
+					}
+					// どちらかで書き込まれていれば主対象とみなす
+					if op.Kind != types.AccessReference {
+						tableOps[i].Kind = op.Kind
+					}
+					found = true
+					break
+				}
+			}
+			if !found {
+				tableOps = append(tableOps, op)
+			}
+		}
+	}
+	
+	operation, err := a.detectOperationType(mainStatement)
+	if err != nil {
+		return nil, "", "", err
+	}
+	// 本体の文はWITH句がない場合と同じ規則で割り当てる（書き込み対象の区別はReadContextEdgesが有効な場合のみ）
+	mainOps, err := a.statementTableOps(mainStatement, operation)
+	if err != nil {
+		return nil, "", "", err
+	}
+	record(mainOps)
+	
+	for _, cte := range ctes {
+		// UNIONなどで連結された各SELECTを個別に解析する
+		for _, part := range setOperatorPattern.Split(cte.body, -1) {
+			// INSERT/UPDATE/DELETE ... RETURNING を含むCTEにも対応
+			partOperation, err := a.detectOperationType(part)
+			if err != nil || partOperation == opMerge || partOperation.IsDDL() {
+				partOperation = types.OpSelect
+			}
+			partOps, err := a.statementTableOps(part, partOperation)
+			if err != nil {
+				return nil, "", "", fmt.Errorf("failed to extract tables from CTE '%s': %w", cte.name, err)
+			}
+			record(partOps)
+		}
+	}
+	
+	// 書き込み文のために読むだけのテーブルは参照として扱う
+	if a.readContext && operation != types.OpSelect {
+		for i := range tableOps {
+			if len(tableOps[i].Operations) == 1 && tableOps[i].Operations[0] == string(types.OpSelect) {
+				tableOps[i].Kind = types.AccessReference
+			} else if tableOps[i].Kind == "" {
+				tableOps[i].Kind = types.AccessPrimary
+			}
+		}
+	}
+	
+	return tableOps, mainStatement, operation, nil
+}
+
+// extractDDLTables extracts the tables affected by CREATE/ALTER/DROP/TRUNCATE statements
+// CREATE INDEXは索引を張るテーブルを対象とみなす
+func (a *Analyzer) extractDDLTables(sqlText string, operation types.Operation) ([]string, error) {
+	normalizedSQL := normalizeSQL(sqlText)
+	tableName := a.getTableNamePattern()
+	
+	var pattern string
+	switch operation {
+	case types.OpCreate:
+		createTable := regexp.MustCompile(`(?i)^CREATE\s+(?:OR\s+REPLACE\s+)?(?:(?:GLOBAL|LOCAL)\s+)?(?:TEMP(?:ORARY)?\s+|UNLOGGED\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?` + tableName)
+		if matches := createTable.FindStringSubmatch(normalizedSQL); len(matches) >= 2 {
+			return []string{a.tableReference(matches[1])}, nil
+		}
+		createIndex := regexp.MustCompile(`(?i)^CREATE\s+(?:UNIQUE\s+)?INDEX\b.*?\bON\s+(?:ONLY\s+)?` + tableName)
+		if matches := createIndex.FindStringSubmatch(normalizedSQL); len(matches) >= 2 {
+			return []string{a.tableReference(matches[1])}, nil
+		}
+		return nil, fmt.Errorf("no table found in CREATE statement")
+	case types.OpAlter:
+		alterTable := regexp.MustCompile(`(?i)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?` + tableName)
+		if matches := alterTable.FindStringSubmatch(normalizedSQL); len(matches) >= 2 {
+			return []string{a.tableReference(matches[1])}, nil
+		}
+		return nil, fmt.Errorf("no table found in ALTER statement")
+	case types.OpDrop:
+		pattern = `(?i)^DROP\s+(?:TEMPORARY\s+)?TABLE\s+(?:IF\s+EXISTS\s+)?`
+	case types.OpTruncate:
+		pattern = `(?i)^TRUNCATE\s+(?:TABLE\s+)?(?:ONLY\s+)?`
+	default:
+		return nil, fmt.Errorf("unsupported DDL operation: %v", operation)
+	}
+	
+	// DROP/TRUNCATEはカンマ区切りで複数テーブルを指定できる
+	prefix := regexp.MustCompile(pattern).FindStringIndex(normalizedSQL)
+	if prefix == nil {
+		return nil, fmt.Errorf("no table found in %s statement", operation)
+	}
+	
+	namePattern := regexp.MustCompile(`^\s*` + tableName)
+	var tables []string
+	for _, part := range strings.Split(normalizedSQL[prefix[1]:], ",") {
+		if matches := namePattern.FindStringSubmatch(part); len(matches) >= 2 {
+			tables = appendUnique(tables, a.tableReference(matches[1]))
+		}
+	}
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("no table found in %s statement", operation)
+	}
+	
 	return tables, nil
 }
 
+// extractWriteTarget returns the table an INSERT/UPDATE/DELETE statement writes to, or ""
+func (a *Analyzer) extractWriteTarget(sqlText string, operation types.Operation) string {
+	var prefix string
+	switch operation {
+	case types.OpInsert:
+		prefix = `(?i)^` + insertIntoPattern
+	case types.OpUpdate:
+		prefix = `(?i)^UPDATE\s+(?:ONLY\s+)?`
+	case types.OpDelete:
+		prefix = `(?i)^DELETE\s+FROM\s+(?:ONLY\s+)?`
+	default:
+		return ""
+	}
+	
+	matches := regexp.MustCompile(prefix + a.getTableNamePattern()).FindStringSubmatch(normalizeSQL(sqlText))
+	if len(matches) < 2 {
+		return ""
+	}
+	return a.tableReference(matches[1])
+}
+
+// appendUnique appends value to values unless it is already present
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
 // extractFromClause extracts table names from FROM clause
 func (a *Analyzer) extractFromClause(sqlText string) ([]string, error) {
 	// よりシンプルなアプローチ: FROMの後で最初のキーワードまで
@@ -152,7 +610,7 @@ func (a *Analyzer) extractJoinTables(sqlText string) ([]string, error) {
 		matches := pattern.FindAllStringSubmatch(sqlText, -1)
 		for _, match := range matches {
 			if len(match) >= 2 {
-				tableName := a.normalizeTableName(match[1])
+				tableName := a.tableReference(match[1])
 				tableSet[tableName] = true
 			}
 		}
@@ -199,15 +657,15 @@ func (a *Analyzer) parseTableList(tableList string) []string {
 		}
 		
 		// エイリアスを除去（table_name AS alias_name または table_name alias_name）
-		aliasPattern := regexp.MustCompile(`^([a-zA-Z_][a-zA-Z0-9_]*(?:\.[a-zA-Z_][a-zA-Z0-9_]*)*)\s+(?:AS\s+)?([a-zA-Z_][a-zA-Z0-9_]*)$`)
+		aliasPattern := regexp.MustCompile(`^` + a.getTableNamePattern() + `\s+(?:AS\s+)?(` + a.getIdentifierPartPattern() + `)$`)
 		if matches := aliasPattern.FindStringSubmatch(part); len(matches) >= 2 {
-			tableName := a.normalizeTableName(matches[1])
+			tableName := a.tableReference(matches[1])
 			tables = append(tables, tableName)
 		} else {
 			// 単純なテーブル名の場合
-			tablePattern := regexp.MustCompile(`^([a-zA-Z_][a-zA-Z0-9_]*(?:\.[a-zA-Z_][a-zA-Z0-9_]*)*)`)
+			tablePattern := regexp.MustCompile(`^` + a.getTableNamePattern())
 			if matches := tablePattern.FindStringSubmatch(part); len(matches) >= 2 {
-				tableName := a.normalizeTableName(matches[1])
+				tableName := a.tableReference(matches[1])
 				tables = append(tables, tableName)
 			}
 		}
@@ -220,14 +678,17 @@ func (a *Analyzer) parseTableList(tableList string) []string {
 func (a *Analyzer) normalizeTableName(tableName string) string {
 	tableName = strings.TrimSpace(tableName)
 	
-	// MySQL/PostgreSQLのクォートを除去
+	// MySQL/PostgreSQLのクォートを除去（schema.tableの各要素を含む）
 	switch a.dialect {
-	case "mysql":
+	case DialectMySQL:
 		// バッククォートを除去
-		tableName = strings.Trim(tableName, "`")
-	case "postgresql":
+		tableName = strings.ReplaceAll(tableName, "`", "")
+	case DialectSQLite:
+		// 角括弧・ダブルクォート・バッククォートを除去
+		tableName = strings.NewReplacer("[", "", "]", "", "\"", "", "`", "").Replace(tableName)
+	default:
 		// ダブルクォートを除去
-		tableName = strings.Trim(tableName, "\"")
+		tableName = strings.ReplaceAll(tableName, "\"", "")
 	}
 	
 	if !a.caseSensitive {
@@ -244,16 +705,25 @@ func (a *Analyzer) isSubquery(text string) bool {
 }
 
 // getTableNamePattern returns the regex pattern for table names based on dialect
+// スキーマ修飾（schema.table）の各要素にクォート識別子を使用可能
 func (a *Analyzer) getTableNamePattern() string {
+	part := a.getIdentifierPartPattern()
+	return `(` + part + `(?:\.` + part + `)*)`
+}
+
+// getIdentifierPartPattern returns the regex pattern for a single identifier part
+func (a *Analyzer) getIdentifierPartPattern() string {
+	const simple = `[a-zA-Z_][a-zA-Z0-9_]*`
+	
 	switch a.dialect {
-	case "mysql":
-		// MySQL: バッククォートでのテーブル名をサポート
-		return `(` + "`" + `[a-zA-Z_][a-zA-Z0-9_]*` + "`" + `|[a-zA-Z_][a-zA-Z0-9_]*(?:\.[a-zA-Z_][a-zA-Z0-9_]*)*)`
-	case "postgresql":
-		// PostgreSQL: ダブルクォートでのテーブル名をサポート
-		return `("[a-zA-Z_][a-zA-Z0-9_]*"|[a-zA-Z_][a-zA-Z0-9_]*(?:\.[a-zA-Z_][a-zA-Z0-9_]*)*)`
+	case DialectMySQL:
+		// MySQL: バッククォートで囲まれた識別子（空白・予約語を含む）をサポート
+		return `(?:` + "`[^`]+`" + `|` + simple + `)`
+	case DialectSQLite:
+		// SQLite: 角括弧・ダブルクォート・バッククォートで囲まれた識別子をサポート
+		return `(?:\[[^\]]+\]|"[^"]+"|` + "`[^`]+`" + `|` + simple + `)`
 	default:
-		// デフォルト（標準SQL）
-		return `([a-zA-Z_][a-zA-Z0-9_]*(?:\.[a-zA-Z_][a-zA-Z0-9_]*)*)`
+		// PostgreSQL・ANSI: ダブルクォートで囲まれた識別子（空白・予約語を含む）をサポート
+		return `(?:"[^"]+"|` + simple + `)`
 	}
-}
\ No newline at end of file
+}
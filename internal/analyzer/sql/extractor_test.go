@@ -1,9 +1,11 @@
 package sql
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/naoyafurudono/sqlc-use-analysis/internal/errors"
+	"github.com/naoyafurudono/sqlc-use-analysis/pkg/types"
 )
 
 func TestExtractFromClause(t *testing.T) {
@@ -136,4 +138,170 @@ func TestParseTableList(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestQuotedIdentifiers(t *testing.T) {
+	tests := []struct {
+		name      string
+		dialect   string
+		sql       string
+		operation types.Operation
+		expected  []string
+	}{
+		{
+			name:      "PostgreSQL quoted name with space",
+			dialect:   "postgresql",
+			sql:       `SELECT * FROM "user data" WHERE id = $1`,
+			operation: types.OpSelect,
+			expected:  []string{"user data"},
+		},
+		{
+			name:      "PostgreSQL reserved word with alias",
+			dialect:   "postgresql",
+			sql:       `SELECT o.id FROM "order" o, users u WHERE o.user_id = u.id`,
+			operation: types.OpSelect,
+			expected:  []string{"order", "users"},
+		},
+		{
+			name:      "PostgreSQL quoted schema-qualified name",
+			dialect:   "postgresql",
+			sql:       `SELECT * FROM "my schema"."user data" AS ud JOIN "group" g ON g.id = ud.group_id`,
+			operation: types.OpSelect,
+			expected:  []string{"my schema.user data", "group"},
+		},
+		{
+			name:      "PostgreSQL UPDATE reserved word",
+			dialect:   "postgresql",
+			sql:       `UPDATE "order" SET status = $1 WHERE id = $2`,
+			operation: types.OpUpdate,
+			expected:  []string{"order"},
+		},
+		{
+			name:      "MySQL quoted name with space",
+			dialect:   "mysql",
+			sql:       "SELECT * FROM `user data` WHERE id = ?",
+			operation: types.OpSelect,
+			expected:  []string{"user data"},
+		},
+		{
+			name:      "MySQL reserved word with alias",
+			dialect:   "mysql",
+			sql:       "SELECT o.id FROM `order` AS o LEFT JOIN `select` s ON s.order_id = o.id",
+			operation: types.OpSelect,
+			expected:  []string{"order", "select"},
+		},
+		{
+			name:      "MySQL INSERT reserved word",
+			dialect:   "mysql",
+			sql:       "INSERT INTO `order` (id, status) VALUES (?, ?)",
+			operation: types.OpInsert,
+			expected:  []string{"order"},
+		},
+		{
+			name:      "SQLite bracketed name with space",
+			dialect:   "sqlite",
+			sql:       "SELECT * FROM [my table]",
+			operation: types.OpSelect,
+			expected:  []string{"my table"},
+		},
+		{
+			name:      "SQLite double-quoted name",
+			dialect:   "sqlite",
+			sql:       `SELECT * FROM "My Table" t JOIN [order] o ON o.table_id = t.id`,
+			operation: types.OpSelect,
+			expected:  []string{"my table", "order"},
+		},
+		{
+			name:      "SQLite backtick name",
+			dialect:   "sqlite",
+			sql:       "DELETE FROM `user data` WHERE id = ?",
+			operation: types.OpDelete,
+			expected:  []string{"user data"},
+		},
+		{
+			name:      "ANSI double-quoted name",
+			dialect:   "ansi",
+			sql:       `UPDATE "user data" SET name = ? WHERE id = ?`,
+			operation: types.OpUpdate,
+			expected:  []string{"user data"},
+		},
+		{
+			name:      "MySQL DELETE quoted schema-qualified name",
+			dialect:   "mysql",
+			sql:       "DELETE FROM `app`.`user data` WHERE id = ?",
+			operation: types.OpDelete,
+			expected:  []string{"app.user data"},
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := NewAnalyzer(tt.dialect, false, errors.NewErrorCollector(10, false))
+			
+			result, err := analyzer.extractTables(tt.sql, tt.operation)
+			if err != nil {
+				t.Fatalf("extractTables() error = %v", err)
+			}
+			
+			if len(result) != len(tt.expected) {
+				t.Errorf("Expected %d tables, got %d: %v", len(tt.expected), len(result), result)
+				return
+			}
+			
+			for _, expected := range tt.expected {
+				found := false
+				for _, actual := range result {
+					if actual == expected {
+						found = true
+						break
+					}
+				}
+				if !found {
+					t.Errorf("Expected table '%s' not found in result: %v", expected, result)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzer_SchemaTables(t *testing.T) {
+	schema := `-- users; accounts
+CREATE TABLE IF NOT EXISTS "Users" (id INT, note TEXT DEFAULT 'a;b');
+CREATE TABLE posts (id INT);
+CREATE UNIQUE INDEX posts_id ON posts (id);
+CREATE TABLE drafts (id INT);
+CREATE FUNCTION f() RETURNS void AS $body$ BEGIN DROP TABLE posts; END; $body$ LANGUAGE plpgsql;
+DROP TABLE IF EXISTS drafts;
+ALTER TABLE posts ADD COLUMN title TEXT`
+	
+	analyzer := NewAnalyzer("postgresql", true, errors.NewErrorCollector(10, false))
+	got := analyzer.SchemaTables(schema)
+	if want := []string{"Users", "posts"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SchemaTables() = %v, want %v", got, want)
+	}
+}
+
+func TestAnalyzer_SchemaRelations(t *testing.T) {
+	schema := `CREATE TABLE users (id INT);
+CREATE TABLE orders (id INT, user_id INT);
+CREATE VIEW active_users AS SELECT id FROM users;
+CREATE OR REPLACE TEMP VIEW recent_orders AS SELECT id FROM orders;
+CREATE MATERIALIZED VIEW IF NOT EXISTS order_totals AS SELECT user_id, count(*) FROM orders GROUP BY user_id;
+CREATE ALGORITHM=MERGE DEFINER=admin SQL SECURITY INVOKER VIEW user_names AS SELECT id FROM users;
+DROP VIEW IF EXISTS recent_orders;
+DROP TABLE orders`
+	
+	analyzer := NewAnalyzer("postgresql", false, errors.NewErrorCollector(10, false))
+	want := []Relation{
+		{Name: "users", Kind: RelationTable},
+		{Name: "active_users", Kind: RelationView},
+		{Name: "order_totals", Kind: RelationMatview},
+		{Name: "user_names", Kind: RelationView},
+	}
+	if got := analyzer.SchemaRelations(schema); !reflect.DeepEqual(got, want) {
+		t.Errorf("SchemaRelations() = %+v, want %+v", got, want)
+	}
+	// Views are not reported as tables
+	if got := analyzer.SchemaTables(schema); !reflect.DeepEqual(got, []string{"users"}) {
+		t.Errorf("SchemaTables() = %v, want [users]", got)
+	}
+}
@@ -0,0 +1,111 @@
+package sql
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode"
+)
+
+// Fingerprint returns a hash that is shared by queries differing only in comments,
+// whitespace, keyword case, literal values or parameter placeholders
+func Fingerprint(sqlText string) string {
+	hash := sha256.Sum256([]byte(fingerprintSQL(sqlText)))
+	return hex.EncodeToString(hash[:8])
+}
+
+// fingerprintSQL returns the normalized form of a query that Fingerprint hashes
+// トークン単位で正規化し、1つの空白で連結する。引用符なしの識別子は大文字小文字を区別しないため大文字にそろえる
+func fingerprintSQL(sqlText string) string {
+	sqlText = sqlcEmbedPattern.ReplaceAllString(sqlText, "$1.*")
+	sqlText = sqlcFuncPattern.ReplaceAllString(sqlText, "?")
+	
+	var tokens []string
+	for i := 0; i < len(sqlText); {
+		c := sqlText[i]
+		rest := sqlText[i:]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.HasPrefix(rest, "--"):
+			// 行コメント
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			i += end
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				i = len(sqlText)
+			} else {
+				i += end + 4
+			}
+		case c == '\'':
+			// 文字列リテラル（''によるエスケープを含む）は値によらず同じトークンにする
+			i += quotedLength(rest, '\'')
+			tokens = append(tokens, "?")
+		case c == '"' || c == '`' || c == '[':
+			// 引用符付きの識別子は大文字小文字を区別するためそのまま残す
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			n := quotedLength(rest, closing)
+			tokens = append(tokens, rest[:n])
+			i += n
+		case c == '?' || ((c == '$' || c == '@') && len(rest) > 1 && isWordByte(rest[1])):
+			// $1、@name、? などのパラメーター
+			n := 1
+			for n < len(rest) && isWordByte(rest[n]) {
+				n++
+			}
+			tokens = append(tokens, "?")
+			i += n
+		case c >= '0' && c <= '9':
+			n := 1
+			for n < len(rest) && (isWordByte(rest[n]) || rest[n] == '.') {
+				n++
+			}
+			tokens = append(tokens, "?")
+			i += n
+		case isWordByte(c):
+			n := 1
+			for n < len(rest) && isWordByte(rest[n]) {
+				n++
+			}
+			tokens = append(tokens, strings.ToUpper(rest[:n]))
+			i += n
+		default:
+			tokens = append(tokens, string(c))
+			i++
+		}
+	}
+	
+	// 末尾のセミコロンの有無は区別しない
+	for len(tokens) > 0 && tokens[len(tokens)-1] == ";" {
+		tokens = tokens[:len(tokens)-1]
+	}
+	return strings.Join(tokens, " ")
+}
+
+// quotedLength returns the length of the quoted token at the start of s, including both quotes
+// A doubled closing quote is an escaped quote; an unterminated token runs to the end of s
+func quotedLength(s string, closing byte) int {
+	for i := 1; i < len(s); i++ {
+		if s[i] != closing {
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == closing && closing != ']' {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return len(s)
+}
+
+// isWordByte reports whether b can be part of an unquoted identifier or keyword
+func isWordByte(b byte) bool {
+	return b == '_' || b >= 0x80 || unicode.IsLetter(rune(b)) || unicode.IsDigit(rune(b))
+}
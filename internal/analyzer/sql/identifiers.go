@@ -0,0 +1,145 @@
+package sql
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/naoyafurudono/sqlc-use-analysis/internal/errors"
+	"github.com/naoyafurudono/sqlc-use-analysis/pkg/types"
+)
+
+// identifierRules are the restrictions a dialect places on unquoted table names
+type identifierRules struct {
+	maxLength int             // 0は制限なし
+	reserved  map[string]bool // クォートなしでは使用できない予約語
+}
+
+// reservedWords builds a reserved word set from a space-separated list
+func reservedWords(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+// commonReservedWords are reserved in every supported dialect
+const commonReservedWords = "ALL AND AS ASC BETWEEN BY CASE CHECK COLUMN CONSTRAINT CREATE CROSS " +
+	"DEFAULT DELETE DESC DISTINCT DROP ELSE EXISTS FOREIGN FROM GROUP HAVING IN INNER INSERT " +
+	"INTO IS JOIN LEFT LIKE NOT NULL ON OR ORDER OUTER PRIMARY REFERENCES RIGHT SELECT SET " +
+	"TABLE THEN TO UNION UNIQUE UPDATE USING VALUES WHEN WHERE WITH"
+
+// dialectIdentifierRules holds the identifier rules per dialect
+// 予約語は代表的なものに限る（各データベースの完全な一覧ではない）
+var dialectIdentifierRules = map[string]identifierRules{
+	DialectPostgreSQL: {
+		maxLength: 63, // NAMEDATALEN - 1
+		reserved: reservedWords(commonReservedWords + " ANALYSE ANALYZE ANY ARRAY ASYMMETRIC BOTH CAST " +
+			"COLLATE CURRENT_CATALOG CURRENT_DATE CURRENT_ROLE CURRENT_TIME CURRENT_TIMESTAMP CURRENT_USER " +
+			"DEFERRABLE DO END EXCEPT FALSE FETCH FOR GRANT INITIALLY INTERSECT LATERAL LEADING LIMIT " +
+			"LOCALTIME LOCALTIMESTAMP OFFSET ONLY PLACING RETURNING SESSION_USER SOME SYMMETRIC TRAILING " +
+			"TRUE USER VARIADIC WINDOW"),
+	},
+	DialectMySQL: {
+		maxLength: 64,
+		reserved: reservedWords(commonReservedWords + " ALTER CONDITION CURRENT_USER DATABASE DUAL " +
+			"EXCEPT FOR FULLTEXT GRANT INDEX INTERVAL KEY KEYS LIMIT LOCK MATCH OPTION RANGE RANK " +
+			"READ RELEASE RENAME REPLACE ROW ROWS SCHEMA SHOW SIGNAL SYSTEM USAGE WINDOW WRITE"),
+	},
+	DialectSQLite: {
+		reserved: reservedWords(commonReservedWords + " ALTER COMMIT DEFERRABLE EXCEPT INDEX " +
+			"INTERSECT LIMIT TRANSACTION TRIGGER VIEW"),
+	},
+	DialectANSI: {
+		maxLength: 128,
+		reserved: reservedWords(commonReservedWords + " ALTER ANY CAST CURRENT_USER END EXCEPT FALSE " +
+			"FETCH FOR GRANT INTERSECT OFFSET ONLY SOME TRUE USER WINDOW"),
+	},
+}
+
+// QuoteIdentifier quotes each part of a possibly schema-qualified name for the dialect
+// MySQLはバッククォート、それ以外は標準SQLの二重引用符を使い、引用符自体は二重にしてエスケープする
+func QuoteIdentifier(name, dialect string) string {
+	quote := `"`
+	if dialect == DialectMySQL {
+		quote = "`"
+	}
+	
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = quote + strings.ReplaceAll(part, quote, quote+quote) + quote
+	}
+	return strings.Join(parts, ".")
+}
+
+// tableReference normalizes a table name matched at a table position and records its spelling
+// 正規化後の名前ではクォートの有無が分からないため、識別子の検査用に元の表記を残す
+func (a *Analyzer) tableReference(raw string) string {
+	a.tableRefs = append(a.tableRefs, strings.TrimSpace(raw))
+	return a.normalizeTableName(raw)
+}
+
+// checkTableIdentifiers warns about the tables of a query whose names break the dialect's identifier rules
+// キーワードを誤検出しないよう、抽出時にテーブル位置で記録した表記だけを検査する
+func (a *Analyzer) checkTableIdentifiers(tableOps []types.TableOperation) {
+	if a.errorCollector == nil || len(tableOps) == 0 {
+		return
+	}
+	
+	tables := make(map[string]bool, len(tableOps))
+	for _, tableOp := range tableOps {
+		tables[tableOp.TableName] = true
+	}
+	
+	checked := make(map[string]bool)
+	for _, name := range a.tableRefs {
+		if checked[name] || !tables[a.normalizeTableName(name)] {
+			continue
+		}
+		checked[name] = true
+		a.checkTableIdentifier(name)
+	}
+}
+
+// checkTableIdentifier warns when a table name breaks the dialect's identifier rules
+// Reserved words are only reported when unquoted; each name is reported once per analyzer
+func (a *Analyzer) checkTableIdentifier(tableName string) {
+	if a.errorCollector == nil {
+		return
+	}
+	
+	rules, ok := dialectIdentifierRules[a.dialect]
+	if !ok {
+		rules = dialectIdentifierRules[DialectANSI]
+	}
+	
+	// schema.tableの各要素を個別に検査する
+	for _, part := range regexp.MustCompile(a.getIdentifierPartPattern()).FindAllString(tableName, -1) {
+		name := strings.Trim(part, "`\"[]")
+		quoted := name != part
+		
+		var message string
+		switch {
+		case rules.maxLength > 0 && len(name) > rules.maxLength:
+			message = fmt.Sprintf("table name '%s' exceeds the %d-character identifier limit of %s",
+				name, rules.maxLength, a.dialect)
+		case !quoted && rules.reserved[strings.ToUpper(name)]:
+			message = fmt.Sprintf("table name '%s' is a reserved word in %s and must be quoted", name, a.dialect)
+		default:
+			continue
+		}
+		
+		if a.reportedNames[message] {
+			continue
+		}
+		if a.reportedNames == nil {
+			a.reportedNames = make(map[string]bool)
+		}
+		a.reportedNames[message] = true
+		
+		details := errors.TableDetails(name)
+		details["dialect"] = a.dialect
+		errors.NewErrorReporter(a.errorCollector).ReportWarning(errors.CategoryValidation, message, details)
+	}
+}
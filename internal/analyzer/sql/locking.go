@@ -0,0 +1,51 @@
+package sql
+
+import (
+	"regexp"
+	"strings"
+)
+
+// lockingClausePattern matches the row-locking clauses of a SELECT
+var lockingClausePattern = regexp.MustCompile(`(?i)\bFOR\s+(NO\s+KEY\s+UPDATE|UPDATE|KEY\s+SHARE|SHARE)\b`)
+
+// lockingOptionPattern matches the wait policy that may follow a locking clause
+var lockingOptionPattern = regexp.MustCompile(`(?i)\s*(?:NOWAIT|SKIP\s+LOCKED)?\s*;?\s*$`)
+
+// extractLocking returns the row lock a SELECT takes on each table, e.g. "FOR UPDATE"
+// OF句がない場合は最外側のFROM/JOINのすべてのテーブルをロック対象とする
+func (a *Analyzer) extractLocking(sqlText string) map[string]string {
+	outer, _ := splitSubqueries(normalizeSQL(sqlText))
+	clauses := lockingClausePattern.FindAllStringSubmatchIndex(outer, -1)
+	if len(clauses) == 0 {
+		return nil
+	}
+	
+	aliases, tables := a.tableAliases(outer)
+	locks := make(map[string]string)
+	for i, loc := range clauses {
+		mode := "FOR " + strings.Join(strings.Fields(strings.ToUpper(submatch(outer, loc, 1))), " ")
+		
+		// 次のロック句までがこの句の範囲（FOR UPDATE OF a FOR SHARE OF b）
+		end := len(outer)
+		if i+1 < len(clauses) {
+			end = clauses[i+1][0]
+		}
+		rest := strings.TrimSpace(lockingOptionPattern.ReplaceAllString(outer[loc[1]:end], ""))
+		
+		targets := tables
+		if of := regexp.MustCompile(`(?i)^OF\s+(.+)$`).FindStringSubmatch(rest); of != nil {
+			targets = nil
+			for _, name := range strings.Split(of[1], ",") {
+				if table, ok := aliases[a.normalizeTableName(strings.TrimSpace(name))]; ok {
+					targets = append(targets, table)
+				}
+			}
+		}
+		for _, table := range targets {
+			if _, exists := locks[table]; !exists {
+				locks[table] = mode
+			}
+		}
+	}
+	return locks
+}
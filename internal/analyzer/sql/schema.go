@@ -0,0 +1,159 @@
+package sql
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/naoyafurudono/sqlc-use-analysis/pkg/types"
+)
+
+var (
+	// lineCommentPattern matches a "--" comment up to the end of the line
+	lineCommentPattern = regexp.MustCompile(`--[^\n]*`)
+	// createTablePattern and dropTablePattern match the statements that change the catalog
+	createTablePattern = regexp.MustCompile(`(?i)^CREATE\s+(?:OR\s+REPLACE\s+)?(?:(?:GLOBAL|LOCAL)\s+)?(?:TEMP(?:ORARY)?\s+|UNLOGGED\s+)?TABLE\b`)
+	dropTablePattern   = regexp.MustCompile(`(?i)^DROP\s+(?:TEMPORARY\s+)?TABLE\b`)
+	// createViewPattern and dropViewPattern match views; group 1 is set for materialized views
+	// MySQLのALGORITHM=...やDEFINER=...、SQL SECURITY句も許容する
+	createViewPattern = regexp.MustCompile(`(?i)^CREATE\s+(?:OR\s+REPLACE\s+)?(?:[A-Z_]+\s*=\s*\S+\s+|SQL\s+SECURITY\s+[A-Z]+\s+|TEMP(?:ORARY)?\s+|RECURSIVE\s+)*(MATERIALIZED\s+)?VIEW\s+(?:IF\s+NOT\s+EXISTS\s+)?`)
+	dropViewPattern   = regexp.MustCompile(`(?i)^DROP\s+(MATERIALIZED\s+)?VIEW\s+(?:IF\s+EXISTS\s+)?`)
+)
+
+// Kinds of relations defined in a schema
+const (
+	RelationTable   = "table"
+	RelationView    = "view"
+	RelationMatview = "matview"
+)
+
+// Relation is a table or view defined in a schema
+type Relation struct {
+	Name string
+	Kind string // RelationTable, RelationView or RelationMatview
+}
+
+// SchemaTables returns the tables a schema file defines, in the order they are created
+// Views are not included, see SchemaRelations
+func (a *Analyzer) SchemaTables(schema string) []string {
+	var tables []string
+	for _, relation := range a.SchemaRelations(schema) {
+		if relation.Kind == RelationTable {
+			tables = append(tables, relation.Name)
+		}
+	}
+	return tables
+}
+
+// SchemaRelations returns the tables and views a schema file defines, in the order they are created
+// CREATEで追加し、DROPで削除する（マイグレーションを順に適用した結果）
+func (a *Analyzer) SchemaRelations(schema string) []Relation {
+	var relations []Relation
+	for _, statement := range splitStatements(lineCommentPattern.ReplaceAllString(schema, "")) {
+		normalizedSQL := normalizeSQL(statement)
+		switch {
+		case createTablePattern.MatchString(normalizedSQL):
+			names, err := a.extractDDLTables(normalizedSQL, types.OpCreate)
+			if err == nil {
+				relations = addRelation(relations, Relation{Name: names[0], Kind: RelationTable})
+			}
+		case dropTablePattern.MatchString(normalizedSQL):
+			names, err := a.extractDDLTables(normalizedSQL, types.OpDrop)
+			if err == nil {
+				relations = removeRelations(relations, names)
+			}
+		case createViewPattern.MatchString(normalizedSQL):
+			matches := createViewPattern.FindStringSubmatchIndex(normalizedSQL)
+			kind := RelationView
+			if matches[2] >= 0 {
+				kind = RelationMatview
+			}
+			if names := a.relationNames(normalizedSQL[matches[1]:]); len(names) > 0 {
+				relations = addRelation(relations, Relation{Name: names[0], Kind: kind})
+			}
+		case dropViewPattern.MatchString(normalizedSQL):
+			loc := dropViewPattern.FindStringIndex(normalizedSQL)
+			relations = removeRelations(relations, a.relationNames(normalizedSQL[loc[1]:]))
+		}
+	}
+	return relations
+}
+
+// relationNames returns the comma-separated relation names at the start of list
+func (a *Analyzer) relationNames(list string) []string {
+	namePattern := regexp.MustCompile(`^\s*` + a.getTableNamePattern())
+	var names []string
+	for _, part := range strings.Split(list, ",") {
+		matches := namePattern.FindStringSubmatch(part)
+		if len(matches) < 2 {
+			break
+		}
+		names = appendUnique(names, a.tableReference(matches[1]))
+	}
+	return names
+}
+
+// addRelation appends relation, replacing an earlier relation of the same name
+func addRelation(relations []Relation, relation Relation) []Relation {
+	for i, existing := range relations {
+		if existing.Name == relation.Name {
+			relations[i] = relation
+			return relations
+		}
+	}
+	return append(relations, relation)
+}
+
+// removeRelations returns relations without the given names
+func removeRelations(relations []Relation, names []string) []Relation {
+	var kept []Relation
+	for _, relation := range relations {
+		if !containsName(names, relation.Name) {
+			kept = append(kept, relation)
+		}
+	}
+	return kept
+}
+
+// containsName reports whether names contains name
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// splitStatements splits SQL text on semicolons outside of string literals,
+// quoted identifiers and dollar-quoted bodies
+func splitStatements(sqlText string) []string {
+	var statements []string
+	start := 0
+	for i := 0; i < len(sqlText); i++ {
+		switch c := sqlText[i]; c {
+		case '\'', '"', '`':
+			if end := strings.IndexByte(sqlText[i+1:], c); end >= 0 {
+				i += end + 1
+			}
+		case '$':
+			// $$ ... $$ や $body$ ... $body$ の関数本体
+			if tag := regexp.MustCompile(`^\$[a-zA-Z_]*\$`).FindString(sqlText[i:]); tag != "" {
+				if end := strings.Index(sqlText[i+len(tag):], tag); end >= 0 {
+					i += len(tag) + end + len(tag) - 1
+				}
+			}
+		case ';':
+			statements = appendStatement(statements, sqlText[start:i])
+			start = i + 1
+		}
+	}
+	return appendStatement(statements, sqlText[start:])
+}
+
+// appendStatement appends a trimmed statement unless it is empty
+func appendStatement(statements []string, statement string) []string {
+	if statement = strings.TrimSpace(statement); statement != "" {
+		statements = append(statements, statement)
+	}
+	return statements
+}
@@ -0,0 +1,82 @@
+package sql
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/naoyafurudono/sqlc-use-analysis/pkg/types"
+)
+
+var (
+	// intoKeywordPattern and fromKeywordPattern locate the INTO and FROM clauses of a SELECT
+	intoKeywordPattern = regexp.MustCompile(`(?i)\bINTO\b`)
+	fromKeywordPattern = regexp.MustCompile(`(?i)\bFROM\b`)
+)
+
+// isSelectInto reports whether a statement is a PostgreSQL "SELECT ... INTO table"
+// MySQLのSELECT ... INTO @var や INTO OUTFILE はテーブルを作らないため対象外とする
+func (a *Analyzer) isSelectInto(sqlText string) bool {
+	if a.dialect != DialectPostgreSQL {
+		return false
+	}
+	outer, _ := splitSubqueries(normalizeSQL(sqlText))
+	if !regexp.MustCompile(`(?i)^SELECT\b`).MatchString(outer) {
+		return false
+	}
+	
+	// 射影とFROM句の間にあるINTOのみを見る（FROMのないSELECT ... INTOも許容する）
+	into := intoKeywordPattern.FindStringIndex(outer)
+	if into == nil {
+		return false
+	}
+	from := fromKeywordPattern.FindStringIndex(outer)
+	return from == nil || into[0] < from[0]
+}
+
+// selectIntoPattern matches the INTO clause of a SELECT; group 1 is the new table
+func (a *Analyzer) selectIntoPattern() *regexp.Regexp {
+	return regexp.MustCompile(`(?i)\s+INTO\s+(?:(?:TEMP|TEMPORARY|UNLOGGED)\s+)?(?:TABLE\s+)?` + a.getTableNamePattern())
+}
+
+// analyzeSelectInto attributes INSERT to the table a SELECT ... INTO creates and SELECT to the tables it reads
+// INTO句を除いたクエリを通常のSELECTとして解析する
+func (a *Analyzer) analyzeSelectInto(sqlText string) ([]types.TableOperation, error) {
+	normalizedSQL := normalizeSQL(sqlText)
+	loc := a.selectIntoPattern().FindStringSubmatchIndex(normalizedSQL)
+	if loc == nil {
+		return nil, fmt.Errorf("unrecognized SELECT INTO statement: %s", sqlText)
+	}
+	target := a.tableReference(normalizedSQL[loc[2]:loc[3]])
+	query := normalizedSQL[:loc[0]] + normalizedSQL[loc[1]:]
+	
+	tables, err := a.extractTablesFromSelect(query)
+	if err != nil {
+		return nil, err
+	}
+	columns := a.extractSelectColumns(query)
+	
+	tableOps := []types.TableOperation{{
+		TableName:  target,
+		Operations: []string{string(types.OpInsert)},
+	}}
+	for _, table := range removeDuplicates(tables) {
+		if table == target {
+			continue
+		}
+		tableOps = append(tableOps, types.TableOperation{
+			TableName:  table,
+			Operations: []string{string(types.OpSelect)},
+			Columns:    columns[table],
+		})
+	}
+	
+	// 参照の区別はReadContextEdgesが有効な場合のみ記録する（参照のみのテーブルは列を持たない）
+	if a.readContext {
+		tableOps[0].Kind = types.AccessPrimary
+		for i := 1; i < len(tableOps); i++ {
+			tableOps[i].Kind = types.AccessReference
+			tableOps[i].Columns = nil
+		}
+	}
+	return tableOps, nil
+}
@@ -30,6 +30,7 @@ func DefaultConfig() *types.Config {
 			IncludeMetadata: true,
 			IncludeDetails:  false,
 			Pretty:          true,
+			PrimaryView:     types.ViewBoth,
 		},
 		Performance: types.PerformanceConfig{
 			MaxWorkers:     runtime.NumCPU(),
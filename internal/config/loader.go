@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/naoyafurudono/sqlc-use-analysis/internal/analyzer/sql"
 	"github.com/naoyafurudono/sqlc-use-analysis/pkg/types"
 )
 
@@ -26,8 +27,32 @@ func NewConfigLoader() *ConfigLoader {
 
 // CodeGeneratorRequest represents a simplified version of sqlc's request
 type CodeGeneratorRequest struct {
-	Settings map[string]interface{} `json:"settings"`
-	Queries  []interface{}          `json:"queries"`
+	Settings    map[string]interface{} `json:"settings"` // プラグインオプション
+	Queries     []Query                `json:"queries"`
+	Engine      string                 `json:"engine,omitempty"`
+	Catalog     *Catalog               `json:"catalog,omitempty"`
+	SQLCVersion string                 `json:"sqlc_version,omitempty"`
+}
+
+// Catalog represents the schema catalog parsed by sqlc
+type Catalog struct {
+	DefaultSchema string      `json:"default_schema"`
+	Tables        []TableName `json:"tables"`
+}
+
+// TableName identifies a table in the catalog
+type TableName struct {
+	Schema string `json:"schema"`
+	Name   string `json:"name"`
+}
+
+// Query represents a query in sqlc's request payload
+type Query struct {
+	Name     string `json:"name"`
+	Cmd      string `json:"cmd"`      // ":one", ":many", ":exec" など
+	Text     string `json:"text"`
+	Filename string `json:"filename"`
+	Comments []string `json:"comments,omitempty"` // sqlcがクエリ本体から取り除いたコメント（プラグインモード）
 }
 
 // LoadFromRequest loads configuration from a CodeGeneratorRequest
@@ -83,6 +108,53 @@ func (cl *ConfigLoader) loadFromEnv(config *types.Config) error {
 		config.Exclude = strings.Split(v, ",")
 	}
 	
+	// 解析設定
+	if v := os.Getenv(cl.envPrefix + "SQL_DIALECT"); v != "" {
+		config.Analysis.SQLDialect = v
+	}
+	
+	// メソッド名の接頭辞（カンマ区切り）
+	if v := os.Getenv(cl.envPrefix + "METHOD_PREFIXES"); v != "" {
+		config.Analysis.MethodPrefixes = splitList(v)
+	}
+	
+	if v := os.Getenv(cl.envPrefix + "INCLUDE_VENDOR"); v != "" {
+		config.Analysis.IncludeVendor = v == "true" || v == "1"
+	}
+	
+	// 解析する依存パッケージの接頭辞（カンマ区切り）
+	if v := os.Getenv(cl.envPrefix + "FOLLOW_PACKAGES"); v != "" {
+		config.Analysis.FollowPackages = strings.Split(v, ",")
+	}
+	
+	// メソッド名の略語（カンマ区切り）
+	if v := os.Getenv(cl.envPrefix + "ACRONYMS"); v != "" {
+		config.Analysis.Acronyms = strings.Split(v, ",")
+	}
+	
+	if v := os.Getenv(cl.envPrefix + "INCLUDE_DDL"); v != "" {
+		config.Analysis.IncludeDDL = v == "true" || v == "1"
+	}
+	
+	if v := os.Getenv(cl.envPrefix + "READ_CONTEXT_EDGES"); v != "" {
+		config.Analysis.ReadContextEdges = v == "true" || v == "1"
+	}
+	
+	if v := os.Getenv(cl.envPrefix + "RAW_SQL"); v != "" {
+		config.Analysis.RawSQL = v == "true" || v == "1"
+	}
+	
+	if v := os.Getenv(cl.envPrefix + "SKIP_GENERATED_FILES"); v != "" {
+		config.Analysis.SkipGeneratedFiles = v == "true" || v == "1"
+	}
+	
+	if v := os.Getenv(cl.envPrefix + "INCLUDE_DRIVER_CALLS"); v != "" {
+		config.Analysis.IncludeDriverCalls = v == "true" || v == "1"
+	}
+	if v := os.Getenv(cl.envPrefix + "STRICT_TYPES"); v != "" {
+		config.Analysis.StrictTypes = v == "true" || v == "1"
+	}
+	
 	// パフォーマンス設定
 	if v := os.Getenv(cl.envPrefix + "MAX_WORKERS"); v != "" {
 		if workers, err := strconv.Atoi(v); err == nil {
@@ -90,14 +162,48 @@ func (cl *ConfigLoader) loadFromEnv(config *types.Config) error {
 		}
 	}
 	
+	if v := os.Getenv(cl.envPrefix + "LOAD_RETRIES"); v != "" {
+		if retries, err := strconv.Atoi(v); err == nil {
+			config.Performance.LoadRetries = retries
+		}
+	}
+	
+	if v := os.Getenv(cl.envPrefix + "MEMORY_LIMIT_MB"); v != "" {
+		if limit, err := strconv.Atoi(v); err == nil {
+			config.Performance.MemoryLimit = limit
+		}
+	}
+	
 	// デバッグ設定
 	if v := os.Getenv(cl.envPrefix + "VERBOSE"); v != "" {
 		config.Debug.Verbose = v == "true" || v == "1"
 	}
 	
+	if v := os.Getenv(cl.envPrefix + "MAX_DETAIL_LENGTH"); v != "" {
+		if length, err := strconv.Atoi(v); err == nil {
+			config.Debug.MaxDetailLength = length
+		}
+	}
+	
+	if v := os.Getenv(cl.envPrefix + "COUNT_ONLY_ERRORS"); v != "" {
+		config.Debug.CountOnlyErrors = v == "true" || v == "1"
+	}
+	
 	return nil
 }
 
+// splitList splits a comma-separated value, trimming spaces and dropping empty entries
+// "Fetch, Save" のように空白を含む指定も受け付ける
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func (cl *ConfigLoader) validate(config *types.Config) error {
 	if config.RootPath == "" {
 		return fmt.Errorf("root_path cannot be empty")
@@ -111,9 +217,54 @@ func (cl *ConfigLoader) validate(config *types.Config) error {
 		return fmt.Errorf("max_workers must be at least 1")
 	}
 	
+	if config.Performance.LoadRetries < 0 {
+		return fmt.Errorf("load_retries cannot be negative")
+	}
+	
+	if config.Performance.MemoryLimit < 0 {
+		return fmt.Errorf("memory_limit_mb cannot be negative")
+	}
+	
+	if !isSupportedDialect(config.Analysis.SQLDialect) {
+		return fmt.Errorf("sql_dialect must be one of '%s', got '%s'",
+			strings.Join(sql.SupportedDialects(), "', '"), config.Analysis.SQLDialect)
+	}
+	
+	if config.Debug.MaxDetailLength < 0 {
+		return fmt.Errorf("max_detail_length cannot be negative")
+	}
+	
+	switch config.Output.Format {
+	case "", types.FormatJSON, types.FormatCSV, types.FormatMarkdown:
+	default:
+		return fmt.Errorf("format must be one of 'json', 'csv' or 'markdown', got '%s'", config.Output.Format)
+	}
+	
+	if config.Output.PrimaryView != "" && !config.Output.PrimaryView.IsValid() {
+		return fmt.Errorf("primary_view must be one of 'function', 'table' or 'both', got '%s'", config.Output.PrimaryView)
+	}
+	
+	if config.Output.OperationStyle != "" && !config.Output.OperationStyle.IsValid() {
+		return fmt.Errorf("operation_style must be one of 'sql' or 'crud', got '%s'", config.Output.OperationStyle)
+	}
+	
+	if config.Output.SplitFiles && config.Output.Format != "" && config.Output.Format != types.FormatJSON {
+		return fmt.Errorf("split_files requires the json format, got '%s'", config.Output.Format)
+	}
+	
 	return nil
 }
 
+// isSupportedDialect reports whether the SQL analyzer understands the dialect
+func isSupportedDialect(dialect string) bool {
+	for _, supported := range sql.SupportedDialects() {
+		if dialect == supported {
+			return true
+		}
+	}
+	return false
+}
+
 func (cl *ConfigLoader) normalize(config *types.Config) {
 	// パスの正規化は後で実装
 	// 今はそのまま
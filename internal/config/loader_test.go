@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"reflect"
 	"testing"
 	
 	"github.com/naoyafurudono/sqlc-use-analysis/pkg/types"
@@ -19,7 +20,7 @@ func TestConfigLoader_LoadFromRequest(t *testing.T) {
 			name: "default config",
 			request: &CodeGeneratorRequest{
 				Settings: make(map[string]interface{}),
-				Queries:  []interface{}{},
+				Queries:  []Query{},
 			},
 			want: func(t *testing.T, cfg *types.Config) {
 				if cfg.RootPath != "." {
@@ -36,7 +37,7 @@ func TestConfigLoader_LoadFromRequest(t *testing.T) {
 				Settings: map[string]interface{}{
 					"root_path": "/custom/path",
 				},
-				Queries: []interface{}{},
+				Queries: []Query{},
 			},
 			want: func(t *testing.T, cfg *types.Config) {
 				if cfg.RootPath != "/custom/path" {
@@ -50,7 +51,7 @@ func TestConfigLoader_LoadFromRequest(t *testing.T) {
 				Settings: map[string]interface{}{
 					"root_path": "/from/options",
 				},
-				Queries: []interface{}{},
+				Queries: []Query{},
 			},
 			env: map[string]string{
 				"SQLC_ANALYZER_ROOT_PATH": "/from/env",
@@ -61,13 +62,122 @@ func TestConfigLoader_LoadFromRequest(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "primary view option",
+			request: &CodeGeneratorRequest{
+				Settings: map[string]interface{}{
+					"output": map[string]interface{}{
+						"primary_view": "table",
+					},
+				},
+				Queries: []Query{},
+			},
+			want: func(t *testing.T, cfg *types.Config) {
+				if cfg.Output.PrimaryView != types.ViewTable {
+					t.Errorf("Expected PrimaryView to be 'table', got '%s'", cfg.Output.PrimaryView)
+				}
+			},
+		},
+		{
+			name: "markdown output format",
+			request: &CodeGeneratorRequest{
+				Settings: map[string]interface{}{
+					"output": map[string]interface{}{
+						"format": "markdown",
+					},
+				},
+				Queries: []Query{},
+			},
+			want: func(t *testing.T, cfg *types.Config) {
+				if cfg.Output.Format != types.FormatMarkdown {
+					t.Errorf("Expected Format to be 'markdown', got '%s'", cfg.Output.Format)
+				}
+			},
+		},
+		{
+			name: "sqlite dialect option",
+			request: &CodeGeneratorRequest{
+				Settings: map[string]interface{}{
+					"analysis": map[string]interface{}{
+						"sql_dialect": "sqlite",
+					},
+				},
+				Queries: []Query{},
+			},
+			want: func(t *testing.T, cfg *types.Config) {
+				if cfg.Analysis.SQLDialect != "sqlite" {
+					t.Errorf("Expected SQLDialect to be 'sqlite', got '%s'", cfg.Analysis.SQLDialect)
+				}
+			},
+		},
+		{
+			name: "invalid config - unknown dialect",
+			request: &CodeGeneratorRequest{
+				Settings: map[string]interface{}{
+					"analysis": map[string]interface{}{
+						"sql_dialect": "oracle",
+					},
+				},
+				Queries: []Query{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid config - unknown output format",
+			request: &CodeGeneratorRequest{
+				Settings: map[string]interface{}{
+					"output": map[string]interface{}{
+						"format": "xml",
+					},
+				},
+				Queries: []Query{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid config - unknown primary view",
+			request: &CodeGeneratorRequest{
+				Settings: map[string]interface{}{
+					"output": map[string]interface{}{
+						"primary_view": "columns",
+					},
+				},
+				Queries: []Query{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid config - unknown operation style",
+			request: &CodeGeneratorRequest{
+				Settings: map[string]interface{}{
+					"output": map[string]interface{}{
+						"operation_style": "rest",
+					},
+				},
+				Queries: []Query{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid config - split files with csv",
+			request: &CodeGeneratorRequest{
+				Settings: map[string]interface{}{
+					"output": map[string]interface{}{
+						"format":      "csv",
+						"split_files": true,
+					},
+				},
+				Queries: []Query{},
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalid config - empty root path",
 			request: &CodeGeneratorRequest{
 				Settings: map[string]interface{}{
 					"root_path": "",
 				},
-				Queries: []interface{}{},
+				Queries: []Query{},
 			},
 			wantErr: true,
 		},
@@ -134,4 +244,20 @@ func TestConfigLoader_loadFromEnv(t *testing.T) {
 	if !config.Debug.Verbose {
 		t.Error("Expected Verbose to be true")
 	}
+}
+
+func TestConfigLoader_loadFromEnv_MethodPrefixes(t *testing.T) {
+	loader := NewConfigLoader()
+	config := DefaultConfig()
+	
+	t.Setenv("SQLC_ANALYZER_METHOD_PREFIXES", "Fetch, Save,, ")
+	
+	if err := loader.loadFromEnv(config); err != nil {
+		t.Fatalf("loadFromEnv() error = %v", err)
+	}
+	
+	want := []string{"Fetch", "Save"}
+	if !reflect.DeepEqual(config.Analysis.MethodPrefixes, want) {
+		t.Errorf("MethodPrefixes = %q, want %q", config.Analysis.MethodPrefixes, want)
+	}
 }
\ No newline at end of file
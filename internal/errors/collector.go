@@ -3,15 +3,21 @@ package errors
 import (
 	"fmt"
 	"sync"
+	"unicode/utf8"
 )
 
 // ErrorCollector collects and manages errors during analysis
 type ErrorCollector struct {
 	errors     []*AnalysisError
 	warnings   []*AnalysisError
+	infos      []*AnalysisError
 	mu         sync.Mutex
 	maxErrors  int
 	stopOnFatal bool
+	maxDetailLength int // 0は無制限
+	countOnly         bool // 上限到達後は件数のみを数える
+	dropped           int  // 上限到達後に保存しなかったエラーの件数
+	droppedByCategory map[ErrorCategory]int
 }
 
 // NewErrorCollector creates a new error collector
@@ -19,16 +25,54 @@ func NewErrorCollector(maxErrors int, stopOnFatal bool) *ErrorCollector {
 	return &ErrorCollector{
 		errors:      make([]*AnalysisError, 0),
 		warnings:    make([]*AnalysisError, 0),
+		infos:       make([]*AnalysisError, 0),
 		maxErrors:   maxErrors,
 		stopOnFatal: stopOnFatal,
 	}
 }
 
+// SetMaxDetailLength caps the length of string details stored with each error
+// Longer values are truncated with an ellipsis and marked with "truncated": true; 0 disables the cap
+func (ec *ErrorCollector) SetMaxDetailLength(length int) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	ec.maxDetailLength = length
+}
+
+// SetCountOnly keeps analysis going past the maxErrors cap
+// Once the cap is reached errors are no longer stored or reported as "too many
+// errors", but they are still counted in the summary totals
+func (ec *ErrorCollector) SetCountOnly(enabled bool) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	ec.countOnly = enabled
+}
+
+// WithLimits returns an empty collector with the given limits
+// Other settings such as count-only mode and the detail length cap are carried over
+func (ec *ErrorCollector) WithLimits(maxErrors int, stopOnFatal bool) *ErrorCollector {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	
+	collector := NewErrorCollector(maxErrors, stopOnFatal)
+	collector.countOnly = ec.countOnly
+	collector.maxDetailLength = ec.maxDetailLength
+	return collector
+}
+
 // Add adds an error to the collector
+// At most maxErrors SeverityError entries are stored: the Add that stores the
+// maxErrors-th error returns a "too many errors" error, and so does every later
+// Add, whose errors are dropped. Fatal errors are always stored.
+// In count-only mode no error is returned and dropped errors are only counted.
 func (ec *ErrorCollector) Add(err *AnalysisError) error {
 	ec.mu.Lock()
 	defer ec.mu.Unlock()
 	
+	if ec.maxDetailLength > 0 {
+		ec.truncateDetails(err)
+	}
+	
 	switch err.Severity {
 	case SeverityFatal:
 		ec.errors = append(ec.errors, err)
@@ -36,17 +80,59 @@ func (ec *ErrorCollector) Add(err *AnalysisError) error {
 			return err // 即座に処理を停止
 		}
 	case SeverityError:
+		// 上限に達した後のエラーは保存しない
+		if len(ec.errors) >= ec.maxErrors {
+			if ec.countOnly {
+				ec.countDropped(err)
+				return nil
+			}
+			return fmt.Errorf("too many errors: %d", len(ec.errors))
+		}
 		ec.errors = append(ec.errors, err)
-		if len(ec.errors) > ec.maxErrors {
+		if len(ec.errors) >= ec.maxErrors && !ec.countOnly {
 			return fmt.Errorf("too many errors: %d", len(ec.errors))
 		}
 	case SeverityWarning:
 		ec.warnings = append(ec.warnings, err)
+	case SeverityInfo:
+		ec.infos = append(ec.infos, err)
 	}
 	
 	return nil
 }
 
+// countDropped records an error that was not stored because the cap was reached
+func (ec *ErrorCollector) countDropped(err *AnalysisError) {
+	if ec.droppedByCategory == nil {
+		ec.droppedByCategory = make(map[ErrorCategory]int)
+	}
+	ec.dropped++
+	ec.droppedByCategory[err.Category]++
+}
+
+// truncateDetails shortens string details exceeding the maximum length
+func (ec *ErrorCollector) truncateDetails(err *AnalysisError) {
+	truncated := false
+	for key, value := range err.Details {
+		str, ok := value.(string)
+		if !ok || len(str) <= ec.maxDetailLength {
+			continue
+		}
+		
+		// マルチバイト文字の途中で切らない
+		cut := ec.maxDetailLength
+		for cut > 0 && !utf8.RuneStart(str[cut]) {
+			cut--
+		}
+		err.Details[key] = str[:cut] + "..."
+		truncated = true
+	}
+	
+	if truncated {
+		err.Details["truncated"] = true
+	}
+}
+
 // HasErrors returns true if there are any errors
 func (ec *ErrorCollector) HasErrors() bool {
 	ec.mu.Lock()
@@ -74,6 +160,17 @@ func (ec *ErrorCollector) GetWarnings() []*AnalysisError {
 	return result
 }
 
+// GetInfos returns all informational findings
+// Infos are not counted as errors or warnings
+func (ec *ErrorCollector) GetInfos() []*AnalysisError {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	
+	result := make([]*AnalysisError, len(ec.infos))
+	copy(result, ec.infos)
+	return result
+}
+
 // ErrorReport represents a complete error report
 type ErrorReport struct {
 	Errors   []*AnalysisError `json:"errors"`
@@ -82,9 +179,11 @@ type ErrorReport struct {
 }
 
 // ErrorSummary provides a summary of errors
+// Totals include errors counted but not stored in count-only mode
 type ErrorSummary struct {
 	TotalErrors   int                      `json:"total_errors"`
 	TotalWarnings int                      `json:"total_warnings"`
+	StoredErrors  int                      `json:"stored_errors"`
 	ByCategory    map[ErrorCategory]int    `json:"by_category"`
 	BySeverity    map[ErrorSeverity]int    `json:"by_severity"`
 }
@@ -103,8 +202,9 @@ func (ec *ErrorCollector) GetReport() *ErrorReport {
 
 func (ec *ErrorCollector) generateSummary() ErrorSummary {
 	summary := ErrorSummary{
-		TotalErrors:   len(ec.errors),
+		TotalErrors:   len(ec.errors) + ec.dropped,
 		TotalWarnings: len(ec.warnings),
+		StoredErrors:  len(ec.errors),
 		ByCategory:    make(map[ErrorCategory]int),
 		BySeverity:    make(map[ErrorSeverity]int),
 	}
@@ -115,6 +215,14 @@ func (ec *ErrorCollector) generateSummary() ErrorSummary {
 		summary.BySeverity[err.Severity]++
 	}
 	
+	// 保存しなかったエラーの集計
+	for category, count := range ec.droppedByCategory {
+		summary.ByCategory[category] += count
+	}
+	if ec.dropped > 0 {
+		summary.BySeverity[SeverityError] += ec.dropped
+	}
+	
 	// 警告の集計
 	for _, warn := range ec.warnings {
 		summary.ByCategory[warn.Category]++
@@ -171,4 +279,7 @@ func (ec *ErrorCollector) Clear() {
 	
 	ec.errors = make([]*AnalysisError, 0)
 	ec.warnings = make([]*AnalysisError, 0)
+	ec.infos = make([]*AnalysisError, 0)
+	ec.dropped = 0
+	ec.droppedByCategory = nil
 }
\ No newline at end of file
@@ -1,6 +1,7 @@
 package errors
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -37,26 +38,90 @@ func TestErrorCollector_Add(t *testing.T) {
 	}
 }
 
+func TestErrorCollector_Infos(t *testing.T) {
+	collector := NewErrorCollector(10, false)
+	
+	info := NewError(CategoryAnalysis, SeverityInfo, "test info")
+	if err := collector.Add(info); err != nil {
+		t.Errorf("Add() error = %v", err)
+	}
+	
+	if infos := collector.GetInfos(); len(infos) != 1 {
+		t.Errorf("Expected 1 info, got %d", len(infos))
+	}
+	
+	// 情報はエラー・警告に含めない
+	if collector.Count() != 0 || collector.HasErrors() || collector.HasWarnings() {
+		t.Error("Expected infos not to count as errors or warnings")
+	}
+	
+	collector.Clear()
+	if infos := collector.GetInfos(); len(infos) != 0 {
+		t.Errorf("Expected infos to be cleared, got %d", len(infos))
+	}
+}
+
 func TestErrorCollector_MaxErrors(t *testing.T) {
 	collector := NewErrorCollector(2, false)
 	
-	// 最大数までエラーを追加
-	for i := 0; i < 2; i++ {
-		err := NewError(CategoryAnalysis, SeverityError, "test error")
-		if addErr := collector.Add(err); addErr != nil {
-			t.Errorf("Add() error = %v", addErr)
-		}
+	// 上限未満のエラーは通常通り追加される
+	if addErr := collector.Add(NewError(CategoryAnalysis, SeverityError, "first error")); addErr != nil {
+		t.Errorf("Add() error = %v", addErr)
 	}
 	
-	// 最大数に達しているので、次のエラーは追加できるはず（まだ境界値）
-	// 実際は maxErrors に達した時点でエラーを返すので、実装を確認
+	// 上限に達したエラーは保存された上で、その追加がエラーを返す
+	if addErr := collector.Add(NewError(CategoryAnalysis, SeverityError, "second error")); addErr == nil {
+		t.Error("Expected error when the error count reaches maxErrors")
+	}
+	if got := len(collector.GetErrors()); got != 2 {
+		t.Errorf("Expected 2 stored errors, got %d", got)
+	}
 	
-	// 最大数を超えるエラーを追加
-	err := NewError(CategoryAnalysis, SeverityError, "too many errors")
-	addErr := collector.Add(err)
-	if addErr == nil {
+	// 上限を超えるエラーは保存されない
+	if addErr := collector.Add(NewError(CategoryAnalysis, SeverityError, "third error")); addErr == nil {
 		t.Error("Expected error when adding too many errors")
 	}
+	if got := len(collector.GetErrors()); got != 2 {
+		t.Errorf("Expected errors to stay capped at 2, got %d", got)
+	}
+}
+
+func TestErrorCollector_MaxDetailLength(t *testing.T) {
+	collector := NewErrorCollector(10, false)
+	collector.SetMaxDetailLength(20)
+	
+	longSQL := "SELECT id, name, email FROM users WHERE id = ?"
+	reporter := NewErrorReporter(collector).WithQueryContext("GetUser", longSQL)
+	if err := reporter.Error(CategoryAnalysis, "failed to analyze SQL query"); err != nil {
+		t.Fatalf("Error() returned %v", err)
+	}
+	
+	details := collector.GetErrors()[0].Details
+	if details["sql"] != longSQL[:20]+"..." {
+		t.Errorf("Expected truncated sql, got %q", details["sql"])
+	}
+	if details["query_name"] != "GetUser" {
+		t.Errorf("Expected short details to be kept, got %q", details["query_name"])
+	}
+	if details["truncated"] != true {
+		t.Error("Expected truncated marker")
+	}
+	
+	// マルチバイト文字の途中で切らない
+	err := NewError(CategoryAnalysis, SeverityWarning, "warning")
+	err.Details["sql"] = strings.Repeat("あ", 10)
+	collector.Add(err)
+	if sql := collector.GetWarnings()[0].Details["sql"]; sql != strings.Repeat("あ", 6)+"..." {
+		t.Errorf("Expected truncation at rune boundary, got %q", sql)
+	}
+	
+	// 短い詳細には印を付けない
+	short := NewError(CategoryAnalysis, SeverityWarning, "warning")
+	short.Details["sql"] = "SELECT 1"
+	collector.Add(short)
+	if _, exists := collector.GetWarnings()[1].Details["truncated"]; exists {
+		t.Error("Expected no truncated marker for short details")
+	}
 }
 
 func TestErrorCollector_StopOnFatal(t *testing.T) {
@@ -100,4 +165,72 @@ func TestErrorCollector_GetReport(t *testing.T) {
 	if report.Summary.ByCategory[CategoryAnalysis] != 1 {
 		t.Errorf("Expected 1 analysis warning, got %d", report.Summary.ByCategory[CategoryAnalysis])
 	}
-}
\ No newline at end of file
+}
+func TestErrorCollector_CountOnly(t *testing.T) {
+	collector := NewErrorCollector(2, false)
+	collector.SetCountOnly(true)
+	
+	categories := []ErrorCategory{CategoryParse, CategoryParse, CategoryParse, CategoryAnalysis, CategoryParse}
+	for i, category := range categories {
+		if addErr := collector.Add(NewError(category, SeverityError, "broken file")); addErr != nil {
+			t.Fatalf("Add() #%d error = %v, want nil in count-only mode", i+1, addErr)
+		}
+		
+		summary := collector.GetReport().Summary
+		if summary.TotalErrors != i+1 {
+			t.Errorf("after %d errors TotalErrors = %d", i+1, summary.TotalErrors)
+		}
+	}
+	
+	// 上限を超えたエラーは保存されない
+	if got := len(collector.GetErrors()); got != 2 {
+		t.Errorf("Expected 2 stored errors, got %d", got)
+	}
+	
+	summary := collector.GetReport().Summary
+	if summary.StoredErrors != 2 {
+		t.Errorf("StoredErrors = %d, want 2", summary.StoredErrors)
+	}
+	if summary.ByCategory[CategoryParse] != 4 || summary.ByCategory[CategoryAnalysis] != 1 {
+		t.Errorf("ByCategory = %v, want 4 PARSE and 1 ANALYSIS", summary.ByCategory)
+	}
+	if summary.BySeverity[SeverityError] != 5 {
+		t.Errorf("BySeverity[error] = %d, want 5", summary.BySeverity[SeverityError])
+	}
+	
+	collector.Clear()
+	if summary := collector.GetReport().Summary; summary.TotalErrors != 0 {
+		t.Errorf("TotalErrors after Clear() = %d, want 0", summary.TotalErrors)
+	}
+}
+
+func TestErrorCollector_WithLimits(t *testing.T) {
+	collector := NewErrorCollector(10, false)
+	collector.SetCountOnly(true)
+	collector.SetMaxDetailLength(5)
+	collector.Add(NewError(CategoryParse, SeverityError, "broken file"))
+	
+	rebuilt := collector.WithLimits(1, true)
+	if rebuilt.GetMaxErrors() != 1 || !rebuilt.IsDebugMode() {
+		t.Errorf("WithLimits() maxErrors = %d, stopOnFatal = %v, want 1 and true", rebuilt.GetMaxErrors(), rebuilt.IsDebugMode())
+	}
+	if got := len(rebuilt.GetErrors()); got != 0 {
+		t.Errorf("WithLimits() kept %d errors, want 0", got)
+	}
+	
+	// count-only モードは引き継がれる
+	for i := 0; i < 3; i++ {
+		if addErr := rebuilt.Add(NewError(CategoryParse, SeverityError, "broken file")); addErr != nil {
+			t.Fatalf("Add() #%d error = %v, want nil in count-only mode", i+1, addErr)
+		}
+	}
+	if summary := rebuilt.GetReport().Summary; summary.TotalErrors != 3 {
+		t.Errorf("TotalErrors = %d, want 3", summary.TotalErrors)
+	}
+	
+	// 詳細の最大長も引き継がれる
+	NewErrorReporter(rebuilt).WithQueryContext("GetUser", "SELECT * FROM users").Warning(CategoryAnalysis, "long detail")
+	if got := rebuilt.GetWarnings()[0].Details["sql"]; got != "SELEC..." {
+		t.Errorf("Details[sql] = %v, want %q", got, "SELEC...")
+	}
+}
@@ -107,8 +107,8 @@ func (el *ErrorLogger) LogErrorReport(ctx context.Context, report *ErrorReport)
 	// Add category breakdown
 	if len(report.Summary.ByCategory) > 0 {
 		categoryAttrs := make([]slog.Attr, 0, len(report.Summary.ByCategory))
-		for category, count := range report.Summary.ByCategory {
-			categoryAttrs = append(categoryAttrs, slog.Int(string(category), count))
+		for _, category := range sortedKeys(report.Summary.ByCategory) {
+			categoryAttrs = append(categoryAttrs, slog.Int(string(category), report.Summary.ByCategory[category]))
 		}
 		categoryGroup := slog.Group("by_category", convertAttrsToAny(categoryAttrs)...)
 		summaryAttrs = append(summaryAttrs, categoryGroup)
@@ -117,8 +117,8 @@ func (el *ErrorLogger) LogErrorReport(ctx context.Context, report *ErrorReport)
 	// Add severity breakdown
 	if len(report.Summary.BySeverity) > 0 {
 		severityAttrs := make([]slog.Attr, 0, len(report.Summary.BySeverity))
-		for severity, count := range report.Summary.BySeverity {
-			severityAttrs = append(severityAttrs, slog.Int(severity.String(), count))
+		for _, severity := range sortedKeys(report.Summary.BySeverity) {
+			severityAttrs = append(severityAttrs, slog.Int(severity.String(), report.Summary.BySeverity[severity]))
 		}
 		severityGroup := slog.Group("by_severity", convertAttrsToAny(severityAttrs)...)
 		summaryAttrs = append(summaryAttrs, severityGroup)
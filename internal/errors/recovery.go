@@ -114,9 +114,10 @@ func ProcessWithPartialFailure[T any](
 
 // ErrorRecoveryOptions defines options for error recovery
 type ErrorRecoveryOptions struct {
-	MaxRetries         int  // 最大リトライ回数
-	ContinueOnError    bool // エラー時も処理を継続するか
-	RecordPartialError bool // 部分的なエラーも記録するか
+	MaxRetries         int              // 最大リトライ回数
+	ContinueOnError    bool             // エラー時も処理を継続するか
+	RecordPartialError bool             // 部分的なエラーも記録するか
+	Retryable          func(error) bool // リトライ対象のエラーか判定する（nilの場合は全てのエラーをリトライ）
 }
 
 // DefaultRecoveryOptions returns default error recovery options
@@ -145,6 +146,11 @@ func RetryWithRecovery(
 
 		lastErr = err
 
+		// リトライしても解消しないエラーはそのまま返す
+		if options.Retryable != nil && !options.Retryable(err) {
+			return err
+		}
+
 		// 最後の試行でない場合は継続
 		if attempt < options.MaxRetries {
 			if options.RecordPartialError {
@@ -171,6 +171,36 @@ func TestRetryWithRecoveryAllFailed(t *testing.T) {
 	}
 }
 
+func TestRetryWithRecoveryNotRetryable(t *testing.T) {
+	collector := NewErrorCollector(10, false)
+	
+	attempts := 0
+	permanent := fmt.Errorf("permanent failure")
+	err := RetryWithRecovery(
+		func() error {
+			attempts++
+			return permanent
+		},
+		ErrorRecoveryOptions{
+			MaxRetries:         3,
+			RecordPartialError: true,
+			Retryable: func(err error) bool {
+				return err != permanent
+			},
+		},
+		collector,
+		"test retry not retryable",
+	)
+
+	if err != permanent {
+		t.Errorf("Expected the non-retryable error to be returned, got: %v", err)
+	}
+
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt, got: %d", attempts)
+	}
+}
+
 func TestCircuitBreaker(t *testing.T) {
 	collector := NewErrorCollector(10, false)
 	cb := NewCircuitBreaker(2, 100) // 2 failures, 100ms timeout
@@ -1,13 +1,25 @@
 package errors
 
 import (
+	"cmp"
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"sort"
 	"strings"
 	"time"
 )
 
+// Patterns for instance-specific parts of error messages
+var (
+	quotedPattern = regexp.MustCompile("'[^']*'|\"[^\"]*\"|`[^`]*`")
+	// パスとみなすのは "/"・"./"・"~/" で始まるもの、拡張子で終わるもの、.go/.sqlのファイル名のみ
+	// （"INSERT/UPDATE" のような語の並びは置換しない）
+	pathPattern = regexp.MustCompile(`(^|[^\w.\-/~])((?:~|\.{1,2})?/[\w.\-]+(?:/[\w.\-]+)*|` +
+		`[\w.\-]+(?:/[\w.\-]+)*/[\w\-]*\.\w+|[\w\-]+\.(?:go|sql)\b)`)
+	numberPattern = regexp.MustCompile(`\d+`)
+)
+
 // ErrorAggregator groups similar errors together
 type ErrorAggregator struct {
 	groups map[string][]*AnalysisError
@@ -36,15 +48,10 @@ func (ea *ErrorAggregator) generateKey(err *AnalysisError) string {
 // simplifyMessage removes instance-specific details from error messages
 func (ea *ErrorAggregator) simplifyMessage(message string) string {
 	// Replace specific values with placeholders for grouping
-	simplified := message
-	
-	// Replace file paths with placeholder
-	simplified = strings.ReplaceAll(simplified, "/", "PATH_SEP")
-	
-	// Replace numbers with placeholder (for line numbers, etc.)
-	for i := 0; i < 10; i++ {
-		simplified = strings.ReplaceAll(simplified, fmt.Sprintf("%d", i), "N")
-	}
+	// 引用された識別子 → パス → 数値の順に置換する
+	simplified := quotedPattern.ReplaceAllString(message, "<id>")
+	simplified = pathPattern.ReplaceAllString(simplified, "${1}<path>")
+	simplified = numberPattern.ReplaceAllString(simplified, "<N>")
 	
 	return simplified
 }
@@ -115,6 +122,7 @@ type ReportFormatter struct {
 	includeStackTrace bool
 	includeDetails    bool
 	maxDetailsLength  int
+	minSeverity       ErrorSeverity
 }
 
 // NewReportFormatter creates a new report formatter
@@ -123,6 +131,7 @@ func NewReportFormatter() *ReportFormatter {
 		includeStackTrace: false,
 		includeDetails:    true,
 		maxDetailsLength:  500,
+		minSeverity:       SeverityInfo,
 	}
 }
 
@@ -144,8 +153,16 @@ func (rf *ReportFormatter) WithMaxDetailsLength(length int) *ReportFormatter {
 	return rf
 }
 
+// WithMinSeverity drops entries less severe than the given severity
+func (rf *ReportFormatter) WithMinSeverity(severity ErrorSeverity) *ReportFormatter {
+	rf.minSeverity = severity
+	return rf
+}
+
 // FormatReport formats an error report in the specified format
 func (rf *ReportFormatter) FormatReport(report *ErrorReport, format string) (string, error) {
+	report = rf.filterReport(report)
+	
 	switch strings.ToLower(format) {
 	case "json":
 		return rf.formatJSON(report)
@@ -162,6 +179,45 @@ func (rf *ReportFormatter) FormatReport(report *ErrorReport, format string) (str
 	}
 }
 
+// filterReport returns a copy of the report without entries below the minimum severity
+func (rf *ReportFormatter) filterReport(report *ErrorReport) *ErrorReport {
+	if rf.minSeverity == SeverityInfo {
+		return report
+	}
+	
+	filtered := &ErrorReport{
+		Errors:   FilterBySeverity(report.Errors, rf.minSeverity),
+		Warnings: FilterBySeverity(report.Warnings, rf.minSeverity),
+		Summary: ErrorSummary{
+			ByCategory: make(map[ErrorCategory]int),
+			BySeverity: make(map[ErrorSeverity]int),
+		},
+	}
+	
+	// サマリーをフィルタ後の内容で再計算
+	filtered.Summary.TotalErrors = len(filtered.Errors)
+	filtered.Summary.TotalWarnings = len(filtered.Warnings)
+	for _, entries := range [][]*AnalysisError{filtered.Errors, filtered.Warnings} {
+		for _, err := range entries {
+			filtered.Summary.ByCategory[err.Category]++
+			filtered.Summary.BySeverity[err.Severity]++
+		}
+	}
+	
+	return filtered
+}
+
+// FilterBySeverity returns the errors at least as severe as minSeverity
+func FilterBySeverity(errors []*AnalysisError, minSeverity ErrorSeverity) []*AnalysisError {
+	result := make([]*AnalysisError, 0, len(errors))
+	for _, err := range errors {
+		if err.Severity.AtLeast(minSeverity) {
+			result = append(result, err)
+		}
+	}
+	return result
+}
+
 // formatJSON formats the report as JSON
 func (rf *ReportFormatter) formatJSON(report *ErrorReport) (string, error) {
 	// Prepare report for JSON serialization
@@ -180,6 +236,13 @@ func (rf *ReportFormatter) formatJSON(report *ErrorReport) (string, error) {
 	return string(data), nil
 }
 
+// EntriesForJSON returns the errors and warnings of a report in the JSON report shape
+// 重大度の高いエラーを先に、警告を後に並べる
+func (rf *ReportFormatter) EntriesForJSON(report *ErrorReport) []map[string]interface{} {
+	report = rf.filterReport(report)
+	return append(rf.prepareErrorsForJSON(report.Errors), rf.prepareErrorsForJSON(report.Warnings)...)
+}
+
 // prepareErrorsForJSON prepares errors for JSON serialization
 func (rf *ReportFormatter) prepareErrorsForJSON(errors []*AnalysisError) []map[string]interface{} {
 	result := make([]map[string]interface{}, len(errors))
@@ -236,8 +299,8 @@ func (rf *ReportFormatter) formatText(report *ErrorReport) string {
 			}
 			if rf.includeDetails && err.Details != nil && len(err.Details) > 0 {
 				buf.WriteString("   Details:\n")
-				for key, value := range err.Details {
-					valueStr := fmt.Sprintf("%v", value)
+				for _, key := range sortedKeys(err.Details) {
+					valueStr := fmt.Sprintf("%v", err.Details[key])
 					if len(valueStr) > rf.maxDetailsLength {
 						valueStr = valueStr[:rf.maxDetailsLength] + "..."
 					}
@@ -267,14 +330,16 @@ func (rf *ReportFormatter) formatText(report *ErrorReport) string {
 
 	if len(report.Summary.ByCategory) > 0 {
 		buf.WriteString("\nBy Category:\n")
-		for category, count := range report.Summary.ByCategory {
+		for _, category := range sortedKeys(report.Summary.ByCategory) {
+			count := report.Summary.ByCategory[category]
 			buf.WriteString(fmt.Sprintf("  %s: %d\n", category, count))
 		}
 	}
 
 	if len(report.Summary.BySeverity) > 0 {
 		buf.WriteString("\nBy Severity:\n")
-		for severity, count := range report.Summary.BySeverity {
+		for _, severity := range sortedKeys(report.Summary.BySeverity) {
+			count := report.Summary.BySeverity[severity]
 			buf.WriteString(fmt.Sprintf("  %s: %d\n", severity.String(), count))
 		}
 	}
@@ -301,8 +366,8 @@ func (rf *ReportFormatter) formatMarkdown(report *ErrorReport) string {
 			}
 			if rf.includeDetails && err.Details != nil && len(err.Details) > 0 {
 				buf.WriteString("**Details:**\n\n")
-				for key, value := range err.Details {
-					buf.WriteString(fmt.Sprintf("- **%s:** `%v`\n", key, value))
+				for _, key := range sortedKeys(err.Details) {
+					buf.WriteString(fmt.Sprintf("- **%s:** `%v`\n", key, err.Details[key]))
 				}
 				buf.WriteString("\n")
 			}
@@ -325,7 +390,8 @@ func (rf *ReportFormatter) formatMarkdown(report *ErrorReport) string {
 
 	if len(report.Summary.ByCategory) > 0 {
 		buf.WriteString("\n### By Category\n\n")
-		for category, count := range report.Summary.ByCategory {
+		for _, category := range sortedKeys(report.Summary.ByCategory) {
+			count := report.Summary.ByCategory[category]
 			buf.WriteString(fmt.Sprintf("- **%s:** %d\n", category, count))
 		}
 	}
@@ -399,7 +465,8 @@ func (rf *ReportFormatter) formatSummary(report *ErrorReport) string {
 
 	if len(report.Summary.ByCategory) > 0 {
 		buf.WriteString("By Category:\n")
-		for category, count := range report.Summary.ByCategory {
+		for _, category := range sortedKeys(report.Summary.ByCategory) {
+			count := report.Summary.ByCategory[category]
 			buf.WriteString(fmt.Sprintf("  %s: %d\n", category, count))
 		}
 		buf.WriteString("\n")
@@ -407,10 +474,21 @@ func (rf *ReportFormatter) formatSummary(report *ErrorReport) string {
 
 	if len(report.Summary.BySeverity) > 0 {
 		buf.WriteString("By Severity:\n")
-		for severity, count := range report.Summary.BySeverity {
+		for _, severity := range sortedKeys(report.Summary.BySeverity) {
+			count := report.Summary.BySeverity[severity]
 			buf.WriteString(fmt.Sprintf("  %s: %d\n", severity.String(), count))
 		}
 	}
 
 	return buf.String()
-}
\ No newline at end of file
+}
+
+// sortedKeys returns the keys of a map in sorted order so reports are deterministic
+func sortedKeys[K cmp.Ordered, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
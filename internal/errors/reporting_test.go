@@ -0,0 +1,123 @@
+package errors
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReportFormatter_WithMinSeverity(t *testing.T) {
+	collector := NewErrorCollector(10, false)
+	collector.Add(NewError(CategoryParse, SeverityError, "broken query"))
+	collector.Add(NewError(CategoryAnalysis, SeverityWarning, "column list missing"))
+	
+	formatter := NewReportFormatter().WithMinSeverity(SeverityError)
+	output, err := formatter.FormatReport(collector.GetReport(), "text")
+	if err != nil {
+		t.Fatalf("FormatReport() error = %v", err)
+	}
+	
+	if !strings.Contains(output, "broken query") {
+		t.Error("Expected error to be included")
+	}
+	
+	if strings.Contains(output, "column list missing") {
+		t.Error("Expected warning to be excluded when filtering at error level")
+	}
+	
+	if !strings.Contains(output, "Total Warnings: 0") {
+		t.Error("Expected summary to be recomputed after filtering")
+	}
+}
+
+func TestFilterBySeverity(t *testing.T) {
+	errors := []*AnalysisError{
+		NewError(CategoryParse, SeverityFatal, "fatal"),
+		NewError(CategoryParse, SeverityError, "error"),
+		NewError(CategoryParse, SeverityWarning, "warning"),
+		NewError(CategoryParse, SeverityInfo, "info"),
+	}
+	
+	filtered := FilterBySeverity(errors, SeverityError)
+	if len(filtered) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(filtered))
+	}
+	
+	for _, err := range filtered {
+		if err.Severity == SeverityWarning || err.Severity == SeverityInfo {
+			t.Errorf("Unexpected entry below threshold: %s", err.Message)
+		}
+	}
+}
+
+func TestErrorAggregator_simplifyMessage(t *testing.T) {
+	aggregator := NewErrorAggregator()
+	
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{
+			name:    "whole numbers",
+			message: "unresolved call at line 200, column 15",
+			want:    "unresolved call at line <N>, column <N>",
+		},
+		{
+			name:    "file paths",
+			message: "failed to parse internal/db/query.sql",
+			want:    "failed to parse <path>",
+		},
+		{
+			name:    "bare file names",
+			message: "syntax error in main.go",
+			want:    "syntax error in <path>",
+		},
+		{
+			name:    "quoted identifiers",
+			message: "failed to analyze function 'GetUser' in \"users\"",
+			want:    "failed to analyze function <id> in <id>",
+		},
+		{
+			name:    "absolute paths",
+			message: "cannot open /var/lib/app/schema.sql: permission denied",
+			want:    "cannot open <path>: permission denied",
+		},
+		{
+			name:    "operations separated by slashes",
+			message: "unqualified INSERT/UPDATE/DELETE on 'users'",
+			want:    "unqualified INSERT/UPDATE/DELETE on <id>",
+		},
+		{
+			name:    "plain message",
+			message: "no SQL queries provided",
+			want:    "no SQL queries provided",
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := aggregator.simplifyMessage(tt.message); got != tt.want {
+				t.Errorf("simplifyMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorAggregator_Grouping(t *testing.T) {
+	aggregator := NewErrorAggregator()
+	aggregator.Add(NewError(CategoryMapping, SeverityWarning, "unresolved call at line 10"))
+	aggregator.Add(NewError(CategoryMapping, SeverityWarning, "unresolved call at line 200"))
+	aggregator.Add(NewError(CategoryMapping, SeverityWarning, "unknown table at line 10"))
+	
+	report := aggregator.GetAggregatedReport()
+	if len(report) != 2 {
+		t.Fatalf("Expected 2 groups, got %d: %+v", len(report), report)
+	}
+	
+	if report[0].Count != 2 || report[0].Key != "MAPPING:WARNING:unresolved call at line <N>" {
+		t.Errorf("Unexpected first group: %s (%d)", report[0].Key, report[0].Count)
+	}
+	if report[1].Count != 1 {
+		t.Errorf("Expected distinct message to stay separate, got count %d", report[1].Count)
+	}
+}
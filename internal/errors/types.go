@@ -1,8 +1,11 @@
 package errors
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"runtime"
+	"strings"
 	"time"
 )
 
@@ -27,11 +30,12 @@ const (
 	CategoryInternal   ErrorCategory = "INTERNAL"   // 内部エラー
 	CategoryMapping    ErrorCategory = "MAPPING"    // マッピング関連
 	CategoryValidation ErrorCategory = "VALIDATION" // 検証関連
+	CategorySecurity   ErrorCategory = "SECURITY"   // セキュリティ関連
 )
 
 // AnalysisError represents an analysis error
 type AnalysisError struct {
-	ID         string                 // 一意のエラーID
+	ID         string                 // 内容から導出したエラーID（同じエラーは実行をまたいで同じID）
 	Category   ErrorCategory          // エラーカテゴリ
 	Severity   ErrorSeverity          // 深刻度
 	Message    string                 // エラーメッセージ
@@ -69,8 +73,7 @@ func NewError(category ErrorCategory, severity ErrorSeverity, message string) *A
 	pc, file, line, _ := runtime.Caller(1)
 	fn := runtime.FuncForPC(pc)
 	
-	return &AnalysisError{
-		ID:        generateErrorID(),
+	err := &AnalysisError{
 		Category:  category,
 		Severity:  severity,
 		Message:   message,
@@ -82,6 +85,15 @@ func NewError(category ErrorCategory, severity ErrorSeverity, message string) *A
 		},
 		Details: make(map[string]interface{}),
 	}
+	err.ID = generateErrorID(err)
+	return err
+}
+
+// WithLocation points the error at a location in the analyzed source instead of the caller
+func (e *AnalysisError) WithLocation(file string, line, column int) *AnalysisError {
+	e.Location = &ErrorLocation{File: file, Line: line, Column: column}
+	e.ID = generateErrorID(e)
+	return e
 }
 
 // Wrap wraps an error with additional context
@@ -93,6 +105,7 @@ func Wrap(err error, message string) *AnalysisError {
 	// 既存のAnalysisErrorの場合は情報を保持
 	if ae, ok := err.(*AnalysisError); ok {
 		ae.Message = fmt.Sprintf("%s: %s", message, ae.Message)
+		ae.ID = generateErrorID(ae)
 		return ae
 	}
 	
@@ -102,10 +115,38 @@ func Wrap(err error, message string) *AnalysisError {
 	return newErr
 }
 
-// generateErrorID generates a unique error ID
-func generateErrorID() string {
-	// 簡単な実装（実際にはより複雑な方法を使用）
-	return fmt.Sprintf("ERR_%d", time.Now().UnixNano())
+// generateErrorID derives an ID from the category, severity, normalized message and location
+// 時刻を含めないため、同じエラーは実行をまたいで同じIDになる（重複排除やスナップショットテスト向け）
+func generateErrorID(err *AnalysisError) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s|%s|%s", err.Category, err.Severity, strings.Join(strings.Fields(err.Message), " "))
+	if err.Location != nil {
+		// ファイルの絶対パスはビルド環境に依存するため、関数名と行番号を使う
+		fmt.Fprintf(hash, "|%s:%d:%d", err.Location.Function, err.Location.Line, err.Location.Column)
+	}
+	return "ERR_" + hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+// ParseSeverity parses a severity name such as "error" or "WARNING"
+func ParseSeverity(name string) (ErrorSeverity, error) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "FATAL":
+		return SeverityFatal, nil
+	case "ERROR":
+		return SeverityError, nil
+	case "WARNING", "WARN":
+		return SeverityWarning, nil
+	case "INFO":
+		return SeverityInfo, nil
+	default:
+		return SeverityInfo, fmt.Errorf("unknown severity: %s", name)
+	}
+}
+
+// AtLeast reports whether s is at least as severe as min
+// 列挙値が小さいほど重大（Fatal < Error < Warning < Info）
+func (s ErrorSeverity) AtLeast(min ErrorSeverity) bool {
+	return s <= min
 }
 
 // String returns the string representation of severity
@@ -40,6 +40,32 @@ func TestNewError(t *testing.T) {
 	}
 }
 
+func TestNewError_DeterministicID(t *testing.T) {
+	newErr := func(message string) *AnalysisError {
+		return NewError(CategoryAnalysis, SeverityWarning, message)
+	}
+	
+	first := newErr("table  users not found")
+	second := newErr("table users not found")
+	if first.ID != second.ID {
+		t.Errorf("Expected identical errors to share an ID, got %s and %s", first.ID, second.ID)
+	}
+	
+	if other := newErr("table posts not found"); other.ID == first.ID {
+		t.Errorf("Expected different messages to have different IDs, both got %s", first.ID)
+	}
+	
+	if warning := NewError(CategoryAnalysis, SeverityError, "table users not found"); warning.ID == first.ID {
+		t.Error("Expected a different severity and location to change the ID")
+	}
+	
+	// Wrapping changes the message, so the ID follows it
+	wrapped := Wrap(newErr("table users not found"), "query GetUser")
+	if wrapped.ID == first.ID {
+		t.Error("Expected wrapping to change the ID")
+	}
+}
+
 func TestAnalysisError_Error(t *testing.T) {
 	err := NewError(CategoryConfig, SeverityError, "test error")
 	
@@ -103,6 +129,47 @@ func TestErrorSeverity_String(t *testing.T) {
 	}
 }
 
+func TestParseSeverity(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    ErrorSeverity
+		wantErr bool
+	}{
+		{"fatal", SeverityFatal, false},
+		{"ERROR", SeverityError, false},
+		{"Warning", SeverityWarning, false},
+		{"info", SeverityInfo, false},
+		{"debug", SeverityInfo, true},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSeverity(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseSeverity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSeverity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorSeverity_AtLeast(t *testing.T) {
+	if !SeverityFatal.AtLeast(SeverityError) {
+		t.Error("Expected FATAL to be at least ERROR")
+	}
+	if !SeverityError.AtLeast(SeverityError) {
+		t.Error("Expected ERROR to be at least ERROR")
+	}
+	if SeverityWarning.AtLeast(SeverityError) {
+		t.Error("Expected WARNING not to be at least ERROR")
+	}
+	if SeverityInfo.AtLeast(SeverityWarning) {
+		t.Error("Expected INFO not to be at least WARNING")
+	}
+}
+
 // Helper function to check if a string contains a substring
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && findSubstring(s, substr)
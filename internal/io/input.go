@@ -1,6 +1,7 @@
 package io
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -21,21 +22,51 @@ func NewInputReader() *InputReader {
 	}
 }
 
-// ReadRequest reads a CodeGeneratorRequest from the input
+// NewInputReaderFromFile creates an input reader for a request captured in a file
+// The file holds either the protobuf GenerateRequest sent by sqlc or its JSON form
+func NewInputReaderFromFile(path string) (*InputReader, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request file: %w", err)
+	}
+	
+	return &InputReader{
+		reader: bytes.NewReader(data),
+	}, nil
+}
+
+// ReadRequest reads a sqlc GenerateRequest (protobuf, or JSON for replayed requests) from the input
 func (ir *InputReader) ReadRequest() (*config.CodeGeneratorRequest, error) {
-	var request config.CodeGeneratorRequest
-	decoder := json.NewDecoder(ir.reader)
+	data, err := io.ReadAll(ir.reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request: %w", err)
+	}
 	
-	if err := decoder.Decode(&request); err != nil {
+	request, err := decodeRequest(data)
+	if err != nil {
 		return nil, fmt.Errorf("failed to decode request: %w", err)
 	}
 	
 	// 必須フィールドの検証
-	if err := ir.validateRequest(&request); err != nil {
+	if err := ir.validateRequest(request); err != nil {
 		return nil, fmt.Errorf("invalid request: %w", err)
 	}
 	
-	return &request, nil
+	return request, nil
+}
+
+// decodeRequest decodes a JSON request when the data is a JSON object, and protobuf otherwise
+// '{' (0x7b) はprotobufでは非対応のワイヤータイプ（グループ開始）になるため判別に使える
+func decodeRequest(data []byte) (*config.CodeGeneratorRequest, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var request config.CodeGeneratorRequest
+		if err := json.Unmarshal(trimmed, &request); err != nil {
+			return nil, fmt.Errorf("invalid JSON request: %w", err)
+		}
+		return &request, nil
+	}
+	
+	return decodeGenerateRequest(data)
 }
 
 func (ir *InputReader) validateRequest(req *config.CodeGeneratorRequest) error {
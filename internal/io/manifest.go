@@ -0,0 +1,63 @@
+package io
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestRule allows a function to access a table
+// An empty Operation allows every operation on the table
+type ManifestRule struct {
+	Table     string
+	Operation string
+}
+
+// ReadDependencyManifest reads a YAML manifest of allowed accesses per function
+// 各関数に "table" または "table/OPERATION" の形式で許可するアクセスを列挙する
+//
+//	service.GetUser:
+//	  - users/SELECT
+//	service.CreatePost:
+//	  - posts
+func ReadDependencyManifest(path string) (map[string][]ManifestRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	
+	var file map[string][]string
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+	
+	manifest := make(map[string][]ManifestRule, len(file))
+	for function, entries := range file {
+		rules := make([]ManifestRule, 0, len(entries))
+		for _, entry := range entries {
+			rule, err := parseManifestRule(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid manifest entry for %s: %w", function, err)
+			}
+			rules = append(rules, rule)
+		}
+		manifest[function] = rules
+	}
+	return manifest, nil
+}
+
+// parseManifestRule parses a "table" or "table/OPERATION" entry
+func parseManifestRule(entry string) (ManifestRule, error) {
+	table, operation, _ := strings.Cut(strings.TrimSpace(entry), "/")
+	table = strings.TrimSpace(table)
+	operation = strings.ToUpper(strings.TrimSpace(operation))
+	if table == "" {
+		return ManifestRule{}, fmt.Errorf("missing table in %q", entry)
+	}
+	if strings.Contains(entry, "/") && operation == "" {
+		return ManifestRule{}, fmt.Errorf("missing operation in %q", entry)
+	}
+	return ManifestRule{Table: table, Operation: operation}, nil
+}
@@ -0,0 +1,58 @@
+package io
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadDependencyManifest(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string][]ManifestRule
+		wantErr bool
+	}{
+		{
+			name: "tables with and without operations",
+			content: `service.GetUser:
+  - users/SELECT
+service.CreatePost:
+  - posts
+  - users/select
+`,
+			want: map[string][]ManifestRule{
+				"service.GetUser":    {{Table: "users", Operation: "SELECT"}},
+				"service.CreatePost": {{Table: "posts"}, {Table: "users", Operation: "SELECT"}},
+			},
+		},
+		{
+			name:    "missing operation",
+			content: "service.GetUser: [users/]\n",
+			wantErr: true,
+		},
+		{
+			name:    "not a function map",
+			content: "- users\n",
+			wantErr: true,
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "dependencies.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatalf("failed to write manifest: %v", err)
+			}
+			
+			got, err := ReadDependencyManifest(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadDependencyManifest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadDependencyManifest() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,18 +1,39 @@
 package io
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"time"
 
+	"github.com/naoyafurudono/sqlc-use-analysis/internal/errors"
+	"github.com/naoyafurudono/sqlc-use-analysis/internal/output"
 	"github.com/naoyafurudono/sqlc-use-analysis/pkg/types"
 )
 
 // OutputWriter writes analysis results to various formats
 type OutputWriter struct {
 	config *types.Config
+	errors *errors.ErrorCollector // Output.IncludeErrors が有効な場合にJSON出力へ埋め込む
+}
+
+// viewResult is the JSON output limited to the views selected by Output.PrimaryView
+// 除外したビューはnilのまま省略し、出力するビューは空でもキーを残す
+type viewResult struct {
+	SchemaVersion string                             `json:"schema_version"`
+	Metadata      types.Metadata                     `json:"metadata"`
+	FunctionView  *map[string][]types.TableAccess    `json:"function_view,omitempty"`
+	TableView     *map[string][]types.FunctionAccess `json:"table_view,omitempty"`
+}
+
+// resultWithErrors is the JSON output with the collected errors and warnings embedded
+type resultWithErrors struct {
+	*viewResult
+	Errors []map[string]interface{} `json:"errors"`
 }
 
 // NewOutputWriter creates a new output writer
@@ -22,9 +43,242 @@ func NewOutputWriter(config *types.Config) *OutputWriter {
 	}
 }
 
+// SetErrorCollector sets the collector whose entries are embedded when Output.IncludeErrors is set
+func (ow *OutputWriter) SetErrorCollector(collector *errors.ErrorCollector) {
+	ow.errors = collector
+}
+
 // WriteResult writes the analysis result to the configured output
 func (ow *OutputWriter) WriteResult(result *types.DependencyResult) error {
+	// ファイルへの書き込み
+	outputPath := ow.outputPath()
+	
+	if ow.config.Output.SplitFiles {
+		return ow.writeSplitFiles(outputPath, result)
+	}
+	
+	data, err := ow.Render(result)
+	if err != nil {
+		return err
+	}
+	
+	if err := ow.ensureDir(outputPath); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	
+	return nil
+}
+
+// CheckWritable verifies that the output can be written, so that a bad OutputPath fails before analysis
+// 出力先のディレクトリを作成し、一時ファイルを書き込めることを確認する
+func (ow *OutputWriter) CheckWritable() error {
+	outputPath := ow.outputPath()
+	dir := filepath.Dir(outputPath)
+	if ow.config.Output.SplitFiles {
+		dir = outputPath
+	}
+	
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return outputError(outputPath, "cannot create output directory", err)
+	}
+	probe, err := os.CreateTemp(dir, ".sqlc-use-analysis-*")
+	if err != nil {
+		return outputError(outputPath, "output directory is not writable", err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	
+	// 既存の出力ファイルは上書きできる必要がある
+	if info, err := os.Stat(outputPath); err == nil && !ow.config.Output.SplitFiles {
+		if info.IsDir() {
+			return outputError(outputPath, "output path is a directory", nil)
+		}
+		file, err := os.OpenFile(outputPath, os.O_WRONLY, 0)
+		if err != nil {
+			return outputError(outputPath, "output file is not writable", err)
+		}
+		file.Close()
+	}
+	return nil
+}
+
+// outputError reports a problem with the output path as an IO error
+func outputError(outputPath, message string, cause error) *errors.AnalysisError {
+	if cause != nil {
+		message = fmt.Sprintf("%s: %v", message, cause)
+	}
+	err := errors.NewError(errors.CategoryIO, errors.SeverityError, message)
+	err.Details["output_path"] = outputPath
+	err.Wrapped = cause
+	return err
+}
+
+// outputPath resolves the configured output path against the root path
+func (ow *OutputWriter) outputPath() string {
+	if filepath.IsAbs(ow.config.OutputPath) {
+		return ow.config.OutputPath
+	}
+	return filepath.Join(ow.config.RootPath, ow.config.OutputPath)
+}
+
+// dependencyEdge is one function-to-table access in dependencies.json
+type dependencyEdge struct {
+	Function   string   `json:"function"`
+	Table      string   `json:"table"`
+	Operations []string `json:"operations"`
+}
+
+// splitSummary is the content of summary.json
+type splitSummary struct {
+	SchemaVersion string                   `json:"schema_version"`
+	Metadata      types.Metadata           `json:"metadata"`
+	Errors        []map[string]interface{} `json:"errors,omitempty"`
+}
+
+// writeSplitFiles writes functions.json, tables.json, dependencies.json and summary.json into dir
+// 巨大な出力を必要なビューだけ読み込めるようにする
+// Output.PrimaryViewで除外したビューのファイルは書き出さない
+func (ow *OutputWriter) writeSplitFiles(dir string, result *types.DependencyResult) error {
+	files, err := ow.splitFiles(result)
+	if err != nil {
+		return err
+	}
+	
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	
+	for _, file := range files {
+		if err := os.WriteFile(filepath.Join(dir, file.Name), file.Contents, 0644); err != nil {
+			return fmt.Errorf("failed to write output file %s: %w", file.Name, err)
+		}
+	}
+	
+	return nil
+}
+
+// splitFiles renders the split output files, named without their directory
+func (ow *OutputWriter) splitFiles(result *types.DependencyResult) ([]*types.GeneratedFile, error) {
+	result = ow.prepare(result)
+	
+	var edges []dependencyEdge
+	for function, accesses := range result.FunctionView {
+		for _, access := range accesses {
+			edges = append(edges, dependencyEdge{Function: function, Table: access.Table, Operations: access.Operations})
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Function != edges[j].Function {
+			return edges[i].Function < edges[j].Function
+		}
+		return edges[i].Table < edges[j].Table
+	})
+	if edges == nil {
+		edges = []dependencyEdge{}
+	}
+	
+	summary := splitSummary{SchemaVersion: result.SchemaVersion, Metadata: result.Metadata}
+	if ow.config.Output.IncludeErrors {
+		summary.Errors = ow.errorEntries()
+	}
+	
+	type splitContent struct {
+		name    string
+		content interface{}
+	}
+	var contents []splitContent
+	if ow.config.Output.PrimaryView.IncludesFunctionView() {
+		contents = append(contents, splitContent{"functions.json", result.FunctionView})
+	}
+	if ow.config.Output.PrimaryView.IncludesTableView() {
+		contents = append(contents, splitContent{"tables.json", result.TableView})
+	}
+	contents = append(contents, splitContent{"dependencies.json", edges}, splitContent{"summary.json", summary})
+	
+	files := make([]*types.GeneratedFile, 0, len(contents))
+	for _, content := range contents {
+		data, err := ow.marshalJSON(content.content)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, &types.GeneratedFile{Name: content.name, Contents: data})
+	}
+	
+	return files, nil
+}
+
+// GeneratedFiles renders the analysis result as files for the sqlc plugin response
+// sqlc writes the files under output_path, so "sqlc generate" leaves the report behind.
+// With split_files output_path is a directory holding one file per view
+func (ow *OutputWriter) GeneratedFiles(result *types.DependencyResult) ([]*types.GeneratedFile, error) {
+	if ow.config.Output.SplitFiles {
+		files, err := ow.splitFiles(result)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			file.Name = path.Join(filepath.ToSlash(ow.config.OutputPath), file.Name)
+		}
+		return files, nil
+	}
+	
+	data, err := ow.Render(result)
+	if err != nil {
+		return nil, err
+	}
+	
+	return []*types.GeneratedFile{{
+		Name:     ow.config.OutputPath,
+		Contents: data,
+	}}, nil
+}
+
+// Render encodes the analysis result in the configured format (json, csv or markdown)
+func (ow *OutputWriter) Render(result *types.DependencyResult) ([]byte, error) {
+	result = ow.prepare(result)
+	
+	switch ow.config.Output.Format {
+	case "", types.FormatJSON:
+	case types.FormatCSV, types.FormatMarkdown:
+		var buf bytes.Buffer
+		formatter := output.NewFormatter(ow.config.Output.Format, false).WithPrimaryView(ow.config.Output.PrimaryView)
+		if err := formatter.FormatResult(result, &buf); err != nil {
+			return nil, fmt.Errorf("failed to render %s output: %w", ow.config.Output.Format, err)
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unsupported output format: %s (supported: json, csv, markdown)", ow.config.Output.Format)
+	}
+	
+	// JSON生成
+	var output interface{} = ow.viewResult(result)
+	if ow.config.Output.IncludeErrors {
+		output = resultWithErrors{viewResult: ow.viewResult(result), Errors: ow.errorEntries()}
+	}
+	
+	return ow.marshalJSON(output)
+}
+
+// viewResult keeps only the dependency views selected by Output.PrimaryView
+func (ow *OutputWriter) viewResult(result *types.DependencyResult) *viewResult {
+	view := &viewResult{SchemaVersion: result.SchemaVersion, Metadata: result.Metadata}
+	if ow.config.Output.PrimaryView.IncludesFunctionView() {
+		view.FunctionView = &result.FunctionView
+	}
+	if ow.config.Output.PrimaryView.IncludesTableView() {
+		view.TableView = &result.TableView
+	}
+	return view
+}
+
+// prepare fills in the metadata and applies the operation style before rendering
+func (ow *OutputWriter) prepare(result *types.DependencyResult) *types.DependencyResult {
 	// メタデータの追加
+	result.SchemaVersion = types.SchemaVersion
 	if result.Metadata.GeneratedAt.IsZero() {
 		result.Metadata.GeneratedAt = time.Now().UTC()
 	}
@@ -36,35 +290,34 @@ func (ow *OutputWriter) WriteResult(result *types.DependencyResult) error {
 	result.Metadata.TotalFuncs = len(result.FunctionView)
 	result.Metadata.TotalTables = len(result.TableView)
 	
-	// JSON生成
+	return output.RenameOperations(result, ow.config.Output.OperationStyle)
+}
+
+// marshalJSON encodes a value as JSON, indented when Output.Pretty is set
+func (ow *OutputWriter) marshalJSON(output interface{}) ([]byte, error) {
 	var jsonBytes []byte
 	var err error
 	
 	if ow.config.Output.Pretty {
-		jsonBytes, err = json.MarshalIndent(result, "", "  ")
+		jsonBytes, err = json.MarshalIndent(output, "", "  ")
 	} else {
-		jsonBytes, err = json.Marshal(result)
+		jsonBytes, err = json.Marshal(output)
 	}
 	
 	if err != nil {
-		return fmt.Errorf("failed to marshal output: %w", err)
-	}
-	
-	// ファイルへの書き込み
-	outputPath := ow.config.OutputPath
-	if !filepath.IsAbs(outputPath) {
-		outputPath = filepath.Join(ow.config.RootPath, outputPath)
-	}
-	
-	if err := ow.ensureDir(outputPath); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
+		return nil, fmt.Errorf("failed to marshal output: %w", err)
 	}
 	
-	if err := os.WriteFile(outputPath, jsonBytes, 0644); err != nil {
-		return fmt.Errorf("failed to write output file: %w", err)
+	return jsonBytes, nil
+}
+
+// errorEntries returns the collected errors and warnings, or an empty list without a collector
+func (ow *OutputWriter) errorEntries() []map[string]interface{} {
+	if ow.errors == nil {
+		return []map[string]interface{}{}
 	}
 	
-	return nil
+	return errors.NewReportFormatter().EntriesForJSON(ow.errors.GetReport())
 }
 
 func (ow *OutputWriter) ensureDir(filePath string) error {
@@ -0,0 +1,396 @@
+package io
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/naoyafurudono/sqlc-use-analysis/internal/errors"
+	"github.com/naoyafurudono/sqlc-use-analysis/pkg/types"
+)
+
+func TestOutputWriter_GeneratedFiles(t *testing.T) {
+	const function = "github.com/example/app/internal/service.UserService.GetUser"
+	newResult := func() *types.DependencyResult {
+		return &types.DependencyResult{
+			FunctionView: map[string][]types.TableAccess{
+				function: {{Table: "users", Operations: []string{"SELECT"}}},
+			},
+			TableView: map[string][]types.FunctionAccess{
+				"users": {{Function: function, Operations: []string{"SELECT"}}},
+			},
+		}
+	}
+	
+	tests := []struct {
+		format   types.OutputFormat
+		view     types.PrimaryView
+		contains []string
+		excludes []string
+	}{
+		{
+			format:   types.FormatJSON,
+			view:     types.ViewBoth,
+			contains: []string{`"` + function + `"`, `"table": "users"`},
+		},
+		{
+			format:   types.FormatJSON,
+			view:     types.ViewFunction,
+			contains: []string{`"function_view"`, `"table": "users"`},
+			excludes: []string{`"table_view"`},
+		},
+		{
+			format:   types.FormatJSON,
+			view:     types.ViewTable,
+			contains: []string{`"table_view"`, `"function": "` + function + `"`},
+			excludes: []string{`"function_view"`},
+		},
+		{
+			format:   types.FormatCSV,
+			view:     types.ViewBoth,
+			contains: []string{"Function,Table,Operations\n" + function + ",users,SELECT\n", "Table,Function,Operations\nusers," + function + ",SELECT\n"},
+		},
+		{
+			format:   types.FormatMarkdown,
+			view:     types.ViewBoth,
+			contains: []string{"# Database Dependencies", "1 functions, 1 tables", "| " + function + " | users | SELECT |", "| users | " + function + " | SELECT |"},
+		},
+		{
+			format:   types.FormatMarkdown,
+			view:     types.ViewTable,
+			contains: []string{"## Tables"},
+			excludes: []string{"## Functions"},
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(string(tt.format)+"/"+string(tt.view), func(t *testing.T) {
+			cfg := &types.Config{
+				OutputPath: "db_dependencies",
+				Output:     types.OutputConfig{Format: tt.format, Pretty: true, PrimaryView: tt.view},
+			}
+			
+			files, err := NewOutputWriter(cfg).GeneratedFiles(newResult())
+			if err != nil {
+				t.Fatalf("GeneratedFiles() error = %v", err)
+			}
+			if len(files) != 1 {
+				t.Fatalf("Expected 1 generated file, got %d", len(files))
+			}
+			file := files[0]
+			if file.Name != "db_dependencies" {
+				t.Errorf("Name = %q, want the output path", file.Name)
+			}
+			
+			contents := string(file.Contents)
+			for _, want := range tt.contains {
+				if !strings.Contains(contents, want) {
+					t.Errorf("Generated file missing %q:\n%s", want, contents)
+				}
+			}
+			for _, unwanted := range tt.excludes {
+				if strings.Contains(contents, unwanted) {
+					t.Errorf("Generated file should not contain %q:\n%s", unwanted, contents)
+				}
+			}
+			if tt.format == types.FormatJSON && !json.Valid(file.Contents) {
+				t.Errorf("Generated file is not valid JSON:\n%s", contents)
+			}
+		})
+	}
+}
+
+func TestOutputWriter_GeneratedFiles_UnsupportedFormat(t *testing.T) {
+	cfg := &types.Config{OutputPath: "db_dependencies.xml", Output: types.OutputConfig{Format: "xml"}}
+	if _, err := NewOutputWriter(cfg).GeneratedFiles(&types.DependencyResult{}); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
+
+func TestOutputWriter_GeneratedFiles_SplitFiles(t *testing.T) {
+	const function = "github.com/example/app/internal/service.UserService.GetUser"
+	result := &types.DependencyResult{
+		FunctionView: map[string][]types.TableAccess{
+			function: {{Table: "users", Operations: []string{"SELECT"}}},
+		},
+		TableView: map[string][]types.FunctionAccess{
+			"users": {{Function: function, Operations: []string{"SELECT"}}},
+		},
+	}
+	
+	cfg := &types.Config{OutputPath: "db_dependencies", Output: types.OutputConfig{SplitFiles: true}}
+	files, err := NewOutputWriter(cfg).GeneratedFiles(result)
+	if err != nil {
+		t.Fatalf("GeneratedFiles() error = %v", err)
+	}
+	
+	// sqlcはoutput_path配下にビューごとのファイルを書き込む
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name)
+		if !json.Valid(file.Contents) {
+			t.Errorf("%s is not valid JSON:\n%s", file.Name, file.Contents)
+		}
+	}
+	want := []string{
+		"db_dependencies/functions.json",
+		"db_dependencies/tables.json",
+		"db_dependencies/dependencies.json",
+		"db_dependencies/summary.json",
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("file names = %v, want %v", names, want)
+	}
+	
+	// primary_viewで除外したビューのファイルは生成しない
+	cfg.Output.PrimaryView = types.ViewTable
+	files, err = NewOutputWriter(cfg).GeneratedFiles(result)
+	if err != nil {
+		t.Fatalf("GeneratedFiles() error = %v", err)
+	}
+	names = nil
+	for _, file := range files {
+		names = append(names, file.Name)
+	}
+	want = []string{
+		"db_dependencies/tables.json",
+		"db_dependencies/dependencies.json",
+		"db_dependencies/summary.json",
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("file names with primary view %q = %v, want %v", cfg.Output.PrimaryView, names, want)
+	}
+}
+
+func TestOutputWriter_IncludeErrors(t *testing.T) {
+	collector := errors.NewErrorCollector(10, false)
+	collector.Add(errors.NewError(errors.CategoryParse, errors.SeverityError, "failed to parse query 'GetUser'"))
+	collector.Add(errors.NewError(errors.CategoryAnalysis, errors.SeverityWarning, "unresolved call in 'Handle'"))
+	
+	render := func(includeErrors bool) map[string]json.RawMessage {
+		writer := NewOutputWriter(&types.Config{Output: types.OutputConfig{Format: types.FormatJSON, IncludeErrors: includeErrors}})
+		writer.SetErrorCollector(collector)
+		data, err := writer.Render(&types.DependencyResult{})
+		if err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+		var output map[string]json.RawMessage
+		if err := json.Unmarshal(data, &output); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, data)
+		}
+		return output
+	}
+	
+	if _, exists := render(false)["errors"]; exists {
+		t.Error("Expected no errors key unless IncludeErrors is set")
+	}
+	
+	output := render(true)
+	var entries []struct {
+		Category string `json:"category"`
+		Severity string `json:"severity"`
+		Message  string `json:"message"`
+	}
+	if err := json.Unmarshal(output["errors"], &entries); err != nil {
+		t.Fatalf("errors is not an array: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d: %s", len(entries), output["errors"])
+	}
+	if entries[0].Severity != "ERROR" || entries[0].Category != "PARSE" || entries[0].Message != "failed to parse query 'GetUser'" {
+		t.Errorf("entries[0] = %+v, want the parse error", entries[0])
+	}
+	if entries[1].Severity != "WARNING" {
+		t.Errorf("entries[1] = %+v, want the warning", entries[1])
+	}
+	if _, exists := output["function_view"]; !exists {
+		t.Error("Expected the result fields next to errors")
+	}
+}
+
+func TestOutputWriter_OperationStyle(t *testing.T) {
+	const function = "github.com/example/app/internal/service.UserService.ArchiveUser"
+	newResult := func() *types.DependencyResult {
+		return &types.DependencyResult{
+			FunctionView: map[string][]types.TableAccess{
+				function: {{Table: "users", Operations: []string{"DELETE", "SELECT", "TRUNCATE"}}},
+			},
+			TableView: map[string][]types.FunctionAccess{
+				"users": {{Function: function, Operations: []string{"DELETE", "SELECT", "TRUNCATE"}}},
+			},
+		}
+	}
+	
+	for _, format := range []types.OutputFormat{types.FormatJSON, types.FormatCSV, types.FormatMarkdown} {
+		t.Run(string(format), func(t *testing.T) {
+			render := func(style types.OperationStyle) string {
+				cfg := &types.Config{Output: types.OutputConfig{Format: format, OperationStyle: style}}
+				data, err := NewOutputWriter(cfg).Render(newResult())
+				if err != nil {
+					t.Fatalf("Render() error = %v", err)
+				}
+				return string(data)
+			}
+			
+			crud := render(types.OperationStyleCRUD)
+			for _, want := range []string{"Delete", "Read"} {
+				if !strings.Contains(crud, want) {
+					t.Errorf("crud output missing %q:\n%s", want, crud)
+				}
+			}
+			for _, unwanted := range []string{"SELECT", "TRUNCATE", "DELETE"} {
+				if strings.Contains(crud, unwanted) {
+					t.Errorf("crud output should not contain %q:\n%s", unwanted, crud)
+				}
+			}
+			// DELETEとTRUNCATEはどちらもDeleteになるため1つにまとめられる
+			if strings.Count(crud, "Delete") != 2 {
+				t.Errorf("Expected Delete once per view:\n%s", crud)
+			}
+			
+			sql := render("")
+			for _, want := range []string{"SELECT", "DELETE", "TRUNCATE"} {
+				if !strings.Contains(sql, want) {
+					t.Errorf("default output missing %q:\n%s", want, sql)
+				}
+			}
+		})
+	}
+}
+
+func TestOutputWriter_SplitFiles(t *testing.T) {
+	const (
+		getUser    = "github.com/example/app/internal/service.UserService.GetUser"
+		createPost = "github.com/example/app/internal/service.PostService.CreatePost"
+	)
+	result := &types.DependencyResult{
+		FunctionView: map[string][]types.TableAccess{
+			getUser:    {{Table: "users", Operations: []string{"SELECT"}}},
+			createPost: {{Table: "posts", Operations: []string{"INSERT"}}, {Table: "users", Operations: []string{"SELECT"}}},
+		},
+		TableView: map[string][]types.FunctionAccess{
+			"users": {{Function: createPost, Operations: []string{"SELECT"}}, {Function: getUser, Operations: []string{"SELECT"}}},
+			"posts": {{Function: createPost, Operations: []string{"INSERT"}}},
+		},
+	}
+	
+	root := t.TempDir()
+	cfg := &types.Config{
+		RootPath:   root,
+		OutputPath: "deps",
+		Output:     types.OutputConfig{Format: types.FormatJSON, SplitFiles: true},
+	}
+	if err := NewOutputWriter(cfg).WriteResult(result); err != nil {
+		t.Fatalf("WriteResult() error = %v", err)
+	}
+	
+	read := func(name string, v interface{}) {
+		data, err := os.ReadFile(filepath.Join(root, "deps", name))
+		if err != nil {
+			t.Fatalf("Expected %s to be written: %v", name, err)
+		}
+		if err := json.Unmarshal(data, v); err != nil {
+			t.Fatalf("invalid JSON in %s: %v\n%s", name, err, data)
+		}
+	}
+	
+	var functions map[string][]types.TableAccess
+	read("functions.json", &functions)
+	if !reflect.DeepEqual(functions, result.FunctionView) {
+		t.Errorf("functions.json = %+v, want %+v", functions, result.FunctionView)
+	}
+	
+	var tables map[string][]types.FunctionAccess
+	read("tables.json", &tables)
+	if !reflect.DeepEqual(tables, result.TableView) {
+		t.Errorf("tables.json = %+v, want %+v", tables, result.TableView)
+	}
+	
+	var dependencies []dependencyEdge
+	read("dependencies.json", &dependencies)
+	wantDependencies := []dependencyEdge{
+		{Function: createPost, Table: "posts", Operations: []string{"INSERT"}},
+		{Function: createPost, Table: "users", Operations: []string{"SELECT"}},
+		{Function: getUser, Table: "users", Operations: []string{"SELECT"}},
+	}
+	if !reflect.DeepEqual(dependencies, wantDependencies) {
+		t.Errorf("dependencies.json = %+v, want %+v", dependencies, wantDependencies)
+	}
+	
+	var summary splitSummary
+	read("summary.json", &summary)
+	if summary.SchemaVersion != types.SchemaVersion || summary.Metadata.TotalFuncs != 2 || summary.Metadata.TotalTables != 2 {
+		t.Errorf("summary.json = %+v, want schema version and totals", summary)
+	}
+	
+	if _, err := os.Stat(filepath.Join(root, "deps.json")); !os.IsNotExist(err) {
+		t.Error("Expected no single output file when SplitFiles is set")
+	}
+}
+
+func TestOutputWriter_CheckWritable(t *testing.T) {
+	root := t.TempDir()
+	
+	t.Run("missing directory is created", func(t *testing.T) {
+		cfg := &types.Config{RootPath: root, OutputPath: "reports/deps.json"}
+		if err := NewOutputWriter(cfg).CheckWritable(); err != nil {
+			t.Fatalf("CheckWritable() error = %v", err)
+		}
+		entries, err := os.ReadDir(filepath.Join(root, "reports"))
+		if err != nil {
+			t.Fatalf("Expected the output directory to be created: %v", err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("Expected the probe file to be removed, got %v", entries)
+		}
+	})
+	
+	t.Run("read-only directory", func(t *testing.T) {
+		if os.Geteuid() == 0 {
+			t.Skip("root can write to read-only directories")
+		}
+		dir := filepath.Join(root, "readonly")
+		if err := os.Mkdir(dir, 0555); err != nil {
+			t.Fatal(err)
+		}
+		cfg := &types.Config{RootPath: root, OutputPath: "readonly/deps.json"}
+		assertIOError(t, NewOutputWriter(cfg).CheckWritable())
+	})
+	
+	t.Run("parent is a file", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(root, "file"), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		for _, split := range []bool{false, true} {
+			cfg := &types.Config{
+				RootPath:   root,
+				OutputPath: "file/deps.json",
+				Output:     types.OutputConfig{SplitFiles: split},
+			}
+			assertIOError(t, NewOutputWriter(cfg).CheckWritable())
+		}
+	})
+	
+	t.Run("output path is a directory", func(t *testing.T) {
+		if err := os.Mkdir(filepath.Join(root, "taken"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		cfg := &types.Config{RootPath: root, OutputPath: "taken"}
+		assertIOError(t, NewOutputWriter(cfg).CheckWritable())
+	})
+}
+
+// assertIOError checks that err is an IO analysis error
+func assertIOError(t *testing.T, err error) {
+	t.Helper()
+	analysisErr, ok := err.(*errors.AnalysisError)
+	if !ok {
+		t.Fatalf("CheckWritable() error = %v, want an *errors.AnalysisError", err)
+	}
+	if analysisErr.Category != errors.CategoryIO {
+		t.Errorf("Category = %s, want %s", analysisErr.Category, errors.CategoryIO)
+	}
+}
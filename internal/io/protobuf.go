@@ -0,0 +1,303 @@
+package io
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"github.com/naoyafurudono/sqlc-use-analysis/internal/config"
+	"github.com/naoyafurudono/sqlc-use-analysis/pkg/types"
+)
+
+// sqlcプラグインプロトコル（plugin/codegen.proto）のフィールド番号
+// 必要なフィールドのみを扱い、それ以外は読み飛ばす
+const (
+	// GenerateRequest
+	fieldRequestSettings      = 1
+	fieldRequestCatalog       = 2
+	fieldRequestQueries       = 3
+	fieldRequestSQLCVersion   = 4
+	fieldRequestPluginOptions = 5
+	
+	// Settings
+	fieldSettingsEngine = 2
+	
+	// Catalog
+	fieldCatalogDefaultSchema = 2
+	fieldCatalogSchemas       = 4
+	
+	// Schema
+	fieldSchemaName   = 2
+	fieldSchemaTables = 3
+	
+	// Table
+	fieldTableRel = 1
+	
+	// Identifier
+	fieldIdentifierSchema = 2
+	fieldIdentifierName   = 3
+	
+	// Query
+	fieldQueryText     = 1
+	fieldQueryName     = 2
+	fieldQueryCmd      = 3
+	fieldQueryComments = 6
+	fieldQueryFilename = 7
+	
+	// GenerateResponse / File
+	fieldResponseFiles = 1
+	fieldFileName      = 1
+	fieldFileContents  = 2
+)
+
+// protobufのワイヤータイプ
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// wireField represents a single decoded protobuf field
+type wireField struct {
+	number   int
+	wireType int
+	varint   uint64
+	bytes    []byte
+}
+
+// decodeFields decodes all top-level fields of a protobuf message
+func decodeFields(data []byte) ([]wireField, error) {
+	var fields []wireField
+	
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, fmt.Errorf("invalid field key")
+		}
+		data = data[n:]
+		
+		field := wireField{number: int(key >> 3), wireType: int(key & 7)}
+		switch field.wireType {
+		case wireVarint:
+			value, n := binary.Uvarint(data)
+			if n <= 0 {
+				return nil, fmt.Errorf("invalid varint in field %d", field.number)
+			}
+			field.varint = value
+			data = data[n:]
+		case wireFixed64:
+			if len(data) < 8 {
+				return nil, fmt.Errorf("truncated fixed64 in field %d", field.number)
+			}
+			data = data[8:]
+		case wireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < length {
+				return nil, fmt.Errorf("truncated bytes in field %d", field.number)
+			}
+			field.bytes = data[n : n+int(length)]
+			data = data[n+int(length):]
+		case wireFixed32:
+			if len(data) < 4 {
+				return nil, fmt.Errorf("truncated fixed32 in field %d", field.number)
+			}
+			data = data[4:]
+		default:
+			return nil, fmt.Errorf("unsupported wire type %d in field %d", field.wireType, field.number)
+		}
+		
+		fields = append(fields, field)
+	}
+	
+	return fields, nil
+}
+
+// decodeGenerateRequest decodes a sqlc GenerateRequest message
+func decodeGenerateRequest(data []byte) (*config.CodeGeneratorRequest, error) {
+	fields, err := decodeFields(data)
+	if err != nil {
+		return nil, err
+	}
+	
+	request := &config.CodeGeneratorRequest{}
+	for _, field := range fields {
+		switch field.number {
+		case fieldRequestSettings:
+			engine, err := decodeSettingsEngine(field.bytes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode settings: %w", err)
+			}
+			request.Engine = engine
+		case fieldRequestCatalog:
+			catalog, err := decodeCatalog(field.bytes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode catalog: %w", err)
+			}
+			request.Catalog = catalog
+		case fieldRequestQueries:
+			query, err := decodeQuery(field.bytes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode query: %w", err)
+			}
+			request.Queries = append(request.Queries, query)
+		case fieldRequestSQLCVersion:
+			request.SQLCVersion = string(field.bytes)
+		case fieldRequestPluginOptions:
+			// sqlc.yamlのプラグインオプションはJSONで渡される
+			if len(field.bytes) > 0 {
+				if err := json.Unmarshal(field.bytes, &request.Settings); err != nil {
+					return nil, fmt.Errorf("failed to decode plugin options: %w", err)
+				}
+			}
+		}
+	}
+	
+	return request, nil
+}
+
+// decodeSettingsEngine extracts the database engine from a Settings message
+func decodeSettingsEngine(data []byte) (string, error) {
+	fields, err := decodeFields(data)
+	if err != nil {
+		return "", err
+	}
+	
+	for _, field := range fields {
+		if field.number == fieldSettingsEngine {
+			return string(field.bytes), nil
+		}
+	}
+	return "", nil
+}
+
+// decodeCatalog flattens a Catalog message into its tables
+func decodeCatalog(data []byte) (*config.Catalog, error) {
+	fields, err := decodeFields(data)
+	if err != nil {
+		return nil, err
+	}
+	
+	catalog := &config.Catalog{}
+	for _, field := range fields {
+		switch field.number {
+		case fieldCatalogDefaultSchema:
+			catalog.DefaultSchema = string(field.bytes)
+		case fieldCatalogSchemas:
+			tables, err := decodeSchemaTables(field.bytes)
+			if err != nil {
+				return nil, err
+			}
+			catalog.Tables = append(catalog.Tables, tables...)
+		}
+	}
+	
+	return catalog, nil
+}
+
+// decodeSchemaTables decodes the tables of a Schema message
+func decodeSchemaTables(data []byte) ([]config.TableName, error) {
+	fields, err := decodeFields(data)
+	if err != nil {
+		return nil, err
+	}
+	
+	var schemaName string
+	var tables []config.TableName
+	for _, field := range fields {
+		switch field.number {
+		case fieldSchemaName:
+			schemaName = string(field.bytes)
+		case fieldSchemaTables:
+			table, err := decodeTableName(field.bytes)
+			if err != nil {
+				return nil, err
+			}
+			tables = append(tables, table)
+		}
+	}
+	
+	// Identifierにスキーマがない場合は親スキーマ名を使用
+	for i := range tables {
+		if tables[i].Schema == "" {
+			tables[i].Schema = schemaName
+		}
+	}
+	
+	return tables, nil
+}
+
+// decodeTableName decodes the identifier of a Table message
+func decodeTableName(data []byte) (config.TableName, error) {
+	fields, err := decodeFields(data)
+	if err != nil {
+		return config.TableName{}, err
+	}
+	
+	var table config.TableName
+	for _, field := range fields {
+		if field.number != fieldTableRel {
+			continue
+		}
+		
+		relFields, err := decodeFields(field.bytes)
+		if err != nil {
+			return config.TableName{}, err
+		}
+		for _, rel := range relFields {
+			switch rel.number {
+			case fieldIdentifierSchema:
+				table.Schema = string(rel.bytes)
+			case fieldIdentifierName:
+				table.Name = string(rel.bytes)
+			}
+		}
+	}
+	
+	return table, nil
+}
+
+// decodeQuery decodes a Query message
+func decodeQuery(data []byte) (config.Query, error) {
+	fields, err := decodeFields(data)
+	if err != nil {
+		return config.Query{}, err
+	}
+	
+	var query config.Query
+	for _, field := range fields {
+		switch field.number {
+		case fieldQueryText:
+			query.Text = string(field.bytes)
+		case fieldQueryName:
+			query.Name = string(field.bytes)
+		case fieldQueryCmd:
+			query.Cmd = string(field.bytes)
+		case fieldQueryComments:
+			query.Comments = append(query.Comments, string(field.bytes))
+		case fieldQueryFilename:
+			query.Filename = string(field.bytes)
+		}
+	}
+	
+	return query, nil
+}
+
+// encodeGenerateResponse encodes a sqlc GenerateResponse message
+func encodeGenerateResponse(files []*types.GeneratedFile) []byte {
+	var buf []byte
+	for _, file := range files {
+		var fileBuf []byte
+		fileBuf = appendBytesField(fileBuf, fieldFileName, []byte(file.Name))
+		fileBuf = appendBytesField(fileBuf, fieldFileContents, file.Contents)
+		buf = appendBytesField(buf, fieldResponseFiles, fileBuf)
+	}
+	return buf
+}
+
+// appendBytesField appends a length-delimited field
+func appendBytesField(buf []byte, number int, value []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(number)<<3|wireBytes)
+	buf = binary.AppendUvarint(buf, uint64(len(value)))
+	return append(buf, value...)
+}
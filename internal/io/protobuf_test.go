@@ -0,0 +1,214 @@
+package io
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/naoyafurudono/sqlc-use-analysis/internal/config"
+	"github.com/naoyafurudono/sqlc-use-analysis/pkg/types"
+)
+
+// buildGenerateRequest serializes a GenerateRequest the way sqlc does
+func buildGenerateRequest(t *testing.T) []byte {
+	t.Helper()
+	
+	var settings []byte
+	settings = appendBytesField(settings, 1, []byte("2"))
+	settings = appendBytesField(settings, fieldSettingsEngine, []byte("postgresql"))
+	
+	var rel []byte
+	rel = appendBytesField(rel, fieldIdentifierName, []byte("users"))
+	var table []byte
+	table = appendBytesField(table, fieldTableRel, rel)
+	var schema []byte
+	schema = appendBytesField(schema, fieldSchemaName, []byte("public"))
+	schema = appendBytesField(schema, fieldSchemaTables, table)
+	var catalog []byte
+	catalog = appendBytesField(catalog, fieldCatalogDefaultSchema, []byte("public"))
+	catalog = appendBytesField(catalog, fieldCatalogSchemas, schema)
+	
+	var query []byte
+	query = appendBytesField(query, fieldQueryText, []byte("SELECT id, name FROM users WHERE id = $1"))
+	query = appendBytesField(query, fieldQueryName, []byte("GetUser"))
+	query = appendBytesField(query, fieldQueryCmd, []byte(":one"))
+	query = appendBytesField(query, fieldQueryComments, []byte("sqlc-analysis:ignore"))
+	// 未対応のvarintフィールドは読み飛ばされる
+	query = append(query, 9<<3|wireVarint, 1)
+	query = appendBytesField(query, fieldQueryFilename, []byte("query.sql"))
+	
+	options, err := json.Marshal(map[string]interface{}{"output_path": "deps.json"})
+	if err != nil {
+		t.Fatalf("failed to marshal options: %v", err)
+	}
+	
+	var request []byte
+	request = appendBytesField(request, fieldRequestSettings, settings)
+	request = appendBytesField(request, fieldRequestCatalog, catalog)
+	request = appendBytesField(request, fieldRequestQueries, query)
+	request = appendBytesField(request, fieldRequestSQLCVersion, []byte("v1.27.0"))
+	request = appendBytesField(request, fieldRequestPluginOptions, options)
+	return request
+}
+
+func TestInputReader_ReadRequest_Protobuf(t *testing.T) {
+	reader := &InputReader{reader: bytes.NewReader(buildGenerateRequest(t))}
+	
+	request, err := reader.ReadRequest()
+	if err != nil {
+		t.Fatalf("ReadRequest() error = %v", err)
+	}
+	
+	if request.Engine != "postgresql" {
+		t.Errorf("Engine = %q, want postgresql", request.Engine)
+	}
+	if request.SQLCVersion != "v1.27.0" {
+		t.Errorf("SQLCVersion = %q, want v1.27.0", request.SQLCVersion)
+	}
+	if request.Settings["output_path"] != "deps.json" {
+		t.Errorf("Settings[output_path] = %v, want deps.json", request.Settings["output_path"])
+	}
+	
+	wantQuery := config.Query{
+		Name:     "GetUser",
+		Cmd:      ":one",
+		Text:     "SELECT id, name FROM users WHERE id = $1",
+		Filename: "query.sql",
+		Comments: []string{"sqlc-analysis:ignore"},
+	}
+	if len(request.Queries) != 1 || !reflect.DeepEqual(request.Queries[0], wantQuery) {
+		t.Errorf("Queries = %+v, want [%+v]", request.Queries, wantQuery)
+	}
+	
+	if request.Catalog == nil {
+		t.Fatal("Catalog is nil")
+	}
+	if request.Catalog.DefaultSchema != "public" {
+		t.Errorf("DefaultSchema = %q, want public", request.Catalog.DefaultSchema)
+	}
+	wantTable := config.TableName{Schema: "public", Name: "users"}
+	if len(request.Catalog.Tables) != 1 || request.Catalog.Tables[0] != wantTable {
+		t.Errorf("Tables = %+v, want [%+v]", request.Catalog.Tables, wantTable)
+	}
+}
+
+func TestInputReader_ReadRequest_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "truncated bytes", data: []byte{fieldRequestQueries<<3 | wireBytes, 10, 'a'}},
+		{name: "invalid key", data: []byte{0x80}},
+		{name: "unsupported wire type", data: []byte{1<<3 | 3}},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := &InputReader{reader: bytes.NewReader(tt.data)}
+			if _, err := reader.ReadRequest(); err == nil {
+				t.Error("expected error for malformed request")
+			}
+		})
+	}
+}
+
+func TestNewInputReaderFromFile(t *testing.T) {
+	dir := t.TempDir()
+	
+	protobufPath := filepath.Join(dir, "request.bin")
+	if err := os.WriteFile(protobufPath, buildGenerateRequest(t), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	jsonPath := filepath.Join(dir, "request.json")
+	jsonRequest := `{
+  "settings": {"output_path": "deps.json"},
+  "engine": "postgresql",
+  "queries": [{"name": "GetUser", "cmd": ":one", "text": "SELECT id, name FROM users WHERE id = $1", "filename": "query.sql", "comments": ["sqlc-analysis:ignore"]}]
+}`
+	if err := os.WriteFile(jsonPath, []byte(jsonRequest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	
+	wantQuery := config.Query{
+		Name:     "GetUser",
+		Cmd:      ":one",
+		Text:     "SELECT id, name FROM users WHERE id = $1",
+		Filename: "query.sql",
+		Comments: []string{"sqlc-analysis:ignore"},
+	}
+	for _, path := range []string{protobufPath, jsonPath} {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			reader, err := NewInputReaderFromFile(path)
+			if err != nil {
+				t.Fatalf("NewInputReaderFromFile() error = %v", err)
+			}
+			request, err := reader.ReadRequest()
+			if err != nil {
+				t.Fatalf("ReadRequest() error = %v", err)
+			}
+			if request.Engine != "postgresql" || request.Settings["output_path"] != "deps.json" {
+				t.Errorf("request = %+v, want the postgresql engine and output_path", request)
+			}
+			if len(request.Queries) != 1 || !reflect.DeepEqual(request.Queries[0], wantQuery) {
+				t.Errorf("Queries = %+v, want [%+v]", request.Queries, wantQuery)
+			}
+		})
+	}
+	
+	if _, err := NewInputReaderFromFile(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("Expected an error for a missing request file")
+	}
+}
+
+func TestResponseWriter_WriteResponse_Protobuf(t *testing.T) {
+	var buffer bytes.Buffer
+	writer := &ResponseWriter{writer: &buffer}
+	
+	files := []*types.GeneratedFile{
+		{Name: "deps.json", Contents: []byte(`{"function_view":{}}`)},
+		{Name: "empty.json", Contents: []byte{}},
+	}
+	if err := writer.WriteResponse(files); err != nil {
+		t.Fatalf("WriteResponse() error = %v", err)
+	}
+	
+	fields, err := decodeFields(buffer.Bytes())
+	if err != nil {
+		t.Fatalf("response is not valid protobuf: %v", err)
+	}
+	if len(fields) != len(files) {
+		t.Fatalf("got %d files, want %d", len(fields), len(files))
+	}
+	
+	for i, field := range fields {
+		if field.number != fieldResponseFiles || field.wireType != wireBytes {
+			t.Fatalf("unexpected field %d (wire type %d)", field.number, field.wireType)
+		}
+		
+		fileFields, err := decodeFields(field.bytes)
+		if err != nil {
+			t.Fatalf("file %d is not valid protobuf: %v", i, err)
+		}
+		
+		var name string
+		var contents []byte
+		for _, f := range fileFields {
+			switch f.number {
+			case fieldFileName:
+				name = string(f.bytes)
+			case fieldFileContents:
+				contents = f.bytes
+			}
+		}
+		
+		if name != files[i].Name {
+			t.Errorf("file %d name = %q, want %q", i, name, files[i].Name)
+		}
+		if !bytes.Equal(contents, files[i].Contents) {
+			t.Errorf("file %d contents = %q, want %q", i, contents, files[i].Contents)
+		}
+	}
+}
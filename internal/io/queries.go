@@ -0,0 +1,57 @@
+package io
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/naoyafurudono/sqlc-use-analysis/internal/config"
+)
+
+// queryAnnotationPattern matches sqlc query annotations such as "-- name: GetUser :one"
+var queryAnnotationPattern = regexp.MustCompile(`^--\s*name:\s*(\S+)\s+(:\S+)`)
+
+// ReadQueryFiles reads sqlc-annotated queries directly from .sql files
+// sqlcを経由せずに解析する場合（watchモードなど）に使用する
+func ReadQueryFiles(paths []string) ([]config.Query, error) {
+	var queries []config.Query
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read query file: %w", err)
+		}
+		queries = append(queries, parseQueries(string(data), path)...)
+	}
+	return queries, nil
+}
+
+// parseQueries splits a query file into annotated queries
+func parseQueries(content, filename string) []config.Query {
+	var queries []config.Query
+	var current *config.Query
+	var text []string
+	
+	flush := func() {
+		if current != nil {
+			current.Text = strings.TrimSpace(strings.Join(text, "\n"))
+			queries = append(queries, *current)
+		}
+		text = nil
+	}
+	
+	for _, line := range strings.Split(content, "\n") {
+		if matches := queryAnnotationPattern.FindStringSubmatch(strings.TrimSpace(line)); matches != nil {
+			flush()
+			current = &config.Query{Name: matches[1], Cmd: matches[2], Filename: filename}
+			continue
+		}
+		// 最初のアノテーションより前の行は無視する
+		if current != nil {
+			text = append(text, line)
+		}
+	}
+	flush()
+	
+	return queries
+}
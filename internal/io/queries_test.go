@@ -0,0 +1,49 @@
+package io
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/naoyafurudono/sqlc-use-analysis/internal/config"
+)
+
+func TestParseQueries(t *testing.T) {
+	content := `-- schema comments before the first annotation are ignored
+-- name: GetUser :one
+SELECT id, name FROM users WHERE id = $1;
+
+-- name: ListPostsByUser :many
+SELECT id, title
+FROM posts
+WHERE author_id = $1;
+`
+	
+	got := parseQueries(content, "query.sql")
+	want := []config.Query{
+		{Name: "GetUser", Cmd: ":one", Text: "SELECT id, name FROM users WHERE id = $1;", Filename: "query.sql"},
+		{Name: "ListPostsByUser", Cmd: ":many", Text: "SELECT id, title\nFROM posts\nWHERE author_id = $1;", Filename: "query.sql"},
+	}
+	
+	if len(got) != len(want) {
+		t.Fatalf("got %d queries, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("query %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestReadQueryFiles_Fixture(t *testing.T) {
+	queries, err := ReadQueryFiles([]string{"../../test/fixtures/simple_project/query.sql"})
+	if err != nil {
+		t.Fatalf("ReadQueryFiles() error = %v", err)
+	}
+	if len(queries) == 0 || queries[0].Name != "GetUser" {
+		t.Errorf("unexpected queries: %+v", queries)
+	}
+	
+	if _, err := ReadQueryFiles([]string{"does-not-exist.sql"}); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
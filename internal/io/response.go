@@ -1,7 +1,7 @@
 package io
 
 import (
-	"encoding/json"
+	"fmt"
 	"io"
 	"os"
 
@@ -20,17 +20,10 @@ func NewResponseWriter() *ResponseWriter {
 	}
 }
 
-// CodeGeneratorResponse represents the response from a code generator
-type CodeGeneratorResponse struct {
-	Files []*types.GeneratedFile `json:"files"`
-}
-
-// WriteResponse writes the plugin response
+// WriteResponse writes the plugin response as a sqlc GenerateResponse (protobuf)
 func (rw *ResponseWriter) WriteResponse(files []*types.GeneratedFile) error {
-	response := &CodeGeneratorResponse{
-		Files: files,
+	if _, err := rw.writer.Write(encodeGenerateResponse(files)); err != nil {
+		return fmt.Errorf("failed to write response: %w", err)
 	}
-	
-	encoder := json.NewEncoder(rw.writer)
-	return encoder.Encode(response)
+	return nil
 }
\ No newline at end of file
@@ -0,0 +1,146 @@
+package io
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SqlcPackage is one query set from sqlc.yaml with paths resolved against the config file
+type SqlcPackage struct {
+	Engine  string
+	Queries []string // .sqlファイルまたはディレクトリ
+	GoOut   string   // 生成されたGoコードの出力先ディレクトリ（Go生成なしの場合は空）
+}
+
+// sqlcConfigFile mirrors the parts of sqlc.yaml/sqlc.json (version 1 and 2) used for analysis
+type sqlcConfigFile struct {
+	Version  string `yaml:"version"`
+	Packages []struct {
+		Path    string     `yaml:"path"`
+		Queries stringList `yaml:"queries"`
+		Engine  string     `yaml:"engine"`
+	} `yaml:"packages"`
+	SQL []struct {
+		Engine  string     `yaml:"engine"`
+		Queries stringList `yaml:"queries"`
+		Gen     struct {
+			Go *struct {
+				Out string `yaml:"out"`
+			} `yaml:"go"`
+		} `yaml:"gen"`
+	} `yaml:"sql"`
+}
+
+// stringList accepts either a single string or a list of strings
+type stringList []string
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (s *stringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*s = stringList{node.Value}
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
+}
+
+// ReadSqlcConfig reads a sqlc.yaml or sqlc.json file
+// JSONはYAMLのサブセットなので同じデコーダで読み込める
+func ReadSqlcConfig(path string) ([]SqlcPackage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sqlc config: %w", err)
+	}
+	
+	var file sqlcConfigFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse sqlc config %s: %w", path, err)
+	}
+	
+	baseDir := filepath.Dir(path)
+	var packages []SqlcPackage
+	switch file.Version {
+	case "1":
+		for _, pkg := range file.Packages {
+			packages = append(packages, SqlcPackage{
+				Engine:  pkg.Engine,
+				Queries: resolvePaths(baseDir, pkg.Queries),
+				GoOut:   resolvePath(baseDir, pkg.Path),
+			})
+		}
+	case "2":
+		for _, sql := range file.SQL {
+			pkg := SqlcPackage{
+				Engine:  sql.Engine,
+				Queries: resolvePaths(baseDir, sql.Queries),
+			}
+			if sql.Gen.Go != nil {
+				pkg.GoOut = resolvePath(baseDir, sql.Gen.Go.Out)
+			}
+			packages = append(packages, pkg)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported sqlc config version: %q", file.Version)
+	}
+	
+	if len(packages) == 0 {
+		return nil, fmt.Errorf("no packages defined in sqlc config %s", path)
+	}
+	return packages, nil
+}
+
+// ListQueryFiles expands query paths into the .sql files they refer to
+// ディレクトリの場合は直下の.sqlファイルのみを対象とする（sqlcと同じ挙動）
+func ListQueryFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to access query path: %w", err)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read query directory: %w", err)
+		}
+		var dirFiles []string
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".sql") {
+				dirFiles = append(dirFiles, filepath.Join(path, entry.Name()))
+			}
+		}
+		sort.Strings(dirFiles)
+		files = append(files, dirFiles...)
+	}
+	return files, nil
+}
+
+// resolvePaths resolves each path against baseDir
+func resolvePaths(baseDir string, paths []string) []string {
+	resolved := make([]string, 0, len(paths))
+	for _, path := range paths {
+		resolved = append(resolved, resolvePath(baseDir, path))
+	}
+	return resolved
+}
+
+// resolvePath resolves a path from the config file against baseDir
+func resolvePath(baseDir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
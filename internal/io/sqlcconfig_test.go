@@ -0,0 +1,84 @@
+package io
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadSqlcConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []SqlcPackage
+		wantErr bool
+	}{
+		{
+			name: "version 2 with query list",
+			file: "sqlc.yaml",
+			content: `version: "2"
+sql:
+  - engine: mysql
+    queries: [users.sql, posts.sql]
+    gen:
+      go:
+        out: db
+  - engine: mysql
+    queries: reports
+`,
+			want: []SqlcPackage{
+				{Engine: "mysql", Queries: []string{"users.sql", "posts.sql"}, GoOut: "db"},
+				{Engine: "mysql", Queries: []string{"reports"}},
+			},
+		},
+		{
+			name:    "version 1 json",
+			file:    "sqlc.json",
+			content: `{"version": "1", "packages": [{"name": "db", "path": "internal/db", "queries": "./query/", "engine": "postgresql"}]}`,
+			want: []SqlcPackage{
+				{Engine: "postgresql", Queries: []string{"query"}, GoOut: "internal/db"},
+			},
+		},
+		{
+			name:    "unsupported version",
+			file:    "sqlc.yaml",
+			content: `version: "3"`,
+			wantErr: true,
+		},
+		{
+			name:    "no packages",
+			file:    "sqlc.yaml",
+			content: `version: "2"`,
+			wantErr: true,
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, tt.file), []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			
+			got, err := ReadSqlcConfig(filepath.Join(dir, tt.file))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadSqlcConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			
+			// 相対パスはconfigファイルのディレクトリを基準に解決される
+			for i := range tt.want {
+				for j, query := range tt.want[i].Queries {
+					tt.want[i].Queries[j] = filepath.Join(dir, query)
+				}
+				if tt.want[i].GoOut != "" {
+					tt.want[i].GoOut = filepath.Join(dir, tt.want[i].GoOut)
+				}
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadSqlcConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package orchestrator
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/naoyafurudono/sqlc-use-analysis/internal/analyzer/dependency"
@@ -19,10 +21,13 @@ type Orchestrator struct {
 
 // New creates a new orchestrator
 func New(cfg *types.Config, errorCollector *errors.ErrorCollector) (*Orchestrator, error) {
+	errorCollector.SetMaxDetailLength(cfg.Debug.MaxDetailLength)
+	errorCollector.SetCountOnly(cfg.Debug.CountOnlyErrors)
+	
 	return &Orchestrator{
 		config:         cfg,
 		errorCollector: errorCollector,
-		engine:         dependency.NewEngine(errorCollector),
+		engine:         newEngine(cfg, errorCollector),
 	}, nil
 }
 
@@ -32,6 +37,7 @@ func (o *Orchestrator) Execute(ctx context.Context, request *config.CodeGenerato
 	
 	// 基本的な結果構造を作成
 	result := &types.DependencyResult{
+		SchemaVersion: types.SchemaVersion,
 		Metadata: types.Metadata{
 			GeneratedAt: startTime,
 			Version:     "dev",
@@ -40,29 +46,61 @@ func (o *Orchestrator) Execute(ctx context.Context, request *config.CodeGenerato
 		TableView:    make(map[string][]types.FunctionAccess),
 	}
 	
-	// TODO: 実際の解析処理を実装
-	// 現在はダミーデータを返す
-	result.FunctionView["example.Handler"] = []types.TableAccess{
-		{
-			Table:      "users",
-			Operations: []string{"SELECT"},
-		},
+	// リクエストからクエリを抽出
+	queries, err := extractQueries(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract queries: %w", err)
 	}
 	
-	result.TableView["users"] = []types.FunctionAccess{
-		{
-			Function:   "example.Handler",
-			Operations: []string{"SELECT"},
-		},
+	// クエリがない場合は空の結果を返す
+	if len(queries) > 0 {
+		packagePaths := packagePathsFromConfig(o.config)
+		
+		if err := o.engine.ValidateInput(queries, packagePaths); err != nil {
+			return nil, fmt.Errorf("input validation failed: %w", err)
+		}
+		
+		// sqlcのカタログがあればテーブル名の検証に使う
+		o.engine.SetKnownTables(knownTablesFromRequest(request))
+		
+		analysis, err := o.engine.AnalyzeDependencies(queries, packagePaths)
+		if err != nil {
+			return nil, fmt.Errorf("dependency analysis failed: %w", err)
+		}
+		
+		fillDependencyResult(result, analysis)
 	}
 	
 	// 実行時間の記録
+	result.Metadata.TotalFuncs = len(result.FunctionView)
+	result.Metadata.TotalTables = len(result.TableView)
 	result.Metadata.AnalysisDuration = time.Since(startTime)
 	
 	return result, nil
 }
 
-// TODO: 各解析モジュールの実装
-// - SQL解析
-// - Go解析
-// - 依存関係マッピング
\ No newline at end of file
+// fillDependencyResult converts the engine result into the plugin output views
+func fillDependencyResult(result *types.DependencyResult, analysis types.AnalysisResult) {
+	for funcName, entry := range analysis.FunctionView {
+		accesses := make([]types.TableAccess, 0, len(entry.TableAccess))
+		for tableName, access := range entry.TableAccess {
+			operations := make([]string, 0, len(access.Operations))
+			for operation := range access.Operations {
+				operations = append(operations, operation)
+			}
+			sort.Strings(operations)
+			accesses = append(accesses, types.TableAccess{Table: tableName, Operations: operations})
+		}
+		sort.Slice(accesses, func(i, j int) bool { return accesses[i].Table < accesses[j].Table })
+		result.FunctionView[funcName] = accesses
+	}
+	
+	for tableName, entry := range analysis.TableView {
+		accesses := make([]types.FunctionAccess, 0, len(entry.AccessedBy))
+		for _, access := range entry.AccessedBy {
+			accesses = append(accesses, access)
+		}
+		sort.Slice(accesses, func(i, j int) bool { return accesses[i].Function < accesses[j].Function })
+		result.TableView[tableName] = accesses
+	}
+}
@@ -20,29 +20,35 @@ type NewOrchestrator struct {
 
 // NewUpdated creates a new orchestrator with the updated dependency engine
 func NewUpdated(cfg *types.Config, errorCollector *errors.ErrorCollector) (*NewOrchestrator, error) {
+	errorCollector.SetMaxDetailLength(cfg.Debug.MaxDetailLength)
+	errorCollector.SetCountOnly(cfg.Debug.CountOnlyErrors)
+	
 	return &NewOrchestrator{
 		config:         cfg,
 		errorCollector: errorCollector,
-		engine:         dependency.NewEngine(errorCollector),
+		engine:         newEngine(cfg, errorCollector),
 	}, nil
 }
 
 // ExecuteAnalysis performs the complete analysis
 func (o *NewOrchestrator) ExecuteAnalysis(ctx context.Context, request *config.CodeGeneratorRequest) (*types.AnalysisReport, error) {
 	// Extract query information from request
-	queries, err := o.extractQueries(request)
+	queries, err := extractQueries(request)
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract queries: %w", err)
 	}
 	
 	// Get Go package paths from configuration
-	packagePaths := o.getPackagePaths()
+	packagePaths := packagePathsFromConfig(o.config)
 	
 	// Validate input
 	if err := o.engine.ValidateInput(queries, packagePaths); err != nil {
 		return nil, fmt.Errorf("input validation failed: %w", err)
 	}
 	
+	// sqlcのカタログがあればテーブル名の検証に使う
+	o.engine.SetKnownTables(knownTablesFromRequest(request))
+	
 	// Perform dependency analysis
 	result, err := o.engine.AnalyzeDependencies(queries, packagePaths)
 	if err != nil {
@@ -59,40 +65,75 @@ func (o *NewOrchestrator) ExecuteAnalysis(ctx context.Context, request *config.C
 	return &report, nil
 }
 
+// newEngine creates a dependency engine configured from the analysis settings
+func newEngine(cfg *types.Config, errorCollector *errors.ErrorCollector) *dependency.Engine {
+	engine := dependency.NewEngineWithDialect(cfg.Analysis.SQLDialect, cfg.Analysis.CaseSensitiveTables, errorCollector)
+	engine.SetMethodPrefixes(cfg.Analysis.MethodPrefixes)
+	engine.SetAcronyms(cfg.Analysis.Acronyms)
+	engine.SetIncludeDDL(cfg.Analysis.IncludeDDL)
+	engine.SetReadContextEdges(cfg.Analysis.ReadContextEdges)
+	engine.SetRawSQL(cfg.Analysis.RawSQL)
+	engine.SetSkipGeneratedFiles(cfg.Analysis.SkipGeneratedFiles)
+	engine.SetIncludeDriverCalls(cfg.Analysis.IncludeDriverCalls)
+	engine.SetStrictTypes(cfg.Analysis.StrictTypes)
+	engine.SetPackageFilters(cfg.Analysis.IncludePackages, cfg.Analysis.ExcludePackages)
+	engine.SetFollowDependencies(cfg.Analysis.IncludeVendor, cfg.Analysis.FollowPackages)
+	engine.SetLoadRetries(cfg.Performance.LoadRetries)
+	engine.SetMemoryLimit(cfg.Performance.MemoryLimit)
+	engine.SetRootPath(cfg.RootPath)
+	return engine
+}
+
 // extractQueries extracts SQL queries from the code generator request
-func (o *NewOrchestrator) extractQueries(request *config.CodeGeneratorRequest) ([]types.QueryInfo, error) {
-	var queries []types.QueryInfo
+func extractQueries(request *config.CodeGeneratorRequest) ([]types.QueryInfo, error) {
+	queries := make([]types.QueryInfo, 0, len(request.Queries))
 	
-	// Extract from sqlc configuration and files
-	// This is a simplified implementation - in practice, you'd parse the sqlc files
-	// For now, we'll add sample queries since CodeGeneratorRequest doesn't have Files field
-	_ = request // Use request to avoid unused variable warning
-	
-	// If no queries found, add some sample queries for testing
-	if len(queries) == 0 {
-		queries = []types.QueryInfo{
-			{
-				Name: "GetUser",
-				SQL:  "SELECT id, name FROM users WHERE id = $1",
-			},
-			{
-				Name: "ListUsers",
-				SQL:  "SELECT id, name FROM users ORDER BY id",
-			},
+	for i, query := range request.Queries {
+		if query.Name == "" {
+			return nil, fmt.Errorf("query at index %d has empty name", i)
 		}
+		
+		queries = append(queries, types.QueryInfo{
+			Name:     query.Name,
+			SQL:      query.Text,
+			Cmd:      query.Cmd,
+			Filename: query.Filename,
+			Comments: query.Comments,
+		})
 	}
 	
 	return queries, nil
 }
 
-// getPackagePaths gets Go package paths from configuration
-func (o *NewOrchestrator) getPackagePaths() []string {
+// knownTablesFromRequest collects the table names defined in the request's catalog
+// Tables are registered both unqualified and qualified with their schema
+func knownTablesFromRequest(request *config.CodeGeneratorRequest) map[string]struct{} {
+	if request.Catalog == nil || len(request.Catalog.Tables) == 0 {
+		return nil
+	}
+	
+	tables := make(map[string]struct{}, len(request.Catalog.Tables)*2)
+	for _, table := range request.Catalog.Tables {
+		if table.Name == "" {
+			continue
+		}
+		tables[table.Name] = struct{}{}
+		if table.Schema != "" {
+			tables[table.Schema+"."+table.Name] = struct{}{}
+		}
+	}
+	
+	return tables
+}
+
+// packagePathsFromConfig gets Go package paths from configuration
+func packagePathsFromConfig(cfg *types.Config) []string {
 	// Default package paths
 	packagePaths := []string{".", "./cmd/...", "./internal/..."}
 	
 	// Add configured paths if available
-	if o.config.GoPackagePaths != nil {
-		packagePaths = o.config.GoPackagePaths
+	if cfg.GoPackagePaths != nil {
+		packagePaths = cfg.GoPackagePaths
 	}
 	
 	return packagePaths
@@ -2,11 +2,17 @@ package orchestrator
 
 import (
 	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/naoyafurudono/sqlc-use-analysis/internal/config"
 	"github.com/naoyafurudono/sqlc-use-analysis/internal/errors"
+	"github.com/naoyafurudono/sqlc-use-analysis/internal/io"
 	"github.com/naoyafurudono/sqlc-use-analysis/pkg/types"
 )
 
@@ -49,7 +55,7 @@ func TestOrchestrator_Execute(t *testing.T) {
 	
 	request := &config.CodeGeneratorRequest{
 		Settings: make(map[string]interface{}),
-		Queries:  []interface{}{},
+		Queries:  []config.Query{},
 	}
 	
 	ctx := context.Background()
@@ -84,13 +90,199 @@ func TestOrchestrator_Execute(t *testing.T) {
 		t.Error("Expected TableView to be initialized")
 	}
 	
-	// ダミーデータの確認（現在の実装）
-	if len(result.FunctionView) == 0 {
-		t.Error("Expected FunctionView to have dummy data")
+	// クエリがない場合は空の結果
+	if len(result.FunctionView) != 0 {
+		t.Errorf("Expected empty FunctionView without queries, got %v", result.FunctionView)
 	}
 	
-	if len(result.TableView) == 0 {
-		t.Error("Expected TableView to have dummy data")
+	if len(result.TableView) != 0 {
+		t.Errorf("Expected empty TableView without queries, got %v", result.TableView)
+	}
+}
+
+func TestOrchestrator_Execute_RealAnalysis(t *testing.T) {
+	cfg := &types.Config{
+		RootPath:       ".",
+		OutputPath:     "test.json",
+		GoPackagePaths: []string{"github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/..."},
+	}
+	errorCollector := errors.NewErrorCollector(10, false)
+	
+	orch, err := New(cfg, errorCollector)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	
+	request := &config.CodeGeneratorRequest{
+		Settings: make(map[string]interface{}),
+		Queries: []config.Query{
+			{
+				Name: "GetUser",
+				Cmd:  ":one",
+				Text: "SELECT id, name, email, created_at FROM users WHERE id = $1",
+			},
+		},
+	}
+	
+	result, err := orch.Execute(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	
+	accesses, exists := result.FunctionView["github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/service.UserService.GetUser"]
+	if !exists {
+		t.Fatalf("Expected UserService.GetUser in FunctionView, got %v", result.FunctionView)
+	}
+	
+	if len(accesses) != 1 || accesses[0].Table != "users" {
+		t.Errorf("Expected UserService.GetUser to access users, got %v", accesses)
+	}
+	
+	if _, exists := result.FunctionView["example.Handler"]; exists {
+		t.Error("Expected no dummy data in FunctionView")
+	}
+	
+	if len(result.TableView["users"]) == 0 {
+		t.Error("Expected users table to be accessed")
+	}
+	
+	if result.Metadata.TotalFuncs != len(result.FunctionView) {
+		t.Errorf("Expected TotalFuncs %d, got %d", len(result.FunctionView), result.Metadata.TotalFuncs)
+	}
+}
+
+func TestNewOrchestrator_ExecuteAnalysis_KnownTables(t *testing.T) {
+	cfg := &types.Config{
+		RootPath:       ".",
+		OutputPath:     "test.json",
+		GoPackagePaths: []string{"github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/..."},
+	}
+	errorCollector := errors.NewErrorCollector(10, false)
+	
+	orch, err := NewUpdated(cfg, errorCollector)
+	if err != nil {
+		t.Fatalf("NewUpdated() error = %v", err)
+	}
+	
+	request := &config.CodeGeneratorRequest{
+		Settings: make(map[string]interface{}),
+		Catalog: &config.Catalog{
+			Tables: []config.TableName{{Name: "posts"}},
+		},
+		Queries: []config.Query{
+			{
+				Name: "GetUser",
+				Cmd:  ":one",
+				Text: "SELECT id, name, email, created_at FROM users WHERE id = $1",
+			},
+		},
+	}
+	
+	if _, err := orch.ExecuteAnalysis(context.Background(), request); err != nil {
+		t.Fatalf("ExecuteAnalysis() error = %v", err)
+	}
+	
+	// カタログにないテーブルは警告される
+	for _, warning := range errorCollector.GetWarnings() {
+		if strings.Contains(warning.Message, "not defined in the schema catalog") {
+			return
+		}
+	}
+	t.Errorf("Expected a warning for users missing from the catalog, got %v", errorCollector.GetWarnings())
+}
+
+func TestOrchestrator_Execute_RequestFromFile(t *testing.T) {
+	requestPath := filepath.Join(t.TempDir(), "request.json")
+	captured := `{
+  "settings": {
+    "go_package_paths": ["github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/..."]
+  },
+  "queries": [
+    {"name": "ListUsers", "cmd": ":many", "text": "SELECT id, name, email, created_at FROM users ORDER BY created_at DESC"}
+  ]
+}`
+	if err := os.WriteFile(requestPath, []byte(captured), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	
+	reader, err := io.NewInputReaderFromFile(requestPath)
+	if err != nil {
+		t.Fatalf("NewInputReaderFromFile() error = %v", err)
+	}
+	request, err := reader.ReadRequest()
+	if err != nil {
+		t.Fatalf("ReadRequest() error = %v", err)
+	}
+	cfg, err := config.NewConfigLoader().LoadFromRequest(request)
+	if err != nil {
+		t.Fatalf("LoadFromRequest() error = %v", err)
+	}
+	
+	orch, err := New(cfg, errors.NewErrorCollector(10, false))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	result, err := orch.Execute(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	
+	accesses := result.FunctionView["github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/service.UserService.ListUsers"]
+	if len(accesses) != 1 || accesses[0].Table != "users" {
+		t.Errorf("Expected UserService.ListUsers to access users, got %v", accesses)
+	}
+}
+
+func TestOrchestrator_Execute_WriteThenRead(t *testing.T) {
+	cfg := &types.Config{
+		RootPath:       ".",
+		OutputPath:     "test.json",
+		GoPackagePaths: []string{"github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/..."},
+	}
+	errorCollector := errors.NewErrorCollector(10, false)
+	
+	orch, err := New(cfg, errorCollector)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	
+	request := &config.CodeGeneratorRequest{
+		Settings: make(map[string]interface{}),
+		Queries: []config.Query{
+			{
+				Name: "CreateUser",
+				Cmd:  ":one",
+				Text: "INSERT INTO users (name, email) VALUES ($1, $2) RETURNING id, name, email, created_at",
+			},
+			{
+				Name: "GetUser",
+				Cmd:  ":one",
+				Text: "SELECT id, name, email, created_at FROM users WHERE id = $1",
+			},
+		},
+	}
+	
+	if _, err := orch.Execute(context.Background(), request); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	
+	var findings []*errors.AnalysisError
+	for _, info := range errorCollector.GetInfos() {
+		if strings.HasPrefix(info.Message, "write-then-read") {
+			findings = append(findings, info)
+		}
+	}
+	
+	if len(findings) != 1 {
+		t.Fatalf("Expected 1 write-then-read finding, got %d: %v", len(findings), findings)
+	}
+	
+	finding := findings[0]
+	if finding.Message != "write-then-read on table users in function UserService.RegisterUser" {
+		t.Errorf("Unexpected finding message: %q", finding.Message)
+	}
+	if finding.Details["write_method"] != "CreateUser" || finding.Details["read_method"] != "GetUser" {
+		t.Errorf("Unexpected finding details: %v", finding.Details)
 	}
 }
 
@@ -108,7 +300,7 @@ func TestOrchestrator_Execute_WithContext(t *testing.T) {
 	
 	request := &config.CodeGeneratorRequest{
 		Settings: make(map[string]interface{}),
-		Queries:  []interface{}{},
+		Queries:  []config.Query{},
 	}
 	
 	// タイムアウトを設定したコンテキスト
@@ -124,4 +316,90 @@ func TestOrchestrator_Execute_WithContext(t *testing.T) {
 	if result == nil {
 		t.Fatal("Execute() returned nil result")
 	}
-}
\ No newline at end of file
+}
+func TestExtractQueries(t *testing.T) {
+	// sqlcのリクエストJSONを模したペイロード
+	payload := `{
+		"settings": {},
+		"queries": [
+			{
+				"name": "GetUser",
+				"cmd": ":one",
+				"text": "SELECT id, name, email FROM users WHERE id = $1",
+				"filename": "query.sql"
+			},
+			{
+				"name": "ListPostsByUser",
+				"cmd": ":many",
+				"text": "SELECT id, title FROM posts WHERE author_id = $1 ORDER BY created_at DESC",
+				"filename": "query.sql"
+			},
+			{
+				"name": "DeleteOldPosts",
+				"cmd": ":exec",
+				"text": "DELETE FROM posts WHERE created_at < $1",
+				"filename": "maintenance.sql",
+				"comments": ["sqlc-analysis:ignore"]
+			}
+		]
+	}`
+	
+	var request config.CodeGeneratorRequest
+	if err := json.Unmarshal([]byte(payload), &request); err != nil {
+		t.Fatalf("Failed to decode request: %v", err)
+	}
+	
+	queries, err := extractQueries(&request)
+	if err != nil {
+		t.Fatalf("extractQueries() error = %v", err)
+	}
+	
+	expected := []types.QueryInfo{
+		{Name: "GetUser", Cmd: ":one", SQL: "SELECT id, name, email FROM users WHERE id = $1", Filename: "query.sql"},
+		{Name: "ListPostsByUser", Cmd: ":many", SQL: "SELECT id, title FROM posts WHERE author_id = $1 ORDER BY created_at DESC", Filename: "query.sql"},
+		{Name: "DeleteOldPosts", Cmd: ":exec", SQL: "DELETE FROM posts WHERE created_at < $1", Filename: "maintenance.sql", Comments: []string{"sqlc-analysis:ignore"}},
+	}
+	
+	if len(queries) != len(expected) {
+		t.Fatalf("Expected %d queries, got %d", len(expected), len(queries))
+	}
+	
+	for i, want := range expected {
+		if !reflect.DeepEqual(queries[i], want) {
+			t.Errorf("Query %d = %+v, want %+v", i, queries[i], want)
+		}
+	}
+}
+
+func TestExtractQueries_EmptyName(t *testing.T) {
+	request := &config.CodeGeneratorRequest{
+		Queries: []config.Query{{Cmd: ":one", Text: "SELECT 1"}},
+	}
+	
+	if _, err := extractQueries(request); err == nil {
+		t.Error("Expected error for query without name")
+	}
+}
+
+func TestKnownTablesFromRequest(t *testing.T) {
+	request := &config.CodeGeneratorRequest{
+		Catalog: &config.Catalog{
+			DefaultSchema: "public",
+			Tables: []config.TableName{
+				{Schema: "public", Name: "users"},
+				{Name: "posts"},
+			},
+		},
+	}
+	
+	tables := knownTablesFromRequest(request)
+	for _, name := range []string{"users", "public.users", "posts"} {
+		if _, ok := tables[name]; !ok {
+			t.Errorf("Expected %q in known tables, got %v", name, tables)
+		}
+	}
+	
+	if tables := knownTablesFromRequest(&config.CodeGeneratorRequest{}); tables != nil {
+		t.Errorf("Expected nil known tables without catalog, got %v", tables)
+	}
+}
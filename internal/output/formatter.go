@@ -1,9 +1,13 @@
 package output
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"html"
 	"io"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/naoyafurudono/sqlc-use-analysis/pkg/types"
@@ -11,25 +15,53 @@ import (
 
 // Formatter handles output formatting for analysis results
 type Formatter struct {
-	format types.OutputFormat
-	pretty bool
+	format         types.OutputFormat
+	pretty         bool
+	primaryView    types.PrimaryView
+	operationStyle types.OperationStyle
 }
 
 // NewFormatter creates a new output formatter
 func NewFormatter(format types.OutputFormat, pretty bool) *Formatter {
 	return &Formatter{
-		format: format,
-		pretty: pretty,
+		format:      format,
+		pretty:      pretty,
+		primaryView: types.ViewBoth,
 	}
 }
 
+// WithPrimaryView sets which dependency view is emitted
+// Views other than the primary one are omitted unless view is "both"
+func (f *Formatter) WithPrimaryView(view types.PrimaryView) *Formatter {
+	if view == "" {
+		view = types.ViewBoth
+	}
+	f.primaryView = view
+	return f
+}
+
+// WithOperationStyle sets how operations are named ("sql" by default, or "crud")
+func (f *Formatter) WithOperationStyle(style types.OperationStyle) *Formatter {
+	f.operationStyle = style
+	return f
+}
+
 // Format formats the analysis report according to the specified format
 func (f *Formatter) Format(report *types.AnalysisReport, writer io.Writer) error {
+	if !f.primaryView.IsValid() {
+		return fmt.Errorf("unsupported primary view: %s", f.primaryView)
+	}
+	report = renameReportOperations(report, f.operationStyle)
+	
 	switch f.format {
 	case types.FormatJSON:
 		return f.formatJSON(report, writer)
+	case types.FormatCSV:
+		return f.formatCSV(report, writer)
+	case types.FormatHTML:
+		return f.formatHTML(report, writer)
 	default:
-		return fmt.Errorf("unsupported format: %s (only JSON is supported)", f.format)
+		return fmt.Errorf("unsupported format: %s (supported: json, csv, html)", f.format)
 	}
 }
 
@@ -41,15 +73,26 @@ func (f *Formatter) formatJSON(report *types.AnalysisReport, writer io.Writer) e
 		encoder.SetIndent("", "  ")
 	}
 	
+	// Only emit the views selected by the primary view setting
+	dependencies := make(map[string]interface{})
+	if f.primaryView.IncludesFunctionView() {
+		dependencies["function_view"] = report.Dependencies.FunctionView
+	}
+	if f.primaryView.IncludesTableView() {
+		dependencies["table_view"] = report.Dependencies.TableView
+	}
+	
 	// Add metadata
 	output := map[string]interface{}{
+		"schema_version": types.SchemaVersion,
 		"metadata": map[string]interface{}{
 			"generated_at": time.Now().Format(time.RFC3339),
 			"version":      "1.0.0",
 			"tool":         "sqlc-use-analysis",
+			"primary_view": f.primaryView,
 		},
 		"summary":      report.Summary,
-		"dependencies": report.Dependencies,
+		"dependencies": dependencies,
 	}
 	
 	// Add optional sections
@@ -63,3 +106,359 @@ func (f *Formatter) formatJSON(report *types.AnalysisReport, writer io.Writer) e
 	
 	return encoder.Encode(output)
 }
+
+// formatCSV formats the report as CSV with one section per view
+func (f *Formatter) formatCSV(report *types.AnalysisReport, writer io.Writer) error {
+	var sections []section
+	
+	if f.primaryView.IncludesFunctionView() {
+		functions := section{header: []string{"Function", "Package", "File", "Tables", "Operations"}}
+		for _, funcName := range sortedKeys(report.Dependencies.FunctionView) {
+			entry := report.Dependencies.FunctionView[funcName]
+			tables := sortedKeys(entry.TableAccess)
+			
+			var operations []string
+			for _, tableName := range tables {
+				operations = append(operations, sortedKeys(entry.TableAccess[tableName].Operations)...)
+			}
+			
+			functions.rows = append(functions.rows, []string{
+				funcName,
+				entry.PackageName,
+				entry.FileName,
+				joinStrings(tables, ";"),
+				joinStrings(uniqueSorted(operations), ";"),
+			})
+		}
+		sections = append(sections, functions)
+	}
+	
+	if f.primaryView.IncludesTableView() {
+		tables := section{header: []string{"Table", "Functions", "Operations"}}
+		for _, tableName := range sortedKeys(report.Dependencies.TableView) {
+			entry := report.Dependencies.TableView[tableName]
+			tables.rows = append(tables.rows, []string{
+				tableName,
+				joinStrings(sortedKeys(entry.AccessedBy), ";"),
+				joinStrings(sortedKeys(entry.OperationSummary), ";"),
+			})
+		}
+		sections = append(sections, tables)
+	}
+	
+	return writeCSV(writer, sections)
+}
+
+// FormatResult formats the plugin's dependency result as CSV or Markdown
+// The plugin writes JSON itself, so only the tabular formats are handled here
+func (f *Formatter) FormatResult(result *types.DependencyResult, writer io.Writer) error {
+	if !f.primaryView.IsValid() {
+		return fmt.Errorf("unsupported primary view: %s", f.primaryView)
+	}
+	result = RenameOperations(result, f.operationStyle)
+	
+	switch f.format {
+	case types.FormatCSV:
+		return writeCSV(writer, f.resultSections(result, ";"))
+	case types.FormatMarkdown:
+		return f.formatResultMarkdown(result, writer)
+	default:
+		return fmt.Errorf("unsupported format: %s (supported: csv, markdown)", f.format)
+	}
+}
+
+// resultSections lists one row per function-table access of the selected views
+func (f *Formatter) resultSections(result *types.DependencyResult, sep string) []section {
+	var sections []section
+	
+	if f.primaryView.IncludesFunctionView() {
+		functions := section{title: "Functions", header: []string{"Function", "Table", "Operations"}}
+		for _, function := range sortedKeys(result.FunctionView) {
+			for _, access := range result.FunctionView[function] {
+				functions.rows = append(functions.rows, []string{function, access.Table, joinStrings(access.Operations, sep)})
+			}
+		}
+		sections = append(sections, functions)
+	}
+	
+	if f.primaryView.IncludesTableView() {
+		tables := section{title: "Tables", header: []string{"Table", "Function", "Operations"}}
+		for _, table := range sortedKeys(result.TableView) {
+			for _, access := range result.TableView[table] {
+				tables.rows = append(tables.rows, []string{table, access.Function, joinStrings(access.Operations, sep)})
+			}
+		}
+		sections = append(sections, tables)
+	}
+	return sections
+}
+
+// formatResultMarkdown formats the dependency result as Markdown tables for human review
+func (f *Formatter) formatResultMarkdown(result *types.DependencyResult, writer io.Writer) error {
+	var buf strings.Builder
+	
+	buf.WriteString("# Database Dependencies\n\n")
+	buf.WriteString(fmt.Sprintf("%d functions, %d tables (schema %s, generated %s)\n",
+		result.Metadata.TotalFuncs, result.Metadata.TotalTables, result.SchemaVersion,
+		result.Metadata.GeneratedAt.Format("2006-01-02 15:04:05 MST")))
+	
+	for _, s := range f.resultSections(result, ", ") {
+		buf.WriteString("\n## " + s.title + "\n\n")
+		buf.WriteString("| " + joinStrings(s.header, " | ") + " |\n")
+		buf.WriteString(strings.Repeat("| --- ", len(s.header)) + "|\n")
+		for _, row := range s.rows {
+			cells := make([]string, len(row))
+			for i, cell := range row {
+				cells[i] = markdownCell(cell)
+			}
+			buf.WriteString("| " + joinStrings(cells, " | ") + " |\n")
+		}
+	}
+	
+	_, err := io.WriteString(writer, buf.String())
+	return err
+}
+
+// section is one dependency view rendered as a table
+type section struct {
+	title  string
+	header []string
+	rows   [][]string
+}
+
+// writeCSV writes the sections as CSV, separated by an empty record
+func writeCSV(writer io.Writer, sections []section) error {
+	csvWriter := csv.NewWriter(writer)
+	for i, s := range sections {
+		if i > 0 {
+			// セクション間の区切り
+			csvWriter.Write([]string{})
+		}
+		csvWriter.Write(s.header)
+		for _, row := range s.rows {
+			csvWriter.Write(row)
+		}
+	}
+	
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// markdownCell escapes characters that would break a Markdown table cell
+func markdownCell(value string) string {
+	return strings.ReplaceAll(value, "|", `\|`)
+}
+
+// formatHTML formats the report as a standalone HTML page
+func (f *Formatter) formatHTML(report *types.AnalysisReport, writer io.Writer) error {
+	var buf strings.Builder
+	
+	buf.WriteString("<!DOCTYPE html>\n<html>\n<head>\n")
+	buf.WriteString("<meta charset=\"utf-8\">\n")
+	buf.WriteString(fmt.Sprintf("<meta name=\"schema-version\" content=\"%s\">\n", types.SchemaVersion))
+	buf.WriteString("<title>SQLC Dependency Analysis Report</title>\n")
+	buf.WriteString("<style>\n" + htmlReportStyle + "</style>\n")
+	buf.WriteString("</head>\n<body>\n")
+	buf.WriteString("<h1>SQLC Dependency Analysis Report</h1>\n")
+	
+	// Summary
+	buf.WriteString("<h2>Summary</h2>\n<ul>\n")
+	buf.WriteString(fmt.Sprintf("<li>Functions: %d</li>\n", report.Summary.FunctionCount))
+	buf.WriteString(fmt.Sprintf("<li>Tables: %d</li>\n", report.Summary.TableCount))
+	buf.WriteString(fmt.Sprintf("<li>Operations: %d</li>\n", sumOperations(report.Summary.OperationCounts)))
+	buf.WriteString("</ul>\n")
+	
+	if f.primaryView.IncludesFunctionView() {
+		buf.WriteString("<h2>Function View</h2>\n")
+		buf.WriteString("<p><label for=\"package-filter\">Filter by package:</label> " +
+			"<input type=\"search\" id=\"package-filter\" placeholder=\"package name\"></p>\n")
+		buf.WriteString("<table id=\"function-view\">\n")
+		buf.WriteString("<tr><th>Function</th><th>Package</th><th>File</th><th>Table</th><th>Operations</th></tr>\n")
+		for _, funcName := range sortedKeys(report.Dependencies.FunctionView) {
+			entry := report.Dependencies.FunctionView[funcName]
+			for _, tableName := range sortedKeys(entry.TableAccess) {
+				buf.WriteString(fmt.Sprintf("<tr data-package=\"%s\" data-table=\"%s\"><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+					html.EscapeString(entry.PackageName),
+					html.EscapeString(tableName),
+					html.EscapeString(funcName),
+					html.EscapeString(entry.PackageName),
+					html.EscapeString(entry.FileName),
+					html.EscapeString(tableName),
+					html.EscapeString(joinStrings(sortedKeys(entry.TableAccess[tableName].Operations), ", "))))
+			}
+		}
+		buf.WriteString("</table>\n")
+	}
+	
+	if f.primaryView.IncludesTableView() {
+		buf.WriteString("<h2>Table View</h2>\n<table id=\"table-view\">\n")
+		buf.WriteString("<tr><th>Table</th><th>Functions</th><th>Operations</th></tr>\n")
+		for _, tableName := range sortedKeys(report.Dependencies.TableView) {
+			entry := report.Dependencies.TableView[tableName]
+			buf.WriteString(fmt.Sprintf("<tr data-table=\"%s\"><td><a href=\"#function-view\" class=\"table-link\">%s</a></td><td>%s</td><td>%s</td></tr>\n",
+				html.EscapeString(tableName),
+				html.EscapeString(tableName),
+				html.EscapeString(joinStrings(sortedKeys(entry.AccessedBy), ", ")),
+				html.EscapeString(joinStrings(sortedKeys(entry.OperationSummary), ", "))))
+		}
+		buf.WriteString("</table>\n")
+	}
+	
+	buf.WriteString("<script>\n" + htmlReportScript + "</script>\n")
+	buf.WriteString("</body>\n</html>\n")
+	
+	_, err := io.WriteString(writer, buf.String())
+	return err
+}
+
+// htmlReportStyle highlights the function rows selected through the table view
+const htmlReportStyle = `tr.highlight { background: #fff3b0; }
+tr.hidden { display: none; }
+`
+
+// htmlReportScript filters function rows by package and, when a table is clicked,
+// highlights every function accessing it
+// 外部依存なしで動作するようにレポートに埋め込む
+const htmlReportScript = `(function () {
+  var rows = document.querySelectorAll("#function-view tr[data-package]");
+  var filter = document.getElementById("package-filter");
+  if (filter) {
+    filter.addEventListener("input", function () {
+      var query = filter.value.toLowerCase();
+      rows.forEach(function (row) {
+        var match = row.getAttribute("data-package").toLowerCase().indexOf(query) !== -1;
+        row.classList.toggle("hidden", !match);
+      });
+    });
+  }
+  document.querySelectorAll("#table-view a.table-link").forEach(function (link) {
+    link.addEventListener("click", function () {
+      var table = link.closest("tr").getAttribute("data-table");
+      rows.forEach(function (row) {
+        row.classList.toggle("highlight", row.getAttribute("data-table") === table);
+      });
+    });
+  });
+})();
+`
+
+// RenameOperations returns a copy of the result with operations named in the given style
+// 呼び出し元の結果（watchモードの差分計算など）は変更しない
+func RenameOperations(result *types.DependencyResult, style types.OperationStyle) *types.DependencyResult {
+	if style != types.OperationStyleCRUD {
+		return result
+	}
+	
+	renamed := *result
+	renamed.FunctionView = make(map[string][]types.TableAccess, len(result.FunctionView))
+	for function, accesses := range result.FunctionView {
+		converted := make([]types.TableAccess, len(accesses))
+		for i, access := range accesses {
+			converted[i] = types.TableAccess{Table: access.Table, Operations: style.Names(access.Operations)}
+		}
+		renamed.FunctionView[function] = converted
+	}
+	renamed.TableView = make(map[string][]types.FunctionAccess, len(result.TableView))
+	for table, accesses := range result.TableView {
+		converted := make([]types.FunctionAccess, len(accesses))
+		for i, access := range accesses {
+			converted[i] = types.FunctionAccess{Function: access.Function, Operations: style.Names(access.Operations)}
+		}
+		renamed.TableView[table] = converted
+	}
+	return &renamed
+}
+
+// renameReportOperations returns a copy of the report with operations named in the given style
+// 同じ名前になる操作（DELETEとTRUNCATE）の呼び出しと件数はまとめる
+func renameReportOperations(report *types.AnalysisReport, style types.OperationStyle) *types.AnalysisReport {
+	if style != types.OperationStyleCRUD {
+		return report
+	}
+	
+	renamed := *report
+	renamed.Summary.OperationCounts = style.Counts(report.Summary.OperationCounts)
+	
+	renamed.Dependencies.FunctionView = make(map[string]types.FunctionViewEntry, len(report.Dependencies.FunctionView))
+	for funcName, entry := range report.Dependencies.FunctionView {
+		tableAccess := make(map[string]types.TableAccessInfo, len(entry.TableAccess))
+		for tableName, access := range entry.TableAccess {
+			operations := make(map[string][]types.OperationCall, len(access.Operations))
+			// 出力が実行ごとに変わらないよう、操作名の順に呼び出しを連結する
+			for _, operation := range sortedKeys(access.Operations) {
+				name := style.Name(operation)
+				operations[name] = append(operations[name], access.Operations[operation]...)
+			}
+			var columns map[string][]string
+			for operation, names := range access.Columns {
+				if columns == nil {
+					columns = make(map[string][]string)
+				}
+				name := style.Name(operation)
+				columns[name] = uniqueSorted(append(columns[name], names...))
+			}
+			access.Operations = operations
+			access.Columns = columns
+			tableAccess[tableName] = access
+		}
+		entry.TableAccess = tableAccess
+		renamed.Dependencies.FunctionView[funcName] = entry
+	}
+	
+	renamed.Dependencies.TableView = make(map[string]types.TableViewEntry, len(report.Dependencies.TableView))
+	for tableName, entry := range report.Dependencies.TableView {
+		accessedBy := make(map[string]types.FunctionAccess, len(entry.AccessedBy))
+		for funcName, access := range entry.AccessedBy {
+			accessedBy[funcName] = types.FunctionAccess{Function: access.Function, Operations: style.Names(access.Operations)}
+		}
+		entry.AccessedBy = accessedBy
+		entry.OperationSummary = style.Counts(entry.OperationSummary)
+		renamed.Dependencies.TableView[tableName] = entry
+	}
+	
+	if report.Dependencies.EntryPoints != nil {
+		renamed.Dependencies.EntryPoints = make(map[string]types.EntryPoint, len(report.Dependencies.EntryPoints))
+		for funcName, entryPoint := range report.Dependencies.EntryPoints {
+			tables := make(map[string][]string, len(entryPoint.Tables))
+			for tableName, operations := range entryPoint.Tables {
+				tables[tableName] = style.Names(operations)
+			}
+			entryPoint.Tables = tables
+			renamed.Dependencies.EntryPoints[funcName] = entryPoint
+		}
+	}
+	return &renamed
+}
+
+// joinStrings joins strings with the given separator
+func joinStrings(strs []string, sep string) string {
+	return strings.Join(strs, sep)
+}
+
+// sumOperations returns the total number of operations
+func sumOperations(operationCounts map[string]int) int {
+	total := 0
+	for _, count := range operationCounts {
+		total += count
+	}
+	return total
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// uniqueSorted returns the unique strings in sorted order
+func uniqueSorted(strs []string) []string {
+	seen := make(map[string]bool)
+	for _, str := range strs {
+		seen[str] = true
+	}
+	return sortedKeys(seen)
+}
@@ -42,6 +42,25 @@ func TestFormatter_FormatJSON(t *testing.T) {
 	}
 }
 
+func TestFormatter_SchemaVersion(t *testing.T) {
+	formatter := NewFormatter(types.FormatJSON, false)
+	report := createTestReport()
+	
+	var buffer bytes.Buffer
+	if err := formatter.Format(&report, &buffer); err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	
+	var result map[string]interface{}
+	if err := json.Unmarshal(buffer.Bytes(), &result); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	
+	if result["schema_version"] != types.SchemaVersion {
+		t.Errorf("schema_version = %v, want %s", result["schema_version"], types.SchemaVersion)
+	}
+}
+
 func TestFormatter_FormatCSV(t *testing.T) {
 	formatter := NewFormatter(types.FormatCSV, false)
 	report := createTestReport()
@@ -114,6 +133,34 @@ func TestFormatter_FormatHTML(t *testing.T) {
 	}
 }
 
+func TestFormatter_HTMLInteractivity(t *testing.T) {
+	formatter := NewFormatter(types.FormatHTML, false)
+	report := createTestReport()
+	
+	var buffer bytes.Buffer
+	if err := formatter.Format(&report, &buffer); err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	output := buffer.String()
+	
+	expected := []string{
+		`<input type="search" id="package-filter"`,
+		`<tr data-package="main" data-table="users">`,
+		`<a href="#function-view" class="table-link">users</a>`,
+		"<script>\n(function () {",
+	}
+	for _, want := range expected {
+		if !strings.Contains(output, want) {
+			t.Errorf("HTML output missing %q", want)
+		}
+	}
+	
+	// The script is embedded; the report must not load anything external
+	if strings.Contains(output, "<script src") {
+		t.Error("HTML output should not reference external scripts")
+	}
+}
+
 func TestFormatter_UnsupportedFormat(t *testing.T) {
 	formatter := NewFormatter("unsupported", false)
 	report := createTestReport()
@@ -163,6 +210,150 @@ func TestFormatter_MinifiedJSON(t *testing.T) {
 	}
 }
 
+func TestFormatter_PrimaryView(t *testing.T) {
+	tests := []struct {
+		name          string
+		view          types.PrimaryView
+		wantFunctions bool
+		wantTables    bool
+	}{
+		{
+			name:          "Function view only",
+			view:          types.ViewFunction,
+			wantFunctions: true,
+			wantTables:    false,
+		},
+		{
+			name:          "Table view only",
+			view:          types.ViewTable,
+			wantFunctions: false,
+			wantTables:    true,
+		},
+		{
+			name:          "Both views",
+			view:          types.ViewBoth,
+			wantFunctions: true,
+			wantTables:    true,
+		},
+		{
+			name:          "Unset defaults to both",
+			view:          "",
+			wantFunctions: true,
+			wantTables:    true,
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatter := NewFormatter(types.FormatJSON, false).WithPrimaryView(tt.view)
+			report := createTestReport()
+			
+			var buffer bytes.Buffer
+			if err := formatter.Format(&report, &buffer); err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			
+			var result struct {
+				Dependencies map[string]json.RawMessage `json:"dependencies"`
+			}
+			if err := json.Unmarshal(buffer.Bytes(), &result); err != nil {
+				t.Fatalf("Output is not valid JSON: %v", err)
+			}
+			
+			dependencies := result.Dependencies
+			if _, exists := dependencies["function_view"]; exists != tt.wantFunctions {
+				t.Errorf("function_view present = %v, want %v", exists, tt.wantFunctions)
+			}
+			if _, exists := dependencies["table_view"]; exists != tt.wantTables {
+				t.Errorf("table_view present = %v, want %v", exists, tt.wantTables)
+			}
+		})
+	}
+}
+
+func TestFormatter_PrimaryViewCSV(t *testing.T) {
+	formatter := NewFormatter(types.FormatCSV, false).WithPrimaryView(types.ViewTable)
+	report := createTestReport()
+	
+	var buffer bytes.Buffer
+	if err := formatter.Format(&report, &buffer); err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	
+	output := buffer.String()
+	if strings.Contains(output, "Function,Package,File,Tables,Operations") {
+		t.Error("CSV output should not contain function view when primary view is table")
+	}
+	
+	if !strings.Contains(output, "Table,Functions,Operations") {
+		t.Error("CSV output missing table view header")
+	}
+}
+
+func TestFormatter_InvalidPrimaryView(t *testing.T) {
+	formatter := NewFormatter(types.FormatJSON, false).WithPrimaryView("columns")
+	report := createTestReport()
+	
+	var buffer bytes.Buffer
+	if err := formatter.Format(&report, &buffer); err == nil {
+		t.Error("Expected error for invalid primary view")
+	}
+}
+
+func TestFormatter_OperationStyle(t *testing.T) {
+	for _, format := range []types.OutputFormat{types.FormatJSON, types.FormatCSV, types.FormatHTML} {
+		t.Run(string(format), func(t *testing.T) {
+			report := createTestReport()
+			
+			var buffer bytes.Buffer
+			formatter := NewFormatter(format, false).WithOperationStyle(types.OperationStyleCRUD)
+			if err := formatter.Format(&report, &buffer); err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			
+			output := buffer.String()
+			for _, want := range []string{"Read", "Create"} {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q:\n%s", want, output)
+				}
+			}
+			for _, unwanted := range []string{"SELECT", "INSERT"} {
+				if strings.Contains(output, unwanted) {
+					t.Errorf("output should not contain %q with crud style:\n%s", unwanted, output)
+				}
+			}
+			
+			// 既定のスタイルではSQLの動詞のまま出力する
+			report = createTestReport()
+			buffer.Reset()
+			if err := NewFormatter(format, false).Format(&report, &buffer); err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			if !strings.Contains(buffer.String(), "SELECT") {
+				t.Errorf("default output missing %q:\n%s", "SELECT", buffer.String())
+			}
+		})
+	}
+	
+	result := &types.DependencyResult{
+		FunctionView: map[string][]types.TableAccess{
+			"service.Handle": {{Table: "users", Operations: []string{"SELECT", "UPDATE"}}},
+		},
+		TableView: map[string][]types.FunctionAccess{
+			"users": {{Function: "service.Handle", Operations: []string{"SELECT", "UPDATE"}}},
+		},
+	}
+	
+	var buffer bytes.Buffer
+	formatter := NewFormatter(types.FormatCSV, false).WithOperationStyle(types.OperationStyleCRUD)
+	if err := formatter.FormatResult(result, &buffer); err != nil {
+		t.Fatalf("FormatResult() error = %v", err)
+	}
+	if want := "service.Handle,users,Read;Update"; !strings.Contains(buffer.String(), want) {
+		t.Errorf("FormatResult output missing %q:\n%s", want, buffer.String())
+	}
+}
+
 func TestFormatter_HelperFunctions(t *testing.T) {
 	// Test joinStrings
 	result := joinStrings([]string{"a", "b", "c"}, ",")
@@ -292,4 +483,46 @@ func createTestReport() types.AnalysisReport {
 			},
 		},
 	}
-}
\ No newline at end of file
+}
+func TestFormatter_FormatResult(t *testing.T) {
+	result := &types.DependencyResult{
+		FunctionView: map[string][]types.TableAccess{
+			"service.Handle|Legacy": {{Table: "users", Operations: []string{"SELECT", "UPDATE"}}},
+		},
+		TableView: map[string][]types.FunctionAccess{
+			"users": {{Function: "service.Handle|Legacy", Operations: []string{"SELECT", "UPDATE"}}},
+		},
+	}
+	
+	tests := []struct {
+		format   types.OutputFormat
+		contains []string
+	}{
+		{
+			format:   types.FormatCSV,
+			contains: []string{"Function,Table,Operations\nservice.Handle|Legacy,users,SELECT;UPDATE\n\nTable,Function,Operations\n"},
+		},
+		{
+			format:   types.FormatMarkdown,
+			contains: []string{"## Functions", `| service.Handle\|Legacy | users | SELECT, UPDATE |`, "## Tables"},
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var buffer bytes.Buffer
+			if err := NewFormatter(tt.format, false).FormatResult(result, &buffer); err != nil {
+				t.Fatalf("FormatResult() error = %v", err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(buffer.String(), want) {
+					t.Errorf("output missing %q:\n%s", want, buffer.String())
+				}
+			}
+		})
+	}
+	
+	if err := NewFormatter(types.FormatHTML, false).FormatResult(result, &bytes.Buffer{}); err == nil {
+		t.Error("Expected error for a format FormatResult does not render")
+	}
+}
@@ -0,0 +1,284 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/naoyafurudono/sqlc-use-analysis/pkg/types"
+)
+
+// EventSource delivers the paths of changed files
+type EventSource interface {
+	Events() <-chan string
+}
+
+// Watcher re-runs a callback when files reported by an event source change
+type Watcher struct {
+	source   EventSource
+	debounce time.Duration
+	onChange func(ctx context.Context, changed []string) error
+}
+
+// New creates a watcher that calls onChange once per burst of events
+func New(source EventSource, debounce time.Duration, onChange func(ctx context.Context, changed []string) error) *Watcher {
+	return &Watcher{
+		source:   source,
+		debounce: debounce,
+		onChange: onChange,
+	}
+}
+
+// Run dispatches debounced change notifications until ctx is done or the source closes
+func (w *Watcher) Run(ctx context.Context) error {
+	pending := make(map[string]bool)
+	var timer *time.Timer
+	var fire <-chan time.Time
+	
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		changed := make([]string, 0, len(pending))
+		for path := range pending {
+			changed = append(changed, path)
+		}
+		sort.Strings(changed)
+		pending = make(map[string]bool)
+		return w.onChange(ctx, changed)
+	}
+	
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case path, ok := <-w.source.Events():
+			if !ok {
+				// ソースが閉じられた場合は保留中の変更を処理して終了
+				return flush()
+			}
+			pending[path] = true
+			
+			// 連続した書き込みをまとめるためタイマーをリセット
+			if timer == nil {
+				timer = time.NewTimer(w.debounce)
+			} else {
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(w.debounce)
+			}
+			fire = timer.C
+		case <-fire:
+			fire = nil
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// PollingSource reports changes to .go and .sql files by periodically scanning directories
+type PollingSource struct {
+	roots    []string
+	interval time.Duration
+	events   chan string
+	files    map[string]fileState
+}
+
+// fileState is the snapshot used to detect modifications
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewPollingSource creates an event source that scans roots every interval
+func NewPollingSource(roots []string, interval time.Duration) *PollingSource {
+	return &PollingSource{
+		roots:    roots,
+		interval: interval,
+		events:   make(chan string, 64),
+	}
+}
+
+// Events returns the channel of changed file paths
+func (p *PollingSource) Events() <-chan string {
+	return p.events
+}
+
+// Start takes the initial snapshot and polls in the background until ctx is done
+func (p *PollingSource) Start(ctx context.Context) error {
+	files, err := p.scan()
+	if err != nil {
+		return err
+	}
+	p.files = files
+	
+	go func() {
+		defer close(p.events)
+		
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !p.poll(ctx) {
+					return
+				}
+			}
+		}
+	}()
+	
+	return nil
+}
+
+// poll compares the current snapshot with the previous one and emits differences
+func (p *PollingSource) poll(ctx context.Context) bool {
+	files, err := p.scan()
+	if err != nil {
+		// 一時的な読み取りエラーは次回のスキャンで再試行する
+		return true
+	}
+	
+	var changed []string
+	for path, state := range files {
+		if previous, exists := p.files[path]; !exists || previous != state {
+			changed = append(changed, path)
+		}
+	}
+	for path := range p.files {
+		if _, exists := files[path]; !exists {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	p.files = files
+	
+	for _, path := range changed {
+		select {
+		case p.events <- path:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// scan collects the state of watched files under the roots
+func (p *PollingSource) scan() (map[string]fileState, error) {
+	files := make(map[string]fileState)
+	for _, root := range p.roots {
+		err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				name := d.Name()
+				if path != root && (name == "vendor" || name == "node_modules" || strings.HasPrefix(name, ".")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !isWatchedFile(path) {
+				return nil
+			}
+			
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			files[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+		}
+	}
+	return files, nil
+}
+
+// isWatchedFile reports whether a change to path can affect the analysis
+func isWatchedFile(path string) bool {
+	switch filepath.Ext(path) {
+	case ".go", ".sql":
+		return true
+	default:
+		return false
+	}
+}
+
+// Diff lists dependencies that appeared or disappeared between two runs
+type Diff struct {
+	Added   []string
+	Removed []string
+}
+
+// IsEmpty reports whether the dependencies are unchanged
+func (d Diff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// String renders the diff as one "+"/"-" line per dependency
+func (d Diff) String() string {
+	if d.IsEmpty() {
+		return "no dependency changes"
+	}
+	
+	var lines []string
+	for _, dep := range d.Added {
+		lines = append(lines, "+ "+dep)
+	}
+	for _, dep := range d.Removed {
+		lines = append(lines, "- "+dep)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// DiffResults compares the function views of two analysis results
+// A nil previous result is treated as empty
+func DiffResults(previous, current *types.DependencyResult) Diff {
+	before := dependencySet(previous)
+	after := dependencySet(current)
+	
+	var diff Diff
+	for dep := range after {
+		if !before[dep] {
+			diff.Added = append(diff.Added, dep)
+		}
+	}
+	for dep := range before {
+		if !after[dep] {
+			diff.Removed = append(diff.Removed, dep)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	return diff
+}
+
+// dependencySet flattens a result into "function -> table OPERATION" entries
+func dependencySet(result *types.DependencyResult) map[string]bool {
+	deps := make(map[string]bool)
+	if result == nil {
+		return deps
+	}
+	
+	for function, accesses := range result.FunctionView {
+		for _, access := range accesses {
+			for _, operation := range access.Operations {
+				deps[fmt.Sprintf("%s -> %s %s", function, access.Table, operation)] = true
+			}
+		}
+	}
+	return deps
+}
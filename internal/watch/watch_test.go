@@ -0,0 +1,140 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/naoyafurudono/sqlc-use-analysis/pkg/types"
+)
+
+// fakeSource is an event source driven directly by the test
+type fakeSource struct {
+	events chan string
+}
+
+func (f *fakeSource) Events() <-chan string {
+	return f.events
+}
+
+func TestWatcher_Run_DebouncesEvents(t *testing.T) {
+	source := &fakeSource{events: make(chan string)}
+	calls := make(chan []string, 10)
+	watcher := New(source, 50*time.Millisecond, func(ctx context.Context, changed []string) error {
+		calls <- changed
+		return nil
+	})
+	
+	done := make(chan error, 1)
+	go func() {
+		done <- watcher.Run(context.Background())
+	}()
+	
+	// 連続した書き込みは1回の再解析にまとめられる
+	source.events <- "internal/service/user_service.go"
+	source.events <- "query.sql"
+	source.events <- "internal/service/user_service.go"
+	
+	select {
+	case changed := <-calls:
+		want := []string{"internal/service/user_service.go", "query.sql"}
+		if !reflect.DeepEqual(changed, want) {
+			t.Errorf("changed = %v, want %v", changed, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("re-analysis was not triggered")
+	}
+	
+	close(source.events)
+	if err := <-done; err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("expected a single re-analysis, got %d extra", len(calls))
+	}
+}
+
+func TestWatcher_Run_FlushesOnClose(t *testing.T) {
+	source := &fakeSource{events: make(chan string, 1)}
+	var got []string
+	watcher := New(source, time.Hour, func(ctx context.Context, changed []string) error {
+		got = changed
+		return nil
+	})
+	
+	source.events <- "query.sql"
+	close(source.events)
+	
+	if err := watcher.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{"query.sql"}) {
+		t.Errorf("changed = %v, want [query.sql]", got)
+	}
+}
+
+func TestPollingSource_DetectsWrite(t *testing.T) {
+	dir := t.TempDir()
+	goFile := filepath.Join(dir, "service.go")
+	if err := os.WriteFile(goFile, []byte("package service\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	
+	source := NewPollingSource([]string{dir}, 10*time.Millisecond)
+	if err := source.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	
+	// 監視対象外の拡張子は無視される
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(goFile, []byte("package service\n\nfunc Changed() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	
+	select {
+	case path := <-source.Events():
+		if path != goFile {
+			t.Errorf("event path = %s, want %s", path, goFile)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event for modified file")
+	}
+}
+
+func TestDiffResults(t *testing.T) {
+	previous := &types.DependencyResult{
+		FunctionView: map[string][]types.TableAccess{
+			"UserService.GetUser":    {{Table: "users", Operations: []string{"SELECT"}}},
+			"UserService.DeleteUser": {{Table: "users", Operations: []string{"DELETE"}}},
+		},
+	}
+	current := &types.DependencyResult{
+		FunctionView: map[string][]types.TableAccess{
+			"UserService.GetUser":    {{Table: "users", Operations: []string{"SELECT"}}},
+			"PostService.CreatePost": {{Table: "posts", Operations: []string{"INSERT"}}},
+		},
+	}
+	
+	diff := DiffResults(previous, current)
+	if !reflect.DeepEqual(diff.Added, []string{"PostService.CreatePost -> posts INSERT"}) {
+		t.Errorf("Added = %v", diff.Added)
+	}
+	if !reflect.DeepEqual(diff.Removed, []string{"UserService.DeleteUser -> users DELETE"}) {
+		t.Errorf("Removed = %v", diff.Removed)
+	}
+	
+	if !DiffResults(current, current).IsEmpty() {
+		t.Error("expected no changes when comparing a result with itself")
+	}
+	if got := len(DiffResults(nil, current).Added); got != 2 {
+		t.Errorf("expected all dependencies added against nil result, got %d", got)
+	}
+}
@@ -3,12 +3,27 @@
 package analyzer
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
+	"encoding/gob"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
 
 	"github.com/naoyafurudono/sqlc-use-analysis/internal/analyzer/dependency"
+	gostatic "github.com/naoyafurudono/sqlc-use-analysis/internal/analyzer/go"
+	"github.com/naoyafurudono/sqlc-use-analysis/internal/analyzer/sql"
 	"github.com/naoyafurudono/sqlc-use-analysis/internal/errors"
-	"github.com/naoyafurudono/sqlc-use-analysis/internal/output"
+	sqlcio "github.com/naoyafurudono/sqlc-use-analysis/internal/io"
 	"github.com/naoyafurudono/sqlc-use-analysis/pkg/types"
 )
 
@@ -16,155 +31,1666 @@ import (
 type Query struct {
 	Name string `json:"name"`
 	SQL  string `json:"sql"`
+	Cmd  string `json:"cmd,omitempty"` // sqlc command such as ":one" or ":many" (default ":exec")
 }
 
 // AnalysisRequest contains all inputs needed for analysis
 type AnalysisRequest struct {
 	SQLQueries   []Query  `json:"sql_queries"`
 	GoPackages   []string `json:"go_packages"`
-	OutputFormat string   `json:"output_format,omitempty"` // "json", "csv", "html"
+	OutputFormat string   `json:"output_format,omitempty"` // "json" (default), "csv", "template"
 	PrettyPrint  bool     `json:"pretty_print,omitempty"`
+	Template     string   `json:"template,omitempty"`      // text/template source for the "template" format
+	TemplateFile string   `json:"template_file,omitempty"` // path to a template file, instead of Template
+	// OperationStyle names operations in AnalyzeAndFormat's output: "sql" (default) or "crud"
+	OperationStyle string `json:"operation_style,omitempty"`
 }
 
 // Result represents the complete analysis result
 type Result struct {
-	Functions    map[string]FunctionInfo  `json:"functions"`
-	Tables       map[string]TableInfo     `json:"tables"`
-	Dependencies []Dependency             `json:"dependencies"`
-	Summary      Summary                  `json:"summary"`
-	Suggestions  []OptimizationTip        `json:"suggestions,omitempty"`
+	SchemaVersion string                    `json:"schema_version"`
+	Functions     map[string]FunctionInfo   `json:"functions"`
+	Tables        map[string]TableInfo      `json:"tables"`
+	Dependencies  []Dependency              `json:"dependencies"`
+	Summary       Summary                   `json:"summary"`
+	Suggestions   []OptimizationTip         `json:"suggestions,omitempty"`
+	EntryPoints   map[string]EntryPointInfo `json:"entry_points,omitempty"`
+	Packages      PackageSummary            `json:"packages"`
+	Collisions    []string                  `json:"collisions,omitempty"` // functions present in more than one merged result, see Merge
+	SchemaView    map[string]SchemaInfo     `json:"schema_view,omitempty"`
+	// UnmatchedRequestQueries lists requested queries no analyzed Go code calls,
+	// which usually means the query list and the generated code have drifted apart
+	UnmatchedRequestQueries []string `json:"unmatched_request_queries,omitempty"`
+}
+
+// PackageSummary tells which requested Go packages were analyzed
+// An empty Loaded list means nothing was analyzed, as opposed to no dependencies being found
+type PackageSummary struct {
+	Loaded []string         `json:"loaded"`
+	Failed []PackageFailure `json:"failed,omitempty"`
+}
+
+// PackageFailure describes a Go package path that could not be loaded
+type PackageFailure struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// EntryPointInfo describes the data footprint of a function no other analyzed function calls
+// (e.g. HTTP handlers or main); Tables covers everything reachable through its calls
+type EntryPointInfo struct {
+	Function string              `json:"function"`
+	Package  string              `json:"package"`
+	File     string              `json:"file"`
+	Reaches  []string            `json:"reaches"` // functions called directly or transitively
+	Tables   map[string][]string `json:"tables"`  // table name -> operations
 }
 
 // FunctionInfo represents information about a Go function
 type FunctionInfo struct {
-	Name        string            `json:"name"`
-	Package     string            `json:"package"`
-	File        string            `json:"file"`
-	StartLine   int               `json:"start_line"`
-	EndLine     int               `json:"end_line"`
-	TableAccess map[string]Access `json:"table_access"`
+	Name          string              `json:"name"`                    // function or "Receiver.Method"
+	QualifiedName string              `json:"qualified_name"`          // "pkgpath.Receiver.Method", the key in Result.Functions
+	Package       string              `json:"package"`
+	File          string              `json:"file"`
+	StartLine     int                 `json:"start_line"`
+	EndLine       int                 `json:"end_line"`
+	Layer         string              `json:"layer,omitempty"`
+	TableAccess   map[string]Access   `json:"table_access"`
+	ColumnAccess  map[string][]string `json:"column_access,omitempty"` // "table.column" -> operations; "table.*" for SELECT *
+	Calls         []string            `json:"calls,omitempty"`         // analyzed functions called directly
+	Schemas       []string            `json:"schemas,omitempty"`       // schemas of the accessed tables, see Options.DefaultSchema
+}
+
+// SchemaInfo groups the accessed tables of one database schema
+type SchemaInfo struct {
+	Name       string   `json:"name"`
+	Tables     []string `json:"tables"`
+	AccessedBy []string `json:"accessed_by"`
+}
+
+// LayerRule assigns a logical architecture layer to matching functions
+// Pattern is a slash-separated path fragment (e.g. "internal/handler") matched
+// against the function's import path and file path on segment boundaries
+type LayerRule struct {
+	Pattern string `json:"pattern"`
+	Layer   string `json:"layer"`
+}
+
+// TableInfo represents information about a database table
+type TableInfo struct {
+	Name                 string              `json:"name"`
+	Kind                 string              `json:"kind,omitempty"` // "table", "view" or "matview" when the schema was loaded with LoadProject
+	AccessedBy           []string            `json:"accessed_by"`
+	OperationCount       map[string]int      `json:"operation_count"`
+	OperationsByFunction map[string][]string `json:"operations_by_function"`
+}
+
+// DependencyPath is one call chain through which a function reaches a table
+// Functions starts with the requested function and ends with the one calling Method
+type DependencyPath struct {
+	Functions  []string `json:"functions"`
+	Method     string   `json:"method"` // query method that accesses the table
+	Table      string   `json:"table"`
+	Operations []string `json:"operations"`
+}
+
+// Hotspots ranks tables and functions by how strongly they are coupled
+// Each list is sorted by Count (descending), then by Name
+type Hotspots struct {
+	Tables     []Hotspot `json:"tables"`      // tables by the number of functions accessing them
+	Functions  []Hotspot `json:"functions"`   // functions by the number of tables they access
+	WriteHeavy []Hotspot `json:"write_heavy"` // tables by the number of INSERT/UPDATE/DELETE dependencies
+}
+
+// Hotspot is one ranked table or function
+type Hotspot struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Dependency represents a dependency between a function and a table
+type Dependency struct {
+	Function   string `json:"function"`
+	Table      string `json:"table"`
+	Operation  string `json:"operation"`
+	AccessKind string `json:"access_kind"` // "primary" or "reference" (read only to perform a write)
+	Method     string `json:"method"`
+	Line       int    `json:"line"`
+	Confidence string `json:"confidence"` // "high", "medium" or "low" depending on how the call was resolved
+}
+
+// Access represents how a function accesses a table
+type Access struct {
+	Operations []string `json:"operations"`
+	Methods    []string `json:"methods"`
+	Count      int      `json:"count"`
+	Locking    []string `json:"locking,omitempty"` // row locks taken by SELECTs, e.g. "FOR UPDATE"
+}
+
+// Summary provides high-level statistics
+type Summary struct {
+	FunctionCount   int            `json:"function_count"`
+	TableCount      int            `json:"table_count"`
+	DependencyCount int            `json:"dependency_count"`
+	OperationCounts map[string]int `json:"operation_counts"`
+	// ReadCount counts SELECT operations and WriteCount every other operation except
+	// driver calls, whose effect is unknown; the ratios are their shares of the sum
+	ReadCount      int         `json:"read_count"`
+	WriteCount     int         `json:"write_count"`
+	ReadRatio      float64     `json:"read_ratio"`
+	WriteRatio     float64     `json:"write_ratio"`
+	WriteFunctions int         `json:"write_functions"`      // functions that write at least one table
+	TopTables      []TableRank `json:"top_tables,omitempty"` // the three most accessed tables
+}
+
+// TableRank is a table with the number of operations performed on it
+type TableRank struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// OptimizationTip provides actionable optimization suggestions
+type OptimizationTip struct {
+	Type        string `json:"type"`
+	Function    string `json:"function,omitempty"`
+	Table       string `json:"table,omitempty"`
+	Description string `json:"description"`
+	Severity    string `json:"severity"`
+}
+
+// Analyzer provides a deep module for dependency analysis
+// It hides all complexity behind a simple interface.
+// An Analyzer is safe for concurrent use; GetErrors, LastRunStats and ReanalyzeFile
+// refer to the most recently finished call
+type Analyzer struct {
+	opts        Options
+	layerRules  []LayerRule
+	
+	mu          sync.Mutex
+	knownTables map[string]struct{} // catalog loaded by LoadProject
+	tableKinds  map[string]string   // lower-cased catalog name -> "table", "view" or "matview"
+	last        *analysisRun
+}
+
+// analysisRun holds the engine and collected errors of one analysis call
+// Every call gets its own so that concurrent calls share no mutable state
+type analysisRun struct {
+	engine     *dependency.Engine
+	errors     *errors.ErrorCollector
+	queries    []Query
+	tableKinds map[string]string
+}
+
+// Options customizes analyzer behavior
+// The zero value matches the defaults used by New
+type Options struct {
+	SQLDialect          string // "mysql" (default), "postgresql", "sqlite", "ansi"
+	CaseSensitiveTables bool
+	Explain             bool // record why calls were or weren't linked, see GetExplanations
+	LayerRules          []LayerRule // first matching rule sets FunctionInfo.Layer
+	MethodPrefixes      []string    // extra method name prefixes treated as sqlc queries (e.g. "Fetch", "Save")
+	Acronyms            []string    // words kept upper case in method names derived from query names (e.g. "ID", "API")
+	IncludeDDL          bool        // record CREATE/ALTER/DROP/TRUNCATE queries as DDL operations
+	ReadContextEdges    bool        // record tables a write only reads (JOIN/FROM/USING) as "reference" SELECTs
+	RawSQL              bool        // also analyze constant SQL strings passed to database/sql Query/Exec calls
+	SkipGeneratedFiles  bool        // leave sqlc-generated files (*.sql.go) out of the analyzed functions
+	IncludeDriverCalls  bool        // record direct driver calls (QueryRowContext, ...) as DRIVER on the "<raw>" table
+	StrictTypes         bool        // fail when a Go package has type errors or no type information, instead of missing its calls
+	IncludePackages     []string    // only analyze Go packages matching these patterns (e.g. "internal/...")
+	ExcludePackages     []string    // skip Go packages matching these patterns (e.g. "internal/telemetry")
+	IncludeVendor       bool        // also analyze every imported package outside the standard library
+	FollowPackages      []string    // also analyze imported packages under these import path prefixes (e.g. "example.com/shared/db")
+	MemoryLimit         int         // MB; load and analyze Go packages in batches that fit this limit (0 loads all at once)
+	CountOnlyErrors     bool        // keep analyzing past the error cap; further errors are only counted
+	DefaultSchema       string      // schema of tables that are not schema-qualified (default: "public")
+	Dir                 string      // module root that relative GoPackages and source paths resolve against (default: working directory)
+	
+	// Progress, when set, is called as each query ("queries" phase) and each Go package
+	// ("packages" phase) is analyzed, with current counting from 1 up to total
+	Progress func(phase string, current, total int)
+}
+
+// New creates a new analyzer with sensible defaults
+func New() *Analyzer {
+	return NewWithOptions(Options{})
+}
+
+// NewWithOptions creates a new analyzer with the given options
+func NewWithOptions(opts Options) *Analyzer {
+	if opts.SQLDialect == "" {
+		opts.SQLDialect = "mysql"
+	}
+	if opts.DefaultSchema == "" {
+		opts.DefaultSchema = "public"
+	}
+	
+	a := &Analyzer{
+		opts:       opts,
+		layerRules: opts.LayerRules,
+	}
+	a.last = a.newRun(nil)
+	return a
+}
+
+// newRun creates the engine and error collector for one analysis call
+func (a *Analyzer) newRun(queries []Query) *analysisRun {
+	opts := a.opts
+	errorCollector := errors.NewErrorCollector(100, false)
+	errorCollector.SetCountOnly(opts.CountOnlyErrors)
+	engine := dependency.NewEngineWithDialect(opts.SQLDialect, opts.CaseSensitiveTables, errorCollector)
+	if opts.Explain {
+		engine.EnableExplainMode()
+	}
+	engine.SetMethodPrefixes(opts.MethodPrefixes)
+	engine.SetAcronyms(opts.Acronyms)
+	engine.SetIncludeDDL(opts.IncludeDDL)
+	engine.SetReadContextEdges(opts.ReadContextEdges)
+	engine.SetRawSQL(opts.RawSQL)
+	engine.SetSkipGeneratedFiles(opts.SkipGeneratedFiles)
+	engine.SetIncludeDriverCalls(opts.IncludeDriverCalls)
+	engine.SetStrictTypes(opts.StrictTypes)
+	engine.SetPackageFilters(opts.IncludePackages, opts.ExcludePackages)
+	engine.SetFollowDependencies(opts.IncludeVendor, opts.FollowPackages)
+	engine.SetMemoryLimit(opts.MemoryLimit)
+	engine.SetRootPath(opts.Dir)
+	engine.SetProgress(opts.Progress)
+	
+	a.mu.Lock()
+	if a.knownTables != nil {
+		engine.SetKnownTables(a.knownTables)
+	}
+	tableKinds := a.tableKinds
+	a.mu.Unlock()
+	
+	return &analysisRun{
+		engine:     engine,
+		errors:     errorCollector,
+		queries:    queries,
+		tableKinds: tableKinds,
+	}
+}
+
+// lastRun returns the most recently finished analysis call
+func (a *Analyzer) lastRun() *analysisRun {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.last
+}
+
+// finishRun records r as the most recently finished analysis call
+func (a *Analyzer) finishRun(r *analysisRun) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.last = r
+}
+
+// Analyze performs complete dependency analysis
+// This is the main interface - all complexity is hidden inside.
+// Without GoPackages only the SQL is analyzed: Tables and Summary describe what the
+// queries access and Functions is empty
+func (a *Analyzer) Analyze(ctx context.Context, request AnalysisRequest) (*Result, error) {
+	// Input validation
+	if err := a.validateRequest(request); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	// Convert external types to internal types
+	queries := a.convertQueries(request.SQLQueries)
+	run := a.newRun(request.SQLQueries)
+	defer a.finishRun(run)
+	
+	// Perform the analysis using the internal engine
+	// All engine complexity is hidden from the caller
+	result, err := run.engine.AnalyzeDependenciesContext(ctx, queries, request.GoPackages)
+	if err != nil {
+		return nil, fmt.Errorf("analysis failed: %w", err)
+	}
+
+	// Convert internal result to external format
+	// This transformation hides internal complexity
+	analysisResult := a.convertResult(run, result)
+	if len(request.GoPackages) == 0 {
+		// No Go code was analyzed, so no query can be reported as never called
+		analysisResult.UnmatchedRequestQueries = nil
+		analysisResult.Summary.OperationCounts = tableOperationCounts(analysisResult.Tables)
+		analysisResult.Summary.countReadsAndWrites()
+	}
+	
+	return analysisResult, nil
+}
+
+// AnalyzeReport performs the same analysis as Analyze but returns the internal report unflattened
+// It is meant for tooling that needs the per-call details, circular dependencies and
+// package counts that Result leaves out
+func (a *Analyzer) AnalyzeReport(ctx context.Context, request AnalysisRequest) (*types.AnalysisReport, error) {
+	if err := a.validateRequest(request); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	
+	run := a.newRun(request.SQLQueries)
+	defer a.finishRun(run)
+	result, err := run.engine.AnalyzeDependenciesContext(ctx, a.convertQueries(request.SQLQueries), request.GoPackages)
+	if err != nil {
+		return nil, fmt.Errorf("analysis failed: %w", err)
+	}
+	
+	report := run.engine.GenerateReport(result)
+	return &report, nil
+}
+
+// AnalyzeSources performs dependency analysis on in-memory Go sources
+// sources maps file paths to file contents, so nothing needs to exist on disk
+func (a *Analyzer) AnalyzeSources(ctx context.Context, queries []Query, sources map[string]string) (*Result, error) {
+	if err := a.validateQueries(queries); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("invalid request: no Go sources provided")
+	}
+	
+	files := make(map[string][]byte, len(sources))
+	for name, content := range sources {
+		files[name] = []byte(content)
+	}
+	
+	run := a.newRun(queries)
+	defer a.finishRun(run)
+	result, err := run.engine.AnalyzeSources(a.convertQueries(queries), files)
+	if err != nil {
+		return nil, fmt.Errorf("analysis failed: %w", err)
+	}
+	
+	return a.convertResult(run, result), nil
+}
+
+// ReanalyzeFile updates prev after one Go file changed, without re-analyzing the other files
+// Only the package of filePath is loaded, with newContent in place of the file on disk.
+// Functions declared in the file are replaced together with their dependencies and the
+// rest of prev is kept; tables, entry points and the summary are recomputed.
+// The queries of the last Analyze or AnalyzeSources call are used
+func (a *Analyzer) ReanalyzeFile(ctx context.Context, prev *Result, filePath string, newContent []byte) (*Result, error) {
+	if prev == nil {
+		return nil, fmt.Errorf("invalid request: no previous result")
+	}
+	queries := a.lastRun().queries
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("invalid request: ReanalyzeFile requires a previous Analyze or AnalyzeSources call")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	
+	path, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file path '%s': %w", filePath, err)
+	}
+	
+	// Functions outside the file stay call targets even though their packages are not loaded
+	external := make([]string, 0, len(prev.Functions))
+	for name, function := range prev.Functions {
+		if function.File != path {
+			external = append(external, name)
+		}
+	}
+	run := a.newRun(queries)
+	defer a.finishRun(run)
+	run.engine.SetExternalFunctions(external)
+	
+	internalResult, err := run.engine.AnalyzeSources(a.convertQueries(queries), map[string][]byte{path: newContent})
+	if err != nil {
+		return nil, fmt.Errorf("analysis failed: %w", err)
+	}
+	
+	result := replaceFile(prev, a.convertResult(run, internalResult), path)
+	result.UnmatchedRequestQueries = unmatchedQueries(queryNames(queries), result.Dependencies)
+	for name, table := range result.Tables {
+		table.Kind = run.tableKinds[strings.ToLower(name)]
+		result.Tables[name] = table
+	}
+	result.SchemaView = schemaView(result.Tables, a.opts.DefaultSchema)
+	return result, nil
+}
+
+// replaceFile combines prev without the functions of file and the functions of file from update
+func replaceFile(prev, update *Result, file string) *Result {
+	result := &Result{
+		SchemaVersion: types.SchemaVersion,
+		Functions:     make(map[string]FunctionInfo),
+		Dependencies:  []Dependency{},
+		Packages:      prev.Packages,
+		Collisions:    prev.Collisions,
+	}
+	
+	for _, source := range []*Result{prev, update} {
+		fromFile := source == update
+		for name, function := range source.Functions {
+			if (function.File == file) == fromFile {
+				result.Functions[name] = function
+			}
+		}
+	}
+	for _, source := range []*Result{prev, update} {
+		fromFile := source == update
+		for _, dep := range source.Dependencies {
+			if function, exists := result.Functions[dep.Function]; exists && (function.File == file) == fromFile {
+				result.Dependencies = append(result.Dependencies, dep)
+			}
+		}
+	}
+	for _, tip := range prev.Suggestions {
+		if function, exists := result.Functions[tip.Function]; tip.Function == "" || (exists && function.File != file) {
+			result.Suggestions = append(result.Suggestions, tip)
+		}
+	}
+	
+	result.Dependencies = sortDependencies(result.Dependencies)
+	result.Tables = tablesFromDependencies(result.Dependencies)
+	result.EntryPoints = findEntryPoints(result.Functions)
+	result.Summary = summarize(result)
+	return result
+}
+
+// tableSchema returns the schema of a table, or defaultSchema when the name is not qualified
+func tableSchema(table, defaultSchema string) string {
+	if dot := strings.LastIndex(table, "."); dot >= 0 {
+		return table[:dot]
+	}
+	return defaultSchema
+}
+
+// accessedSchemas returns the sorted schemas of the tables a function accesses
+func accessedSchemas(access map[string]Access, defaultSchema string) []string {
+	var schemas []string
+	for table := range access {
+		if schema := tableSchema(table, defaultSchema); !containsString(schemas, schema) {
+			schemas = append(schemas, schema)
+		}
+	}
+	sort.Strings(schemas)
+	return schemas
+}
+
+// schemaView groups tables and the functions accessing them by schema
+func schemaView(tables map[string]TableInfo, defaultSchema string) map[string]SchemaInfo {
+	view := make(map[string]SchemaInfo)
+	for name, table := range tables {
+		schema := tableSchema(name, defaultSchema)
+		info := view[schema]
+		info.Name = schema
+		info.Tables = unionStrings(info.Tables, []string{name})
+		info.AccessedBy = unionStrings(info.AccessedBy, table.AccessedBy)
+		view[schema] = info
+	}
+	return view
+}
+
+// queryNames returns the names of the requested queries
+// Queries marked with "-- sqlc-analysis:ignore" are left out
+func queryNames(queries []Query) []string {
+	names := make([]string, 0, len(queries))
+	for _, query := range queries {
+		if !sql.IsIgnored(sql.Query{Name: query.Name, Text: query.SQL}) {
+			names = append(names, query.Name)
+		}
+	}
+	return names
+}
+
+// unmatchedQueries returns the query names that no dependency's method refers to, sorted
+// Names are compared ignoring case and underscores, since "get_user" generates GetUser
+func unmatchedQueries(names []string, deps []Dependency) []string {
+	normalize := func(name string) string {
+		return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+	}
+	called := make(map[string]bool, len(deps))
+	for _, dep := range deps {
+		called[normalize(dep.Method)] = true
+	}
+	
+	var unmatched []string
+	for _, name := range names {
+		if !called[normalize(name)] && !containsString(unmatched, name) {
+			unmatched = append(unmatched, name)
+		}
+	}
+	sort.Strings(unmatched)
+	return unmatched
+}
+
+// tablesFromDependencies rebuilds the table view from individual dependencies
+func tablesFromDependencies(deps []Dependency) map[string]TableInfo {
+	tables := make(map[string]TableInfo)
+	for _, dep := range deps {
+		table, exists := tables[dep.Table]
+		if !exists {
+			table = TableInfo{
+				Name:                 dep.Table,
+				AccessedBy:           []string{},
+				OperationCount:       make(map[string]int),
+				OperationsByFunction: make(map[string][]string),
+			}
+		}
+		
+		table.OperationCount[dep.Operation]++
+		if !containsString(table.AccessedBy, dep.Function) {
+			table.AccessedBy = append(table.AccessedBy, dep.Function)
+		}
+		if operations := table.OperationsByFunction[dep.Function]; !containsString(operations, dep.Operation) {
+			table.OperationsByFunction[dep.Function] = append(operations, dep.Operation)
+		}
+		tables[dep.Table] = table
+	}
+	
+	// Dependencies are sorted by function, table and operation, so the lists already are too
+	return tables
+}
+
+// findEntryPoints recomputes the entry points of a set of functions from their calls
+func findEntryPoints(functions map[string]FunctionInfo) map[string]EntryPointInfo {
+	view := types.AnalysisResult{FunctionView: make(map[string]types.FunctionViewEntry, len(functions))}
+	for name, function := range functions {
+		entry := types.FunctionViewEntry{
+			Calls:       function.Calls,
+			TableAccess: make(map[string]types.TableAccessInfo, len(function.TableAccess)),
+		}
+		for table, access := range function.TableAccess {
+			operations := make(map[string][]types.OperationCall, len(access.Operations))
+			for _, operation := range access.Operations {
+				operations[operation] = nil
+			}
+			entry.TableAccess[table] = types.TableAccessInfo{TableName: table, Operations: operations}
+		}
+		view.FunctionView[name] = entry
+	}
+	
+	found := gostatic.NewDependencyMapper(nil).FindEntryPoints(view)
+	if len(found) == 0 {
+		return nil
+	}
+	entryPoints := make(map[string]EntryPointInfo, len(found))
+	for name, entryPoint := range found {
+		entryPoints[name] = EntryPointInfo{
+			Function: entryPoint.Function,
+			Package:  functions[name].Package,
+			File:     functions[name].File,
+			Reaches:  entryPoint.Reaches,
+			Tables:   entryPoint.Tables,
+		}
+	}
+	return entryPoints
+}
+
+// AnalyzeAndFormat performs analysis and returns formatted output
+// This combines analysis and formatting in a single call for convenience
+func (a *Analyzer) AnalyzeAndFormat(ctx context.Context, request AnalysisRequest) ([]byte, error) {
+	// Determine output format
+	format := request.OutputFormat
+	if format == "" {
+		format = "json"
+	}
+	
+	// テンプレートの誤りは解析前に報告する
+	var tmpl *template.Template
+	if types.OutputFormat(format) == types.FormatTemplate {
+		var err error
+		if tmpl, err = parseTemplate(request); err != nil {
+			return nil, err
+		}
+	}
+	
+	result, err := a.Analyze(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	result = renameOperations(result, types.OperationStyle(request.OperationStyle))
+
+	switch types.OutputFormat(format) {
+	case types.FormatJSON:
+		if request.PrettyPrint {
+			return json.MarshalIndent(result, "", "  ")
+		}
+		return json.Marshal(result)
+	case types.FormatCSV:
+		return formatDependenciesCSV(result)
+	case types.FormatTemplate:
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, result); err != nil {
+			return nil, fmt.Errorf("failed to execute template: %w", err)
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, validateOutputFormat(format)
+	}
+}
+
+// renameOperations returns a copy of the result with operations named in the given style
+// Operations sharing a name (DELETE and TRUNCATE) are merged and their counts summed
+func renameOperations(result *Result, style types.OperationStyle) *Result {
+	if style != types.OperationStyleCRUD {
+		return result
+	}
+	
+	renamed := *result
+	renamed.Functions = make(map[string]FunctionInfo, len(result.Functions))
+	for name, function := range result.Functions {
+		tableAccess := make(map[string]Access, len(function.TableAccess))
+		for table, access := range function.TableAccess {
+			access.Operations = style.Names(access.Operations)
+			tableAccess[table] = access
+		}
+		function.TableAccess = tableAccess
+		if function.ColumnAccess != nil {
+			columnAccess := make(map[string][]string, len(function.ColumnAccess))
+			for column, operations := range function.ColumnAccess {
+				columnAccess[column] = style.Names(operations)
+			}
+			function.ColumnAccess = columnAccess
+		}
+		renamed.Functions[name] = function
+	}
+	
+	renamed.Tables = make(map[string]TableInfo, len(result.Tables))
+	for name, table := range result.Tables {
+		table.OperationCount = style.Counts(table.OperationCount)
+		operationsByFunction := make(map[string][]string, len(table.OperationsByFunction))
+		for function, operations := range table.OperationsByFunction {
+			operationsByFunction[function] = style.Names(operations)
+		}
+		table.OperationsByFunction = operationsByFunction
+		renamed.Tables[name] = table
+	}
+	
+	renamed.Dependencies = make([]Dependency, len(result.Dependencies))
+	for i, dep := range result.Dependencies {
+		dep.Operation = style.Name(dep.Operation)
+		renamed.Dependencies[i] = dep
+	}
+	sortDependencies(renamed.Dependencies)
+	
+	renamed.Summary.OperationCounts = style.Counts(result.Summary.OperationCounts)
+	
+	if result.EntryPoints != nil {
+		renamed.EntryPoints = make(map[string]EntryPointInfo, len(result.EntryPoints))
+		for name, entryPoint := range result.EntryPoints {
+			tables := make(map[string][]string, len(entryPoint.Tables))
+			for table, operations := range entryPoint.Tables {
+				tables[table] = style.Names(operations)
+			}
+			entryPoint.Tables = tables
+			renamed.EntryPoints[name] = entryPoint
+		}
+	}
+	return &renamed
+}
+
+// templateFuncs are the helper functions available to output templates
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+	// keys returns the keys of a string-keyed map in sorted order
+	"keys": func(m interface{}) ([]string, error) {
+		value := reflect.ValueOf(m)
+		if value.Kind() != reflect.Map || value.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("keys: expected a map with string keys, got %T", m)
+		}
+		keys := make([]string, 0, value.Len())
+		for _, key := range value.MapKeys() {
+			keys = append(keys, key.String())
+		}
+		sort.Strings(keys)
+		return keys, nil
+	},
+}
+
+// parseTemplate parses the output template of a request, with the Result as its data
+func parseTemplate(request AnalysisRequest) (*template.Template, error) {
+	source := request.Template
+	switch {
+	case request.Template != "" && request.TemplateFile != "":
+		return nil, fmt.Errorf("specify either a template or a template file, not both")
+	case request.TemplateFile != "":
+		data, err := os.ReadFile(request.TemplateFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template: %w", err)
+		}
+		source = string(data)
+	case source == "":
+		return nil, fmt.Errorf("template output requires a template")
+	}
+	
+	tmpl, err := template.New("output").Funcs(templateFuncs).Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// formatDependenciesCSV writes one row per dependency, in the order of sortDependencies
+func formatDependenciesCSV(result *Result) ([]byte, error) {
+	dependencies := sortDependencies(append([]Dependency{}, result.Dependencies...))
+	
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"function", "package", "table", "operation", "method", "line"})
+	for _, dep := range dependencies {
+		writer.Write([]string{
+			dep.Function,
+			result.Functions[dep.Function].Package,
+			dep.Table,
+			dep.Operation,
+			dep.Method,
+			strconv.Itoa(dep.Line),
+		})
+	}
+	
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// grantPrivileges lists the privileges granted for each operation, in the order they are emitted
+// DDLなど権限に対応しない操作は対象外とする
+var grantPrivileges = []string{"SELECT", "INSERT", "UPDATE", "DELETE"}
+
+// GenerateGrants renders least-privilege GRANT statements for role, one per table
+// Each table is granted the union of the operations any analyzed function performs on it
+func GenerateGrants(result *Result, role string) string {
+	return generateGrants(result, role, func(name string) string { return name })
+}
+
+// GenerateGrantsForDialect is GenerateGrants with table and role names quoted for dialect,
+// one of "mysql" (default), "postgresql", "sqlite" or "ansi"
+func GenerateGrantsForDialect(result *Result, role, dialect string) string {
+	if dialect == "" {
+		dialect = sql.DialectMySQL
+	}
+	return generateGrants(result, role, func(name string) string { return sql.QuoteIdentifier(name, dialect) })
+}
+
+// generateGrants renders the GRANT statements with names formatted by quote
+func generateGrants(result *Result, role string, quote func(string) string) string {
+	if result == nil {
+		return ""
+	}
+	
+	operations := make(map[string]map[string]bool)
+	for _, dep := range result.Dependencies {
+		if operations[dep.Table] == nil {
+			operations[dep.Table] = make(map[string]bool)
+		}
+		operations[dep.Table][dep.Operation] = true
+	}
+	
+	tables := make([]string, 0, len(operations))
+	for table := range operations {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	
+	var buf strings.Builder
+	for _, table := range tables {
+		var privileges []string
+		for _, privilege := range grantPrivileges {
+			if operations[table][privilege] {
+				privileges = append(privileges, privilege)
+			}
+		}
+		if len(privileges) == 0 {
+			continue
+		}
+		fmt.Fprintf(&buf, "GRANT %s ON %s TO %s;\n", strings.Join(privileges, ","), quote(table), quote(role))
+	}
+	
+	return buf.String()
+}
+
+// Merge combines the results of several analysis runs (e.g. one per module) into one
+// Functions, tables and dependencies are unioned, operation counts are summed and the
+// summary is recomputed. Functions present in more than one result are merged as well
+// and listed in Collisions, since their counts may then include the same calls twice
+func Merge(results ...*Result) *Result {
+	merged := &Result{
+		SchemaVersion: types.SchemaVersion,
+		Functions:     make(map[string]FunctionInfo),
+		Tables:        make(map[string]TableInfo),
+		Dependencies:  []Dependency{},
+		Packages:      PackageSummary{Loaded: []string{}},
+	}
+	
+	collisions := make(map[string]bool)
+	var unmatched []string
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		unmatched = unionStrings(unmatched, result.UnmatchedRequestQueries)
+		
+		for name, function := range result.Functions {
+			if existing, exists := merged.Functions[name]; exists {
+				collisions[name] = true
+				function = mergeFunction(existing, function)
+			}
+			merged.Functions[name] = function
+		}
+		
+		for name, table := range result.Tables {
+			merged.Tables[name] = mergeTable(merged.Tables[name], table)
+		}
+		
+		for name, schema := range result.SchemaView {
+			if merged.SchemaView == nil {
+				merged.SchemaView = make(map[string]SchemaInfo)
+			}
+			existing := merged.SchemaView[name]
+			merged.SchemaView[name] = SchemaInfo{
+				Name:       name,
+				Tables:     unionStrings(existing.Tables, schema.Tables),
+				AccessedBy: unionStrings(existing.AccessedBy, schema.AccessedBy),
+			}
+		}
+		
+		merged.Dependencies = append(merged.Dependencies, result.Dependencies...)
+		
+		for _, tip := range result.Suggestions {
+			if !containsTip(merged.Suggestions, tip) {
+				merged.Suggestions = append(merged.Suggestions, tip)
+			}
+		}
+		
+		for name, entryPoint := range result.EntryPoints {
+			if merged.EntryPoints == nil {
+				merged.EntryPoints = make(map[string]EntryPointInfo)
+			}
+			if existing, exists := merged.EntryPoints[name]; exists {
+				entryPoint.Reaches = unionStrings(existing.Reaches, entryPoint.Reaches)
+				entryPoint.Tables = unionOperations(existing.Tables, entryPoint.Tables)
+			}
+			merged.EntryPoints[name] = entryPoint
+		}
+		
+		merged.Packages.Loaded = unionStrings(merged.Packages.Loaded, result.Packages.Loaded)
+		merged.Packages.Failed = append(merged.Packages.Failed, result.Packages.Failed...)
+	}
+	
+	merged.Dependencies = sortDependencies(merged.Dependencies)
+	sort.Slice(merged.Packages.Failed, func(i, j int) bool {
+		return merged.Packages.Failed[i].Path < merged.Packages.Failed[j].Path
+	})
+	for name := range collisions {
+		merged.Collisions = append(merged.Collisions, name)
+	}
+	sort.Strings(merged.Collisions)
+	// 他のモジュールで呼ばれているクエリは不一致ではない
+	merged.UnmatchedRequestQueries = unmatchedQueries(unmatched, merged.Dependencies)
+	merged.Summary = summarize(merged)
+	
+	return merged
+}
+
+// PathTo returns every call chain from function to a query accessing table
+// Chains are followed through Functions[].Calls without revisiting a function,
+// so recursive calls do not produce infinite paths
+func (r *Result) PathTo(function, table string) []DependencyPath {
+	// 関数ごとに、テーブルにアクセスするクエリメソッドと操作をまとめる
+	queries := make(map[string]map[string][]string)
+	for _, dep := range r.Dependencies {
+		if dep.Table != table {
+			continue
+		}
+		if queries[dep.Function] == nil {
+			queries[dep.Function] = make(map[string][]string)
+		}
+		if !containsString(queries[dep.Function][dep.Method], dep.Operation) {
+			queries[dep.Function][dep.Method] = append(queries[dep.Function][dep.Method], dep.Operation)
+		}
+	}
+	
+	var paths []DependencyPath
+	onPath := make(map[string]bool)
+	var walk func(chain []string)
+	walk = func(chain []string) {
+		current := chain[len(chain)-1]
+		onPath[current] = true
+		defer delete(onPath, current)
+		
+		for method, operations := range queries[current] {
+			paths = append(paths, DependencyPath{
+				Functions:  append([]string(nil), chain...),
+				Method:     method,
+				Table:      table,
+				Operations: unionStrings(nil, operations),
+			})
+		}
+		for _, callee := range r.Functions[current].Calls {
+			if !onPath[callee] {
+				walk(append(chain, callee))
+			}
+		}
+	}
+	if _, exists := r.Functions[function]; exists {
+		walk([]string{function})
+	}
+	
+	sort.Slice(paths, func(i, j int) bool {
+		a, b := strings.Join(paths[i].Functions, " "), strings.Join(paths[j].Functions, " ")
+		if a != b {
+			return a < b
+		}
+		return paths[i].Method < paths[j].Method
+	})
+	return paths
+}
+
+// PackageTableAccess rolls function-level table access up to packages
+// The result maps FunctionInfo.Package to table to the sorted operations its functions perform
+func (r *Result) PackageTableAccess() map[string]map[string][]string {
+	packages := make(map[string]map[string][]string)
+	for _, function := range r.Functions {
+		if len(function.TableAccess) == 0 {
+			continue
+		}
+		tables := packages[function.Package]
+		if tables == nil {
+			tables = make(map[string][]string)
+			packages[function.Package] = tables
+		}
+		for table, access := range function.TableAccess {
+			tables[table] = unionStrings(tables[table], access.Operations)
+		}
+	}
+	return packages
+}
+
+// Hotspots returns the topN most coupled tables and functions and the most written tables
+// A topN of 0 or less returns every entry with a non-zero count
+func (r *Result) Hotspots(topN int) Hotspots {
+	var tables, writeHeavy []Hotspot
+	for name, table := range r.Tables {
+		if len(table.AccessedBy) > 0 {
+			tables = append(tables, Hotspot{Name: name, Count: len(table.AccessedBy)})
+		}
+		
+		writes := 0
+		for _, operation := range []types.Operation{types.OpInsert, types.OpUpdate, types.OpDelete} {
+			writes += table.OperationCount[string(operation)]
+		}
+		if writes > 0 {
+			writeHeavy = append(writeHeavy, Hotspot{Name: name, Count: writes})
+		}
+	}
+	
+	var functions []Hotspot
+	for name, function := range r.Functions {
+		if len(function.TableAccess) > 0 {
+			functions = append(functions, Hotspot{Name: name, Count: len(function.TableAccess)})
+		}
+	}
+	
+	return Hotspots{
+		Tables:     rankHotspots(tables, topN),
+		Functions:  rankHotspots(functions, topN),
+		WriteHeavy: rankHotspots(writeHeavy, topN),
+	}
+}
+
+// rankHotspots sorts hotspots by count and keeps the first topN
+func rankHotspots(hotspots []Hotspot, topN int) []Hotspot {
+	sort.Slice(hotspots, func(i, j int) bool {
+		if hotspots[i].Count != hotspots[j].Count {
+			return hotspots[i].Count > hotspots[j].Count
+		}
+		return hotspots[i].Name < hotspots[j].Name
+	})
+	if topN > 0 && len(hotspots) > topN {
+		hotspots = hotspots[:topN]
+	}
+	return hotspots
+}
+
+// mergeFunction combines two records of the same function
+// 同じ関数が複数の結果に含まれる場合、テーブルアクセスを和集合にし回数は合算する
+func mergeFunction(a, b FunctionInfo) FunctionInfo {
+	merged := a
+	merged.TableAccess = make(map[string]Access, len(a.TableAccess))
+	for table, access := range a.TableAccess {
+		merged.TableAccess[table] = access
+	}
+	for table, access := range b.TableAccess {
+		existing := merged.TableAccess[table]
+		combined := Access{
+			Operations: unionStrings(existing.Operations, access.Operations),
+			Methods:    unionStrings(existing.Methods, access.Methods),
+			Count:      existing.Count + access.Count,
+		}
+		if len(existing.Locking)+len(access.Locking) > 0 {
+			combined.Locking = unionStrings(existing.Locking, access.Locking)
+		}
+		merged.TableAccess[table] = combined
+	}
+	
+	if len(b.ColumnAccess) > 0 {
+		merged.ColumnAccess = unionOperations(a.ColumnAccess, b.ColumnAccess)
+	}
+	if len(b.Calls) > 0 {
+		merged.Calls = unionStrings(a.Calls, b.Calls)
+	}
+	if len(b.Schemas) > 0 {
+		merged.Schemas = unionStrings(a.Schemas, b.Schemas)
+	}
+	if merged.Layer == "" {
+		merged.Layer = b.Layer
+	}
+	return merged
+}
+
+// mergeTable combines the records of a table from two results, summing operation counts
+func mergeTable(a, b TableInfo) TableInfo {
+	merged := TableInfo{
+		Name:                 b.Name,
+		Kind:                 b.Kind,
+		AccessedBy:           unionStrings(a.AccessedBy, b.AccessedBy),
+		OperationCount:       make(map[string]int),
+		OperationsByFunction: unionOperations(a.OperationsByFunction, b.OperationsByFunction),
+	}
+	for operation, count := range a.OperationCount {
+		merged.OperationCount[operation] += count
+	}
+	for operation, count := range b.OperationCount {
+		merged.OperationCount[operation] += count
+	}
+	if merged.Kind == "" {
+		merged.Kind = a.Kind
+	}
+	return merged
+}
+
+// unionStrings returns the sorted union of two string lists
+func unionStrings(a, b []string) []string {
+	union := make([]string, 0, len(a)+len(b))
+	for _, values := range [][]string{a, b} {
+		for _, value := range values {
+			if !containsString(union, value) {
+				union = append(union, value)
+			}
+		}
+	}
+	sort.Strings(union)
+	return union
+}
+
+// unionOperations merges two "key -> operations" maps
+func unionOperations(a, b map[string][]string) map[string][]string {
+	union := make(map[string][]string, len(a)+len(b))
+	for key, operations := range a {
+		union[key] = unionStrings(nil, operations)
+	}
+	for key, operations := range b {
+		union[key] = unionStrings(union[key], operations)
+	}
+	return union
+}
+
+// containsTip checks if a suggestion is already in the list
+func containsTip(tips []OptimizationTip, tip OptimizationTip) bool {
+	for _, t := range tips {
+		if t == tip {
+			return true
+		}
+	}
+	return false
+}
+
+// binaryResult has the fields of Result without its MarshalBinary method,
+// which gob would otherwise call recursively
+type binaryResult Result
+
+// MarshalBinary encodes the result with encoding/gob
+// It is faster and more compact than JSON for caching and passing results between processes
+func (r *Result) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode((*binaryResult)(r)); err != nil {
+		return nil, fmt.Errorf("failed to encode result: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a result encoded by MarshalBinary
+func (r *Result) UnmarshalBinary(data []byte) error {
+	var decoded binaryResult
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&decoded); err != nil {
+		return fmt.Errorf("failed to decode result: %w", err)
+	}
+	*r = Result(decoded)
+	r.restoreEmpty()
+	return nil
+}
+
+// restoreEmpty recreates the empty maps and slices gob does not transmit,
+// so a decoded result serializes to the same JSON as the original
+func (r *Result) restoreEmpty() {
+	if r.Functions == nil {
+		r.Functions = make(map[string]FunctionInfo)
+	}
+	if r.Tables == nil {
+		r.Tables = make(map[string]TableInfo)
+	}
+	if r.Dependencies == nil {
+		r.Dependencies = []Dependency{}
+	}
+	if r.Summary.OperationCounts == nil {
+		r.Summary.OperationCounts = make(map[string]int)
+	}
+	if r.Packages.Loaded == nil {
+		r.Packages.Loaded = []string{}
+	}
+	
+	for name, function := range r.Functions {
+		if function.TableAccess == nil {
+			function.TableAccess = make(map[string]Access)
+		}
+		for table, access := range function.TableAccess {
+			if access.Operations == nil {
+				access.Operations = []string{}
+			}
+			if access.Methods == nil {
+				access.Methods = []string{}
+			}
+			function.TableAccess[table] = access
+		}
+		r.Functions[name] = function
+	}
+	for name, table := range r.Tables {
+		if table.AccessedBy == nil {
+			table.AccessedBy = []string{}
+		}
+		if table.OperationCount == nil {
+			table.OperationCount = make(map[string]int)
+		}
+		if table.OperationsByFunction == nil {
+			table.OperationsByFunction = make(map[string][]string)
+		}
+		r.Tables[name] = table
+	}
+	for name, entryPoint := range r.EntryPoints {
+		if entryPoint.Reaches == nil {
+			entryPoint.Reaches = []string{}
+		}
+		r.EntryPoints[name] = entryPoint
+	}
 }
 
-// TableInfo represents information about a database table
-type TableInfo struct {
-	Name          string            `json:"name"`
-	AccessedBy    []string          `json:"accessed_by"`
-	OperationCount map[string]int   `json:"operation_count"`
+// FormatMetrics renders the summary of a result in the Prometheus text exposition format
+// Values describe one analysis run, so every metric is a gauge
+func FormatMetrics(result *Result) string {
+	if result == nil {
+		return ""
+	}
+	
+	var buf strings.Builder
+	writeMetric := func(name, help string, samples func()) {
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+		samples()
+	}
+	
+	writeMetric("sqlc_analysis_functions_total", "Number of analyzed functions.", func() {
+		fmt.Fprintf(&buf, "sqlc_analysis_functions_total %d\n", result.Summary.FunctionCount)
+	})
+	writeMetric("sqlc_analysis_tables_total", "Number of accessed tables.", func() {
+		fmt.Fprintf(&buf, "sqlc_analysis_tables_total %d\n", result.Summary.TableCount)
+	})
+	writeMetric("sqlc_analysis_dependencies_total", "Number of function to table dependencies.", func() {
+		fmt.Fprintf(&buf, "sqlc_analysis_dependencies_total %d\n", result.Summary.DependencyCount)
+	})
+	writeMetric("sqlc_analysis_operations_total", "Number of dependencies by operation.", func() {
+		for _, operation := range sortedKeys(result.Summary.OperationCounts) {
+			fmt.Fprintf(&buf, "sqlc_analysis_operations_total{op=\"%s\"} %d\n",
+				escapeLabelValue(operation), result.Summary.OperationCounts[operation])
+		}
+	})
+	writeMetric("sqlc_analysis_table_functions", "Number of functions accessing each table.", func() {
+		for _, table := range sortedKeys(result.Tables) {
+			fmt.Fprintf(&buf, "sqlc_analysis_table_functions{table=\"%s\"} %d\n",
+				escapeLabelValue(table), len(result.Tables[table].AccessedBy))
+		}
+	})
+	
+	return buf.String()
 }
 
-// Dependency represents a dependency between a function and a table
-type Dependency struct {
-	Function  string `json:"function"`
-	Table     string `json:"table"`
-	Operation string `json:"operation"`
-	Method    string `json:"method"`
-	Line      int    `json:"line"`
+// escapeLabelValue escapes a Prometheus label value
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
 }
 
-// Access represents how a function accesses a table
-type Access struct {
-	Operations []string `json:"operations"`
-	Methods    []string `json:"methods"`
-	Count      int      `json:"count"`
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
-// Summary provides high-level statistics
-type Summary struct {
-	FunctionCount   int            `json:"function_count"`
-	TableCount      int            `json:"table_count"`
-	DependencyCount int            `json:"dependency_count"`
-	OperationCounts map[string]int `json:"operation_counts"`
+// GraphOptions controls the DOT and Mermaid exports
+type GraphOptions struct {
+	MinWeight int // edges with fewer calls than this are hidden; 0 keeps every edge
 }
 
-// OptimizationTip provides actionable optimization suggestions
-type OptimizationTip struct {
-	Type        string `json:"type"`
-	Function    string `json:"function,omitempty"`
-	Table       string `json:"table,omitempty"`
-	Description string `json:"description"`
-	Severity    string `json:"severity"`
+// maxEdgeWidth is the line width of the most-called edge in graph exports
+const maxEdgeWidth = 5.0
+
+// graphEdge is one function -> table dependency weighted by its number of calls
+type graphEdge struct {
+	Function   string
+	Table      string
+	Operations []string
+	Weight     int
 }
 
-// Analyzer provides a deep module for dependency analysis
-// It hides all complexity behind a simple interface
-type Analyzer struct {
-	engine *dependency.Engine
-	errors *errors.ErrorCollector
+// Label shows the operations and the call count, e.g. "SELECT, UPDATE (3)"
+func (e graphEdge) Label() string {
+	return fmt.Sprintf("%s (%d)", strings.Join(e.Operations, ", "), e.Weight)
 }
 
-// New creates a new analyzer with sensible defaults
-// This is the only way to create an analyzer, ensuring proper initialization
-func New() *Analyzer {
-	errorCollector := errors.NewErrorCollector(100, false)
-	return &Analyzer{
-		engine: dependency.NewEngine(errorCollector),
-		errors: errorCollector,
+// graphEdges returns the edges at or above opts.MinWeight, sorted by function then table,
+// together with the heaviest weight used to scale line widths
+func graphEdges(result *Result, opts GraphOptions) ([]graphEdge, int) {
+	var edges []graphEdge
+	maxWeight := 0
+	for _, function := range sortedKeys(result.Functions) {
+		access := result.Functions[function].TableAccess
+		for _, table := range sortedKeys(access) {
+			if access[table].Count < opts.MinWeight {
+				continue
+			}
+			edges = append(edges, graphEdge{
+				Function:   function,
+				Table:      table,
+				Operations: access[table].Operations,
+				Weight:     access[table].Count,
+			})
+			maxWeight = max(maxWeight, access[table].Count)
+		}
 	}
+	return edges, maxWeight
 }
 
-// Analyze performs complete dependency analysis
-// This is the main interface - all complexity is hidden inside
-func (a *Analyzer) Analyze(ctx context.Context, request AnalysisRequest) (*Result, error) {
-	// Input validation
-	if err := a.validateRequest(request); err != nil {
-		return nil, fmt.Errorf("invalid request: %w", err)
+// edgeWidth scales a weight so the heaviest edge is maxEdgeWidth wide; edges are at least 1 wide
+func edgeWidth(weight, maxWeight int) float64 {
+	if maxWeight == 0 {
+		return 1
 	}
+	return max(1, maxEdgeWidth*float64(weight)/float64(maxWeight))
+}
 
-	// Convert external types to internal types
-	queries := a.convertQueries(request.SQLQueries)
+// FormatDOT renders the function -> table dependencies as a Graphviz digraph
+// Edge penwidth is proportional to the call count, so heavily used dependencies stand out
+func FormatDOT(result *Result, opts GraphOptions) string {
+	if result == nil {
+		return ""
+	}
 	
-	// Perform the analysis using the internal engine
-	// All engine complexity is hidden from the caller
-	result, err := a.engine.AnalyzeDependencies(queries, request.GoPackages)
-	if err != nil {
-		return nil, fmt.Errorf("analysis failed: %w", err)
+	edges, maxWeight := graphEdges(result, opts)
+	
+	var buf strings.Builder
+	buf.WriteString("digraph dependencies {\n  rankdir=LR;\n  node [shape=box];\n")
+	declared := make(map[string]bool)
+	for _, edge := range edges {
+		if !declared[edge.Table] {
+			declared[edge.Table] = true
+			fmt.Fprintf(&buf, "  \"%s\" [shape=cylinder];\n", escapeLabelValue(edge.Table))
+		}
 	}
+	for _, edge := range edges {
+		fmt.Fprintf(&buf, "  \"%s\" -> \"%s\" [label=\"%s\", penwidth=%.2f];\n",
+			escapeLabelValue(edge.Function), escapeLabelValue(edge.Table),
+			escapeLabelValue(edge.Label()), edgeWidth(edge.Weight, maxWeight))
+	}
+	buf.WriteString("}\n")
+	
+	return buf.String()
+}
 
-	// Convert internal result to external format
-	// This transformation hides internal complexity
-	analysisResult := a.convertResult(result)
+// FormatMermaid renders the function -> table dependencies as a Mermaid flowchart
+// Edge labels carry the operations and call count; linkStyle widths follow the same scale as FormatDOT
+func FormatMermaid(result *Result, opts GraphOptions) string {
+	if result == nil {
+		return ""
+	}
 	
-	return analysisResult, nil
+	edges, maxWeight := graphEdges(result, opts)
+	
+	// Mermaidのノード名には記号を使えないため連番のIDを振り、名前はラベルに書く
+	ids := make(map[string]string)
+	counts := make(map[string]int)
+	nodeID := func(prefix, name string) (string, bool) {
+		key := prefix + ":" + name
+		if id, exists := ids[key]; exists {
+			return id, false
+		}
+		ids[key] = fmt.Sprintf("%s%d", prefix, counts[prefix])
+		counts[prefix]++
+		return ids[key], true
+	}
+	escape := strings.NewReplacer(`"`, "#quot;").Replace
+	
+	var buf strings.Builder
+	buf.WriteString("flowchart LR\n")
+	for _, edge := range edges {
+		from, newFunction := nodeID("f", edge.Function)
+		to, newTable := nodeID("t", edge.Table)
+		fromNode, toNode := from, to
+		if newFunction {
+			fromNode = fmt.Sprintf("%s[\"%s\"]", from, escape(edge.Function))
+		}
+		if newTable {
+			toNode = fmt.Sprintf("%s[(\"%s\")]", to, escape(edge.Table))
+		}
+		fmt.Fprintf(&buf, "  %s -->|\"%s\"| %s\n", fromNode, escape(edge.Label()), toNode)
+	}
+	for i, edge := range edges {
+		fmt.Fprintf(&buf, "  linkStyle %d stroke-width:%.2fpx\n", i, edgeWidth(edge.Weight, maxWeight))
+	}
+	
+	return buf.String()
 }
 
-// AnalyzeAndFormat performs analysis and returns formatted output
-// This combines analysis and formatting in a single call for convenience
-func (a *Analyzer) AnalyzeAndFormat(ctx context.Context, request AnalysisRequest) ([]byte, error) {
-	result, err := a.Analyze(ctx, request)
+// TablesInQuery returns the tables a single SQL statement touches, mapped to their operations
+// dialect is one of "mysql" (default), "postgresql", "sqlite" or "ansi"
+func TablesInQuery(query, dialect string) (map[string][]string, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("query is empty")
+	}
+	if dialect == "" {
+		dialect = sql.DialectMySQL
+	}
+	
+	methodInfo, err := sql.NewAnalyzer(dialect, false, errors.NewErrorCollector(100, false)).AnalyzeQuery(sql.Query{
+		Name: "Query",
+		Text: query,
+	})
 	if err != nil {
 		return nil, err
 	}
+	
+	tables := make(map[string][]string, len(methodInfo.Tables))
+	for _, table := range methodInfo.Tables {
+		for _, operation := range table.Operations {
+			if !containsString(tables[table.TableName], operation) {
+				tables[table.TableName] = append(tables[table.TableName], operation)
+			}
+		}
+	}
+	for name := range tables {
+		sort.Strings(tables[name])
+	}
+	
+	return tables, nil
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
 
-	// Determine output format
-	format := request.OutputFormat
-	if format == "" {
-		format = "json"
+// LoadFromSqlcConfig builds an analysis request from a sqlc.yaml or sqlc.json file
+// Queries are read from each package's "queries" path and the generated Go
+// "out" directories become GoPackages; add the packages that call them as needed
+func LoadFromSqlcConfig(path string) (AnalysisRequest, error) {
+	packages, err := sqlcio.ReadSqlcConfig(path)
+	if err != nil {
+		return AnalysisRequest{}, err
+	}
+	
+	var request AnalysisRequest
+	for _, pkg := range packages {
+		files, err := sqlcio.ListQueryFiles(pkg.Queries)
+		if err != nil {
+			return AnalysisRequest{}, err
+		}
+		queries, err := sqlcio.ReadQueryFiles(files)
+		if err != nil {
+			return AnalysisRequest{}, err
+		}
+		for _, query := range queries {
+			request.SQLQueries = append(request.SQLQueries, Query{Name: query.Name, SQL: query.Text, Cmd: query.Cmd})
+		}
+		
+		if pkg.GoOut != "" {
+			request.GoPackages = append(request.GoPackages, packagePattern(pkg.GoOut))
+		}
 	}
+	
+	if len(request.SQLQueries) == 0 {
+		return AnalysisRequest{}, fmt.Errorf("no queries found in sqlc config %s", path)
+	}
+	return request, nil
+}
 
-	// Convert to internal format types
-	var outputFormat types.OutputFormat
-	switch format {
-	case "json":
-		outputFormat = types.FormatJSON
-	default:
-		return nil, fmt.Errorf("unsupported output format: %s (only JSON is supported)", format)
+// LoadProject reads a sqlc-style project: schema files matching schemaGlob and query files matching queryGlob
+// The tables and views created by the schema become the catalog that later analyses validate
+// table names against and take TableInfo.Kind from; the annotated queries are returned as a
+// request to which GoPackages are added
+func (a *Analyzer) LoadProject(schemaGlob, queryGlob string) (AnalysisRequest, error) {
+	schemaFiles, err := globFiles(schemaGlob)
+	if err != nil {
+		return AnalysisRequest{}, fmt.Errorf("failed to find schema files: %w", err)
+	}
+	queryFiles, err := globFiles(queryGlob)
+	if err != nil {
+		return AnalysisRequest{}, fmt.Errorf("failed to find query files: %w", err)
+	}
+	
+	engine := a.newRun(nil).engine
+	catalog := make(map[string]struct{})
+	kinds := make(map[string]string)
+	for _, path := range schemaFiles {
+		schema, err := os.ReadFile(path)
+		if err != nil {
+			return AnalysisRequest{}, fmt.Errorf("failed to read schema file: %w", err)
+		}
+		for _, relation := range engine.SchemaRelations(string(schema)) {
+			catalog[relation.Name] = struct{}{}
+			kinds[strings.ToLower(relation.Name)] = relation.Kind
+		}
+	}
+	
+	queries, err := sqlcio.ReadQueryFiles(queryFiles)
+	if err != nil {
+		return AnalysisRequest{}, err
+	}
+	var request AnalysisRequest
+	for _, query := range queries {
+		request.SQLQueries = append(request.SQLQueries, Query{Name: query.Name, SQL: query.Text, Cmd: query.Cmd})
 	}
+	if len(request.SQLQueries) == 0 {
+		return AnalysisRequest{}, fmt.Errorf("no queries found in %s", queryGlob)
+	}
+	
+	a.mu.Lock()
+	a.knownTables = catalog
+	a.tableKinds = kinds
+	a.mu.Unlock()
+	return request, nil
+}
+
+// globFiles returns the files matching pattern, failing when there are none
+func globFiles(pattern string) ([]string, error) {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files match %s", pattern)
+	}
+	return files, nil
+}
+
+// packagePattern turns a directory into a go/packages pattern
+// Relative directories need a "./" prefix so they are not taken as import paths
+func packagePattern(dir string) string {
+	if filepath.IsAbs(dir) || strings.HasPrefix(dir, ".") {
+		return dir
+	}
+	return "." + string(filepath.Separator) + dir
+}
+
+// Violation is a dependency not allowed by a manifest
+type Violation struct {
+	Function  string `json:"function"`
+	Table     string `json:"table"`
+	Operation string `json:"operation"`
+	Method    string `json:"method"`
+	Line      int    `json:"line"`
+	Reason    string `json:"reason"`
+}
 
-	// Format the result
-	// Note: This is a simplified implementation for demonstration
-	// In practice, you'd use the formatter to generate actual output
-	_ = output.NewFormatter(outputFormat, request.PrettyPrint)
-	_ = a.convertToReport(result)
+// CheckAgainstManifest reports dependencies the manifest at manifestPath does not allow
+// The manifest maps functions to allowed "table" or "table/OPERATION" entries; a function
+// key matches the qualified name or its trailing "package.Function" part
+func CheckAgainstManifest(result *Result, manifestPath string) ([]Violation, error) {
+	manifest, err := sqlcio.ReadDependencyManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, nil
+	}
 	
-	// For now, return a simple JSON representation
-	// TODO: Implement proper formatting
-	return []byte(`{"status": "analysis_complete"}`), nil
+	var violations []Violation
+	for _, dep := range sortDependencies(append([]Dependency{}, result.Dependencies...)) {
+		rules, listed := manifestRules(manifest, dep.Function)
+		reason := ""
+		switch {
+		case !listed:
+			reason = "function is not listed in the manifest"
+		case !allowsAccess(rules, dep.Table, dep.Operation):
+			reason = fmt.Sprintf("%s on %s is not allowed", dep.Operation, dep.Table)
+		default:
+			continue
+		}
+		violations = append(violations, Violation{
+			Function:  dep.Function,
+			Table:     dep.Table,
+			Operation: dep.Operation,
+			Method:    dep.Method,
+			Line:      dep.Line,
+			Reason:    reason,
+		})
+	}
+	
+	return violations, nil
+}
+
+// manifestRules collects the rules of every manifest key that names function
+func manifestRules(manifest map[string][]sqlcio.ManifestRule, function string) ([]sqlcio.ManifestRule, bool) {
+	var rules []sqlcio.ManifestRule
+	listed := false
+	for key, keyRules := range manifest {
+		if key == function || strings.HasSuffix(function, "/"+key) {
+			rules = append(rules, keyRules...)
+			listed = true
+		}
+	}
+	return rules, listed
+}
+
+// allowsAccess reports whether any rule allows operation on table
+func allowsAccess(rules []sqlcio.ManifestRule, table, operation string) bool {
+	for _, rule := range rules {
+		if rule.Table == table && (rule.Operation == "" || rule.Operation == operation) {
+			return true
+		}
+	}
+	return false
 }
 
 // GetErrors returns any errors that occurred during analysis
 // This provides access to detailed error information if needed
 func (a *Analyzer) GetErrors() []AnalysisError {
-	internalErrors := a.errors.GetAllErrors()
+	return convertErrors(a.lastRun().errors.GetAllErrors())
+}
+
+// GetErrorsFiltered returns errors at least as severe as minSeverity
+// minSeverity is one of "fatal", "error", "warning" or "info"; unknown values return all errors
+func (a *Analyzer) GetErrorsFiltered(minSeverity string) []AnalysisError {
+	severity, err := errors.ParseSeverity(minSeverity)
+	if err != nil {
+		return a.GetErrors()
+	}
+	return convertErrors(errors.FilterBySeverity(a.lastRun().errors.GetAllErrors(), severity))
+}
+
+// GetExplanations returns why each Go method call was or wasn't linked to a query
+// Explanations are only recorded when Options.Explain is set
+func (a *Analyzer) GetExplanations() []AnalysisError {
+	return convertErrors(a.lastRun().errors.GetInfos())
+}
+
+// GetAggregatedErrors returns collected errors grouped by similarity
+// Groups are ordered by count, largest first
+func (a *Analyzer) GetAggregatedErrors() []AggregatedError {
+	aggregator := errors.NewErrorAggregator()
+	for _, err := range a.lastRun().errors.GetAllErrors() {
+		aggregator.Add(err)
+	}
+	
+	groups := aggregator.GetAggregatedReport()
+	aggregated := make([]AggregatedError, len(groups))
+	for i, group := range groups {
+		locations := make([]ErrorLocation, len(group.Locations))
+		for j, loc := range group.Locations {
+			locations[j] = ErrorLocation{
+				File:     loc.File,
+				Line:     loc.Line,
+				Column:   loc.Column,
+				Function: loc.Function,
+			}
+		}
+		sort.Slice(locations, func(x, y int) bool {
+			if locations[x].File != locations[y].File {
+				return locations[x].File < locations[y].File
+			}
+			return locations[x].Line < locations[y].Line
+		})
+		
+		aggregated[i] = AggregatedError{
+			Key:        group.Key,
+			Count:      group.Count,
+			Category:   string(group.Category),
+			Severity:   group.Severity.String(),
+			FirstError: convertErrors([]*errors.AnalysisError{group.FirstError})[0],
+			Locations:  locations,
+		}
+	}
+	
+	return aggregated
+}
+
+// LastRunStats returns per-phase timings and input counts of the most recent analysis
+// The zero value is returned before any analysis has run
+func (a *Analyzer) LastRunStats() RunStats {
+	metrics := a.lastRun().engine.GetStats().RunMetrics
+	return RunStats{
+		SQLAnalysis: metrics.SQLAnalysisDuration,
+		PackageLoad: metrics.PackageLoadDuration,
+		GoAnalysis:  metrics.GoAnalysisDuration,
+		Mapping:     metrics.MappingDuration,
+		Queries:     metrics.QueryCount,
+		Packages:    metrics.PackageCount,
+		Files:       metrics.FileCount,
+	}
+}
+
+func convertErrors(internalErrors []*errors.AnalysisError) []AnalysisError {
 	externalErrors := make([]AnalysisError, len(internalErrors))
 	
 	for i, err := range internalErrors {
@@ -189,18 +1715,76 @@ type AnalysisError struct {
 	Details  map[string]interface{} `json:"details,omitempty"`
 }
 
+// AggregatedError represents a group of similar errors
+type AggregatedError struct {
+	Key        string          `json:"key"`
+	Count      int             `json:"count"`
+	Category   string          `json:"category"`
+	Severity   string          `json:"severity"`
+	FirstError AnalysisError   `json:"first_error"`
+	Locations  []ErrorLocation `json:"locations,omitempty"`
+}
+
+// ErrorLocation represents where an error occurred
+type ErrorLocation struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column,omitempty"`
+	Function string `json:"function,omitempty"`
+}
+
+// RunStats reports how long each analysis phase took and how much input it processed
+type RunStats struct {
+	SQLAnalysis time.Duration `json:"sql_analysis"`
+	PackageLoad time.Duration `json:"package_load"`
+	GoAnalysis  time.Duration `json:"go_analysis"`
+	Mapping     time.Duration `json:"mapping"`
+	Queries     int           `json:"queries"`
+	Packages    int           `json:"packages"`
+	Files       int           `json:"files"`
+}
+
 // Helper methods (private, hiding complexity)
 
 func (a *Analyzer) validateRequest(request AnalysisRequest) error {
-	if len(request.SQLQueries) == 0 {
-		return fmt.Errorf("no SQL queries provided")
+	if err := a.validateQueries(request.SQLQueries); err != nil {
+		return err
 	}
 	
-	if len(request.GoPackages) == 0 {
-		return fmt.Errorf("no Go packages provided")
+	// GoPackages are optional, see Analyze
+	if err := validateOutputFormat(request.OutputFormat); err != nil {
+		return err
+	}
+	if style := types.OperationStyle(request.OperationStyle); style != "" && !style.IsValid() {
+		return fmt.Errorf("unsupported operation style %q (supported: sql, crud)", request.OperationStyle)
+	}
+	return nil
+}
+
+// supportedOutputFormats are the values accepted by AnalysisRequest.OutputFormat
+var supportedOutputFormats = []types.OutputFormat{types.FormatJSON, types.FormatCSV, types.FormatTemplate}
+
+// validateOutputFormat rejects unknown formats before the analysis runs; "" means json
+func validateOutputFormat(format string) error {
+	if format == "" {
+		return nil
+	}
+	names := make([]string, len(supportedOutputFormats))
+	for i, supported := range supportedOutputFormats {
+		if types.OutputFormat(format) == supported {
+			return nil
+		}
+		names[i] = string(supported)
+	}
+	return fmt.Errorf("unsupported output format %q (supported: %s)", format, strings.Join(names, ", "))
+}
+
+func (a *Analyzer) validateQueries(queries []Query) error {
+	if len(queries) == 0 {
+		return fmt.Errorf("no SQL queries provided")
 	}
 	
-	for i, query := range request.SQLQueries {
+	for i, query := range queries {
 		if query.Name == "" {
 			return fmt.Errorf("query %d has empty name", i)
 		}
@@ -218,31 +1802,34 @@ func (a *Analyzer) convertQueries(queries []Query) []types.QueryInfo {
 		converted[i] = types.QueryInfo{
 			Name: q.Name,
 			SQL:  q.SQL,
+			Cmd:  q.Cmd,
 		}
 	}
 	return converted
 }
 
-func (a *Analyzer) convertResult(internalResult types.AnalysisResult) *Result {
+func (a *Analyzer) convertResult(run *analysisRun, internalResult types.AnalysisResult) *Result {
 	result := &Result{
-		Functions:    make(map[string]FunctionInfo),
-		Tables:       make(map[string]TableInfo),
-		Dependencies: []Dependency{},
-		Summary: Summary{
-			OperationCounts: make(map[string]int),
-		},
+		SchemaVersion: types.SchemaVersion,
+		Functions:     make(map[string]FunctionInfo),
+		Tables:        make(map[string]TableInfo),
+		Dependencies:  []Dependency{},
 	}
 	
 	// Convert function view
 	for funcName, funcEntry := range internalResult.FunctionView {
 		funcInfo := FunctionInfo{
-			Name:        funcEntry.FunctionName,
-			Package:     funcEntry.PackageName,
-			File:        funcEntry.FileName,
-			StartLine:   funcEntry.StartLine,
-			EndLine:     funcEntry.EndLine,
-			TableAccess: make(map[string]Access),
+			Name:          funcEntry.FunctionName,
+			QualifiedName: funcName,
+			Package:       funcEntry.PackageName,
+			File:          funcEntry.FileName,
+			StartLine:     funcEntry.StartLine,
+			EndLine:       funcEntry.EndLine,
+			Layer:         a.resolveLayer(funcEntry.PackagePath, funcEntry.FileName),
+			TableAccess:   make(map[string]Access),
+			Calls:         append([]string(nil), funcEntry.Calls...),
 		}
+		sort.Strings(funcInfo.Calls)
 		
 		// Convert table access information
 		for tableName, tableAccess := range funcEntry.TableAccess {
@@ -261,17 +1848,39 @@ func (a *Analyzer) convertResult(internalResult types.AnalysisResult) *Result {
 					
 					// Create dependency entry
 					result.Dependencies = append(result.Dependencies, Dependency{
-						Function:  funcName,
-						Table:     tableName,
-						Operation: operation,
-						Method:    call.MethodName,
-						Line:      call.Line,
+						Function:   funcName,
+						Table:      tableName,
+						Operation:  operation,
+						AccessKind: string(call.Kind),
+						Method:     call.MethodName,
+						Line:       call.Line,
+						Confidence: string(call.Confidence),
 					})
 				}
 			}
 			
+			sort.Strings(access.Operations)
+			sort.Strings(access.Methods)
+			if len(tableAccess.Locking) > 0 {
+				access.Locking = append([]string{}, tableAccess.Locking...)
+				sort.Strings(access.Locking)
+			}
 			funcInfo.TableAccess[tableName] = access
+			
+			for operation, columns := range tableAccess.Columns {
+				for _, column := range columns {
+					if funcInfo.ColumnAccess == nil {
+						funcInfo.ColumnAccess = make(map[string][]string)
+					}
+					key := tableName + "." + column
+					if !containsString(funcInfo.ColumnAccess[key], operation) {
+						funcInfo.ColumnAccess[key] = append(funcInfo.ColumnAccess[key], operation)
+						sort.Strings(funcInfo.ColumnAccess[key])
+					}
+				}
+			}
 		}
+		funcInfo.Schemas = accessedSchemas(funcInfo.TableAccess, a.opts.DefaultSchema)
 		
 		result.Functions[funcName] = funcInfo
 	}
@@ -279,47 +1888,194 @@ func (a *Analyzer) convertResult(internalResult types.AnalysisResult) *Result {
 	// Convert table view
 	for tableName, tableEntry := range internalResult.TableView {
 		accessedBy := make([]string, 0, len(tableEntry.AccessedBy))
-		for funcName := range tableEntry.AccessedBy {
+		operationsByFunction := make(map[string][]string, len(tableEntry.AccessedBy))
+		for funcName, funcAccess := range tableEntry.AccessedBy {
 			accessedBy = append(accessedBy, funcName)
+			
+			// Which operations each function performs on this table
+			operations := append([]string{}, funcAccess.Operations...)
+			sort.Strings(operations)
+			operationsByFunction[funcName] = operations
 		}
+		sort.Strings(accessedBy)
 		
 		result.Tables[tableName] = TableInfo{
-			Name:           tableName,
-			AccessedBy:     accessedBy,
-			OperationCount: tableEntry.OperationSummary,
+			Name:                 tableName,
+			Kind:                 run.tableKinds[strings.ToLower(tableName)],
+			AccessedBy:           accessedBy,
+			OperationCount:       tableEntry.OperationSummary,
+			OperationsByFunction: operationsByFunction,
+		}
+	}
+	
+	// Convert entry points
+	if len(internalResult.EntryPoints) > 0 {
+		result.EntryPoints = make(map[string]EntryPointInfo, len(internalResult.EntryPoints))
+		for funcName, entryPoint := range internalResult.EntryPoints {
+			funcEntry := internalResult.FunctionView[funcName]
+			result.EntryPoints[funcName] = EntryPointInfo{
+				Function: entryPoint.Function,
+				Package:  funcEntry.PackageName,
+				File:     funcEntry.FileName,
+				Reaches:  entryPoint.Reaches,
+				Tables:   entryPoint.Tables,
+			}
 		}
 	}
 	
-	// Calculate summary
-	result.Summary.FunctionCount = len(result.Functions)
-	result.Summary.TableCount = len(result.Tables)
-	result.Summary.DependencyCount = len(result.Dependencies)
+	result.Dependencies = sortDependencies(result.Dependencies)
+	result.Summary = summarize(result)
+	result.SchemaView = schemaView(result.Tables, a.opts.DefaultSchema)
+	result.Packages = packageSummary(run.engine)
+	result.UnmatchedRequestQueries = unmatchedQueries(queryNames(run.queries), result.Dependencies)
+	
+	return result
+}
+
+// summarize calculates the summary statistics of a result
+func summarize(result *Result) Summary {
+	summary := Summary{
+		FunctionCount:   len(result.Functions),
+		TableCount:      len(result.Tables),
+		DependencyCount: len(result.Dependencies),
+		OperationCounts: make(map[string]int),
+	}
 	
 	// Count operations
 	for _, dep := range result.Dependencies {
-		result.Summary.OperationCounts[dep.Operation]++
+		summary.OperationCounts[dep.Operation]++
 	}
+	summary.countReadsAndWrites()
 	
-	return result
+	for _, function := range result.Functions {
+		if writesTable(function) {
+			summary.WriteFunctions++
+		}
+	}
+	summary.TopTables = topTables(result.Tables, 3)
+	return summary
 }
 
-func (a *Analyzer) convertToReport(result *Result) *types.AnalysisReport {
-	// Convert external result back to internal report format
-	// This is needed for the formatter
-	report := &types.AnalysisReport{
-		Summary: types.AnalysisSummary{
-			FunctionCount:   result.Summary.FunctionCount,
-			TableCount:      result.Summary.TableCount,
-			OperationCounts: result.Summary.OperationCounts,
-			PackageCounts:   make(map[string]int),
-		},
-		Dependencies: types.AnalysisResult{
-			FunctionView: make(map[string]types.FunctionViewEntry),
-			TableView:    make(map[string]types.TableViewEntry),
-		},
-		Suggestions: []types.OptimizationSuggestion{},
-	}
-	
-	// This would need full conversion logic, but shows the pattern
-	return report
+// countReadsAndWrites derives the read and write counts and ratios from OperationCounts
+func (s *Summary) countReadsAndWrites() {
+	s.ReadCount, s.WriteCount = 0, 0
+	for operation, count := range s.OperationCounts {
+		switch types.Operation(operation) {
+		case types.OpSelect:
+			s.ReadCount += count
+		case types.OpDriver:
+		default:
+			s.WriteCount += count
+		}
+	}
+	s.ReadRatio, s.WriteRatio = 0, 0
+	if total := s.ReadCount + s.WriteCount; total > 0 {
+		s.ReadRatio = float64(s.ReadCount) / float64(total)
+		s.WriteRatio = float64(s.WriteCount) / float64(total)
+	}
+}
+
+// writesTable reports whether a function performs any operation other than SELECT on a table
+func writesTable(function FunctionInfo) bool {
+	for _, access := range function.TableAccess {
+		for _, operation := range access.Operations {
+			if operation != string(types.OpSelect) && operation != string(types.OpDriver) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// topTables returns up to n tables with the most operations, ties broken by name
+func topTables(tables map[string]TableInfo, n int) []TableRank {
+	ranks := make([]TableRank, 0, len(tables))
+	for name, table := range tables {
+		rank := TableRank{Name: name}
+		for _, count := range table.OperationCount {
+			rank.Count += count
+		}
+		ranks = append(ranks, rank)
+	}
+	sort.Slice(ranks, func(i, j int) bool {
+		if ranks[i].Count != ranks[j].Count {
+			return ranks[i].Count > ranks[j].Count
+		}
+		return ranks[i].Name < ranks[j].Name
+	})
+	if len(ranks) > n {
+		ranks = ranks[:n]
+	}
+	return ranks
+}
+
+// tableOperationCounts sums the operation counts of all tables
+func tableOperationCounts(tables map[string]TableInfo) map[string]int {
+	counts := make(map[string]int)
+	for _, table := range tables {
+		for operation, count := range table.OperationCount {
+			counts[operation] += count
+		}
+	}
+	return counts
+}
+
+// packageSummary reports which Go packages a run of engine loaded and which failed
+func packageSummary(engine *dependency.Engine) PackageSummary {
+	loaded, failed := engine.PackageLoadSummary()
+	
+	summary := PackageSummary{Loaded: append([]string{}, loaded...)}
+	sort.Strings(summary.Loaded)
+	for _, failure := range failed {
+		summary.Failed = append(summary.Failed, PackageFailure{Path: failure.Path, Reason: failure.Reason})
+	}
+	sort.Slice(summary.Failed, func(i, j int) bool {
+		return summary.Failed[i].Path < summary.Failed[j].Path
+	})
+	return summary
+}
+
+// sortDependencies orders dependencies by function, table, operation, access kind, method, line
+// and confidence, so results are identical across runs
+// Every field takes part in the order, so only identical dependencies compare equal
+func sortDependencies(deps []Dependency) []Dependency {
+	sort.Slice(deps, func(i, j int) bool {
+		a, b := deps[i], deps[j]
+		switch {
+		case a.Function != b.Function:
+			return a.Function < b.Function
+		case a.Table != b.Table:
+			return a.Table < b.Table
+		case a.Operation != b.Operation:
+			return a.Operation < b.Operation
+		case a.AccessKind != b.AccessKind:
+			return a.AccessKind < b.AccessKind
+		case a.Method != b.Method:
+			return a.Method < b.Method
+		case a.Line != b.Line:
+			return a.Line < b.Line
+		default:
+			return a.Confidence < b.Confidence
+		}
+	})
+	return deps
+}
+
+// resolveLayer returns the layer of the first rule matching the import path or file path
+// Patterns match on path segment boundaries, so "internal/handler" does not match "internal/handlers"
+func (a *Analyzer) resolveLayer(paths ...string) string {
+	for _, rule := range a.layerRules {
+		pattern := strings.Trim(filepath.ToSlash(rule.Pattern), "/")
+		if pattern == "" {
+			continue
+		}
+		
+		for _, path := range paths {
+			wrapped := "/" + strings.Trim(filepath.ToSlash(path), "/") + "/"
+			if strings.Contains(wrapped, "/"+pattern+"/") {
+				return rule.Layer
+			}
+		}
+	}
+	return ""
 }
\ No newline at end of file
@@ -2,7 +2,19 @@ package analyzer
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/naoyafurudono/sqlc-use-analysis/internal/errors"
+	"github.com/naoyafurudono/sqlc-use-analysis/pkg/types"
 )
 
 func TestAnalyzer_SimpleInterface(t *testing.T) {
@@ -90,12 +102,12 @@ func TestAnalyzer_RequestValidation(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name: "Empty packages",
+			name: "Empty packages (SQL only)",
 			request: AnalysisRequest{
 				SQLQueries: []Query{{Name: "test", SQL: "SELECT 1"}},
 				GoPackages: []string{},
 			},
-			wantErr: true,
+			wantErr: false,
 		},
 		{
 			name: "Query with empty name",
@@ -113,6 +125,24 @@ func TestAnalyzer_RequestValidation(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "Unsupported output format",
+			request: AnalysisRequest{
+				SQLQueries:   []Query{{Name: "test", SQL: "SELECT 1"}},
+				GoPackages:   []string{"./test"},
+				OutputFormat: "pdf",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Template output format",
+			request: AnalysisRequest{
+				SQLQueries:   []Query{{Name: "test", SQL: "SELECT 1"}},
+				GoPackages:   []string{"./test"},
+				OutputFormat: "template",
+			},
+			wantErr: false,
+		},
 	}
 	
 	for _, tt := range tests {
@@ -231,4 +261,2515 @@ func BenchmarkAnalyzer_SimpleOperation(b *testing.B) {
 		// This will likely fail in benchmark environment, but measures interface overhead
 		analyzer.Analyze(ctx, request)
 	}
-}
\ No newline at end of file
+}
+func TestAnalyzer_AnalyzeSources(t *testing.T) {
+	analyzer := New()
+	
+	queries := []Query{
+		{Name: "GetUser", SQL: "SELECT id, name FROM users WHERE id = ?"},
+	}
+	sources := map[string]string{
+		"virtual/service.go": `package virtual
+
+import "context"
+
+type Queries struct{}
+
+func (q *Queries) GetUser(ctx context.Context, id int64) error {
+	return nil
+}
+
+func LoadProfile(ctx context.Context, q *Queries) error {
+	return q.GetUser(ctx, 1)
+}
+`,
+	}
+	
+	ctx := context.Background()
+	result, err := analyzer.AnalyzeSources(ctx, queries, sources)
+	if err != nil {
+		t.Fatalf("AnalyzeSources() error = %v", err)
+	}
+	
+	funcInfo, exists := result.Functions["github.com/naoyafurudono/sqlc-use-analysis/pkg/analyzer/virtual.LoadProfile"]
+	if !exists {
+		t.Fatalf("Expected LoadProfile in result, got %v", result.Functions)
+	}
+	
+	access, exists := funcInfo.TableAccess["users"]
+	if !exists {
+		t.Fatalf("Expected LoadProfile to access users, got %v", funcInfo.TableAccess)
+	}
+	
+	if len(access.Operations) != 1 || access.Operations[0] != "SELECT" {
+		t.Errorf("Expected SELECT operation, got %v", access.Operations)
+	}
+	
+	// The call is resolved through type information
+	for _, dep := range result.Dependencies {
+		if dep.Function == funcInfo.QualifiedName && dep.Confidence != "high" {
+			t.Errorf("Expected high confidence for %+v", dep)
+		}
+	}
+}
+
+func TestAnalyzer_AnalyzeSources_IgnoredQuery(t *testing.T) {
+	analyzer := New()
+	
+	queries := []Query{
+		{Name: "GetUser", SQL: "SELECT id, name FROM users WHERE id = ?"},
+		{Name: "PurgeUsers", SQL: "-- sqlc-analysis:ignore\nDELETE FROM users"},
+	}
+	sources := map[string]string{
+		"virtual/ignored/service.go": `package ignored
+
+import "context"
+
+type Queries struct{}
+
+func (q *Queries) GetUser(ctx context.Context, id int64) error {
+	return nil
+}
+
+func (q *Queries) PurgeUsers(ctx context.Context) error {
+	return nil
+}
+
+func Reset(ctx context.Context, q *Queries) error {
+	if err := q.PurgeUsers(ctx); err != nil {
+		return err
+	}
+	return q.GetUser(ctx, 1)
+}
+`,
+	}
+	
+	result, err := analyzer.AnalyzeSources(context.Background(), queries, sources)
+	if err != nil {
+		t.Fatalf("AnalyzeSources() error = %v", err)
+	}
+	
+	reset := result.Functions["github.com/naoyafurudono/sqlc-use-analysis/pkg/analyzer/virtual/ignored.Reset"]
+	if got := reset.TableAccess["users"].Operations; !reflect.DeepEqual(got, []string{"SELECT"}) {
+		t.Errorf("users operations = %v, want only SELECT from the non-ignored query", got)
+	}
+	// Calls to an ignored query are not reported as unresolved
+	for _, warning := range analyzer.lastRun().errors.GetWarnings() {
+		if strings.Contains(warning.Message, "PurgeUsers") {
+			t.Errorf("Unexpected warning for the ignored query: %v", warning)
+		}
+	}
+	if len(result.UnmatchedRequestQueries) != 0 {
+		t.Errorf("UnmatchedRequestQueries = %v, want none", result.UnmatchedRequestQueries)
+	}
+}
+
+func TestAnalyzer_ConcurrentAnalyze(t *testing.T) {
+	// One Analyzer shared by parallel calls; run with -race to check for data races
+	analyzer := New()
+	tables := []string{"users", "posts", "comments", "tags"}
+	
+	var wg sync.WaitGroup
+	results := make([]*Result, len(tables))
+	errs := make([]error, len(tables))
+	for i, table := range tables {
+		wg.Add(1)
+		go func(i int, table string) {
+			defer wg.Done()
+			queries := []Query{{Name: "GetRow", SQL: "SELECT id FROM " + table + " WHERE id = ?"}}
+			sources := map[string]string{
+				"virtual/concurrent/service.go": `package concurrent
+
+import "context"
+
+type Queries struct{}
+
+func (q *Queries) GetRow(ctx context.Context, id int64) error {
+	return nil
+}
+
+func Load(ctx context.Context, q *Queries) error {
+	return q.GetRow(ctx, 1)
+}
+`,
+			}
+			results[i], errs[i] = analyzer.AnalyzeSources(context.Background(), queries, sources)
+		}(i, table)
+	}
+	wg.Wait()
+	
+	for i, table := range tables {
+		if errs[i] != nil {
+			t.Fatalf("AnalyzeSources(%s) error = %v", table, errs[i])
+		}
+		function, ok := results[i].Functions["github.com/naoyafurudono/sqlc-use-analysis/pkg/analyzer/virtual/concurrent.Load"]
+		if !ok {
+			t.Fatalf("Expected Load in result for %s, got %v", table, results[i].Functions)
+		}
+		if len(function.TableAccess) != 1 {
+			t.Errorf("Expected Load to access only %s, got %v", table, function.TableAccess)
+		}
+		if _, ok := function.TableAccess[table]; !ok {
+			t.Errorf("Expected Load to access %s, got %v", table, function.TableAccess)
+		}
+	}
+	
+	if _, err := analyzer.ReanalyzeFile(context.Background(), results[0], "virtual/concurrent/service.go", []byte("package concurrent\n")); err != nil {
+		t.Errorf("ReanalyzeFile() after concurrent calls error = %v", err)
+	}
+	_ = analyzer.GetErrors()
+	_ = analyzer.LastRunStats()
+}
+
+func TestAnalyzer_Acronyms(t *testing.T) {
+	queries := []Query{
+		{Name: "get_api_key", SQL: "SELECT id, key FROM api_keys WHERE id = ?"},
+	}
+	sources := map[string]string{
+		"virtual/acronyms/service.go": `package acronyms
+
+import "context"
+
+type Queries struct{}
+
+func (q *Queries) GetAPIKey(ctx context.Context, id int64) error {
+	return nil
+}
+
+func LoadKey(ctx context.Context, q *Queries) error {
+	return q.GetAPIKey(ctx, 1)
+}
+`,
+	}
+	loadKey := "github.com/naoyafurudono/sqlc-use-analysis/pkg/analyzer/virtual/acronyms.LoadKey"
+	
+	// Without acronyms the query maps to GetApiKey and the call is missed
+	result, err := New().AnalyzeSources(context.Background(), queries, sources)
+	if err != nil {
+		t.Fatalf("AnalyzeSources() error = %v", err)
+	}
+	if _, ok := result.Functions[loadKey].TableAccess["api_keys"]; ok {
+		t.Errorf("Expected no api_keys access without acronyms, got %v", result.Functions[loadKey].TableAccess)
+	}
+	
+	result, err = NewWithOptions(Options{Acronyms: []string{"ID", "API"}}).AnalyzeSources(context.Background(), queries, sources)
+	if err != nil {
+		t.Fatalf("AnalyzeSources() error = %v", err)
+	}
+	if _, ok := result.Functions[loadKey].TableAccess["api_keys"]; !ok {
+		t.Errorf("Expected LoadKey to access api_keys with acronyms, got %v", result.Functions[loadKey].TableAccess)
+	}
+}
+
+func TestAnalyzer_SchemaView(t *testing.T) {
+	queries := []Query{
+		{Name: "ListOrders", SQL: "SELECT id FROM sales.orders"},
+		{Name: "GetUser", SQL: "SELECT id FROM public.users WHERE id = $1"},
+		{Name: "GetAccount", SQL: "SELECT id FROM accounts WHERE id = $1"},
+	}
+	sources := map[string]string{
+		"virtual/schemas/service.go": `package schemas
+
+import "context"
+
+type Queries struct{}
+
+func (q *Queries) ListOrders(ctx context.Context) error { return nil }
+func (q *Queries) GetUser(ctx context.Context, id int64) error { return nil }
+func (q *Queries) GetAccount(ctx context.Context, id int64) error { return nil }
+
+func Checkout(ctx context.Context, q *Queries) error {
+	if err := q.GetUser(ctx, 1); err != nil {
+		return err
+	}
+	return q.ListOrders(ctx)
+}
+
+func Billing(ctx context.Context, q *Queries) error {
+	return q.GetAccount(ctx, 1)
+}
+`,
+	}
+	
+	result, err := NewWithOptions(Options{SQLDialect: "postgresql"}).AnalyzeSources(context.Background(), queries, sources)
+	if err != nil {
+		t.Fatalf("AnalyzeSources() error = %v", err)
+	}
+	
+	pkg := "github.com/naoyafurudono/sqlc-use-analysis/pkg/analyzer/virtual/schemas."
+	if got := result.Functions[pkg+"Checkout"].Schemas; !reflect.DeepEqual(got, []string{"public", "sales"}) {
+		t.Errorf("Checkout schemas = %v, want [public sales]", got)
+	}
+	// Unqualified tables belong to the default schema
+	if got := result.Functions[pkg+"Billing"].Schemas; !reflect.DeepEqual(got, []string{"public"}) {
+		t.Errorf("Billing schemas = %v, want [public]", got)
+	}
+	
+	want := map[string]SchemaInfo{
+		"public": {Name: "public", Tables: []string{"accounts", "public.users"}, AccessedBy: []string{pkg + "Billing", pkg + "Checkout"}},
+		"sales":  {Name: "sales", Tables: []string{"sales.orders"}, AccessedBy: []string{pkg + "Checkout"}},
+	}
+	if !reflect.DeepEqual(result.SchemaView, want) {
+		t.Errorf("SchemaView = %+v, want %+v", result.SchemaView, want)
+	}
+	
+	// The default schema is configurable
+	result, err = NewWithOptions(Options{SQLDialect: "postgresql", DefaultSchema: "app"}).AnalyzeSources(context.Background(), queries, sources)
+	if err != nil {
+		t.Fatalf("AnalyzeSources() error = %v", err)
+	}
+	if got := result.Functions[pkg+"Billing"].Schemas; !reflect.DeepEqual(got, []string{"app"}) {
+		t.Errorf("Billing schemas with DefaultSchema = %v, want [app]", got)
+	}
+	if len(result.SchemaView) != 3 {
+		t.Errorf("Expected app, public and sales schemas, got %+v", result.SchemaView)
+	}
+}
+
+func TestAnalyzer_FollowPackages(t *testing.T) {
+	const fixture = "github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/"
+	request := AnalysisRequest{
+		SQLQueries: []Query{
+			{Name: "CreateUser", SQL: "INSERT INTO users (name, email) VALUES ($1, $2)"},
+		},
+		GoPackages: []string{fixture + "handler"},
+	}
+	handler := fixture + "handler.UserHandler.CreateUser"
+	
+	// The query call is made in the service package, which is not requested
+	result, err := New().Analyze(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if tables := result.EntryPoints[handler].Tables; len(tables) != 0 {
+		t.Errorf("Expected no tables without following the service package, got %v", tables)
+	}
+	
+	result, err = NewWithOptions(Options{FollowPackages: []string{fixture + "service"}}).Analyze(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if got := result.EntryPoints[handler].Tables; !reflect.DeepEqual(got, map[string][]string{"users": {"INSERT"}}) {
+		t.Errorf("Tables of %s = %v, want users INSERT through the service package", handler, got)
+	}
+}
+
+func TestResult_BinaryRoundTrip(t *testing.T) {
+	request := AnalysisRequest{
+		SQLQueries: []Query{
+			{Name: "GetUser", SQL: "SELECT id, name, email, created_at FROM users WHERE id = $1"},
+			{Name: "ListUsers", SQL: "SELECT id, name, email, created_at FROM users ORDER BY created_at DESC"},
+			{Name: "CreateUser", SQL: "INSERT INTO users (name, email) VALUES ($1, $2) RETURNING id, name, email, created_at"},
+			{Name: "GetPost", SQL: "SELECT p.id, p.title, u.name as author_name FROM posts p JOIN users u ON p.author_id = u.id WHERE p.id = $1"},
+			{Name: "ListPostsByUser", SQL: "SELECT id, title FROM posts WHERE author_id = $1 ORDER BY created_at DESC"},
+			{Name: "CreatePost", SQL: "INSERT INTO posts (title, content, author_id) VALUES ($1, $2, $3)"},
+			{Name: "GetCommentsByPost", SQL: "SELECT c.id, u.name FROM comments c JOIN users u ON c.author_id = u.id WHERE c.post_id = $1"},
+			{Name: "CreateComment", SQL: "INSERT INTO comments (post_id, author_id, content) VALUES ($1, $2, $3)"},
+			{Name: "DeleteUser", SQL: "DELETE FROM users WHERE id = $1"},
+		},
+		GoPackages: []string{"github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/..."},
+	}
+	
+	result, err := New().Analyze(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	
+	data, err := result.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	var decoded Result
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary() error = %v", err)
+	}
+	if !reflect.DeepEqual(&decoded, result) {
+		t.Errorf("decoded result differs from the original\ngot:  %+v\nwant: %+v", decoded, *result)
+	}
+	
+	// Results without any dependency decode to the same JSON as well
+	empty := New().convertResult(New().lastRun(), types.AnalysisResult{})
+	data, err = empty.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	decoded = Result{}
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary() error = %v", err)
+	}
+	want, _ := json.Marshal(empty)
+	got, _ := json.Marshal(&decoded)
+	if string(got) != string(want) {
+		t.Errorf("decoded empty result = %s, want %s", got, want)
+	}
+	
+	if err := decoded.UnmarshalBinary([]byte("not gob")); err == nil {
+		t.Error("Expected an error for invalid data")
+	}
+}
+
+func TestAnalyzer_Analyze_TablesOnly(t *testing.T) {
+	analyzer := New()
+	request := AnalysisRequest{
+		SQLQueries: []Query{
+			{Name: "GetUser", SQL: "SELECT id, name FROM users WHERE id = ?"},
+			{Name: "ListUsers", SQL: "SELECT id, name FROM users"},
+			{Name: "CreatePost", SQL: "INSERT INTO posts (title, author_id) VALUES (?, ?)"},
+			{Name: "ArchivePosts", SQL: "UPDATE posts SET archived = 1 WHERE author_id = ?"},
+		},
+	}
+	
+	result, err := analyzer.Analyze(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Analyze() without GoPackages error = %v", err)
+	}
+	
+	if len(result.Functions) != 0 || len(result.Dependencies) != 0 {
+		t.Errorf("Expected no functions or dependencies, got %v and %v", result.Functions, result.Dependencies)
+	}
+	wantTables := map[string]map[string]int{
+		"users": {"SELECT": 2},
+		"posts": {"INSERT": 1, "UPDATE": 1},
+	}
+	if len(result.Tables) != len(wantTables) {
+		t.Fatalf("Tables = %v, want %v", result.Tables, wantTables)
+	}
+	for name, want := range wantTables {
+		table := result.Tables[name]
+		if !reflect.DeepEqual(table.OperationCount, want) {
+			t.Errorf("Tables[%s].OperationCount = %v, want %v", name, table.OperationCount, want)
+		}
+		if len(table.AccessedBy) != 0 {
+			t.Errorf("Tables[%s].AccessedBy = %v, want none", name, table.AccessedBy)
+		}
+	}
+	
+	wantCounts := map[string]int{"SELECT": 2, "INSERT": 1, "UPDATE": 1}
+	if result.Summary.TableCount != 2 || result.Summary.FunctionCount != 0 || !reflect.DeepEqual(result.Summary.OperationCounts, wantCounts) {
+		t.Errorf("Summary = %+v, want 2 tables, 0 functions and %v", result.Summary, wantCounts)
+	}
+	if result.Summary.ReadCount != 2 || result.Summary.WriteCount != 2 {
+		t.Errorf("ReadCount, WriteCount = %d, %d, want 2, 2", result.Summary.ReadCount, result.Summary.WriteCount)
+	}
+	if len(result.UnmatchedRequestQueries) != 0 {
+		t.Errorf("Expected no unmatched queries without Go code, got %v", result.UnmatchedRequestQueries)
+	}
+	if len(result.Packages.Loaded) != 0 {
+		t.Errorf("Expected no loaded packages, got %v", result.Packages.Loaded)
+	}
+	
+	// The report path accepts a request without GoPackages as well
+	report, err := analyzer.AnalyzeReport(context.Background(), request)
+	if err != nil {
+		t.Fatalf("AnalyzeReport() without GoPackages error = %v", err)
+	}
+	if len(report.Dependencies.TableView) != 2 {
+		t.Errorf("Expected 2 tables in the report, got %v", report.Dependencies.TableView)
+	}
+}
+
+func TestAnalyzer_StrictTypes(t *testing.T) {
+	queries := []Query{
+		{Name: "GetUser", SQL: "SELECT id, name FROM users WHERE id = ?"},
+	}
+	sources := map[string]string{
+		"virtual/broken/service.go": `package broken
+
+import "context"
+
+type Queries struct{}
+
+func (q *Queries) GetUser(ctx context.Context, id int64) error {
+	return nil
+}
+
+func LoadProfile(ctx context.Context, q *Queries) error {
+	return q.GetUser(ctx, missingID)
+}
+`,
+	}
+	const pkgPath = "github.com/naoyafurudono/sqlc-use-analysis/pkg/analyzer/virtual/broken"
+	ctx := context.Background()
+	
+	// Without strict types the package is analyzed despite the type error
+	if _, err := New().AnalyzeSources(ctx, queries, sources); err != nil {
+		t.Fatalf("AnalyzeSources() error = %v", err)
+	}
+	
+	analyzer := NewWithOptions(Options{StrictTypes: true})
+	_, err := analyzer.AnalyzeSources(ctx, queries, sources)
+	if err == nil || !strings.Contains(err.Error(), pkgPath) {
+		t.Fatalf("AnalyzeSources() error = %v, want an incomplete type information error for %s", err, pkgPath)
+	}
+	
+	found := false
+	for _, analysisErr := range analyzer.GetErrors() {
+		if analysisErr.Severity == "ERROR" && analysisErr.Details["package"] == pkgPath &&
+			strings.Contains(analysisErr.Message, "incomplete type information") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected an incomplete type information error, got %+v", analyzer.GetErrors())
+	}
+}
+
+func TestAnalyzer_QueryInLoop(t *testing.T) {
+	analyzer := New()
+	
+	request := AnalysisRequest{
+		SQLQueries: []Query{
+			{Name: "GetUser", SQL: "SELECT id, name, email, created_at FROM users WHERE id = $1"},
+			{Name: "ListPostsByUser", SQL: "SELECT id, title FROM posts WHERE author_id = $1 ORDER BY created_at DESC"},
+			{Name: "CreatePost", SQL: "INSERT INTO posts (title, content, author_id) VALUES ($1, $2, $3)"},
+		},
+		GoPackages: []string{"github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/feed"},
+	}
+	
+	if _, err := analyzer.Analyze(context.Background(), request); err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	
+	var findings []AnalysisError
+	for _, analysisErr := range analyzer.GetErrors() {
+		if strings.HasPrefix(analysisErr.Message, "possible N+1") {
+			findings = append(findings, analysisErr)
+		}
+	}
+	
+	// The range expression runs once and writes in a loop are not reads, so only GetUser is reported
+	if len(findings) != 1 {
+		t.Fatalf("Expected one N+1 finding, got %+v", findings)
+	}
+	finding := findings[0]
+	if finding.Severity != "WARNING" || finding.Message != "possible N+1: method GetUser called in a loop in function Feed.AuthorsOf" {
+		t.Errorf("finding = %+v, want a warning for GetUser in Feed.AuthorsOf", finding)
+	}
+	if finding.Details["loop_line"] != 25 || finding.Details["line"] != 26 {
+		t.Errorf("Details = %v, want loop_line 25 and line 26", finding.Details)
+	}
+}
+
+func TestAnalyzer_UnmatchedRequestQueries(t *testing.T) {
+	queries := []Query{
+		{Name: "GetUser", SQL: "SELECT id, name FROM users WHERE id = $1"},
+		{Name: "list_posts", SQL: "SELECT id, title FROM posts"},
+		{Name: "FooBar", SQL: "SELECT id FROM foo_bars"},
+	}
+	sources := map[string]string{
+		"virtual/app/service.go": `package app
+
+import "context"
+
+type Queries struct{}
+
+func (q *Queries) GetUser(ctx context.Context, id int64) error { return nil }
+func (q *Queries) ListPosts(ctx context.Context) error         { return nil }
+
+func Load(ctx context.Context, q *Queries) {
+	q.GetUser(ctx, 1)
+	q.ListPosts(ctx)
+}
+`,
+	}
+	
+	result, err := New().AnalyzeSources(context.Background(), queries, sources)
+	if err != nil {
+		t.Fatalf("AnalyzeSources() error = %v", err)
+	}
+	
+	// list_posts generates ListPosts, so only FooBar has no caller
+	if want := []string{"FooBar"}; !reflect.DeepEqual(result.UnmatchedRequestQueries, want) {
+		t.Errorf("UnmatchedRequestQueries = %v, want %v", result.UnmatchedRequestQueries, want)
+	}
+	
+	// A query called in another merged result is no longer unmatched
+	other := &Result{
+		Dependencies:            []Dependency{{Function: "other.Use", Table: "foo_bars", Operation: "SELECT", Method: "FooBar"}},
+		UnmatchedRequestQueries: []string{"GetUser"},
+	}
+	if merged := Merge(result, other); len(merged.UnmatchedRequestQueries) != 0 {
+		t.Errorf("Merge() UnmatchedRequestQueries = %v, want none", merged.UnmatchedRequestQueries)
+	}
+}
+
+func TestAnalyzer_AnalyzeSources_Validation(t *testing.T) {
+	analyzer := New()
+	ctx := context.Background()
+	
+	if _, err := analyzer.AnalyzeSources(ctx, nil, map[string]string{"a.go": "package a"}); err == nil {
+		t.Error("Expected validation error for empty queries")
+	}
+	
+	queries := []Query{{Name: "GetUser", SQL: "SELECT id FROM users"}}
+	if _, err := analyzer.AnalyzeSources(ctx, queries, nil); err == nil {
+		t.Error("Expected validation error for empty sources")
+	}
+}
+
+func TestAnalyzer_ConvertResult_OperationsByFunction(t *testing.T) {
+	analyzer := New()
+	
+	internalResult := types.AnalysisResult{
+		FunctionView: map[string]types.FunctionViewEntry{},
+		TableView: map[string]types.TableViewEntry{
+			"posts": {
+				TableName: "posts",
+				AccessedBy: map[string]types.FunctionAccess{
+					"DeleteOldPosts": {Function: "DeleteOldPosts", Operations: []string{"DELETE"}},
+					"ListPosts":      {Function: "ListPosts", Operations: []string{"SELECT"}},
+					"GetPost":        {Function: "GetPost", Operations: []string{"SELECT"}},
+				},
+				OperationSummary: map[string]int{"DELETE": 1, "SELECT": 2},
+			},
+		},
+	}
+	
+	result := analyzer.convertResult(analyzer.lastRun(), internalResult)
+	
+	posts, exists := result.Tables["posts"]
+	if !exists {
+		t.Fatal("Expected posts table in result")
+	}
+	
+	expected := map[string][]string{
+		"DeleteOldPosts": {"DELETE"},
+		"ListPosts":      {"SELECT"},
+		"GetPost":        {"SELECT"},
+	}
+	if !reflect.DeepEqual(posts.OperationsByFunction, expected) {
+		t.Errorf("OperationsByFunction = %v, want %v", posts.OperationsByFunction, expected)
+	}
+	
+	// Only DeleteOldPosts writes posts
+	var writers []string
+	for funcName, operations := range posts.OperationsByFunction {
+		for _, operation := range operations {
+			if operation != "SELECT" {
+				writers = append(writers, funcName)
+			}
+		}
+	}
+	if len(writers) != 1 || writers[0] != "DeleteOldPosts" {
+		t.Errorf("Expected only DeleteOldPosts to write posts, got %v", writers)
+	}
+}
+
+func TestAnalyzer_NewWithOptions_SQLiteDialect(t *testing.T) {
+	analyzer := NewWithOptions(Options{SQLDialect: "sqlite"})
+	
+	queries := []Query{
+		{Name: "GetSetting", SQL: "SELECT * FROM [app settings] WHERE id = ?"},
+	}
+	sources := map[string]string{
+		"virtual/settings.go": `package virtual
+
+import "context"
+
+type Queries struct{}
+
+func (q *Queries) GetSetting(ctx context.Context, id int64) error {
+	return nil
+}
+
+func LoadSettings(ctx context.Context, q *Queries) error {
+	return q.GetSetting(ctx, 1)
+}
+`,
+	}
+	
+	result, err := analyzer.AnalyzeSources(context.Background(), queries, sources)
+	if err != nil {
+		t.Fatalf("AnalyzeSources() error = %v", err)
+	}
+	
+	if _, exists := result.Tables["app settings"]; !exists {
+		t.Errorf("Expected table 'app settings', got %v", result.Tables)
+	}
+}
+
+func TestAnalyzer_GetErrorsFiltered(t *testing.T) {
+	analyzer := New()
+	analyzer.lastRun().errors.Add(errors.NewError(errors.CategoryParse, errors.SeverityError, "broken query"))
+	analyzer.lastRun().errors.Add(errors.NewError(errors.CategoryAnalysis, errors.SeverityWarning, "column list missing"))
+	
+	filtered := analyzer.GetErrorsFiltered("error")
+	if len(filtered) != 1 {
+		t.Fatalf("Expected 1 error, got %d: %v", len(filtered), filtered)
+	}
+	
+	if filtered[0].Severity != "ERROR" {
+		t.Errorf("Expected ERROR severity, got %s", filtered[0].Severity)
+	}
+	
+	if all := analyzer.GetErrorsFiltered("warning"); len(all) != 2 {
+		t.Errorf("Expected 2 entries at warning level, got %d", len(all))
+	}
+}
+
+func TestAnalyzer_GetAggregatedErrors(t *testing.T) {
+	analyzer := New()
+	// The messages are identical so that grouping does not depend on message normalization
+	for i := 1; i <= 50; i++ {
+		err := errors.NewError(errors.CategoryMapping, errors.SeverityWarning, "unresolved method call")
+		err.Location = &errors.ErrorLocation{File: "service.go", Line: i}
+		analyzer.lastRun().errors.Add(err)
+	}
+	analyzer.lastRun().errors.Add(errors.NewError(errors.CategoryParse, errors.SeverityError, "broken query"))
+	
+	aggregated := analyzer.GetAggregatedErrors()
+	if len(aggregated) != 2 {
+		t.Fatalf("Expected 2 groups, got %d: %+v", len(aggregated), aggregated)
+	}
+	
+	group := aggregated[0]
+	if group.Count != 50 {
+		t.Errorf("Expected count 50, got %d", group.Count)
+	}
+	if group.Severity != "WARNING" || group.Category != string(errors.CategoryMapping) {
+		t.Errorf("Unexpected group classification: %s/%s", group.Category, group.Severity)
+	}
+	if group.FirstError.Message != "unresolved method call" {
+		t.Errorf("Unexpected first error: %q", group.FirstError.Message)
+	}
+	if len(group.Locations) != 50 || group.Locations[0].Line != 1 || group.Locations[49].Line != 50 {
+		t.Errorf("Expected 50 sorted locations, got %+v", group.Locations)
+	}
+	
+	if aggregated[1].Count != 1 || aggregated[1].FirstError.Message != "broken query" {
+		t.Errorf("Unexpected second group: %+v", aggregated[1])
+	}
+}
+
+func TestAnalyzer_LayerRules(t *testing.T) {
+	analyzer := NewWithOptions(Options{
+		LayerRules: []LayerRule{
+			{Pattern: "internal/handler", Layer: "handler"},
+			{Pattern: "internal/service", Layer: "service"},
+		},
+	})
+	
+	tests := []struct {
+		name        string
+		packagePath string
+		fileName    string
+		expected    string
+	}{
+		{
+			name:        "Import path under internal/handler",
+			packagePath: "github.com/example/app/internal/handler",
+			fileName:    "/src/app/internal/handler/user_handler.go",
+			expected:    "handler",
+		},
+		{
+			name:        "File path only",
+			packagePath: "",
+			fileName:    "/src/app/internal/service/user_service.go",
+			expected:    "service",
+		},
+		{
+			name:        "Segment boundary is respected",
+			packagePath: "github.com/example/app/internal/handlers",
+			fileName:    "/src/app/internal/handlers/user.go",
+			expected:    "",
+		},
+		{
+			name:        "No matching rule",
+			packagePath: "github.com/example/app/internal/db",
+			fileName:    "/src/app/internal/db/query.sql.go",
+			expected:    "",
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			internalResult := types.AnalysisResult{
+				FunctionView: map[string]types.FunctionViewEntry{
+					"Handle": {
+						FunctionName: "Handle",
+						PackagePath:  tt.packagePath,
+						FileName:     tt.fileName,
+						TableAccess:  map[string]types.TableAccessInfo{},
+					},
+				},
+				TableView: map[string]types.TableViewEntry{},
+			}
+			
+			result := analyzer.convertResult(analyzer.lastRun(), internalResult)
+			if layer := result.Functions["Handle"].Layer; layer != tt.expected {
+				t.Errorf("Layer = %q, want %q", layer, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnalyzer_ResultSchemaVersion(t *testing.T) {
+	analyzer := New()
+	result := analyzer.convertResult(analyzer.lastRun(), types.AnalysisResult{})
+	
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	
+	if decoded["schema_version"] != types.SchemaVersion {
+		t.Errorf("schema_version = %v, want %s", decoded["schema_version"], types.SchemaVersion)
+	}
+}
+
+func TestAnalyzer_EntryPoints(t *testing.T) {
+	analyzer := New()
+	
+	request := AnalysisRequest{
+		SQLQueries: []Query{
+			{Name: "GetUser", SQL: "SELECT id, name, email, created_at FROM users WHERE id = $1"},
+			{Name: "ListUsers", SQL: "SELECT id, name, email, created_at FROM users ORDER BY created_at DESC"},
+			{Name: "CreateUser", SQL: "INSERT INTO users (name, email) VALUES ($1, $2) RETURNING id, name, email, created_at"},
+			{Name: "GetPost", SQL: "SELECT p.id, p.title, u.name as author_name FROM posts p JOIN users u ON p.author_id = u.id WHERE p.id = $1"},
+			{Name: "ListPostsByUser", SQL: "SELECT id, title FROM posts WHERE author_id = $1 ORDER BY created_at DESC"},
+			{Name: "CreatePost", SQL: "INSERT INTO posts (title, content, author_id) VALUES ($1, $2, $3)"},
+			{Name: "GetCommentsByPost", SQL: "SELECT c.id, u.name FROM comments c JOIN users u ON c.author_id = u.id WHERE c.post_id = $1"},
+			{Name: "CreateComment", SQL: "INSERT INTO comments (post_id, author_id, content) VALUES ($1, $2, $3)"},
+		},
+		GoPackages: []string{"github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/..."},
+	}
+	
+	result, err := analyzer.Analyze(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	
+	const fixture = "github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/"
+	expected := map[string]map[string][]string{
+		fixture + "handler.UserHandler.CreateUser":          {"users": {"INSERT"}},
+		fixture + "handler.UserHandler.GetUserProfile":      {"users": {"SELECT"}, "posts": {"SELECT"}},
+		fixture + "handler.UserHandler.ListAllUsers":        {"users": {"SELECT"}},
+		fixture + "handler.PostHandler.CreatePost":          {"users": {"SELECT"}, "posts": {"INSERT"}},
+		fixture + "handler.PostHandler.GetPostWithComments": {"users": {"SELECT"}, "posts": {"SELECT"}, "comments": {"SELECT"}},
+		fixture + "handler.PostHandler.AddComment":          {"users": {"SELECT"}, "posts": {"SELECT"}, "comments": {"INSERT"}},
+		fixture + "service.UserService.RegisterUser":        {"users": {"INSERT", "SELECT"}},
+		fixture + "lookup.UserLookup.FindUser":              {"users": {"SELECT"}},
+		fixture + "lookup.LatestPost":                       {"posts": {"SELECT"}},
+		fixture + "feed.Feed.AuthorsOf":                     {"users": {"SELECT"}, "posts": {"SELECT"}},
+		fixture + "feed.Feed.Publish":                       {"posts": {"INSERT"}},
+		fixture + "batch.Loader.UsersByID":                  {"users": {"SELECT"}},
+		fixture + "batch.Loader.CountUsers":                 {"users": {"SELECT"}},
+		fixture + "batch.PostsOf":                           {"posts": {"SELECT"}},
+	}
+	
+	if len(result.EntryPoints) != len(expected) {
+		t.Errorf("Expected %d entry points, got %d: %v", len(expected), len(result.EntryPoints), result.EntryPoints)
+	}
+	
+	for name, tables := range expected {
+		entryPoint, exists := result.EntryPoints[name]
+		if !exists {
+			t.Errorf("Expected entry point %s", name)
+			continue
+		}
+		if !reflect.DeepEqual(entryPoint.Tables, tables) {
+			t.Errorf("%s tables = %v, want %v", name, entryPoint.Tables, tables)
+		}
+	}
+	
+	// サービス層は呼び出し元があるためエントリーポイントではない
+	if _, exists := result.EntryPoints[fixture+"service.UserService.GetUser"]; exists {
+		t.Error("Expected UserService.GetUser not to be an entry point")
+	}
+	
+	profile := result.EntryPoints[fixture+"handler.UserHandler.GetUserProfile"]
+	wantReaches := []string{
+		fixture + "db.Queries.GetUser",
+		fixture + "db.Queries.ListPostsByUser",
+		fixture + "service.UserService.GetUser",
+		fixture + "service.UserService.GetUserPosts",
+	}
+	if !reflect.DeepEqual(profile.Reaches, wantReaches) {
+		t.Errorf("Reaches = %v, want %v", profile.Reaches, wantReaches)
+	}
+	if profile.Package != "handler" {
+		t.Errorf("Package = %q, want handler", profile.Package)
+	}
+}
+
+func TestResult_PackageTableAccess(t *testing.T) {
+	analyzer := New()
+	
+	request := AnalysisRequest{
+		SQLQueries: []Query{
+			{Name: "GetUser", SQL: "SELECT id, name, email, created_at FROM users WHERE id = $1"},
+			{Name: "ListUsers", SQL: "SELECT id, name, email, created_at FROM users ORDER BY created_at DESC"},
+			{Name: "CreateUser", SQL: "INSERT INTO users (name, email) VALUES ($1, $2) RETURNING id, name, email, created_at"},
+			{Name: "GetPost", SQL: "SELECT p.id, p.title, u.name as author_name FROM posts p JOIN users u ON p.author_id = u.id WHERE p.id = $1"},
+			{Name: "ListPostsByUser", SQL: "SELECT id, title FROM posts WHERE author_id = $1 ORDER BY created_at DESC"},
+			{Name: "CreatePost", SQL: "INSERT INTO posts (title, content, author_id) VALUES ($1, $2, $3)"},
+			{Name: "GetCommentsByPost", SQL: "SELECT c.id, u.name FROM comments c JOIN users u ON c.author_id = u.id WHERE c.post_id = $1"},
+			{Name: "CreateComment", SQL: "INSERT INTO comments (post_id, author_id, content) VALUES ($1, $2, $3)"},
+		},
+		GoPackages: []string{"github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/..."},
+	}
+	
+	result, err := analyzer.Analyze(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	
+	rollup := result.PackageTableAccess()
+	expected := map[string][]string{
+		"users":    {"INSERT", "SELECT"},
+		"posts":    {"INSERT", "SELECT"},
+		"comments": {"INSERT", "SELECT"},
+	}
+	if !reflect.DeepEqual(rollup["service"], expected) {
+		t.Errorf("service = %v, want %v", rollup["service"], expected)
+	}
+	if want := map[string][]string{"users": {"SELECT"}, "posts": {"SELECT"}}; !reflect.DeepEqual(rollup["lookup"], want) {
+		t.Errorf("lookup = %v, want %v", rollup["lookup"], want)
+	}
+	
+	empty := (&Result{Functions: map[string]FunctionInfo{"pkg.Helper": {Package: "pkg"}}}).PackageTableAccess()
+	if len(empty) != 0 {
+		t.Errorf("Expected packages without table access to be omitted, got %v", empty)
+	}
+}
+
+func TestResult_Hotspots(t *testing.T) {
+	access := func(operations ...string) Access { return Access{Operations: operations} }
+	result := &Result{
+		Functions: map[string]FunctionInfo{
+			"svc.Checkout":  {TableAccess: map[string]Access{"orders": access("INSERT"), "users": access("SELECT"), "items": access("UPDATE")}},
+			"svc.Profile":   {TableAccess: map[string]Access{"users": access("SELECT"), "orders": access("SELECT")}},
+			"svc.Rename":    {TableAccess: map[string]Access{"users": access("UPDATE")}},
+			"svc.CleanUp":   {TableAccess: map[string]Access{"orders": access("DELETE")}},
+			"svc.Formatter": {},
+		},
+		Tables: map[string]TableInfo{
+			"users":  {AccessedBy: []string{"svc.Checkout", "svc.Profile", "svc.Rename"}, OperationCount: map[string]int{"SELECT": 2, "UPDATE": 1}},
+			"orders": {AccessedBy: []string{"svc.CleanUp", "svc.Checkout", "svc.Profile"}, OperationCount: map[string]int{"INSERT": 1, "DELETE": 2, "SELECT": 1}},
+			"items":  {AccessedBy: []string{"svc.Checkout"}, OperationCount: map[string]int{"UPDATE": 1}},
+			"audit":  {AccessedBy: []string{}, OperationCount: map[string]int{}},
+		},
+	}
+	
+	hotspots := result.Hotspots(0)
+	if want := []Hotspot{{"orders", 3}, {"users", 3}, {"items", 1}}; !reflect.DeepEqual(hotspots.Tables, want) {
+		t.Errorf("Tables = %v, want %v", hotspots.Tables, want)
+	}
+	if want := []Hotspot{{"svc.Checkout", 3}, {"svc.Profile", 2}, {"svc.CleanUp", 1}, {"svc.Rename", 1}}; !reflect.DeepEqual(hotspots.Functions, want) {
+		t.Errorf("Functions = %v, want %v", hotspots.Functions, want)
+	}
+	if want := []Hotspot{{"orders", 3}, {"items", 1}, {"users", 1}}; !reflect.DeepEqual(hotspots.WriteHeavy, want) {
+		t.Errorf("WriteHeavy = %v, want %v", hotspots.WriteHeavy, want)
+	}
+	
+	top := result.Hotspots(1)
+	if len(top.Tables) != 1 || len(top.Functions) != 1 || len(top.WriteHeavy) != 1 {
+		t.Fatalf("Hotspots(1) = %+v, want one entry per list", top)
+	}
+	if top.Functions[0].Name != "svc.Checkout" {
+		t.Errorf("Top function = %s, want svc.Checkout", top.Functions[0].Name)
+	}
+}
+
+func TestAnalyzer_AnalyzeReport(t *testing.T) {
+	request := AnalysisRequest{
+		SQLQueries: []Query{
+			{Name: "GetUser", SQL: "SELECT id, name, email, created_at FROM users WHERE id = $1"},
+			{Name: "ListUsers", SQL: "SELECT id, name, email, created_at FROM users ORDER BY created_at DESC"},
+			{Name: "CreateUser", SQL: "INSERT INTO users (name, email) VALUES ($1, $2) RETURNING id, name, email, created_at"},
+			{Name: "GetPost", SQL: "SELECT p.id, p.title, u.name as author_name FROM posts p JOIN users u ON p.author_id = u.id WHERE p.id = $1"},
+			{Name: "ListPostsByUser", SQL: "SELECT id, title FROM posts WHERE author_id = $1 ORDER BY created_at DESC"},
+			{Name: "CreatePost", SQL: "INSERT INTO posts (title, content, author_id) VALUES ($1, $2, $3)"},
+		},
+		GoPackages: []string{"github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/service"},
+	}
+	ctx := context.Background()
+	
+	result, err := New().Analyze(ctx, request)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	report, err := New().AnalyzeReport(ctx, request)
+	if err != nil {
+		t.Fatalf("AnalyzeReport() error = %v", err)
+	}
+	
+	if report.Summary.FunctionCount != result.Summary.FunctionCount {
+		t.Errorf("FunctionCount = %d, want %d", report.Summary.FunctionCount, result.Summary.FunctionCount)
+	}
+	if report.Summary.TableCount != result.Summary.TableCount {
+		t.Errorf("TableCount = %d, want %d", report.Summary.TableCount, result.Summary.TableCount)
+	}
+	if !reflect.DeepEqual(report.Summary.OperationCounts, result.Summary.OperationCounts) {
+		t.Errorf("OperationCounts = %v, want %v", report.Summary.OperationCounts, result.Summary.OperationCounts)
+	}
+	
+	// The report keeps what Result flattens away
+	if report.Summary.PackageCounts["service"] != result.Summary.FunctionCount {
+		t.Errorf("PackageCounts = %v, want every function in service", report.Summary.PackageCounts)
+	}
+	if _, exists := report.Dependencies.FunctionView["github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/service.UserService.GetUser"]; !exists {
+		t.Errorf("Expected UserService.GetUser in the function view")
+	}
+	
+	if _, err := New().AnalyzeReport(ctx, AnalysisRequest{}); err == nil {
+		t.Error("Expected an error for an empty request")
+	}
+}
+
+func TestAnalyzer_AnalyzeAndFormat_CSV(t *testing.T) {
+	analyzer := New()
+	
+	request := AnalysisRequest{
+		SQLQueries: []Query{
+			{Name: "GetUser", SQL: "SELECT id, name, email, created_at FROM users WHERE id = $1"},
+		},
+		GoPackages:   []string{"github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/service"},
+		OutputFormat: "csv",
+	}
+	
+	output, err := analyzer.AnalyzeAndFormat(context.Background(), request)
+	if err != nil {
+		t.Fatalf("AnalyzeAndFormat() error = %v", err)
+	}
+	
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if lines[0] != "function,package,table,operation,method,line" {
+		t.Errorf("Unexpected header: %q", lines[0])
+	}
+	
+	want := "github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/service.UserService.GetUser,service,users,SELECT,GetUser,46"
+	found := false
+	for _, line := range lines[1:] {
+		if line == want {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Errorf("Expected row %q in CSV output:\n%s", want, output)
+	}
+}
+
+func TestAnalyzer_AnalyzeAndFormat_OperationStyle(t *testing.T) {
+	analyzer := New()
+	
+	request := AnalysisRequest{
+		SQLQueries: []Query{
+			{Name: "GetUser", SQL: "SELECT id, name, email, created_at FROM users WHERE id = $1"},
+		},
+		GoPackages:     []string{"github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/service"},
+		OutputFormat:   "csv",
+		OperationStyle: "crud",
+	}
+	
+	output, err := analyzer.AnalyzeAndFormat(context.Background(), request)
+	if err != nil {
+		t.Fatalf("AnalyzeAndFormat() error = %v", err)
+	}
+	
+	want := "github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/service.UserService.GetUser,service,users,Read,GetUser,46"
+	if !strings.Contains(string(output), want) {
+		t.Errorf("Expected row %q in CSV output:\n%s", want, output)
+	}
+	
+	request.OutputFormat = "json"
+	output, err = analyzer.AnalyzeAndFormat(context.Background(), request)
+	if err != nil {
+		t.Fatalf("AnalyzeAndFormat() error = %v", err)
+	}
+	if !strings.Contains(string(output), `"Read"`) {
+		t.Errorf("Expected crud operation names in JSON output:\n%s", output)
+	}
+	if strings.Contains(string(output), `"SELECT"`) {
+		t.Errorf("JSON output should not contain SQL operation names with crud style:\n%s", output)
+	}
+	
+	request.OperationStyle = "verbs"
+	if _, err := analyzer.AnalyzeAndFormat(context.Background(), request); err == nil {
+		t.Error("Expected error for unsupported operation style")
+	}
+}
+
+func TestFormatDependenciesCSV_Order(t *testing.T) {
+	result := &Result{
+		Functions: map[string]FunctionInfo{"service.Handle": {Package: "service"}},
+		Dependencies: []Dependency{
+			{Function: "service.Handle", Table: "users", Operation: "SELECT", Method: "ListUsers", Line: 10},
+			{Function: "service.Handle", Table: "users", Operation: "SELECT", Method: "GetUser", Line: 20},
+		},
+	}
+	
+	output, err := formatDependenciesCSV(result)
+	if err != nil {
+		t.Fatalf("formatDependenciesCSV() error = %v", err)
+	}
+	
+	// Rows follow sortDependencies, which compares methods before lines
+	want := "function,package,table,operation,method,line\n" +
+		"service.Handle,service,users,SELECT,GetUser,20\n" +
+		"service.Handle,service,users,SELECT,ListUsers,10\n"
+	if string(output) != want {
+		t.Errorf("formatDependenciesCSV() = %q, want %q", output, want)
+	}
+}
+
+func TestAnalyzer_AnalyzeAndFormat_UnsupportedFormat(t *testing.T) {
+	analyzer := New()
+	
+	request := AnalysisRequest{
+		SQLQueries: []Query{
+			{Name: "GetUser", SQL: "SELECT id FROM users WHERE id = $1"},
+		},
+		GoPackages:   []string{"github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/service"},
+		OutputFormat: "xml",
+	}
+	
+	if _, err := analyzer.AnalyzeAndFormat(context.Background(), request); err == nil {
+		t.Error("Expected error for unsupported output format")
+	}
+}
+
+func TestAnalyzer_AnalyzeAndFormat_UnsupportedFormatBeforeAnalysis(t *testing.T) {
+	analyzer := New()
+	
+	request := AnalysisRequest{
+		SQLQueries: []Query{
+			{Name: "GetUser", SQL: "SELECT id FROM users WHERE id = $1"},
+		},
+		GoPackages:   []string{"github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/service"},
+		OutputFormat: "pdf",
+	}
+	
+	_, err := analyzer.AnalyzeAndFormat(context.Background(), request)
+	if err == nil {
+		t.Fatal("Expected error for unsupported output format")
+	}
+	if want := `unsupported output format "pdf" (supported: json, csv, template)`; !strings.Contains(err.Error(), want) {
+		t.Errorf("error = %q, want it to contain %q", err, want)
+	}
+	
+	// The request is rejected before any package is loaded
+	if stats := analyzer.LastRunStats(); stats.Queries != 0 || stats.Packages != 0 {
+		t.Errorf("Expected no analysis to run, got stats %+v", stats)
+	}
+}
+
+func TestAnalyzer_AnalyzeAndFormat_Template(t *testing.T) {
+	request := AnalysisRequest{
+		SQLQueries: []Query{
+			{Name: "GetUser", SQL: "SELECT id, name, email, created_at FROM users WHERE id = $1"},
+			{Name: "ListPostsByUser", SQL: "SELECT id, title FROM posts WHERE author_id = $1"},
+		},
+		GoPackages:   []string{"github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/service"},
+		OutputFormat: "template",
+		Template: `{{range $name := keys .Tables}}{{with index $.Tables $name}}` +
+			`{{$name}}: {{len .AccessedBy}} ({{join (keys .OperationCount) ","}})
+{{end}}{{end}}`,
+	}
+	
+	output, err := New().AnalyzeAndFormat(context.Background(), request)
+	if err != nil {
+		t.Fatalf("AnalyzeAndFormat() error = %v", err)
+	}
+	
+	want := "posts: 1 (SELECT)\nusers: 2 (SELECT)\n"
+	if string(output) != want {
+		t.Errorf("output =\n%s\nwant\n%s", output, want)
+	}
+	
+	// The same template can be read from a file
+	path := filepath.Join(t.TempDir(), "report.tmpl")
+	if err := os.WriteFile(path, []byte(request.Template), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	request.Template, request.TemplateFile = "", path
+	if output, err := New().AnalyzeAndFormat(context.Background(), request); err != nil || string(output) != want {
+		t.Errorf("AnalyzeAndFormat() with template file = %q, %v", output, err)
+	}
+	
+	request.TemplateFile = ""
+	request.Template = "{{range .Tables}"
+	if _, err := New().AnalyzeAndFormat(context.Background(), request); err == nil {
+		t.Error("Expected error for invalid template")
+	}
+}
+
+func TestAnalyzer_Dir(t *testing.T) {
+	request := AnalysisRequest{
+		SQLQueries: []Query{
+			{Name: "GetUser", SQL: "SELECT id, name, email, created_at FROM users WHERE id = $1"},
+		},
+		GoPackages: []string{"./internal/service"},
+	}
+	const getUser = "github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/service.UserService.GetUser"
+	
+	// The working directory is pkg/analyzer, so the pattern only resolves against Dir
+	result, err := NewWithOptions(Options{Dir: "../../test/fixtures/simple_project"}).Analyze(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if _, exists := result.Functions[getUser]; !exists {
+		t.Errorf("Expected %s to be analyzed, got %v", getUser, result.Functions)
+	}
+	
+	result, err = New().Analyze(context.Background(), request)
+	if err == nil {
+		if _, exists := result.Functions[getUser]; exists {
+			t.Error("Expected the relative pattern not to resolve from the working directory")
+		}
+	}
+}
+
+func TestAnalyzer_LastRunStats(t *testing.T) {
+	analyzer := New()
+	
+	if stats := analyzer.LastRunStats(); stats != (RunStats{}) {
+		t.Errorf("Expected zero stats before analysis, got %+v", stats)
+	}
+	
+	request := AnalysisRequest{
+		SQLQueries: []Query{
+			{Name: "GetUser", SQL: "SELECT id, name, email, created_at FROM users WHERE id = $1"},
+			{Name: "ListUsers", SQL: "SELECT id, name, email, created_at FROM users ORDER BY created_at DESC"},
+		},
+		GoPackages: []string{"github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/service"},
+	}
+	
+	if _, err := analyzer.Analyze(context.Background(), request); err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	
+	stats := analyzer.LastRunStats()
+	durations := map[string]time.Duration{
+		"SQLAnalysis": stats.SQLAnalysis,
+		"PackageLoad": stats.PackageLoad,
+		"GoAnalysis":  stats.GoAnalysis,
+		"Mapping":     stats.Mapping,
+	}
+	for phase, duration := range durations {
+		if duration < 0 {
+			t.Errorf("%s duration = %v, want non-negative", phase, duration)
+		}
+	}
+	if stats.PackageLoad == 0 {
+		t.Error("Expected package loading to take measurable time")
+	}
+	
+	if stats.Queries != len(request.SQLQueries) {
+		t.Errorf("Queries = %d, want %d", stats.Queries, len(request.SQLQueries))
+	}
+	if stats.Packages != len(request.GoPackages) {
+		t.Errorf("Packages = %d, want %d", stats.Packages, len(request.GoPackages))
+	}
+	// The service package consists of post_service.go and user_service.go
+	if stats.Files != 2 {
+		t.Errorf("Files = %d, want 2", stats.Files)
+	}
+}
+
+func TestAnalyzer_DeterministicOutput(t *testing.T) {
+	request := AnalysisRequest{
+		SQLQueries: []Query{
+			{Name: "GetUser", SQL: "SELECT id, name, email, created_at FROM users WHERE id = $1"},
+			{Name: "CreateUser", SQL: "INSERT INTO users (name, email) VALUES ($1, $2)"},
+			{Name: "ListPostsByUser", SQL: "SELECT id, title FROM posts WHERE author_id = $1"},
+			{Name: "GetPost", SQL: "SELECT p.id, u.name FROM posts p JOIN users u ON p.author_id = u.id WHERE p.id = $1"},
+		},
+		GoPackages: []string{"github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/service"},
+	}
+	
+	var outputs [][]byte
+	for i := 0; i < 3; i++ {
+		result, err := New().Analyze(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Analyze() error = %v", err)
+		}
+		data, err := json.Marshal(result)
+		if err != nil {
+			t.Fatalf("json.Marshal() error = %v", err)
+		}
+		outputs = append(outputs, data)
+	}
+	
+	for i := 1; i < len(outputs); i++ {
+		if string(outputs[i]) != string(outputs[0]) {
+			t.Fatalf("run %d produced different JSON:\n%s\nvs\n%s", i, outputs[i], outputs[0])
+		}
+	}
+}
+
+func TestSortDependencies(t *testing.T) {
+	deps := []Dependency{
+		{Function: "B", Table: "users", Operation: "SELECT", Method: "GetUser", Line: 10},
+		{Function: "A", Table: "users", Operation: "SELECT", Method: "GetUser", Line: 20},
+		{Function: "A", Table: "posts", Operation: "SELECT", Method: "GetPost", Line: 5},
+		{Function: "A", Table: "users", Operation: "SELECT", Method: "GetUser", Line: 12},
+		{Function: "A", Table: "users", Operation: "SELECT", Method: "GetUser", Line: 20},
+		{Function: "B", Table: "users", Operation: "SELECT", Method: "GetUser", Line: 10, Confidence: "high"},
+		{Function: "B", Table: "users", Operation: "SELECT", Method: "GetUser", Line: 10, Confidence: "low"},
+	}
+	
+	// Repeated calls on the same line are kept, matching Access.Count
+	want := []Dependency{
+		{Function: "A", Table: "posts", Operation: "SELECT", Method: "GetPost", Line: 5},
+		{Function: "A", Table: "users", Operation: "SELECT", Method: "GetUser", Line: 12},
+		{Function: "A", Table: "users", Operation: "SELECT", Method: "GetUser", Line: 20},
+		{Function: "A", Table: "users", Operation: "SELECT", Method: "GetUser", Line: 20},
+		{Function: "B", Table: "users", Operation: "SELECT", Method: "GetUser", Line: 10},
+		{Function: "B", Table: "users", Operation: "SELECT", Method: "GetUser", Line: 10, Confidence: "high"},
+		{Function: "B", Table: "users", Operation: "SELECT", Method: "GetUser", Line: 10, Confidence: "low"},
+	}
+	
+	if got := sortDependencies(deps); !reflect.DeepEqual(got, want) {
+		t.Errorf("sortDependencies() = %+v, want %+v", got, want)
+	}
+}
+
+func TestAnalyzer_ReadContextEdges(t *testing.T) {
+	analyzer := NewWithOptions(Options{SQLDialect: "postgresql", ReadContextEdges: true})
+	
+	queries := []Query{
+		{Name: "UpdatePostCounts", SQL: "UPDATE users SET post_count = p.cnt FROM posts p WHERE p.author_id = users.id"},
+		{Name: "DeleteArchivedComments", SQL: "DELETE FROM comments USING posts WHERE comments.post_id = posts.id AND posts.archived = true"},
+	}
+	sources := map[string]string{
+		"virtual/maintenance.go": `package virtual
+
+import "context"
+
+type Queries struct{}
+
+func (q *Queries) UpdatePostCounts(ctx context.Context) error { return nil }
+
+func (q *Queries) DeleteArchivedComments(ctx context.Context) error { return nil }
+
+func Cleanup(ctx context.Context, q *Queries) error {
+	if err := q.UpdatePostCounts(ctx); err != nil {
+		return err
+	}
+	return q.DeleteArchivedComments(ctx)
+}
+`,
+	}
+	
+	result, err := analyzer.AnalyzeSources(context.Background(), queries, sources)
+	if err != nil {
+		t.Fatalf("AnalyzeSources() error = %v", err)
+	}
+	
+	got := make(map[string]string)
+	for _, dep := range result.Dependencies {
+		if dep.Function == "github.com/naoyafurudono/sqlc-use-analysis/pkg/analyzer/virtual.Cleanup" {
+			got[dep.Method+":"+dep.Table+":"+dep.Operation] = dep.AccessKind
+		}
+	}
+	
+	want := map[string]string{
+		"UpdatePostCounts:users:UPDATE":          "primary",
+		"UpdatePostCounts:posts:SELECT":          "reference",
+		"DeleteArchivedComments:comments:DELETE": "primary",
+		"DeleteArchivedComments:posts:SELECT":    "reference",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dependencies = %v, want %v", got, want)
+	}
+}
+
+func TestTablesInQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		dialect string
+		want    map[string][]string
+		wantErr bool
+	}{
+		{
+			name:    "SELECT with JOIN",
+			query:   "SELECT u.name, p.title FROM users u JOIN posts p ON p.author_id = u.id WHERE u.id = $1",
+			dialect: "postgresql",
+			want: map[string][]string{
+				"users": {"SELECT"},
+				"posts": {"SELECT"},
+			},
+		},
+		{
+			name:    "UPDATE ... FROM",
+			query:   "UPDATE users SET post_count = p.cnt FROM posts p WHERE p.author_id = users.id",
+			dialect: "postgresql",
+			want: map[string][]string{
+				"users": {"UPDATE"},
+				"posts": {"UPDATE"},
+			},
+		},
+		{
+			name:  "Default dialect",
+			query: "DELETE FROM `user sessions` WHERE expires_at < NOW()",
+			want:  map[string][]string{"user sessions": {"DELETE"}},
+		},
+		{
+			name:    "Empty query",
+			query:   "  ",
+			wantErr: true,
+		},
+		{
+			name:    "Unknown statement",
+			query:   "VACUUM users",
+			wantErr: true,
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TablesInQuery(tt.query, tt.dialect)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TablesInQuery() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TablesInQuery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFromSqlcConfig(t *testing.T) {
+	dir := t.TempDir()
+	
+	queries := `-- name: GetUser :one
+SELECT id, name FROM users WHERE id = $1;
+
+-- name: CreatePost :exec
+INSERT INTO posts (author_id, title) VALUES ($1, $2);
+`
+	if err := os.MkdirAll(filepath.Join(dir, "db", "queries"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "db", "queries", "users.sql"), []byte(queries), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	
+	config := `version: "2"
+sql:
+  - engine: postgresql
+    schema: db/schema.sql
+    queries: db/queries
+    gen:
+      go:
+        package: db
+        out: internal/db
+`
+	configPath := filepath.Join(dir, "sqlc.yaml")
+	if err := os.WriteFile(configPath, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	
+	request, err := LoadFromSqlcConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadFromSqlcConfig() error = %v", err)
+	}
+	
+	wantQueries := []Query{
+		{Name: "GetUser", SQL: "SELECT id, name FROM users WHERE id = $1;", Cmd: ":one"},
+		{Name: "CreatePost", SQL: "INSERT INTO posts (author_id, title) VALUES ($1, $2);", Cmd: ":exec"},
+	}
+	if !reflect.DeepEqual(request.SQLQueries, wantQueries) {
+		t.Errorf("SQLQueries = %+v, want %+v", request.SQLQueries, wantQueries)
+	}
+	
+	wantPackages := []string{filepath.Join(dir, "internal", "db")}
+	if !reflect.DeepEqual(request.GoPackages, wantPackages) {
+		t.Errorf("GoPackages = %v, want %v", request.GoPackages, wantPackages)
+	}
+	
+	if _, err := LoadFromSqlcConfig(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("Expected error for missing config file")
+	}
+}
+
+func TestAnalyzer_LoadProject_Views(t *testing.T) {
+	dir := t.TempDir()
+	schema := `CREATE TABLE users (id BIGINT, active BOOLEAN);
+CREATE VIEW active_users AS SELECT id FROM users WHERE active;
+CREATE MATERIALIZED VIEW user_counts AS SELECT count(*) AS n FROM users;
+`
+	queries := `-- name: GetUser :one
+SELECT id FROM users WHERE id = $1;
+
+-- name: ListActiveUsers :many
+SELECT id FROM active_users;
+
+-- name: CountUsers :one
+SELECT n FROM user_counts;
+`
+	if err := os.WriteFile(filepath.Join(dir, "schema.sql"), []byte(schema), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "query.sql"), []byte(queries), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	
+	analyzer := NewWithOptions(Options{SQLDialect: "postgresql"})
+	request, err := analyzer.LoadProject(filepath.Join(dir, "schema.sql"), filepath.Join(dir, "query.sql"))
+	if err != nil {
+		t.Fatalf("LoadProject() error = %v", err)
+	}
+	sources := map[string]string{
+		"virtual/views/service.go": `package views
+
+import "context"
+
+type Queries struct{}
+
+func (q *Queries) GetUser(ctx context.Context, id int64) error { return nil }
+func (q *Queries) ListActiveUsers(ctx context.Context) error { return nil }
+func (q *Queries) CountUsers(ctx context.Context) error { return nil }
+
+func Dashboard(ctx context.Context, q *Queries) error {
+	_ = q.GetUser(ctx, 1)
+	_ = q.ListActiveUsers(ctx)
+	return q.CountUsers(ctx)
+}
+`,
+	}
+	result, err := analyzer.AnalyzeSources(context.Background(), request.SQLQueries, sources)
+	if err != nil {
+		t.Fatalf("AnalyzeSources() error = %v", err)
+	}
+	
+	for table, want := range map[string]string{"users": "table", "active_users": "view", "user_counts": "matview"} {
+		if got := result.Tables[table].Kind; got != want {
+			t.Errorf("Tables[%s].Kind = %q, want %q", table, got, want)
+		}
+	}
+	// Views are part of the catalog, so they are not reported as unknown tables
+	for _, err := range analyzer.GetErrors() {
+		if strings.Contains(err.Message, "active_users") || strings.Contains(err.Message, "user_counts") {
+			t.Errorf("Unexpected error for a view: %s", err.Message)
+		}
+	}
+	
+	// Without a loaded schema the kind is unknown
+	result, err = NewWithOptions(Options{SQLDialect: "postgresql"}).AnalyzeSources(context.Background(), request.SQLQueries, sources)
+	if err != nil {
+		t.Fatalf("AnalyzeSources() error = %v", err)
+	}
+	if got := result.Tables["active_users"].Kind; got != "" {
+		t.Errorf("Kind without a schema = %q, want empty", got)
+	}
+}
+
+func TestAnalyzer_LoadProject(t *testing.T) {
+	dir := t.TempDir()
+	
+	schema := `CREATE TABLE users (
+	id BIGSERIAL PRIMARY KEY,
+	name TEXT NOT NULL DEFAULT 'unknown;'
+);
+
+CREATE TABLE legacy_users (id BIGINT);
+CREATE INDEX users_name_idx ON users (name);
+
+CREATE FUNCTION touch() RETURNS trigger AS $$
+BEGIN
+	NEW.updated_at = now();
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TABLE legacy_users;
+`
+	queries := `-- name: GetUser :one
+SELECT id, name FROM users WHERE id = $1;
+
+-- name: ListAuditLog :many
+SELECT id FROM audit_log;
+`
+	if err := os.WriteFile(filepath.Join(dir, "schema.sql"), []byte(schema), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "query.sql"), []byte(queries), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	
+	analyzer := NewWithOptions(Options{SQLDialect: "postgresql"})
+	request, err := analyzer.LoadProject(filepath.Join(dir, "schema*.sql"), filepath.Join(dir, "query*.sql"))
+	if err != nil {
+		t.Fatalf("LoadProject() error = %v", err)
+	}
+	
+	wantQueries := []Query{
+		{Name: "GetUser", SQL: "SELECT id, name FROM users WHERE id = $1;", Cmd: ":one"},
+		{Name: "ListAuditLog", SQL: "SELECT id FROM audit_log;", Cmd: ":many"},
+	}
+	if !reflect.DeepEqual(request.SQLQueries, wantQueries) {
+		t.Errorf("SQLQueries = %+v, want %+v", request.SQLQueries, wantQueries)
+	}
+	
+	sources := map[string]string{
+		"virtual/project/service.go": `package project
+
+import "context"
+
+type Queries struct{}
+
+func (q *Queries) GetUser(ctx context.Context, id int64) error { return nil }
+
+func (q *Queries) ListAuditLog(ctx context.Context) error { return nil }
+`,
+	}
+	if _, err := analyzer.AnalyzeSources(context.Background(), request.SQLQueries, sources); err != nil {
+		t.Fatalf("AnalyzeSources() error = %v", err)
+	}
+	
+	// Only the table missing from the schema catalog is reported
+	var unknown []string
+	for _, analysisErr := range analyzer.GetErrors() {
+		if strings.Contains(analysisErr.Message, "not defined in the schema catalog") {
+			unknown = append(unknown, analysisErr.Message)
+		}
+	}
+	if len(unknown) != 1 || !strings.Contains(unknown[0], `"audit_log"`) {
+		t.Errorf("catalog warnings = %v, want one for audit_log", unknown)
+	}
+	
+	if _, err := analyzer.LoadProject(filepath.Join(dir, "missing*.sql"), filepath.Join(dir, "query*.sql")); err == nil {
+		t.Error("Expected an error when no schema file matches")
+	}
+}
+
+func TestAnalyzer_PackageLoadSummary(t *testing.T) {
+	queries := []Query{
+		{Name: "GetUser", SQL: "SELECT id, name, email, created_at FROM users WHERE id = $1"},
+	}
+	validPath := "github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/service"
+	invalidPath := "./does/not/exist"
+	
+	t.Run("one valid and one invalid path", func(t *testing.T) {
+		analyzer := New()
+		result, err := analyzer.Analyze(context.Background(), AnalysisRequest{
+			SQLQueries: queries,
+			GoPackages: []string{validPath, invalidPath},
+		})
+		if err != nil {
+			t.Fatalf("Analyze() error = %v", err)
+		}
+		
+		if !reflect.DeepEqual(result.Packages.Loaded, []string{validPath}) {
+			t.Errorf("Loaded = %v, want [%s]", result.Packages.Loaded, validPath)
+		}
+		if len(result.Packages.Failed) != 1 || result.Packages.Failed[0].Path != invalidPath {
+			t.Fatalf("Failed = %+v, want a single failure for %s", result.Packages.Failed, invalidPath)
+		}
+		if result.Packages.Failed[0].Reason == "" {
+			t.Error("Expected a reason for the failed path")
+		}
+		
+		for _, analysisErr := range analyzer.GetErrors() {
+			if strings.HasPrefix(analysisErr.Message, "no Go packages loaded") {
+				t.Errorf("Unexpected error while some packages loaded: %s", analysisErr.Message)
+			}
+		}
+	})
+	
+	t.Run("all paths invalid", func(t *testing.T) {
+		analyzer := New()
+		result, err := analyzer.Analyze(context.Background(), AnalysisRequest{
+			SQLQueries: queries,
+			GoPackages: []string{invalidPath},
+		})
+		if err != nil {
+			t.Fatalf("Analyze() error = %v", err)
+		}
+		
+		if len(result.Packages.Loaded) != 0 {
+			t.Errorf("Loaded = %v, want none", result.Packages.Loaded)
+		}
+		
+		var found *AnalysisError
+		for _, analysisErr := range analyzer.GetErrors() {
+			if analysisErr.Message == "no Go packages loaded; 1 of 1 paths failed" {
+				found = &analysisErr
+				break
+			}
+		}
+		if found == nil {
+			t.Fatalf("Expected a no-packages-loaded error, got %+v", analyzer.GetErrors())
+		}
+		if found.Severity != "ERROR" {
+			t.Errorf("Severity = %s, want ERROR", found.Severity)
+		}
+		if _, exists := found.Details[invalidPath]; !exists {
+			t.Errorf("Expected a reason for %s in %v", invalidPath, found.Details)
+		}
+	})
+}
+
+func TestAnalyzer_QualifiedFunctionNames(t *testing.T) {
+	analyzer := New()
+	
+	queries := []Query{
+		{Name: "FindUser", SQL: "SELECT id, name FROM users WHERE id = ?"},
+		{Name: "FindAdmin", SQL: "SELECT id, name FROM admins WHERE id = ?"},
+	}
+	sources := map[string]string{
+		"virtual/users/service.go": `package users
+
+import "context"
+
+type Queries struct{}
+
+func (q *Queries) FindUser(ctx context.Context, id int64) error { return nil }
+
+func GetUser(ctx context.Context, q *Queries) error {
+	return q.FindUser(ctx, 1)
+}
+`,
+		"virtual/admin/service.go": `package admin
+
+import "context"
+
+type Queries struct{}
+
+func (q *Queries) FindAdmin(ctx context.Context, id int64) error { return nil }
+
+func GetUser(ctx context.Context, q *Queries) error {
+	return q.FindAdmin(ctx, 1)
+}
+`,
+	}
+	
+	result, err := analyzer.AnalyzeSources(context.Background(), queries, sources)
+	if err != nil {
+		t.Fatalf("AnalyzeSources() error = %v", err)
+	}
+	
+	const base = "github.com/naoyafurudono/sqlc-use-analysis/pkg/analyzer/virtual/"
+	wantTables := map[string]string{
+		base + "users.GetUser": "users",
+		base + "admin.GetUser": "admins",
+	}
+	for qualifiedName, table := range wantTables {
+		funcInfo, exists := result.Functions[qualifiedName]
+		if !exists {
+			t.Errorf("Expected %s in result, got %v", qualifiedName, result.Functions)
+			continue
+		}
+		if funcInfo.Name != "GetUser" || funcInfo.QualifiedName != qualifiedName {
+			t.Errorf("Name = %q, QualifiedName = %q, want GetUser and %s", funcInfo.Name, funcInfo.QualifiedName, qualifiedName)
+		}
+		if len(funcInfo.TableAccess) != 1 {
+			t.Errorf("%s table access = %v, want only %s", qualifiedName, funcInfo.TableAccess, table)
+		}
+		if _, exists := funcInfo.TableAccess[table]; !exists {
+			t.Errorf("%s table access = %v, want %s", qualifiedName, funcInfo.TableAccess, table)
+		}
+	}
+	
+	if accessedBy := result.Tables["admins"].AccessedBy; !reflect.DeepEqual(accessedBy, []string{base + "admin.GetUser"}) {
+		t.Errorf("admins accessed by %v, want only the admin package's GetUser", accessedBy)
+	}
+}
+
+func TestGenerateGrants(t *testing.T) {
+	result := &Result{
+		Dependencies: []Dependency{
+			{Function: "service.GetUser", Table: "users", Operation: "SELECT"},
+			{Function: "service.ListUsers", Table: "users", Operation: "SELECT"},
+			{Function: "service.PublishPost", Table: "posts", Operation: "UPDATE"},
+			{Function: "service.CreatePost", Table: "posts", Operation: "INSERT"},
+			{Function: "service.GetPost", Table: "posts", Operation: "SELECT"},
+			{Function: "service.CreatePost", Table: "users", Operation: "SELECT", AccessKind: "reference"},
+			{Function: "service.PurgeSessions", Table: "sessions", Operation: "DELETE"},
+			{Function: "migrate.Run", Table: "audit_log", Operation: "CREATE"},
+		},
+	}
+	
+	want := "GRANT SELECT,INSERT,UPDATE ON posts TO app;\n" +
+		"GRANT DELETE ON sessions TO app;\n" +
+		"GRANT SELECT ON users TO app;\n"
+	if got := GenerateGrants(result, "app"); got != want {
+		t.Errorf("GenerateGrants() =\n%s\nwant\n%s", got, want)
+	}
+	
+	if got := GenerateGrants(&Result{}, "app"); got != "" {
+		t.Errorf("GenerateGrants() for empty result = %q, want empty", got)
+	}
+}
+
+func TestGenerateGrantsForDialect(t *testing.T) {
+	result := &Result{
+		Dependencies: []Dependency{
+			{Function: "service.Touch", Table: "user sessions", Operation: "UPDATE"},
+			{Function: "service.GetOrder", Table: "shop.Order", Operation: "SELECT"},
+		},
+	}
+	
+	tests := []struct {
+		dialect string
+		role    string
+		want    string
+	}{
+		{
+			dialect: "postgresql",
+			role:    `App"Role`,
+			want: "GRANT SELECT ON \"shop\".\"Order\" TO \"App\"\"Role\";\n" +
+				"GRANT UPDATE ON \"user sessions\" TO \"App\"\"Role\";\n",
+		},
+		{
+			dialect: "mysql",
+			role:    "app",
+			want: "GRANT SELECT ON `shop`.`Order` TO `app`;\n" +
+				"GRANT UPDATE ON `user sessions` TO `app`;\n",
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.dialect, func(t *testing.T) {
+			if got := GenerateGrantsForDialect(result, tt.role, tt.dialect); got != tt.want {
+				t.Errorf("GenerateGrantsForDialect() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarize_ReadWrite(t *testing.T) {
+	dep := func(function, table, operation string) Dependency {
+		return Dependency{Function: function, Table: table, Operation: operation}
+	}
+	access := func(operations ...string) Access {
+		return Access{Operations: operations}
+	}
+	result := &Result{
+		Functions: map[string]FunctionInfo{
+			"svc.GetUser":     {TableAccess: map[string]Access{"users": access("SELECT")}},
+			"svc.CreateOrder": {TableAccess: map[string]Access{"orders": access("INSERT"), "users": access("SELECT")}},
+			"svc.CloseOrder":  {TableAccess: map[string]Access{"orders": access("UPDATE")}},
+			"svc.Ping":        {TableAccess: map[string]Access{types.RawTable: access("DRIVER")}},
+			"svc.Helper":      {TableAccess: map[string]Access{}},
+		},
+		Tables: map[string]TableInfo{
+			"users":        {OperationCount: map[string]int{"SELECT": 2}},
+			"orders":       {OperationCount: map[string]int{"INSERT": 1, "UPDATE": 1}},
+			"audit":        {OperationCount: map[string]int{"INSERT": 1}},
+			types.RawTable: {OperationCount: map[string]int{"DRIVER": 1}},
+		},
+		Dependencies: []Dependency{
+			dep("svc.GetUser", "users", "SELECT"),
+			dep("svc.CreateOrder", "users", "SELECT"),
+			dep("svc.CreateOrder", "orders", "INSERT"),
+			dep("svc.CloseOrder", "orders", "UPDATE"),
+			dep("svc.CloseOrder", "audit", "INSERT"),
+			dep("svc.Ping", types.RawTable, "DRIVER"),
+		},
+	}
+	
+	summary := summarize(result)
+	// Driver calls are neither reads nor writes
+	if summary.ReadCount != 2 || summary.WriteCount != 3 {
+		t.Errorf("ReadCount, WriteCount = %d, %d, want 2, 3", summary.ReadCount, summary.WriteCount)
+	}
+	if summary.ReadRatio != 0.4 || summary.WriteRatio != 0.6 {
+		t.Errorf("ReadRatio, WriteRatio = %v, %v, want 0.4, 0.6", summary.ReadRatio, summary.WriteRatio)
+	}
+	if summary.WriteFunctions != 2 {
+		t.Errorf("WriteFunctions = %d, want 2", summary.WriteFunctions)
+	}
+	// Ties are broken by name and only three tables are kept
+	want := []TableRank{{Name: "orders", Count: 2}, {Name: "users", Count: 2}, {Name: types.RawTable, Count: 1}}
+	if !reflect.DeepEqual(summary.TopTables, want) {
+		t.Errorf("TopTables = %+v, want %+v", summary.TopTables, want)
+	}
+	
+	// Without operations the ratios stay zero instead of dividing by zero
+	empty := summarize(&Result{})
+	if empty.ReadRatio != 0 || empty.WriteRatio != 0 || len(empty.TopTables) != 0 {
+		t.Errorf("summarize(empty) = %+v, want zero ratios and no top tables", empty)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	analyze := func(dialect, dir string) *Result {
+		t.Helper()
+		queries := []Query{
+			{Name: "GetUser", SQL: "SELECT id, name FROM users WHERE id = ?"},
+			{Name: "CreateOrder", SQL: "INSERT INTO orders (user_id) VALUES (?)"},
+		}
+		sources := map[string]string{
+			"virtual/" + dir + "/service.go": `package ` + dir + `
+
+import "context"
+
+type Queries struct{}
+
+func (q *Queries) GetUser(ctx context.Context, id int64) error { return nil }
+func (q *Queries) CreateOrder(ctx context.Context, id int64) error { return nil }
+
+func Checkout(ctx context.Context, q *Queries) error {
+	if err := q.GetUser(ctx, 1); err != nil {
+		return err
+	}
+	return q.CreateOrder(ctx, 1)
+}
+`,
+		}
+		result, err := NewWithOptions(Options{SQLDialect: dialect}).AnalyzeSources(context.Background(), queries, sources)
+		if err != nil {
+			t.Fatalf("AnalyzeSources() error = %v", err)
+		}
+		return result
+	}
+	
+	const virtual = "github.com/naoyafurudono/sqlc-use-analysis/pkg/analyzer/virtual/"
+	billing := analyze("mysql", "billing")
+	shop := analyze("postgresql", "shop")
+	
+	merged := Merge(billing, shop, nil)
+	if len(merged.Collisions) != 0 {
+		t.Errorf("Collisions = %v, want none", merged.Collisions)
+	}
+	for _, name := range []string{virtual + "billing.Checkout", virtual + "shop.Checkout"} {
+		if _, exists := merged.Functions[name]; !exists {
+			t.Errorf("Expected function %s in merged result", name)
+		}
+	}
+	
+	// Both runs access users, so the shared table combines their functions and counts
+	users := merged.Tables["users"]
+	if want := []string{virtual + "billing.Checkout", virtual + "shop.Checkout"}; !reflect.DeepEqual(users.AccessedBy, want) {
+		t.Errorf("users.AccessedBy = %v, want %v", users.AccessedBy, want)
+	}
+	if want := billing.Tables["users"].OperationCount["SELECT"] + shop.Tables["users"].OperationCount["SELECT"]; users.OperationCount["SELECT"] != want {
+		t.Errorf("users.OperationCount[SELECT] = %d, want %d", users.OperationCount["SELECT"], want)
+	}
+	
+	if want := len(billing.Dependencies) + len(shop.Dependencies); len(merged.Dependencies) != want {
+		t.Errorf("len(Dependencies) = %d, want %d", len(merged.Dependencies), want)
+	}
+	wantSummary := Summary{
+		FunctionCount:   len(merged.Functions),
+		TableCount:      2,
+		DependencyCount: len(merged.Dependencies),
+		OperationCounts: map[string]int{"SELECT": 2, "INSERT": 2},
+		ReadCount:       2,
+		WriteCount:      2,
+		ReadRatio:       0.5,
+		WriteRatio:      0.5,
+		WriteFunctions:  2,
+		TopTables:       []TableRank{{Name: "orders", Count: 2}, {Name: "users", Count: 2}},
+	}
+	if !reflect.DeepEqual(merged.Summary, wantSummary) {
+		t.Errorf("Summary = %+v, want %+v", merged.Summary, wantSummary)
+	}
+	
+	// Merging the same run twice reports every function as a collision
+	again := Merge(billing, billing)
+	want := make([]string, 0, len(billing.Functions))
+	for name := range billing.Functions {
+		want = append(want, name)
+	}
+	sort.Strings(want)
+	if !reflect.DeepEqual(again.Collisions, want) {
+		t.Errorf("Collisions = %v, want %v", again.Collisions, want)
+	}
+	if access := again.Functions[virtual+"billing.Checkout"].TableAccess["users"]; access.Count != 2 {
+		t.Errorf("users access count = %d, want 2", access.Count)
+	}
+}
+
+func TestFormatMetrics(t *testing.T) {
+	result := &Result{
+		Tables: map[string]TableInfo{
+			"users": {Name: "users", AccessedBy: []string{"service.GetUser", "service.CreatePost"}},
+			"posts": {Name: "posts", AccessedBy: []string{"service.CreatePost"}},
+		},
+		Summary: Summary{
+			FunctionCount:   2,
+			TableCount:      2,
+			DependencyCount: 3,
+			OperationCounts: map[string]int{"SELECT": 2, "INSERT": 1},
+		},
+	}
+	
+	want := `# HELP sqlc_analysis_functions_total Number of analyzed functions.
+# TYPE sqlc_analysis_functions_total gauge
+sqlc_analysis_functions_total 2
+# HELP sqlc_analysis_tables_total Number of accessed tables.
+# TYPE sqlc_analysis_tables_total gauge
+sqlc_analysis_tables_total 2
+# HELP sqlc_analysis_dependencies_total Number of function to table dependencies.
+# TYPE sqlc_analysis_dependencies_total gauge
+sqlc_analysis_dependencies_total 3
+# HELP sqlc_analysis_operations_total Number of dependencies by operation.
+# TYPE sqlc_analysis_operations_total gauge
+sqlc_analysis_operations_total{op="INSERT"} 1
+sqlc_analysis_operations_total{op="SELECT"} 2
+# HELP sqlc_analysis_table_functions Number of functions accessing each table.
+# TYPE sqlc_analysis_table_functions gauge
+sqlc_analysis_table_functions{table="posts"} 1
+sqlc_analysis_table_functions{table="users"} 2
+`
+	if got := FormatMetrics(result); got != want {
+		t.Errorf("FormatMetrics() =\n%s\nwant\n%s", got, want)
+	}
+	
+	if got := escapeLabelValue(`a"b\c`); got != `a\"b\\c` {
+		t.Errorf("escapeLabelValue() = %s", got)
+	}
+}
+
+// graphResult has edges with 1, 2 and 4 calls
+func graphResult() *Result {
+	return &Result{
+		Functions: map[string]FunctionInfo{
+			"service.GetUser": {TableAccess: map[string]Access{
+				"users": {Operations: []string{"SELECT"}, Count: 1},
+			}},
+			"service.CreatePost": {TableAccess: map[string]Access{
+				"posts": {Operations: []string{"INSERT"}, Count: 4},
+				"users": {Operations: []string{"SELECT"}, Count: 2},
+			}},
+		},
+	}
+}
+
+func TestFormatDOT(t *testing.T) {
+	want := `digraph dependencies {
+  rankdir=LR;
+  node [shape=box];
+  "posts" [shape=cylinder];
+  "users" [shape=cylinder];
+  "service.CreatePost" -> "posts" [label="INSERT (4)", penwidth=5.00];
+  "service.CreatePost" -> "users" [label="SELECT (2)", penwidth=2.50];
+  "service.GetUser" -> "users" [label="SELECT (1)", penwidth=1.25];
+}
+`
+	if got := FormatDOT(graphResult(), GraphOptions{}); got != want {
+		t.Errorf("FormatDOT() =\n%s\nwant\n%s", got, want)
+	}
+	
+	// MinWeight hides rarely used edges; widths stay relative to the heaviest edge
+	filtered := FormatDOT(graphResult(), GraphOptions{MinWeight: 2})
+	if strings.Contains(filtered, "service.GetUser") {
+		t.Errorf("Expected the single-call edge to be hidden:\n%s", filtered)
+	}
+	if !strings.Contains(filtered, `"service.CreatePost" -> "users" [label="SELECT (2)", penwidth=2.50];`) {
+		t.Errorf("Expected the two-call edge to remain:\n%s", filtered)
+	}
+}
+
+func TestFormatMermaid(t *testing.T) {
+	want := `flowchart LR
+  f0["service.CreatePost"] -->|"INSERT (4)"| t0[("posts")]
+  f0 -->|"SELECT (2)"| t1[("users")]
+  f1["service.GetUser"] -->|"SELECT (1)"| t1
+  linkStyle 0 stroke-width:5.00px
+  linkStyle 1 stroke-width:2.50px
+  linkStyle 2 stroke-width:1.25px
+`
+	if got := FormatMermaid(graphResult(), GraphOptions{}); got != want {
+		t.Errorf("FormatMermaid() =\n%s\nwant\n%s", got, want)
+	}
+	
+	if got := FormatMermaid(graphResult(), GraphOptions{MinWeight: 5}); got != "flowchart LR\n" {
+		t.Errorf("Expected no edges above the heaviest weight, got:\n%s", got)
+	}
+}
+
+func TestCheckAgainstManifest(t *testing.T) {
+	analyzer := New()
+	
+	queries := []Query{
+		{Name: "GetPost", SQL: "SELECT id, title FROM posts WHERE id = ?"},
+		{Name: "UpdatePost", SQL: "UPDATE posts SET archived = 1 WHERE id = ?"},
+	}
+	sources := map[string]string{
+		"virtual/manifest/service.go": `package manifest
+
+import "context"
+
+type Queries struct{}
+
+func (q *Queries) GetPost(ctx context.Context, id int64) error { return nil }
+func (q *Queries) UpdatePost(ctx context.Context, id int64) error { return nil }
+
+func ShowPost(ctx context.Context, q *Queries) error {
+	return q.GetPost(ctx, 1)
+}
+
+func ArchiveOnView(ctx context.Context, q *Queries) error {
+	if err := q.GetPost(ctx, 1); err != nil {
+		return err
+	}
+	return q.UpdatePost(ctx, 1)
+}
+`,
+	}
+	
+	result, err := analyzer.AnalyzeSources(context.Background(), queries, sources)
+	if err != nil {
+		t.Fatalf("AnalyzeSources() error = %v", err)
+	}
+	
+	tests := []struct {
+		name     string
+		manifest string
+		want     []Violation
+	}{
+		{
+			name: "allowed accesses",
+			manifest: `manifest.ShowPost:
+  - posts/SELECT
+manifest.ArchiveOnView:
+  - posts
+`,
+		},
+		{
+			name: "newly introduced write",
+			manifest: `manifest.ShowPost:
+  - posts/SELECT
+manifest.ArchiveOnView:
+  - posts/select
+`,
+			want: []Violation{{
+				Function:  "github.com/naoyafurudono/sqlc-use-analysis/pkg/analyzer/virtual/manifest.ArchiveOnView",
+				Table:     "posts",
+				Operation: "UPDATE",
+				Method:    "UpdatePost",
+				Line:      18,
+				Reason:    "UPDATE on posts is not allowed",
+			}},
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifestPath := filepath.Join(t.TempDir(), "dependencies.yaml")
+			if err := os.WriteFile(manifestPath, []byte(tt.manifest), 0o644); err != nil {
+				t.Fatalf("failed to write manifest: %v", err)
+			}
+			
+			violations, err := CheckAgainstManifest(result, manifestPath)
+			if err != nil {
+				t.Fatalf("CheckAgainstManifest() error = %v", err)
+			}
+			if !reflect.DeepEqual(violations, tt.want) {
+				t.Errorf("CheckAgainstManifest() = %+v, want %+v", violations, tt.want)
+			}
+		})
+	}
+	
+	if _, err := CheckAgainstManifest(result, filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("Expected error for missing manifest")
+	}
+}
+
+func TestAnalyzer_ColumnAccess(t *testing.T) {
+	analyzer := New()
+	
+	queries := []Query{
+		{Name: "GetUser", SQL: "SELECT * FROM users WHERE id = ?"},
+		{Name: "UpdateUserEmail", SQL: "UPDATE users SET email = ? WHERE id = ?"},
+	}
+	sources := map[string]string{
+		"virtual/columns/service.go": `package columns
+
+import "context"
+
+type Queries struct{}
+
+func (q *Queries) GetUser(ctx context.Context, id int64) error { return nil }
+func (q *Queries) UpdateUserEmail(ctx context.Context, id int64) error { return nil }
+
+func ChangeEmail(ctx context.Context, q *Queries) error {
+	if err := q.GetUser(ctx, 1); err != nil {
+		return err
+	}
+	return q.UpdateUserEmail(ctx, 1)
+}
+`,
+	}
+	
+	result, err := analyzer.AnalyzeSources(context.Background(), queries, sources)
+	if err != nil {
+		t.Fatalf("AnalyzeSources() error = %v", err)
+	}
+	
+	function, exists := result.Functions["github.com/naoyafurudono/sqlc-use-analysis/pkg/analyzer/virtual/columns.ChangeEmail"]
+	if !exists {
+		t.Fatalf("ChangeEmail not found in %v", result.Functions)
+	}
+	want := map[string][]string{
+		"users.*":     {"SELECT"},
+		"users.email": {"UPDATE"},
+	}
+	if !reflect.DeepEqual(function.ColumnAccess, want) {
+		t.Errorf("ColumnAccess = %v, want %v", function.ColumnAccess, want)
+	}
+}
+
+func TestAnalyzer_RawSQL(t *testing.T) {
+	const report = "github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/report"
+	request := AnalysisRequest{
+		SQLQueries: []Query{
+			{Name: "GetUser", SQL: "SELECT id, name, email, created_at FROM users WHERE id = $1"},
+		},
+		GoPackages: []string{report},
+	}
+	
+	// Without RawSQL the database/sql call is not attributed to any table
+	result, err := NewWithOptions(Options{SQLDialect: "postgresql"}).Analyze(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if _, exists := result.Tables["orders"]; exists {
+		t.Error("Expected orders not to be analyzed without RawSQL")
+	}
+	
+	result, err = NewWithOptions(Options{SQLDialect: "postgresql", RawSQL: true}).Analyze(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	
+	function, exists := result.Functions[report+".OrderReport.TotalByUser"]
+	if !exists {
+		t.Fatalf("TotalByUser not found in %v", result.Functions)
+	}
+	if access := function.TableAccess["orders"]; !reflect.DeepEqual(access.Operations, []string{"SELECT"}) {
+		t.Errorf("TableAccess[orders] = %+v, want SELECT", access)
+	}
+	if table, exists := result.Tables["orders"]; !exists || !containsString(table.AccessedBy, report+".OrderReport.TotalByUser") {
+		t.Errorf("Expected orders to be accessed by TotalByUser, got %+v", table)
+	}
+}
+
+func TestAnalyzer_Progress(t *testing.T) {
+	type event struct {
+		current, total int
+	}
+	progress := make(map[string][]event)
+	analyzer := NewWithOptions(Options{
+		Progress: func(phase string, current, total int) {
+			progress[phase] = append(progress[phase], event{current, total})
+		},
+	})
+	
+	request := AnalysisRequest{
+		SQLQueries: []Query{
+			{Name: "GetUser", SQL: "SELECT id FROM users WHERE id = ?"},
+			{Name: "ListUsers", SQL: "SELECT id FROM users"},
+			{Name: "CreateUser", SQL: "INSERT INTO users (name) VALUES (?)"},
+		},
+		GoPackages: []string{
+			"github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/service",
+			"github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/handler",
+		},
+	}
+	if _, err := analyzer.Analyze(context.Background(), request); err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	
+	for _, phase := range []string{"queries", "packages"} {
+		events := progress[phase]
+		if len(events) == 0 {
+			t.Errorf("Expected progress for phase %q", phase)
+			continue
+		}
+		for i, e := range events {
+			if e.current != i+1 || e.total != events[0].total {
+				t.Errorf("%s progress[%d] = %d/%d, want %d/%d", phase, i, e.current, e.total, i+1, events[0].total)
+			}
+		}
+		if last := events[len(events)-1]; last.current != last.total {
+			t.Errorf("%s progress ended at %d/%d", phase, last.current, last.total)
+		}
+	}
+	if total := progress["queries"][0].total; total != len(request.SQLQueries) {
+		t.Errorf("queries total = %d, want %d", total, len(request.SQLQueries))
+	}
+}
+
+func TestAnalyzer_SkipGeneratedFiles(t *testing.T) {
+	const fixture = "github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/"
+	request := AnalysisRequest{
+		SQLQueries: []Query{
+			{Name: "GetUser", SQL: "SELECT id, name, email, created_at FROM users WHERE id = $1"},
+			{Name: "CreateUser", SQL: "INSERT INTO users (name, email) VALUES ($1, $2)"},
+		},
+		GoPackages: []string{fixture + "..."},
+	}
+	
+	result, err := NewWithOptions(Options{SkipGeneratedFiles: true}).Analyze(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	
+	// query.sql.go is generated by sqlc, so its Queries methods are not analyzed functions
+	for name := range result.Functions {
+		if strings.HasPrefix(name, fixture+"db.") {
+			t.Errorf("Expected generated function %s to be skipped", name)
+		}
+	}
+	if access, exists := result.Functions[fixture+"service.UserService.GetUser"].TableAccess["users"]; !exists || access.Operations[0] != "SELECT" {
+		t.Errorf("Expected UserService.GetUser to still read users, got %+v", result.Functions[fixture+"service.UserService.GetUser"])
+	}
+}
+
+func TestResult_PathTo(t *testing.T) {
+	const fixture = "github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/"
+	request := AnalysisRequest{
+		SQLQueries: []Query{
+			{Name: "GetUser", SQL: "SELECT id, name, email, created_at FROM users WHERE id = $1"},
+			{Name: "ListPostsByUser", SQL: "SELECT id, title FROM posts WHERE author_id = $1"},
+			{Name: "CreatePost", SQL: "INSERT INTO posts (title, content, author_id) VALUES ($1, $2, $3)"},
+		},
+		GoPackages: []string{fixture + "..."},
+	}
+	
+	result, err := New().Analyze(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	
+	tests := []struct {
+		name     string
+		function string
+		table    string
+		want     []DependencyPath
+	}{
+		{
+			name:     "handler reaches users through the service",
+			function: fixture + "handler.UserHandler.GetUserProfile",
+			table:    "users",
+			want: []DependencyPath{{
+				Functions:  []string{fixture + "handler.UserHandler.GetUserProfile", fixture + "service.UserService.GetUser"},
+				Method:     "GetUser",
+				Table:      "users",
+				Operations: []string{"SELECT"},
+			}},
+		},
+		{
+			name:     "handler reaches posts for reading and writing",
+			function: fixture + "handler.PostHandler.CreatePost",
+			table:    "posts",
+			want: []DependencyPath{{
+				Functions:  []string{fixture + "handler.PostHandler.CreatePost", fixture + "service.PostService.CreatePost"},
+				Method:     "CreatePost",
+				Table:      "posts",
+				Operations: []string{"INSERT"},
+			}},
+		},
+		{
+			name:     "no path to an unrelated table",
+			function: fixture + "handler.UserHandler.ListAllUsers",
+			table:    "posts",
+		},
+		{
+			name:     "unknown function",
+			function: fixture + "handler.Missing",
+			table:    "users",
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := result.PathTo(tt.function, tt.table); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PathTo() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzer_IncludeDriverCalls(t *testing.T) {
+	const db = "github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/db"
+	request := AnalysisRequest{
+		SQLQueries: []Query{
+			{Name: "GetUser", SQL: "SELECT id, name, email, created_at FROM users WHERE id = $1"},
+		},
+		GoPackages: []string{db},
+	}
+	
+	for _, include := range []bool{false, true} {
+		t.Run(fmt.Sprintf("include=%v", include), func(t *testing.T) {
+			result, err := NewWithOptions(Options{IncludeDriverCalls: include}).Analyze(context.Background(), request)
+			if err != nil {
+				t.Fatalf("Analyze() error = %v", err)
+			}
+			
+			access, exists := result.Functions[db+".Queries.GetUser"].TableAccess[types.RawTable]
+			if exists != include {
+				t.Fatalf("<raw> access recorded = %v, want %v", exists, include)
+			}
+			if !include {
+				return
+			}
+			
+			if !reflect.DeepEqual(access.Operations, []string{"DRIVER"}) || !reflect.DeepEqual(access.Methods, []string{"QueryRowContext"}) {
+				t.Errorf("<raw> access = %+v, want DRIVER via QueryRowContext", access)
+			}
+			// The generated layer issues its queries through the DBTX interface
+			for _, dep := range result.Dependencies {
+				if dep.Function == db+".Queries.GetUser" && dep.Table == types.RawTable && dep.Confidence != "medium" {
+					t.Errorf("Confidence = %s, want medium for an interface receiver", dep.Confidence)
+				}
+			}
+			if _, exists := result.Tables[types.RawTable]; !exists {
+				t.Error("Expected the <raw> table in the table view")
+			}
+		})
+	}
+}
+
+func TestAnalyzer_Locking(t *testing.T) {
+	queries := []Query{
+		{Name: "GetAccountForUpdate", SQL: "SELECT a.balance FROM accounts a JOIN users u ON u.id = a.user_id WHERE a.id = $1 FOR UPDATE OF a"},
+		{Name: "ListUsers", SQL: "SELECT id FROM users"},
+	}
+	sources := map[string]string{
+		"virtual/locking/service.go": `package locking
+
+import "context"
+
+type Queries struct{}
+
+func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) error { return nil }
+
+func (q *Queries) ListUsers(ctx context.Context) error { return nil }
+
+func Transfer(ctx context.Context, q *Queries) error {
+	if err := q.GetAccountForUpdate(ctx, 1); err != nil {
+		return err
+	}
+	return q.ListUsers(ctx)
+}
+`,
+	}
+	
+	result, err := NewWithOptions(Options{SQLDialect: "postgresql"}).AnalyzeSources(context.Background(), queries, sources)
+	if err != nil {
+		t.Fatalf("AnalyzeSources() error = %v", err)
+	}
+	
+	funcInfo := result.Functions["github.com/naoyafurudono/sqlc-use-analysis/pkg/analyzer/virtual/locking.Transfer"]
+	if got := funcInfo.TableAccess["accounts"]; !reflect.DeepEqual(got.Locking, []string{"FOR UPDATE"}) || !reflect.DeepEqual(got.Operations, []string{"SELECT"}) {
+		t.Errorf("accounts access = %+v, want a SELECT locked FOR UPDATE", got)
+	}
+	if got := funcInfo.TableAccess["users"]; got.Locking != nil {
+		t.Errorf("users access = %+v, want no lock", got)
+	}
+}
+
+func TestAnalyzer_ReanalyzeFile(t *testing.T) {
+	analyzer := New()
+	
+	request := AnalysisRequest{
+		SQLQueries: []Query{
+			{Name: "GetUser", SQL: "SELECT id, name, email, created_at FROM users WHERE id = $1"},
+			{Name: "ListUsers", SQL: "SELECT id, name, email, created_at FROM users ORDER BY created_at DESC"},
+			{Name: "CreateUser", SQL: "INSERT INTO users (name, email) VALUES ($1, $2) RETURNING id, name, email, created_at"},
+			{Name: "GetPost", SQL: "SELECT p.id, p.title, u.name as author_name FROM posts p JOIN users u ON p.author_id = u.id WHERE p.id = $1"},
+			{Name: "ListPostsByUser", SQL: "SELECT id, title FROM posts WHERE author_id = $1 ORDER BY created_at DESC"},
+			{Name: "CreatePost", SQL: "INSERT INTO posts (title, content, author_id) VALUES ($1, $2, $3)"},
+			{Name: "GetCommentsByPost", SQL: "SELECT c.id, u.name FROM comments c JOIN users u ON c.author_id = u.id WHERE c.post_id = $1"},
+			{Name: "CreateComment", SQL: "INSERT INTO comments (post_id, author_id, content) VALUES ($1, $2, $3)"},
+		},
+		GoPackages: []string{"github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/..."},
+	}
+	
+	ctx := context.Background()
+	prev, err := analyzer.Analyze(ctx, request)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	
+	path, err := filepath.Abs("../../test/fixtures/simple_project/internal/lookup/lookup.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	original, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	// FindUser switches to ListUsers, LatestPost is removed and a caller of the service layer is added
+	content := `package lookup
+
+import (
+	"context"
+
+	"github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/db"
+	"github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/service"
+)
+
+type UserLookup struct {
+	querier db.Querier
+}
+
+func (l *UserLookup) FindUser(ctx context.Context) ([]db.User, error) {
+	return l.querier.ListUsers(ctx)
+}
+
+func ServiceUser(ctx context.Context, users *service.UserService, id int32) (*db.User, error) {
+	return users.GetUser(ctx, id)
+}
+`
+	result, err := analyzer.ReanalyzeFile(ctx, prev, path, []byte(content))
+	if err != nil {
+		t.Fatalf("ReanalyzeFile() error = %v", err)
+	}
+	
+	const fixture = "github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/"
+	findUser := result.Functions[fixture+"lookup.UserLookup.FindUser"]
+	if got := findUser.TableAccess["users"].Methods; !reflect.DeepEqual(got, []string{"ListUsers"}) {
+		t.Errorf("FindUser methods = %v, want [ListUsers]", got)
+	}
+	if _, exists := result.Functions[fixture+"lookup.LatestPost"]; exists {
+		t.Error("Expected removed LatestPost to be dropped")
+	}
+	for _, function := range result.Tables["posts"].AccessedBy {
+		if function == fixture+"lookup.LatestPost" {
+			t.Error("Expected posts not to be accessed by LatestPost anymore")
+		}
+	}
+	
+	// 他パッケージの関数への呼び出しも辺として残る
+	serviceUser := result.Functions[fixture+"lookup.ServiceUser"]
+	if !containsString(serviceUser.Calls, fixture+"service.UserService.GetUser") {
+		t.Errorf("ServiceUser calls = %v, want service.UserService.GetUser", serviceUser.Calls)
+	}
+	if tables := result.EntryPoints[fixture+"lookup.ServiceUser"].Tables; !reflect.DeepEqual(tables, map[string][]string{"users": {"SELECT"}}) {
+		t.Errorf("ServiceUser entry point tables = %v, want users SELECT", tables)
+	}
+	
+	// 他のファイルの関数は変わらない
+	for name, function := range prev.Functions {
+		if strings.HasPrefix(name, fixture+"lookup.") {
+			continue
+		}
+		if !reflect.DeepEqual(result.Functions[name], function) {
+			t.Errorf("%s changed:\n got  %+v\n want %+v", name, result.Functions[name], function)
+		}
+	}
+	
+	current, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(current) != string(original) {
+		t.Error("Expected the file on disk to be left unchanged")
+	}
+	
+	if _, err := New().ReanalyzeFile(ctx, prev, path, []byte(content)); err == nil {
+		t.Error("Expected an error without a previous analysis")
+	}
+}
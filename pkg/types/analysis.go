@@ -2,11 +2,16 @@ package types
 
 import "time"
 
+// SchemaVersion is the version of the JSON output schema
+// Additive changes bump the minor version, breaking changes bump the major version
+const SchemaVersion = "2.10.0"
+
 // DependencyResult represents the complete analysis result
 type DependencyResult struct {
-	Metadata     Metadata                   `json:"metadata"`
-	FunctionView map[string][]TableAccess   `json:"function_view"`
-	TableView    map[string][]FunctionAccess `json:"table_view"`
+	SchemaVersion string                      `json:"schema_version"`
+	Metadata      Metadata                    `json:"metadata"`
+	FunctionView  map[string][]TableAccess    `json:"function_view"`
+	TableView     map[string][]FunctionAccess `json:"table_view"`
 }
 
 // Metadata contains analysis metadata
@@ -32,16 +37,40 @@ type FunctionAccess struct {
 
 // SQLMethodInfo represents information about a sqlc-generated method
 type SQLMethodInfo struct {
-	MethodName string          `json:"method_name"`
-	Tables     []TableOperation `json:"tables"`
+	MethodName  string           `json:"method_name"`
+	Tables      []TableOperation `json:"tables"`
+	Fingerprint string           `json:"fingerprint,omitempty"` // 正規化したSQLのハッシュ（空白・大文字小文字・リテラルの違いを無視）
 }
 
 // TableOperation represents an operation on a table
 type TableOperation struct {
-	TableName  string   `json:"table_name"`
-	Operations []string `json:"operations"`
+	TableName  string     `json:"table_name"`
+	Operations []string   `json:"operations"`
+	Kind       AccessKind `json:"kind,omitempty"`    // 空の場合はAccessPrimaryとして扱う
+	Columns    []string   `json:"columns,omitempty"` // 読み書きする列（ColumnWildcardは全列）
+	Locking    string     `json:"locking,omitempty"` // SELECTが取る行ロック（"FOR UPDATE"など）
 }
 
+// ColumnWildcard marks a "SELECT *" (or "table.*") read of every column
+const ColumnWildcard = "*"
+
+// AccessKind distinguishes tables a statement acts on from tables it only reads to do so
+type AccessKind string
+
+const (
+	AccessPrimary   AccessKind = "primary"   // 文が書き込む（SELECTでは読み取る）テーブル
+	AccessReference AccessKind = "reference" // 書き込みのためにJOIN/FROM/USING/サブクエリで参照するテーブル
+)
+
+// Confidence describes how reliably a Go call was resolved to a sqlc query method
+type Confidence string
+
+const (
+	ConfidenceHigh   Confidence = "high"   // go/typesでQueries型のメソッドとして解決できた
+	ConfidenceMedium Confidence = "medium" // レシーバーの型は解決できたが、メソッドは名前で判定した
+	ConfidenceLow    Confidence = "low"    // 型情報がなく、メソッド名のみで判定した
+)
+
 // GoFunctionInfo represents information about a Go function
 type GoFunctionInfo struct {
 	FullName      string     `json:"full_name"`
@@ -73,38 +102,58 @@ type GeneratedFile struct {
 
 // SQLCall represents a call to an SQL method
 type SQLCall struct {
-	MethodName string `json:"method_name"`
-	Line       int    `json:"line"`
-	Column     int    `json:"column"`
+	MethodName string           `json:"method_name"`
+	Line       int              `json:"line"`
+	Column     int              `json:"column"`
+	Confidence Confidence       `json:"confidence,omitempty"`
+	SQL        string           `json:"sql,omitempty"`    // database/sqlの呼び出しに直接渡されたSQL
+	Tables     []TableOperation `json:"tables,omitempty"` // SQLから解析したテーブル操作（sqlcのメソッドでは空）
+	Driver     bool             `json:"driver,omitempty"` // ドライバーメソッド（QueryRowContextなど）の直接呼び出し
+	LoopLine   int              `json:"loop_line,omitempty"` // 呼び出しを囲む最も内側のfor/rangeループの行（ループ外は0）
 }
 
 // AnalysisResult represents the complete analysis result
 type AnalysisResult struct {
 	FunctionView map[string]FunctionViewEntry `json:"function_view"`
 	TableView    map[string]TableViewEntry    `json:"table_view"`
+	EntryPoints  map[string]EntryPoint        `json:"entry_points,omitempty"`
+}
+
+// EntryPoint represents a function not called by any other analyzed function
+// together with everything it transitively reaches
+type EntryPoint struct {
+	Function string              `json:"function"`
+	Reaches  []string            `json:"reaches"` // 推移的に呼び出す関数
+	Tables   map[string][]string `json:"tables"`  // テーブル名 -> 操作
 }
 
 // FunctionViewEntry represents a function's database access information
 type FunctionViewEntry struct {
 	FunctionName string                    `json:"function_name"`
 	PackageName  string                    `json:"package_name"`
+	PackagePath  string                    `json:"package_path,omitempty"`
 	FileName     string                    `json:"file_name"`
 	StartLine    int                       `json:"start_line"`
 	EndLine      int                       `json:"end_line"`
 	TableAccess  map[string]TableAccessInfo `json:"table_access"`
+	Calls        []string                  `json:"calls,omitempty"` // 呼び出す解析対象の関数
 }
 
 // TableAccessInfo represents how a function accesses a table
 type TableAccessInfo struct {
 	TableName  string                       `json:"table_name"`
 	Operations map[string][]OperationCall   `json:"operations"`
+	Columns    map[string][]string          `json:"columns,omitempty"` // 操作 -> 列
+	Locking    []string                     `json:"locking,omitempty"` // 行ロックの種類
 }
 
 // OperationCall represents a specific operation call
 type OperationCall struct {
-	MethodName string `json:"method_name"`
-	Line       int    `json:"line"`
-	Column     int    `json:"column"`
+	MethodName string     `json:"method_name"`
+	Line       int        `json:"line"`
+	Column     int        `json:"column"`
+	Kind       AccessKind `json:"kind"`
+	Confidence Confidence `json:"confidence,omitempty"`
 }
 
 // TableViewEntry represents a table's access information
@@ -147,6 +196,9 @@ type AnalysisReport struct {
 
 // QueryInfo represents information about a SQL query
 type QueryInfo struct {
-	Name string `json:"name"`
-	SQL  string `json:"sql"`
+	Name     string `json:"name"`
+	SQL      string `json:"sql"`
+	Cmd      string `json:"cmd,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	Comments []string `json:"comments,omitempty"` // comments sqlc removed from the query text
 }
\ No newline at end of file
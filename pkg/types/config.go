@@ -27,13 +27,22 @@ type Config struct {
 type AnalysisConfig struct {
 	// Go解析設定
 	IncludeTests       bool     `json:"include_tests" yaml:"include_tests"`
-	IncludeVendor      bool     `json:"include_vendor" yaml:"include_vendor"`
+	IncludeVendor      bool     `json:"include_vendor" yaml:"include_vendor"`     // 標準ライブラリ以外の依存パッケージも解析する
+	FollowPackages     []string `json:"follow_packages" yaml:"follow_packages"`   // 解析する依存パッケージのインポートパスの接頭辞（例: "example.com/shared/db"）
 	FollowSymlinks     bool     `json:"follow_symlinks" yaml:"follow_symlinks"`
 	MaxDepth           int      `json:"max_depth" yaml:"max_depth"`
 	
 	// SQL解析設定（MySQL優先）
-	SQLDialect         string   `json:"sql_dialect" yaml:"sql_dialect"` // デフォルト: "mysql"
+	SQLDialect         string   `json:"sql_dialect" yaml:"sql_dialect"` // "mysql"（デフォルト）, "postgresql", "sqlite", "ansi"
 	CaseSensitiveTables bool    `json:"case_sensitive_tables" yaml:"case_sensitive_tables"`
+	MethodPrefixes     []string `json:"method_prefixes" yaml:"method_prefixes"` // sqlcメソッドとみなす追加の接頭辞（例: "Fetch", "Save"）
+	Acronyms           []string `json:"acronyms" yaml:"acronyms"`               // クエリ名からメソッド名を導く際に大文字のまま扱う略語（例: "ID", "API", "URL"）
+	IncludeDDL         bool     `json:"include_ddl" yaml:"include_ddl"`         // CREATE/ALTER/DROP/TRUNCATEをDDL操作として記録する
+	ReadContextEdges   bool     `json:"read_context_edges" yaml:"read_context_edges"` // 書き込み文が参照するだけのテーブルをreferenceのSELECTとして記録する
+	RawSQL             bool     `json:"raw_sql" yaml:"raw_sql"`                 // database/sqlの呼び出しに渡されたSQL文字列も解析する
+	SkipGeneratedFiles bool     `json:"skip_generated_files" yaml:"skip_generated_files"` // sqlcが生成したファイル（*.sql.go）の関数を解析対象から除く
+	IncludeDriverCalls bool     `json:"include_driver_calls" yaml:"include_driver_calls"` // QueryRowContextなどの直接呼び出しを<raw>テーブルへのDRIVER操作として記録する
+	StrictTypes        bool     `json:"strict_types" yaml:"strict_types"` // 型情報が不完全なパッケージがある場合に解析を失敗させる
 	
 	// フィルタリング
 	IncludePackages    []string `json:"include_packages" yaml:"include_packages"`
@@ -42,10 +51,14 @@ type AnalysisConfig struct {
 
 // OutputConfig contains output-specific configuration
 type OutputConfig struct {
-	Format            OutputFormat `json:"format" yaml:"format"`     // 常に"json"
+	Format            OutputFormat `json:"format" yaml:"format"`     // デフォルト: "json"
 	IncludeMetadata   bool        `json:"include_metadata" yaml:"include_metadata"`
 	IncludeDetails    bool        `json:"include_details" yaml:"include_details"`
 	Pretty            bool        `json:"pretty" yaml:"pretty"`
+	PrimaryView       PrimaryView `json:"primary_view" yaml:"primary_view"` // "function", "table", "both"
+	IncludeErrors     bool        `json:"include_errors" yaml:"include_errors"` // 収集したエラー・警告をJSON出力の"errors"に含める
+	OperationStyle    OperationStyle `json:"operation_style" yaml:"operation_style"` // "sql"（デフォルト）または "crud"
+	SplitFiles        bool        `json:"split_files" yaml:"split_files"` // OutputPathをディレクトリとして扱い、ビューごとのJSONファイルに分割する
 }
 
 // PerformanceConfig contains performance-related configuration
@@ -54,6 +67,7 @@ type PerformanceConfig struct {
 	EnableCache       bool `json:"enable_cache" yaml:"enable_cache"`
 	MemoryLimit       int  `json:"memory_limit_mb" yaml:"memory_limit_mb"`
 	TimeoutSeconds    int  `json:"timeout_seconds" yaml:"timeout_seconds"`
+	LoadRetries       int  `json:"load_retries" yaml:"load_retries"` // Go package loading retries on transient failures (0 disables)
 }
 
 // DebugConfig contains debug-related configuration
@@ -62,11 +76,98 @@ type DebugConfig struct {
 	LogFile          string `json:"log_file" yaml:"log_file"`
 	ProfileOutput    string `json:"profile_output" yaml:"profile_output"`
 	TraceCallPaths   bool   `json:"trace_call_paths" yaml:"trace_call_paths"`
+	MaxDetailLength  int    `json:"max_detail_length" yaml:"max_detail_length"` // エラー詳細の最大長（0は無制限）
+	CountOnlyErrors  bool   `json:"count_only_errors" yaml:"count_only_errors"` // エラー上限到達後も解析を続け、超過分は件数のみ数える
 }
 
 // OutputFormat represents the output format
 type OutputFormat string
 
 const (
-	FormatJSON OutputFormat = "json"
-)
\ No newline at end of file
+	FormatJSON     OutputFormat = "json"
+	FormatCSV      OutputFormat = "csv"
+	FormatHTML     OutputFormat = "html"
+	FormatMarkdown OutputFormat = "markdown" // プラグインの生成ファイル向けの人が読む形式
+	FormatTemplate OutputFormat = "template" // Go text/templateで任意の形式を出力する（公開APIのみ）
+)
+
+// PrimaryView represents which dependency view is emitted in the output
+type PrimaryView string
+
+const (
+	ViewFunction PrimaryView = "function" // 関数ビューのみ出力
+	ViewTable    PrimaryView = "table"    // テーブルビューのみ出力
+	ViewBoth     PrimaryView = "both"     // 両方のビューを出力
+)
+
+// IsValid checks if the primary view is valid
+func (v PrimaryView) IsValid() bool {
+	switch v {
+	case ViewFunction, ViewTable, ViewBoth:
+		return true
+	default:
+		return false
+	}
+}
+
+// OperationStyle represents how operations are named in the output
+type OperationStyle string
+
+const (
+	OperationStyleSQL  OperationStyle = "sql"  // SQLの動詞（SELECT, INSERT, ...）
+	OperationStyleCRUD OperationStyle = "crud" // CRUDの動詞（Read, Create, Update, Delete）
+)
+
+// IsValid checks if the operation style is valid
+func (s OperationStyle) IsValid() bool {
+	switch s {
+	case OperationStyleSQL, OperationStyleCRUD:
+		return true
+	default:
+		return false
+	}
+}
+
+// Name returns the name of an operation in this style
+func (s OperationStyle) Name(operation string) string {
+	if s != OperationStyleCRUD {
+		return operation
+	}
+	return Operation(operation).CRUD()
+}
+
+// Counts renames the keys of per-operation counts in this style, summing those that share a name
+func (s OperationStyle) Counts(counts map[string]int) map[string]int {
+	if counts == nil {
+		return nil
+	}
+	renamed := make(map[string]int, len(counts))
+	for operation, count := range counts {
+		renamed[s.Name(operation)] += count
+	}
+	return renamed
+}
+
+// Names renames operations in this style, merging those that share a name (DELETE and TRUNCATE)
+func (s OperationStyle) Names(operations []string) []string {
+	renamed := make([]string, 0, len(operations))
+	seen := make(map[string]bool, len(operations))
+	for _, operation := range operations {
+		name := s.Name(operation)
+		if !seen[name] {
+			seen[name] = true
+			renamed = append(renamed, name)
+		}
+	}
+	return renamed
+}
+
+// IncludesFunctionView returns true if the function view should be emitted
+func (v PrimaryView) IncludesFunctionView() bool {
+	return v != ViewTable
+}
+
+// IncludesTableView returns true if the table view should be emitted
+func (v PrimaryView) IncludesTableView() bool {
+	return v != ViewFunction
+}
\ No newline at end of file
@@ -44,8 +44,20 @@ const (
 	OpInsert Operation = "INSERT"
 	OpUpdate Operation = "UPDATE"
 	OpDelete Operation = "DELETE"
+	
+	// DDL操作（AnalysisConfig.IncludeDDLが有効な場合のみ記録される）
+	OpCreate   Operation = "CREATE"
+	OpAlter    Operation = "ALTER"
+	OpDrop     Operation = "DROP"
+	OpTruncate Operation = "TRUNCATE"
+	
+	// ドライバーメソッドの直接呼び出し（AnalysisConfig.IncludeDriverCallsが有効な場合のみ記録される）
+	OpDriver Operation = "DRIVER"
 )
 
+// RawTable is the synthetic table that driver calls (OpDriver) are attributed to
+const RawTable = "<raw>"
+
 // String returns the string representation of an operation
 func (o Operation) String() string {
 	return string(o)
@@ -54,7 +66,34 @@ func (o Operation) String() string {
 // IsValid checks if the operation is valid
 func (o Operation) IsValid() bool {
 	switch o {
-	case OpSelect, OpInsert, OpUpdate, OpDelete:
+	case OpSelect, OpInsert, OpUpdate, OpDelete, OpDriver:
+		return true
+	default:
+		return o.IsDDL()
+	}
+}
+
+// CRUD returns the CRUD verb of a data operation, or the operation itself if it has none
+func (o Operation) CRUD() string {
+	switch o {
+	case OpSelect:
+		return "Read"
+	case OpInsert:
+		return "Create"
+	case OpUpdate:
+		return "Update"
+	case OpDelete, OpTruncate:
+		// TRUNCATEは全行の削除としてDeleteに含める
+		return "Delete"
+	default:
+		return string(o)
+	}
+}
+
+// IsDDL reports whether the operation changes the schema rather than data
+func (o Operation) IsDDL() bool {
+	switch o {
+	case OpCreate, OpAlter, OpDrop, OpTruncate:
 		return true
 	default:
 		return false
@@ -0,0 +1,47 @@
+package batch
+
+import (
+	"context"
+
+	"github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/db"
+)
+
+// Loader calls generated queries through method values instead of selectors
+type Loader struct {
+	queries *db.Queries
+}
+
+func NewLoader(queries *db.Queries) *Loader {
+	return &Loader{queries: queries}
+}
+
+// UsersByID holds the query method in a variable and calls it once per id
+func (l *Loader) UsersByID(ctx context.Context, ids []int32) ([]db.User, error) {
+	getUser := l.queries.GetUser
+
+	var users []db.User
+	for _, id := range ids {
+		user, err := getUser(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// PostsOf calls the query through a method expression
+func PostsOf(ctx context.Context, queries *db.Queries, authorID int32) ([]db.Post, error) {
+	list := (*db.Queries).ListPostsByUser
+	return list(queries, ctx, authorID)
+}
+
+// CountUsers passes the query method as a callback
+func (l *Loader) CountUsers(ctx context.Context) (int, error) {
+	return count(ctx, l.queries.ListUsers)
+}
+
+func count(ctx context.Context, list func(context.Context) ([]db.User, error)) (int, error) {
+	users, err := list(ctx)
+	return len(users), err
+}
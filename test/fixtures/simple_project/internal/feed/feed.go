@@ -0,0 +1,43 @@
+package feed
+
+import (
+	"context"
+
+	"github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/db"
+)
+
+type Feed struct {
+	queries *db.Queries
+}
+
+func NewFeed(queries *db.Queries) *Feed {
+	return &Feed{queries: queries}
+}
+
+// AuthorsOf loads the author of every post with one query per post
+func (f *Feed) AuthorsOf(ctx context.Context, authorID int32) ([]db.User, error) {
+	posts, err := f.queries.ListPostsByUser(ctx, authorID)
+	if err != nil {
+		return nil, err
+	}
+
+	var authors []db.User
+	for _, post := range posts {
+		author, err := f.queries.GetUser(ctx, post.AuthorID)
+		if err != nil {
+			return nil, err
+		}
+		authors = append(authors, author)
+	}
+	return authors, nil
+}
+
+// Publish creates posts one by one; writes in a loop are not reported
+func (f *Feed) Publish(ctx context.Context, params []db.CreatePostParams) error {
+	for _, p := range params {
+		if _, err := f.queries.CreatePost(ctx, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
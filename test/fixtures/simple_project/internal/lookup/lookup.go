@@ -0,0 +1,34 @@
+package lookup
+
+import (
+	"context"
+
+	"github.com/naoyafurudono/sqlc-use-analysis/test/fixtures/simple_project/internal/db"
+)
+
+// UserLookup depends on the generated Querier interface so it can be mocked
+type UserLookup struct {
+	querier db.Querier
+}
+
+func NewUserLookup(querier db.Querier) *UserLookup {
+	return &UserLookup{querier: querier}
+}
+
+func (l *UserLookup) FindUser(ctx context.Context, id int32) (db.User, error) {
+	return l.querier.GetUser(ctx, id)
+}
+
+// postReader is a narrow view of the generated queries defined by its consumer
+type postReader interface {
+	GetPost(ctx context.Context, id int32) (db.GetPostRow, error)
+	ListPostsByUser(ctx context.Context, authorID int32) ([]db.Post, error)
+}
+
+func LatestPost(ctx context.Context, reader postReader, authorID int32) (*db.Post, error) {
+	posts, err := reader.ListPostsByUser(ctx, authorID)
+	if err != nil || len(posts) == 0 {
+		return nil, err
+	}
+	return &posts[0], nil
+}
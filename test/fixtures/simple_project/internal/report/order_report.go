@@ -0,0 +1,37 @@
+package report
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type OrderReport struct {
+	db *sql.DB
+}
+
+func NewOrderReport(db *sql.DB) *OrderReport {
+	return &OrderReport{db: db}
+}
+
+func (r *OrderReport) TotalByUser(ctx context.Context, userID int32) (int64, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, total FROM orders WHERE user_id = $1", userID)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var sum int64
+	for rows.Next() {
+		var id, total int64
+		if err := rows.Scan(&id, &total); err != nil {
+			return 0, err
+		}
+		sum += total
+	}
+	return sum, rows.Err()
+}
+
+func (r *OrderReport) OrdersSortedBy(ctx context.Context, column string) (*sql.Rows, error) {
+	return r.db.QueryContext(ctx, fmt.Sprintf("SELECT id, total FROM orders ORDER BY %s", column))
+}
@@ -27,6 +27,21 @@ func (s *UserService) CreateUser(ctx context.Context, name, email string) (*db.U
 	return &user, nil
 }
 
+func (s *UserService) RegisterUser(ctx context.Context, name, email string) (*db.User, error) {
+	created, err := s.queries.CreateUser(ctx, db.CreateUserParams{
+		Name:  name,
+		Email: email,
+	})
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.queries.GetUser(ctx, created.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (s *UserService) GetUser(ctx context.Context, id int32) (*db.User, error) {
 	user, err := s.queries.GetUser(ctx, id)
 	if err != nil {
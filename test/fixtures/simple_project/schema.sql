@@ -19,4 +19,9 @@ CREATE TABLE comments (
     author_id INTEGER REFERENCES users(id),
     content TEXT NOT NULL,
     created_at TIMESTAMP DEFAULT NOW()
-);
\ No newline at end of file
+);
+CREATE TABLE orders (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id),
+    total BIGINT NOT NULL
+);
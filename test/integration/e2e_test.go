@@ -224,24 +224,13 @@ func TestE2EComplexProject(t *testing.T) {
 		},
 	}
 
-	// Create temporary Go files for complex test
-	tmpDir := t.TempDir()
-	
-	// Create complex Go files
-	createComplexGoFiles(t, tmpDir)
-
-	// Create analysis request
-	request := analyzer.AnalysisRequest{
-		SQLQueries:   queries,
-		GoPackages:   []string{filepath.Join(tmpDir, "internal")},
-		OutputFormat: "json",
-		PrettyPrint:  true,
-	}
+	// Provide complex Go files as in-memory sources
+	sources := createComplexGoSources()
 
 	// Run analysis
 	ctx := context.Background()
 	a := analyzer.New()
-	result, err := a.Analyze(ctx, request)
+	result, err := a.AnalyzeSources(ctx, queries, sources)
 	
 	// Verify analysis succeeded
 	require.NoError(t, err, "Complex analysis should succeed")
@@ -251,12 +240,7 @@ func TestE2EComplexProject(t *testing.T) {
 	testComplexQueryAnalysis(t, result)
 }
 
-func createComplexGoFiles(t *testing.T, tmpDir string) {
-	// Create complex service file
-	serviceDir := filepath.Join(tmpDir, "internal", "service")
-	err := os.MkdirAll(serviceDir, 0755)
-	require.NoError(t, err)
-
+func createComplexGoSources() map[string]string {
 	complexServiceContent := `
 package service
 
@@ -294,8 +278,9 @@ func (s *ComplexService) DeleteOldPosts(ctx context.Context) error {
 }
 `
 
-	err = os.WriteFile(filepath.Join(serviceDir, "complex_service.go"), []byte(complexServiceContent), 0644)
-	require.NoError(t, err)
+	return map[string]string{
+		"internal/service/complex_service.go": complexServiceContent,
+	}
 }
 
 func testComplexQueryAnalysis(t *testing.T, result *analyzer.Result) {